@@ -4,6 +4,7 @@ import (
 	"context"
 	"crypto/ecdsa"
 	"crypto/rand"
+	"encoding/binary"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
@@ -18,6 +19,8 @@ import (
 	"github.com/ethersphere/swarm/log"
 	"github.com/ethersphere/swarm/sctx"
 	"github.com/ethersphere/swarm/storage"
+	"github.com/ethersphere/swarm/storage/feed"
+	"github.com/ethersphere/swarm/storage/feed/lookup"
 	"golang.org/x/crypto/scrypt"
 	"golang.org/x/crypto/sha3"
 )
@@ -26,6 +29,9 @@ var (
 	ErrDecrypt                = errors.New("cant decrypt - forbidden")
 	ErrUnknownAccessType      = errors.New("unknown access type (or not implemented)")
 	ErrDecryptDomainForbidden = errors.New("decryption request domain forbidden - can only decrypt on localhost")
+	ErrNotPublisher           = errors.New("not the publisher of the access control manifest")
+	ErrNoGranteeList          = errors.New("access control manifest has no grantee list")
+	ErrGrantExpired           = errors.New("access grant expired")
 	AllowedDecryptDomains     = []string{
 		"localhost",
 		"127.0.0.1",
@@ -34,12 +40,18 @@ var (
 
 const EmptyCredentials = ""
 
+// actGranteesPath is the path of the ACT manifest entry with the list of grantees,
+// which is encrypted for the publisher
+const actGranteesPath = "grantees"
+
 type AccessEntry struct {
 	Type      AccessType
 	Publisher string
 	Salt      []byte
 	Act       string
 	KdfParams *KdfParams
+	Expires   time.Time // the grant can not be used after this time if set
+	Feed      string    // address of a feed manifest whose updates replace the encrypted reference if set
 }
 
 type DecryptFunc func(*ManifestEntry) error
@@ -52,12 +64,16 @@ func (a *AccessEntry) MarshalJSON() (out []byte, err error) {
 		Salt      string     `json:"salt,omitempty"`
 		Act       string     `json:"act,omitempty"`
 		KdfParams *KdfParams `json:"kdf_params,omitempty"`
+		Expires   string     `json:"expires,omitempty"`
+		Feed      string     `json:"feed,omitempty"`
 	}{
 		Type:      a.Type,
 		Publisher: a.Publisher,
 		Salt:      hex.EncodeToString(a.Salt),
 		Act:       a.Act,
 		KdfParams: a.KdfParams,
+		Expires:   expiresString(a.Expires),
+		Feed:      a.Feed,
 	})
 
 }
@@ -69,6 +85,8 @@ func (a *AccessEntry) UnmarshalJSON(value []byte) error {
 		Salt      string     `json:"salt,omitempty"`
 		Act       string     `json:"act,omitempty"`
 		KdfParams *KdfParams `json:"kdf_params,omitempty"`
+		Expires   string     `json:"expires,omitempty"`
+		Feed      string     `json:"feed,omitempty"`
 	}{}
 
 	err := json.Unmarshal(value, &v)
@@ -86,9 +104,46 @@ func (a *AccessEntry) UnmarshalJSON(value []byte) error {
 		return errors.New("salt should be 32 bytes long")
 	}
 	a.Type = v.Type
+	a.Feed = v.Feed
+	a.Expires = time.Time{}
+	if v.Expires != "" {
+		a.Expires, err = time.Parse(time.RFC3339, v.Expires)
+		if err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+// Expired reports whether the grant has an expiry which has passed
+func (a *AccessEntry) Expired() bool {
+	return !a.Expires.IsZero() && time.Now().After(a.Expires)
+}
+
+// keySalt returns the salt the session keys of the access entry are derived with
+func (a *AccessEntry) keySalt() []byte {
+	return grantSalt(a.Salt, a.Expires)
+}
+
+// grantSalt returns the salt session keys are derived with for a grant with the given expiry.
+// The expiry is part of it so that changing the expiry in the manifest makes the grant unusable.
+func grantSalt(salt []byte, expires time.Time) []byte {
+	if expires.IsZero() {
+		return salt
+	}
+	b := make([]byte, len(salt)+8)
+	copy(b, salt)
+	binary.BigEndian.PutUint64(b[len(salt):], uint64(expires.Unix()))
+	return b
+}
+
+func expiresString(expires time.Time) string {
+	if expires.IsZero() {
+		return ""
+	}
+	return expires.UTC().Format(time.RFC3339)
+}
+
 type KdfParams struct {
 	N int `json:"n"`
 	P int `json:"p"`
@@ -171,7 +226,7 @@ func NewSessionKeyPassword(password string, accessEntry *AccessEntry) ([]byte, e
 		return nil, errors.New("incorrect access entry type")
 
 	}
-	return sessionKeyPassword(password, accessEntry.Salt, accessEntry.KdfParams)
+	return sessionKeyPassword(password, accessEntry.keySalt(), accessEntry.KdfParams)
 }
 
 func sessionKeyPassword(password string, salt []byte, kdfParams *KdfParams) ([]byte, error) {
@@ -217,6 +272,10 @@ func (a *API) doDecrypt(ctx context.Context, credentials string, pk *ecdsa.Priva
 			return ErrDecryptDomainForbidden
 		}
 
+		if m.Access.Expired() {
+			return ErrGrantExpired
+		}
+
 		switch m.Access.Type {
 		case "pass":
 			if credentials != "" {
@@ -225,7 +284,7 @@ func (a *API) doDecrypt(ctx context.Context, credentials string, pk *ecdsa.Priva
 					return err
 				}
 
-				ref, err := hex.DecodeString(m.Hash)
+				ref, err := a.encryptedReference(ctx, m)
 				if err != nil {
 					return err
 				}
@@ -250,11 +309,11 @@ func (a *API) doDecrypt(ctx context.Context, credentials string, pk *ecdsa.Priva
 			if err != nil {
 				return ErrDecrypt
 			}
-			key, err := NewSessionKeyPK(pk, publisher, m.Access.Salt)
+			key, err := NewSessionKeyPK(pk, publisher, m.Access.keySalt())
 			if err != nil {
 				return ErrDecrypt
 			}
-			ref, err := hex.DecodeString(m.Hash)
+			ref, err := a.encryptedReference(ctx, m)
 			if err != nil {
 				return err
 			}
@@ -283,7 +342,7 @@ func (a *API) doDecrypt(ctx context.Context, credentials string, pk *ecdsa.Priva
 				return ErrDecrypt
 			}
 
-			sessionKey, err = NewSessionKeyPK(pk, publisher, m.Access.Salt)
+			sessionKey, err = NewSessionKeyPK(pk, publisher, m.Access.keySalt())
 			if err != nil {
 				return ErrDecrypt
 			}
@@ -316,7 +375,7 @@ func (a *API) doDecrypt(ctx context.Context, credentials string, pk *ecdsa.Priva
 				return ErrDecrypt
 			}
 
-			ref, err := hex.DecodeString(m.Hash)
+			ref, err := a.encryptedReference(ctx, m)
 			if err != nil {
 				return err
 			}
@@ -334,15 +393,26 @@ func (a *API) doDecrypt(ctx context.Context, credentials string, pk *ecdsa.Priva
 	}
 }
 
-func (a *API) getACTDecryptionKey(ctx context.Context, actManifestAddress storage.Address, sessionKey []byte) (found bool, ciphertext, decryptionKey []byte, err error) {
-	hasher := sha3.NewLegacyKeccak256()
-	hasher.Write(append(sessionKey, 0))
-	lookupKey := hasher.Sum(nil)
-	hasher.Reset()
+// encryptedReference returns the encrypted reference of the manifest entry with access control,
+// which is the latest update of the feed of feed-backed grants. The reference in the entry itself
+// is used until the feed has an update.
+func (a *API) encryptedReference(ctx context.Context, m *ManifestEntry) ([]byte, error) {
+	if m.Access.Feed != "" {
+		fd, err := a.ResolveFeedManifest(ctx, storage.Address(common.FromHex(m.Access.Feed)))
+		if err != nil {
+			return nil, err
+		}
+		data, err := a.FeedsLookup(ctx, feed.NewQueryLatest(fd, lookup.NoClue))
+		if err == nil && len(data) > 0 {
+			return data, nil
+		}
+		log.Debug("no update of access control feed, using the manifest reference", "feed", m.Access.Feed, "err", err)
+	}
+	return hex.DecodeString(m.Hash)
+}
 
-	hasher.Write(append(sessionKey, 1))
-	accessKeyDecryptionKey := hasher.Sum(nil)
-	hasher.Reset()
+func (a *API) getACTDecryptionKey(ctx context.Context, actManifestAddress storage.Address, sessionKey []byte) (found bool, ciphertext, decryptionKey []byte, err error) {
+	lookupKey, accessKeyDecryptionKey := actKeys(sessionKey)
 
 	lk := hex.EncodeToString(lookupKey)
 	list, err := a.GetManifestList(ctx, NOOPDecrypt, actManifestAddress, lk)
@@ -389,7 +459,7 @@ func GenerateAccessControlManifest(ref string, accessKey []byte, ae *AccessEntry
 
 // DoPK is a helper function to the CLI API that handles the entire business logic for
 // creating a session key and access entry given the cli context, ec keys and salt
-func DoPK(privateKey *ecdsa.PrivateKey, granteePublicKey string, salt []byte) (sessionKey []byte, ae *AccessEntry, err error) {
+func DoPK(privateKey *ecdsa.PrivateKey, granteePublicKey string, salt []byte, expires time.Time) (sessionKey []byte, ae *AccessEntry, err error) {
 	if granteePublicKey == "" {
 		return nil, nil, errors.New("need a grantee Public Key")
 	}
@@ -405,7 +475,7 @@ func DoPK(privateKey *ecdsa.PrivateKey, granteePublicKey string, salt []byte) (s
 		return nil, nil, err
 	}
 
-	sessionKey, err = NewSessionKeyPK(privateKey, granteePub, salt)
+	sessionKey, err = NewSessionKeyPK(privateKey, granteePub, grantSalt(salt, expires))
 	if err != nil {
 		log.Error("error getting session key", "err", err)
 		return nil, nil, err
@@ -416,13 +486,15 @@ func DoPK(privateKey *ecdsa.PrivateKey, granteePublicKey string, salt []byte) (s
 		log.Error("error generating access entry", "err", err)
 		return nil, nil, err
 	}
+	ae.Expires = expires
 
 	return sessionKey, ae, nil
 }
 
 // DoACT is a helper function to the CLI API that handles the entire business logic for
-// creating a access key, access entry and ACT manifest (including uploading it) given the cli context, ec keys, password grantees and salt
-func DoACT(privateKey *ecdsa.PrivateKey, salt []byte, grantees []string, encryptPasswords []string) (accessKey []byte, ae *AccessEntry, actManifest *Manifest, err error) {
+// creating a access key, access entry and ACT manifest (including uploading it) given the cli context, ec keys, password grantees and salt.
+// If expires is not zero, the grants can not be used after that time.
+func DoACT(privateKey *ecdsa.PrivateKey, salt []byte, grantees []string, encryptPasswords []string, expires time.Time) (accessKey []byte, ae *AccessEntry, actManifest *Manifest, err error) {
 	if len(grantees) == 0 && len(encryptPasswords) == 0 {
 		return nil, nil, nil, errors.New("did not get any grantee public keys or any encryption passwords")
 	}
@@ -437,67 +509,34 @@ func DoACT(privateKey *ecdsa.PrivateKey, salt []byte, grantees []string, encrypt
 	if _, err := io.ReadFull(rand.Reader, accessKey); err != nil {
 		panic("reading from crypto/rand failed: " + err.Error())
 	}
+	keySalt := grantSalt(salt, expires)
 
 	lookupPathEncryptedAccessKeyMap := make(map[string]string)
-	i := 0
 	for _, v := range grantees {
-		i++
 		if v == "" {
 			return nil, nil, nil, errors.New("need a grantee Public Key")
 		}
-		b, err := hex.DecodeString(v)
+		sessionKey, err := granteeSessionKey(privateKey, v, keySalt)
 		if err != nil {
-			log.Error("error decoding grantee public key", "err", err)
 			return nil, nil, nil, err
 		}
-
-		granteePub, err := crypto.DecompressPubkey(b)
-		if err != nil {
-			log.Error("error decompressing grantee public key", "err", err)
-			return nil, nil, nil, err
-		}
-		sessionKey, err := NewSessionKeyPK(privateKey, granteePub, salt)
-		if err != nil {
-			return nil, nil, nil, err
-		}
-
-		hasher := sha3.NewLegacyKeccak256()
-		hasher.Write(append(sessionKey, 0))
-		lookupKey := hasher.Sum(nil)
-
-		hasher.Reset()
-		hasher.Write(append(sessionKey, 1))
-
-		accessKeyEncryptionKey := hasher.Sum(nil)
-
-		enc := NewRefEncryption(len(accessKey))
-		encryptedAccessKey, err := enc.Encrypt(accessKey, accessKeyEncryptionKey)
+		entry, err := newACTEntry(sessionKey, accessKey)
 		if err != nil {
 			return nil, nil, nil, err
 		}
-		lookupPathEncryptedAccessKeyMap[hex.EncodeToString(lookupKey)] = hex.EncodeToString(encryptedAccessKey)
+		lookupPathEncryptedAccessKeyMap[entry.Path] = entry.Hash
 	}
 
 	for _, pass := range encryptPasswords {
-		sessionKey, err := sessionKeyPassword(pass, salt, DefaultKdfParams)
+		sessionKey, err := sessionKeyPassword(pass, keySalt, DefaultKdfParams)
 		if err != nil {
 			return nil, nil, nil, err
 		}
-		hasher := sha3.NewLegacyKeccak256()
-		hasher.Write(append(sessionKey, 0))
-		lookupKey := hasher.Sum(nil)
-
-		hasher.Reset()
-		hasher.Write(append(sessionKey, 1))
-
-		accessKeyEncryptionKey := hasher.Sum(nil)
-
-		enc := NewRefEncryption(len(accessKey))
-		encryptedAccessKey, err := enc.Encrypt(accessKey, accessKeyEncryptionKey)
+		entry, err := newACTEntry(sessionKey, accessKey)
 		if err != nil {
 			return nil, nil, nil, err
 		}
-		lookupPathEncryptedAccessKeyMap[hex.EncodeToString(lookupKey)] = hex.EncodeToString(encryptedAccessKey)
+		lookupPathEncryptedAccessKeyMap[entry.Path] = entry.Hash
 	}
 
 	m := &Manifest{
@@ -512,10 +551,24 @@ func DoACT(privateKey *ecdsa.PrivateKey, salt []byte, grantees []string, encrypt
 		})
 	}
 
+	// keep the list of grantees for the publisher to manage them later
+	list := &ACTGrantees{Passwords: len(encryptPasswords)}
+	for _, v := range grantees {
+		if v != publisherPub && !containsString(list.PublicKeys, v) {
+			list.PublicKeys = append(list.PublicKeys, v)
+		}
+	}
+	granteesEntry, err := newACTGranteesEntry(privateKey, keySalt, list)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	m.Entries = append(m.Entries, granteesEntry)
+
 	ae, err = NewAccessEntryACT(hex.EncodeToString(crypto.CompressPubkey(&privateKey.PublicKey)), salt, "")
 	if err != nil {
 		return nil, nil, nil, err
 	}
+	ae.Expires = expires
 
 	return accessKey, ae, m, nil
 }
@@ -523,11 +576,12 @@ func DoACT(privateKey *ecdsa.PrivateKey, salt []byte, grantees []string, encrypt
 // DoPassword is a helper function to the CLI API that handles the entire business logic for
 // creating a session key and an access entry given the password and salt.
 // By default - DefaultKdfParams are used as the scrypt params
-func DoPassword(password string, salt []byte) (sessionKey []byte, ae *AccessEntry, err error) {
+func DoPassword(password string, salt []byte, expires time.Time) (sessionKey []byte, ae *AccessEntry, err error) {
 	ae, err = NewAccessEntryPassword(salt, DefaultKdfParams)
 	if err != nil {
 		return nil, nil, err
 	}
+	ae.Expires = expires
 
 	sessionKey, err = NewSessionKeyPassword(password, ae)
 	if err != nil {
@@ -535,3 +589,400 @@ func DoPassword(password string, salt []byte) (sessionKey []byte, ae *AccessEntr
 	}
 	return sessionKey, ae, nil
 }
+
+// ACTGrantees lists the grantees of an ACT manifest other than the publisher
+type ACTGrantees struct {
+	PublicKeys []string `json:"publicKeys,omitempty"` // hex encoded compressed public keys
+	Passwords  int      `json:"passwords,omitempty"`  // number of password grantees
+}
+
+// GranteeFingerprint returns the fingerprint of a hex encoded compressed grantee
+// public key, which is the hex encoded first 8 bytes of its hash
+func GranteeFingerprint(publicKey string) (string, error) {
+	b, err := hex.DecodeString(publicKey)
+	if err != nil {
+		return "", err
+	}
+	if _, err := crypto.DecompressPubkey(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(crypto.Keccak256(b)[:8]), nil
+}
+
+// ListACTGrantees returns the grantees of the ACT manifest referred to by the access entry,
+// which are only known to the publisher with the given private key
+func ListACTGrantees(privateKey *ecdsa.PrivateKey, ae *AccessEntry, actManifest *Manifest) (*ACTGrantees, error) {
+	if err := checkPublisher(privateKey, ae); err != nil {
+		return nil, err
+	}
+	for _, e := range actManifest.Entries {
+		if e.Path == actGranteesPath {
+			return decryptACTGrantees(privateKey, ae.keySalt(), e.Hash)
+		}
+	}
+	return nil, ErrNoGranteeList
+}
+
+// AddACTGrantees returns a copy of the ACT manifest referred to by the access entry
+// that also grants access to the given public key and password grantees.
+// The access key does not change, so the root access manifest only needs to
+// be updated with the address of the new ACT manifest. ACT manifests created
+// before the grantee lists were kept have no grantee list, and the new manifest
+// has none either, as the previous grantees are not known.
+func AddACTGrantees(privateKey *ecdsa.PrivateKey, ae *AccessEntry, actManifest *Manifest, grantees []string, encryptPasswords []string) (*Manifest, error) {
+	if len(grantees) == 0 && len(encryptPasswords) == 0 {
+		return nil, errors.New("did not get any grantee public keys or any encryption passwords")
+	}
+	list, err := ListACTGrantees(privateKey, ae, actManifest)
+	if err != nil && err != ErrNoGranteeList {
+		return nil, err
+	}
+	accessKey, err := publisherAccessKey(privateKey, ae, actManifest)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make(map[string]ManifestEntry)
+	for _, e := range actManifest.Entries {
+		entries[e.Path] = e
+	}
+	for _, v := range grantees {
+		if v == "" {
+			return nil, errors.New("need a grantee Public Key")
+		}
+		sessionKey, err := granteeSessionKey(privateKey, v, ae.keySalt())
+		if err != nil {
+			return nil, err
+		}
+		entry, err := newACTEntry(sessionKey, accessKey)
+		if err != nil {
+			return nil, err
+		}
+		entries[entry.Path] = entry
+		if list != nil && v != ae.Publisher && !containsString(list.PublicKeys, v) {
+			list.PublicKeys = append(list.PublicKeys, v)
+		}
+	}
+	for _, pass := range encryptPasswords {
+		sessionKey, err := sessionKeyPassword(pass, ae.keySalt(), ae.KdfParams)
+		if err != nil {
+			return nil, err
+		}
+		entry, err := newACTEntry(sessionKey, accessKey)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := entries[entry.Path]; !ok && list != nil {
+			list.Passwords++
+		}
+		entries[entry.Path] = entry
+	}
+	if list != nil {
+		granteesEntry, err := newACTGranteesEntry(privateKey, ae.keySalt(), list)
+		if err != nil {
+			return nil, err
+		}
+		entries[granteesEntry.Path] = granteesEntry
+	}
+
+	m := &Manifest{Entries: make([]ManifestEntry, 0, len(entries))}
+	for _, e := range entries {
+		m.Entries = append(m.Entries, e)
+	}
+	return m, nil
+}
+
+// RemoveACTGrantees revokes the access of the given public key grantees to
+// the reference in the root access manifest. A new access key and salt are
+// generated, so new root access and ACT manifests are returned with the reference
+// encrypted with the new access key, and revoked grantees can not decrypt it
+// even though they know the previous access key. Password grantees can not be
+// carried over and have to be given again in encryptPasswords to keep their access.
+// The expiry and feed of the grant are kept. The feed of a feed-backed grant keeps
+// serving its latest reference encrypted with the previous access key, which revoked
+// grantees can still decrypt, so it has to be updated with the reference re-encrypted
+// with the new access key (see DecryptACTReference and EncryptACTReference).
+// ACT manifests created before the grantee lists were kept have no grantee list,
+// so the full list of their public key grantees has to be given in current, and
+// their other grantees are taken as password grantees. The new ACT manifest
+// always has a grantee list.
+func RemoveACTGrantees(privateKey *ecdsa.PrivateKey, rootManifest, actManifest *Manifest, grantees, current []string, encryptPasswords []string) (newRootManifest, newActManifest *Manifest, err error) {
+	if len(rootManifest.Entries) != 1 || rootManifest.Entries[0].Access == nil || rootManifest.Entries[0].Access.Type != AccessTypeACT {
+		return nil, nil, errors.New("not an ACT root access manifest")
+	}
+	root := rootManifest.Entries[0]
+	list, err := ListACTGrantees(privateKey, root.Access, actManifest)
+	if err == ErrNoGranteeList && len(current) > 0 {
+		list, err = currentACTGrantees(privateKey, root.Access, actManifest, current)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	accessKey, err := publisherAccessKey(privateKey, root.Access, actManifest)
+	if err != nil {
+		return nil, nil, err
+	}
+	encryptedRef, err := hex.DecodeString(root.Hash)
+	if err != nil {
+		return nil, nil, err
+	}
+	enc := NewRefEncryption(len(encryptedRef) - 8)
+	ref, err := enc.Decrypt(encryptedRef, accessKey)
+	if err != nil {
+		return nil, nil, ErrDecrypt
+	}
+
+	var remaining []string
+	for _, v := range list.PublicKeys {
+		if !containsString(grantees, v) {
+			remaining = append(remaining, v)
+		}
+	}
+	for _, v := range grantees {
+		if !containsString(list.PublicKeys, v) {
+			return nil, nil, fmt.Errorf("%s is not a grantee", v)
+		}
+	}
+	if list.Passwords > len(encryptPasswords) {
+		log.Warn("password grantees not given again lose access", "passwords", list.Passwords, "given", len(encryptPasswords))
+	}
+	if len(remaining) == 0 && len(encryptPasswords) == 0 {
+		return nil, nil, errors.New("can not remove all grantees")
+	}
+
+	salt := make([]byte, 32)
+	newAccessKey, ae, newActManifest, err := DoACT(privateKey, salt, remaining, encryptPasswords, root.Access.Expires)
+	if err != nil {
+		return nil, nil, err
+	}
+	ae.Feed = root.Access.Feed
+	newRootManifest, err = GenerateAccessControlManifest(hex.EncodeToString(ref), newAccessKey, ae)
+	if err != nil {
+		return nil, nil, err
+	}
+	return newRootManifest, newActManifest, nil
+}
+
+// currentACTGrantees returns the grantee list of the ACT manifest without one,
+// with the given public key grantees, which must all have an entry in the manifest.
+// The remaining entries other than the publisher's are counted as password grantees.
+func currentACTGrantees(privateKey *ecdsa.PrivateKey, ae *AccessEntry, actManifest *Manifest, current []string) (*ACTGrantees, error) {
+	paths := make(map[string]bool)
+	for _, e := range actManifest.Entries {
+		paths[e.Path] = true
+	}
+	list := &ACTGrantees{}
+	for _, v := range current {
+		if v == ae.Publisher || containsString(list.PublicKeys, v) {
+			continue
+		}
+		sessionKey, err := granteeSessionKey(privateKey, v, ae.keySalt())
+		if err != nil {
+			return nil, err
+		}
+		lookupKey, _ := actKeys(sessionKey)
+		if !paths[hex.EncodeToString(lookupKey)] {
+			return nil, fmt.Errorf("%s is not a grantee", v)
+		}
+		list.PublicKeys = append(list.PublicKeys, v)
+	}
+	// one of the entries is of the publisher
+	if n := len(actManifest.Entries) - 1 - len(list.PublicKeys); n > 0 {
+		list.Passwords = n
+	}
+	return list, nil
+}
+
+// EncryptACTReference encrypts a reference with the access key of the ACT manifest referred to
+// by the access entry. The publisher of a feed-backed grant updates the feed of the grant with
+// the encrypted reference to change the content behind the root access manifest.
+func EncryptACTReference(privateKey *ecdsa.PrivateKey, ae *AccessEntry, actManifest *Manifest, ref string) ([]byte, error) {
+	if err := checkPublisher(privateKey, ae); err != nil {
+		return nil, err
+	}
+	accessKey, err := publisherAccessKey(privateKey, ae, actManifest)
+	if err != nil {
+		return nil, err
+	}
+	refBytes, err := hex.DecodeString(ref)
+	if err != nil {
+		return nil, err
+	}
+	enc := NewRefEncryption(len(refBytes))
+	return enc.Encrypt(refBytes, accessKey)
+}
+
+// DecryptACTReference decrypts a reference encrypted with the access key of the ACT manifest
+// referred to by the access entry, such as the latest update of the feed of a feed-backed grant.
+func DecryptACTReference(privateKey *ecdsa.PrivateKey, ae *AccessEntry, actManifest *Manifest, encryptedRef []byte) (string, error) {
+	if err := checkPublisher(privateKey, ae); err != nil {
+		return "", err
+	}
+	accessKey, err := publisherAccessKey(privateKey, ae, actManifest)
+	if err != nil {
+		return "", err
+	}
+	if len(encryptedRef) <= 8 {
+		return "", ErrDecrypt
+	}
+	enc := NewRefEncryption(len(encryptedRef) - 8)
+	ref, err := enc.Decrypt(encryptedRef, accessKey)
+	if err != nil {
+		return "", ErrDecrypt
+	}
+	return hex.EncodeToString(ref), nil
+}
+
+// checkPublisher returns ErrNotPublisher if the private key is not the key
+// of the publisher of the ACT access entry
+func checkPublisher(privateKey *ecdsa.PrivateKey, ae *AccessEntry) error {
+	if ae == nil || ae.Type != AccessTypeACT {
+		return errors.New("not an ACT access entry")
+	}
+	if ae.Publisher != hex.EncodeToString(crypto.CompressPubkey(&privateKey.PublicKey)) {
+		return ErrNotPublisher
+	}
+	return nil
+}
+
+// publisherAccessKey decrypts the access key from the publisher's own entry in the ACT manifest
+func publisherAccessKey(privateKey *ecdsa.PrivateKey, ae *AccessEntry, actManifest *Manifest) ([]byte, error) {
+	sessionKey, err := NewSessionKeyPK(privateKey, &privateKey.PublicKey, ae.keySalt())
+	if err != nil {
+		return nil, err
+	}
+	lookupKey, accessKeyDecryptionKey := actKeys(sessionKey)
+	lk := hex.EncodeToString(lookupKey)
+	for _, e := range actManifest.Entries {
+		if e.Path != lk {
+			continue
+		}
+		ciphertext, err := hex.DecodeString(e.Hash)
+		if err != nil {
+			return nil, err
+		}
+		enc := NewRefEncryption(len(ciphertext) - 8)
+		accessKey, err := enc.Decrypt(ciphertext, accessKeyDecryptionKey)
+		if err != nil {
+			return nil, ErrDecrypt
+		}
+		return accessKey, nil
+	}
+	return nil, ErrNotPublisher
+}
+
+// actKeys derives the path of a grantee's entry in the ACT manifest and the key
+// the access key is encrypted with in the entry from the grantee's session key
+func actKeys(sessionKey []byte) (lookupKey, accessKeyEncryptionKey []byte) {
+	hasher := sha3.NewLegacyKeccak256()
+	hasher.Write(append(sessionKey, 0))
+	lookupKey = hasher.Sum(nil)
+
+	hasher.Reset()
+	hasher.Write(append(sessionKey, 1))
+	accessKeyEncryptionKey = hasher.Sum(nil)
+	return lookupKey, accessKeyEncryptionKey
+}
+
+// newACTEntry returns the ACT manifest entry with the access key
+// for the grantee with the given session key
+func newACTEntry(sessionKey, accessKey []byte) (ManifestEntry, error) {
+	lookupKey, accessKeyEncryptionKey := actKeys(sessionKey)
+	enc := NewRefEncryption(len(accessKey))
+	encryptedAccessKey, err := enc.Encrypt(accessKey, accessKeyEncryptionKey)
+	if err != nil {
+		return ManifestEntry{}, err
+	}
+	return ManifestEntry{
+		Path:        hex.EncodeToString(lookupKey),
+		Hash:        hex.EncodeToString(encryptedAccessKey),
+		ContentType: "text/plain",
+	}, nil
+}
+
+// granteeSessionKey returns the session key of the publisher and the grantee
+// with the hex encoded compressed public key
+func granteeSessionKey(privateKey *ecdsa.PrivateKey, grantee string, salt []byte) ([]byte, error) {
+	b, err := hex.DecodeString(grantee)
+	if err != nil {
+		log.Error("error decoding grantee public key", "err", err)
+		return nil, err
+	}
+	granteePub, err := crypto.DecompressPubkey(b)
+	if err != nil {
+		log.Error("error decompressing grantee public key", "err", err)
+		return nil, err
+	}
+	return NewSessionKeyPK(privateKey, granteePub, salt)
+}
+
+// actGranteesKey derives the key the grantee list is encrypted with from the publisher's
+// own session key and a random nonce, which is stored with the encrypted list
+// so that a key is never reused for different lists
+func actGranteesKey(privateKey *ecdsa.PrivateKey, salt, nonce []byte) ([]byte, error) {
+	sessionKey, err := NewSessionKeyPK(privateKey, &privateKey.PublicKey, salt)
+	if err != nil {
+		return nil, err
+	}
+	return crypto.Keccak256(sessionKey, []byte{2}, nonce), nil
+}
+
+// newACTGranteesEntry returns the ACT manifest entry with the grantee list encrypted for the publisher
+func newACTGranteesEntry(privateKey *ecdsa.PrivateKey, salt []byte, list *ACTGrantees) (ManifestEntry, error) {
+	data, err := json.Marshal(list)
+	if err != nil {
+		return ManifestEntry{}, err
+	}
+	nonce := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return ManifestEntry{}, err
+	}
+	key, err := actGranteesKey(privateKey, salt, nonce)
+	if err != nil {
+		return ManifestEntry{}, err
+	}
+	enc := NewRefEncryption(len(data))
+	encrypted, err := enc.Encrypt(data, key)
+	if err != nil {
+		return ManifestEntry{}, err
+	}
+	return ManifestEntry{
+		Path:        actGranteesPath,
+		Hash:        hex.EncodeToString(append(nonce, encrypted...)),
+		ContentType: "application/octet-stream",
+	}, nil
+}
+
+// decryptACTGrantees decrypts the grantee list from the hash of its ACT manifest entry
+func decryptACTGrantees(privateKey *ecdsa.PrivateKey, salt []byte, hash string) (*ACTGrantees, error) {
+	b, err := hex.DecodeString(hash)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) < 32+8 {
+		return nil, errors.New("invalid grantee list entry")
+	}
+	key, err := actGranteesKey(privateKey, salt, b[:32])
+	if err != nil {
+		return nil, err
+	}
+	enc := NewRefEncryption(len(b) - 32 - 8)
+	data, err := enc.Decrypt(b[32:], key)
+	if err != nil {
+		return nil, ErrNotPublisher
+	}
+	list := &ACTGrantees{}
+	if err := json.Unmarshal(data, list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
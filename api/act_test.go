@@ -0,0 +1,293 @@
+// Copyright 2019 The Swarm Authors
+// This file is part of the Swarm library.
+//
+// The Swarm library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Swarm library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Swarm library. If not, see <http://www.gnu.org/licenses/>.
+
+package api
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethersphere/swarm/sctx"
+)
+
+// TestACTGrantees tests listing, adding and removing the grantees of an ACT manifest
+func TestACTGrantees(t *testing.T) {
+	publisher, _ := crypto.GenerateKey()
+	alice, _ := crypto.GenerateKey()
+	bob, _ := crypto.GenerateKey()
+	alicePub := hex.EncodeToString(crypto.CompressPubkey(&alice.PublicKey))
+	bobPub := hex.EncodeToString(crypto.CompressPubkey(&bob.PublicKey))
+	ref := hex.EncodeToString(crypto.Keccak256([]byte("content")))
+
+	salt := make([]byte, 32)
+	accessKey, ae, actManifest, err := DoACT(publisher, salt, []string{alicePub}, nil, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err := GenerateAccessControlManifest(ref, accessKey, ae)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	list, err := ListACTGrantees(publisher, ae, actManifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.PublicKeys) != 1 || list.PublicKeys[0] != alicePub || list.Passwords != 0 {
+		t.Fatalf("unexpected grantees %+v", list)
+	}
+	if _, err := ListACTGrantees(alice, ae, actManifest); err != ErrNotPublisher {
+		t.Fatalf("expected error %v, got %v", ErrNotPublisher, err)
+	}
+	fingerprint, err := GranteeFingerprint(alicePub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fingerprint) != 16 {
+		t.Fatalf("unexpected fingerprint %s", fingerprint)
+	}
+
+	// bob is added with the same access key
+	actManifest, err = AddACTGrantees(publisher, ae, actManifest, []string{bobPub}, []string{"secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	list, err = ListACTGrantees(publisher, ae, actManifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.PublicKeys) != 2 || list.PublicKeys[1] != bobPub || list.Passwords != 1 {
+		t.Fatalf("unexpected grantees %+v", list)
+	}
+	for _, grantee := range []*ecdsa.PrivateKey{alice, bob} {
+		if got := granteeRef(t, grantee, &publisher.PublicKey, root, actManifest); got != ref {
+			t.Fatalf("expected reference %s, got %s", ref, got)
+		}
+	}
+
+	// alice is removed, which rotates the access key and the salt
+	newRoot, newActManifest, err := RemoveACTGrantees(publisher, root, actManifest, []string{alicePub}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if newRoot.Entries[0].Hash == root.Entries[0].Hash {
+		t.Fatal("expected the reference to be encrypted with a new access key")
+	}
+	list, err = ListACTGrantees(publisher, newRoot.Entries[0].Access, newActManifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.PublicKeys) != 1 || list.PublicKeys[0] != bobPub || list.Passwords != 0 {
+		t.Fatalf("unexpected grantees %+v", list)
+	}
+	if got := granteeRef(t, bob, &publisher.PublicKey, newRoot, newActManifest); got != ref {
+		t.Fatalf("expected reference %s, got %s", ref, got)
+	}
+	if got := granteeRef(t, alice, &publisher.PublicKey, newRoot, newActManifest); got != "" {
+		t.Fatal("expected removed grantee not to have access")
+	}
+	// the previous access key does not decrypt the new reference
+	enc := NewRefEncryption(32)
+	encrypted, _ := hex.DecodeString(newRoot.Entries[0].Hash)
+	if got, err := enc.Decrypt(encrypted, accessKey); err == nil && hex.EncodeToString(got) == ref {
+		t.Fatal("expected the previous access key not to decrypt the reference")
+	}
+
+	// the latest update of the feed of a feed-backed grant is re-encrypted with the new access key
+	feedRef := strings.Repeat("ab", 32)
+	update, err := EncryptACTReference(publisher, root.Entries[0].Access, actManifest, feedRef)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decrypted, err := DecryptACTReference(publisher, root.Entries[0].Access, actManifest, update)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decrypted != feedRef {
+		t.Fatalf("expected feed reference %s, got %s", feedRef, decrypted)
+	}
+	update, err = EncryptACTReference(publisher, newRoot.Entries[0].Access, newActManifest, decrypted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := DecryptACTReference(publisher, newRoot.Entries[0].Access, newActManifest, update); err != nil || got != feedRef {
+		t.Fatalf("expected re-encrypted feed reference %s, got %s, %v", feedRef, got, err)
+	}
+
+	if _, _, err := RemoveACTGrantees(publisher, newRoot, newActManifest, []string{alicePub}, nil, nil); err == nil {
+		t.Fatal("expected error removing a public key that is not a grantee")
+	}
+	if _, _, err := RemoveACTGrantees(publisher, newRoot, newActManifest, []string{bobPub}, nil, nil); err == nil {
+		t.Fatal("expected error removing all grantees")
+	}
+}
+
+// TestACTGranteesWithoutList tests adding and removing the grantees of an ACT
+// manifest created before the grantee lists were kept
+func TestACTGranteesWithoutList(t *testing.T) {
+	publisher, _ := crypto.GenerateKey()
+	alice, _ := crypto.GenerateKey()
+	bob, _ := crypto.GenerateKey()
+	carol, _ := crypto.GenerateKey()
+	alicePub := hex.EncodeToString(crypto.CompressPubkey(&alice.PublicKey))
+	bobPub := hex.EncodeToString(crypto.CompressPubkey(&bob.PublicKey))
+	carolPub := hex.EncodeToString(crypto.CompressPubkey(&carol.PublicKey))
+	ref := hex.EncodeToString(crypto.Keccak256([]byte("content")))
+
+	salt := make([]byte, 32)
+	accessKey, ae, actManifest, err := DoACT(publisher, salt, []string{alicePub}, []string{"secret"}, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err := GenerateAccessControlManifest(ref, accessKey, ae)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries := actManifest.Entries[:0]
+	for _, e := range actManifest.Entries {
+		if e.Path != actGranteesPath {
+			entries = append(entries, e)
+		}
+	}
+	actManifest.Entries = entries
+
+	// bob is added without a grantee list
+	actManifest, err = AddACTGrantees(publisher, ae, actManifest, []string{bobPub}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ListACTGrantees(publisher, ae, actManifest); err != ErrNoGranteeList {
+		t.Fatalf("expected error %v, got %v", ErrNoGranteeList, err)
+	}
+	if got := granteeRef(t, bob, &publisher.PublicKey, root, actManifest); got != ref {
+		t.Fatalf("expected reference %s, got %s", ref, got)
+	}
+
+	// grantees can only be removed with the full list of public key grantees
+	if _, _, err := RemoveACTGrantees(publisher, root, actManifest, []string{alicePub}, nil, nil); err != ErrNoGranteeList {
+		t.Fatalf("expected error %v, got %v", ErrNoGranteeList, err)
+	}
+	if _, _, err := RemoveACTGrantees(publisher, root, actManifest, []string{alicePub}, []string{alicePub, carolPub}, nil); err == nil {
+		t.Fatal("expected error with a public key that is not a grantee in the full list")
+	}
+	newRoot, newActManifest, err := RemoveACTGrantees(publisher, root, actManifest, []string{alicePub}, []string{alicePub, bobPub}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	list, err := ListACTGrantees(publisher, newRoot.Entries[0].Access, newActManifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.PublicKeys) != 1 || list.PublicKeys[0] != bobPub || list.Passwords != 0 {
+		t.Fatalf("unexpected grantees %+v", list)
+	}
+	if got := granteeRef(t, bob, &publisher.PublicKey, newRoot, newActManifest); got != ref {
+		t.Fatalf("expected reference %s, got %s", ref, got)
+	}
+	if got := granteeRef(t, alice, &publisher.PublicKey, newRoot, newActManifest); got != "" {
+		t.Fatal("expected removed grantee not to have access")
+	}
+}
+
+// granteeRef decrypts the reference in the root access manifest the way a grantee does,
+// returning an empty string if the grantee has no entry in the ACT manifest
+func granteeRef(t *testing.T, grantee *ecdsa.PrivateKey, publisher *ecdsa.PublicKey, root, actManifest *Manifest) string {
+	t.Helper()
+	sessionKey, err := NewSessionKeyPK(grantee, publisher, root.Entries[0].Access.keySalt())
+	if err != nil {
+		t.Fatal(err)
+	}
+	lookupKey, accessKeyDecryptionKey := actKeys(sessionKey)
+	for _, e := range actManifest.Entries {
+		if e.Path != hex.EncodeToString(lookupKey) {
+			continue
+		}
+		ciphertext, _ := hex.DecodeString(e.Hash)
+		accessKey, err := NewRefEncryption(len(ciphertext)-8).Decrypt(ciphertext, accessKeyDecryptionKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		encrypted, _ := hex.DecodeString(root.Entries[0].Hash)
+		ref, err := NewRefEncryption(len(encrypted)-8).Decrypt(encrypted, accessKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return hex.EncodeToString(ref)
+	}
+	return ""
+}
+
+// TestACTExpiry tests that time-limited grants keep their expiry and feed in the manifest,
+// that the expiry can not be changed without invalidating the grant, and that expired grants are refused
+func TestACTExpiry(t *testing.T) {
+	publisher, _ := crypto.GenerateKey()
+	grantee, _ := crypto.GenerateKey()
+	granteePub := hex.EncodeToString(crypto.CompressPubkey(&grantee.PublicKey))
+	ref := hex.EncodeToString(crypto.Keccak256([]byte("content")))
+	expires := time.Now().Add(time.Hour).Truncate(time.Second)
+
+	salt := make([]byte, 32)
+	accessKey, ae, actManifest, err := DoACT(publisher, salt, []string{granteePub}, nil, expires)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ae.Feed = hex.EncodeToString(crypto.Keccak256([]byte("feed")))
+	root, err := GenerateAccessControlManifest(ref, accessKey, ae)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := json.Marshal(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root = &Manifest{}
+	if err := json.Unmarshal(data, root); err != nil {
+		t.Fatal(err)
+	}
+	access := root.Entries[0].Access
+	if !access.Expires.Equal(expires) || access.Feed != ae.Feed {
+		t.Fatalf("expected expiry %v and feed %s, got %v and %s", expires, ae.Feed, access.Expires, access.Feed)
+	}
+	if access.Expired() {
+		t.Fatal("expected grant not to be expired")
+	}
+	if got := granteeRef(t, grantee, &publisher.PublicKey, root, actManifest); got != ref {
+		t.Fatalf("expected reference %s, got %s", ref, got)
+	}
+
+	// extending the expiry in the manifest invalidates the grant
+	access.Expires = expires.Add(24 * time.Hour)
+	if got := granteeRef(t, grantee, &publisher.PublicKey, root, actManifest); got != "" {
+		t.Fatal("expected grant with a changed expiry not to give access")
+	}
+
+	access.Expires = time.Now().Add(-time.Minute)
+	if !access.Expired() {
+		t.Fatal("expected grant to be expired")
+	}
+	a := &API{}
+	ctx := sctx.SetHost(context.Background(), "localhost")
+	if err := a.doDecrypt(ctx, "", grantee)(&root.Entries[0]); err != ErrGrantExpired {
+		t.Fatalf("expected error %v, got %v", ErrGrantExpired, err)
+	}
+}
@@ -46,6 +46,7 @@ import (
 	"github.com/ethersphere/swarm/storage"
 	"github.com/ethersphere/swarm/storage/feed"
 	"github.com/ethersphere/swarm/storage/feed/lookup"
+	lru "github.com/hashicorp/golang-lru"
 	"github.com/opentracing/opentracing-go"
 )
 
@@ -194,10 +195,13 @@ type API struct {
 	rns       Resolver //provides access to rns resolvers
 	Tags      *chunk.Tags
 	Decryptor func(context.Context, string) DecryptFunc
+
+	searchIndexes *lru.Cache // decoded search indexes by reference
 }
 
 // NewAPI the api constructor initialises a new API instance.
 func NewAPI(fileStore *storage.FileStore, dns Resolver, rns Resolver, feedHandler *feed.Handler, pk *ecdsa.PrivateKey, tags *chunk.Tags) (self *API) {
+	searchIndexes, _ := lru.New(searchIndexCacheCapacity)
 	self = &API{
 		fileStore: fileStore,
 		dns:       dns,
@@ -207,6 +211,7 @@ func NewAPI(fileStore *storage.FileStore, dns Resolver, rns Resolver, feedHandle
 		Decryptor: func(ctx context.Context, credentials string) DecryptFunc {
 			return self.doDecrypt(ctx, credentials, pk)
 		},
+		searchIndexes: searchIndexes,
 	}
 	return
 }
@@ -275,6 +280,13 @@ func tld(address string) (tld string) {
 
 // Resolve resolves a URI to an Address using the MultiResolver.
 func (a *API) ResolveURI(ctx context.Context, uri *URI, credentials string) (storage.Address, error) {
+	addr, _, err := a.ResolveURIEntry(ctx, uri, credentials)
+	return addr, err
+}
+
+// ResolveURIEntry resolves a URI to an Address as ResolveURI does, also returning
+// the manifest entry of the URI path, which is nil if the URI has no path.
+func (a *API) ResolveURIEntry(ctx context.Context, uri *URI, credentials string) (storage.Address, *ManifestEntry, error) {
 	apiResolveCount.Inc(1)
 	log.Trace("resolving", "uri", uri.Addr)
 
@@ -288,22 +300,22 @@ func (a *API) ResolveURI(ctx context.Context, uri *URI, credentials string) (sto
 	if uri.Immutable() {
 		key := uri.Address()
 		if key == nil {
-			return nil, fmt.Errorf("immutable address not a content hash: %q", uri.Addr)
+			return nil, nil, fmt.Errorf("immutable address not a content hash: %q", uri.Addr)
 		}
-		return key, nil
+		return key, nil, nil
 	}
 
 	addr, err := a.Resolve(ctx, uri.Addr)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	if uri.Path == "" {
-		return addr, nil
+		return addr, nil, nil
 	}
 	walker, err := a.NewManifestWalker(ctx, addr, a.Decryptor(ctx, credentials), nil)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	var entry *ManifestEntry
 	walker.Walk(func(e *ManifestEntry) error {
@@ -327,25 +339,40 @@ func (a *API) ResolveURI(ctx context.Context, uri *URI, credentials string) (sto
 		return ErrSkipManifest
 	})
 	if entry == nil {
-		return nil, errors.New("not found")
+		return nil, nil, errors.New("not found")
 	}
 	addr = storage.Address(common.Hex2Bytes(entry.Hash))
-	return addr, nil
+	return addr, entry, nil
 }
 
 // Get uses iterative manifest retrieval and prefix matching
 // to resolve basePath to content using FileStore retrieve
 // it returns a section reader, mimeType, status, the key of the actual content and an error
 func (a *API) Get(ctx context.Context, decrypt DecryptFunc, manifestAddr storage.Address, path string) (reader storage.LazySectionReader, mimeType string, status int, contentAddr storage.Address, err error) {
+	reader, entry, status, contentAddr, err := a.GetEntry(ctx, decrypt, manifestAddr, path)
+	if entry != nil {
+		mimeType = entry.ContentType
+	}
+	return reader, mimeType, status, contentAddr, err
+}
+
+// GetEntry resolves the path to content as Get does, returning the manifest
+// entry of the content instead of its mime type. The content read from
+// the reader is compressed if the entry has a content encoding.
+func (a *API) GetEntry(ctx context.Context, decrypt DecryptFunc, manifestAddr storage.Address, path string) (reader storage.LazySectionReader, manifestEntry *ManifestEntry, status int, contentAddr storage.Address, err error) {
 	log.Debug("api.get", "key", manifestAddr, "path", path)
 	apiGetCount.Inc(1)
 	trie, err := loadManifest(ctx, a.fileStore, manifestAddr, nil, decrypt)
 	if err != nil {
 		apiGetNotFound.Inc(1)
 		status = http.StatusNotFound
-		return nil, "", http.StatusNotFound, nil, err
+		return nil, nil, http.StatusNotFound, nil, err
 	}
+	return a.getEntry(ctx, decrypt, trie, manifestAddr, path)
+}
 
+// getEntry resolves the path to content in the loaded manifest trie
+func (a *API) getEntry(ctx context.Context, decrypt DecryptFunc, trie *manifestTrie, manifestAddr storage.Address, path string) (reader storage.LazySectionReader, manifestEntry *ManifestEntry, status int, contentAddr storage.Address, err error) {
 	log.Debug("trie getting entry", "key", manifestAddr, "path", path)
 	entry, _ := trie.getEntry(path)
 
@@ -356,22 +383,22 @@ func (a *API) Get(ctx context.Context, decrypt DecryptFunc, manifestAddr storage
 			log.Debug("entry is manifest", "key", manifestAddr, "new key", entry.Hash)
 			adr, err := hex.DecodeString(entry.Hash)
 			if err != nil {
-				return nil, "", 0, nil, err
+				return nil, nil, 0, nil, err
 			}
-			return a.Get(ctx, decrypt, adr, entry.Path)
+			return a.GetEntry(ctx, decrypt, adr, entry.Path)
 		}
 
 		// we need to do some extra work if this is a Swarm feed manifest
 		if entry.ContentType == FeedContentType {
 			if entry.Feed == nil {
-				return reader, mimeType, status, nil, fmt.Errorf("Cannot decode Feed in manifest")
+				return reader, nil, status, nil, fmt.Errorf("Cannot decode Feed in manifest")
 			}
 			_, err := a.feed.Lookup(ctx, feed.NewQueryLatest(entry.Feed, lookup.NoClue))
 			if err != nil {
 				apiGetNotFound.Inc(1)
 				status = http.StatusNotFound
 				log.Debug(fmt.Sprintf("get feed update content error: %v", err))
-				return reader, mimeType, status, nil, err
+				return reader, nil, status, nil, err
 			}
 			// get the data of the update
 			_, contentAddr, err := a.feed.GetContent(entry.Feed)
@@ -379,7 +406,7 @@ func (a *API) Get(ctx context.Context, decrypt DecryptFunc, manifestAddr storage
 				apiGetNotFound.Inc(1)
 				status = http.StatusNotFound
 				log.Warn(fmt.Sprintf("get feed update content error: %v", err))
-				return reader, mimeType, status, nil, err
+				return reader, nil, status, nil, err
 			}
 
 			// extract content hash
@@ -388,7 +415,7 @@ func (a *API) Get(ctx context.Context, decrypt DecryptFunc, manifestAddr storage
 				status = http.StatusUnprocessableEntity
 				errorMessage := fmt.Sprintf("invalid swarm hash in feed update. Expected %d bytes. Got %d", storage.AddressLength, len(contentAddr))
 				log.Warn(errorMessage)
-				return reader, mimeType, status, nil, errors.New(errorMessage)
+				return reader, nil, status, nil, errors.New(errorMessage)
 			}
 			manifestAddr = storage.Address(contentAddr)
 			log.Trace("feed update contains swarm hash", "key", manifestAddr)
@@ -399,7 +426,7 @@ func (a *API) Get(ctx context.Context, decrypt DecryptFunc, manifestAddr storage
 				apiGetNotFound.Inc(1)
 				status = http.StatusNotFound
 				log.Warn(fmt.Sprintf("loadManifestTrie (feed update) error: %v", err))
-				return reader, mimeType, status, nil, err
+				return reader, nil, status, nil, err
 			}
 
 			// finally, get the manifest entry
@@ -410,7 +437,7 @@ func (a *API) Get(ctx context.Context, decrypt DecryptFunc, manifestAddr storage
 				apiGetNotFound.Inc(1)
 				err = fmt.Errorf("manifest (feed update) entry for '%s' not found", path)
 				log.Trace("manifest (feed update) entry not found", "key", manifestAddr, "path", path)
-				return reader, mimeType, status, nil, err
+				return reader, nil, status, nil, err
 			}
 		}
 
@@ -420,10 +447,10 @@ func (a *API) Get(ctx context.Context, decrypt DecryptFunc, manifestAddr storage
 		status = entry.Status
 		if status == http.StatusMultipleChoices {
 			apiGetHTTP300.Inc(1)
-			return nil, entry.ContentType, status, contentAddr, err
+			return nil, &entry.ManifestEntry, status, contentAddr, err
 		}
-		mimeType = entry.ContentType
-		log.Debug("content lookup key", "key", contentAddr, "mimetype", mimeType)
+		manifestEntry = &entry.ManifestEntry
+		log.Debug("content lookup key", "key", contentAddr, "mimetype", entry.ContentType)
 		reader, _ = a.fileStore.Retrieve(ctx, contentAddr)
 	} else {
 		// no entry found
@@ -435,6 +462,101 @@ func (a *API) Get(ctx context.Context, decrypt DecryptFunc, manifestAddr storage
 	return
 }
 
+// HasEntry reports whether the manifest has an entry with exactly the given path.
+// Note that Get falls back to the default entry of the manifest and serves
+// entries that only match a prefix of the path.
+func (a *API) HasEntry(ctx context.Context, decrypt DecryptFunc, manifestAddr storage.Address, path string) (bool, error) {
+	m, err := a.LoadManifest(ctx, decrypt, manifestAddr)
+	if err != nil {
+		return false, err
+	}
+	return m.HasEntry(path), nil
+}
+
+// IsFeed reports whether the manifest resolves the path through a feed,
+// so that the content Get returns for it changes with feed updates
+func (a *API) IsFeed(ctx context.Context, decrypt DecryptFunc, manifestAddr storage.Address, path string) (bool, error) {
+	m, err := a.LoadManifest(ctx, decrypt, manifestAddr)
+	if err != nil {
+		return false, err
+	}
+	return m.IsFeed(ctx, path)
+}
+
+// GetWebsite returns the website configuration of the manifest,
+// or nil if it has none
+func (a *API) GetWebsite(ctx context.Context, decrypt DecryptFunc, manifestAddr storage.Address) (*WebsiteConfig, error) {
+	m, err := a.LoadManifest(ctx, decrypt, manifestAddr)
+	if err != nil {
+		return nil, err
+	}
+	return m.Website(), nil
+}
+
+// LoadedManifest is a manifest loaded for looking up several paths in it,
+// for example while serving a single request, without retrieving
+// and decoding it again for every lookup.
+// Subtries are loaded on demand and kept for later lookups.
+type LoadedManifest struct {
+	api     *API
+	addr    storage.Address
+	decrypt DecryptFunc
+	trie    *manifestTrie
+}
+
+// LoadManifest retrieves and decodes the manifest at manifestAddr
+func (a *API) LoadManifest(ctx context.Context, decrypt DecryptFunc, manifestAddr storage.Address) (*LoadedManifest, error) {
+	trie, err := loadManifest(ctx, a.fileStore, manifestAddr, nil, decrypt)
+	if err != nil {
+		return nil, err
+	}
+	return &LoadedManifest{
+		api:     a,
+		addr:    manifestAddr,
+		decrypt: decrypt,
+		trie:    trie,
+	}, nil
+}
+
+// Address returns the address of the manifest
+func (m *LoadedManifest) Address() storage.Address {
+	return m.addr
+}
+
+// GetEntry resolves the path to content as API.GetEntry does
+func (m *LoadedManifest) GetEntry(ctx context.Context, path string) (reader storage.LazySectionReader, manifestEntry *ManifestEntry, status int, contentAddr storage.Address, err error) {
+	log.Debug("api.get", "key", m.addr, "path", path)
+	apiGetCount.Inc(1)
+	return m.api.getEntry(ctx, m.decrypt, m.trie, m.addr, path)
+}
+
+// HasEntry reports whether the manifest has an entry with exactly the given path
+func (m *LoadedManifest) HasEntry(path string) bool {
+	return m.trie.getExactEntry(RegularSlashes(path), nil) != nil
+}
+
+// IsFeed reports whether the manifest resolves the path through a feed
+func (m *LoadedManifest) IsFeed(ctx context.Context, path string) (bool, error) {
+	entry, _ := m.trie.getEntry(path)
+	if entry == nil {
+		return false, nil
+	}
+	if entry.ContentType == ManifestType {
+		adr, err := hex.DecodeString(entry.Hash)
+		if err != nil {
+			return false, err
+		}
+		return m.api.IsFeed(ctx, m.decrypt, adr, entry.Path)
+	}
+	return entry.ContentType == FeedContentType, nil
+}
+
+// Website returns the website configuration of the manifest,
+// or nil if it has none
+func (m *LoadedManifest) Website() *WebsiteConfig {
+	return m.trie.website
+}
+
 // Delete handles removing a file from the manifest.
 // This creates a new manifest without the given path
 func (a *API) Delete(ctx context.Context, addr string, path string) (storage.Address, error) {
@@ -493,6 +615,16 @@ func (a *API) GetDirectoryTar(ctx context.Context, decrypt DecryptFunc, uri *URI
 				return err
 			}
 
+			// compressed content is written decompressed
+			content, err := Decompress(io.NewSectionReader(reader, 0, size), entry.ContentEncoding)
+			if err != nil {
+				return err
+			}
+			defer content.Close()
+			if entry.ContentEncoding != "" {
+				size = entry.Size
+			}
+
 			// write a tar header for the entry
 			hdr := &tar.Header{
 				Name:    entry.Path,
@@ -502,6 +634,7 @@ func (a *API) GetDirectoryTar(ctx context.Context, decrypt DecryptFunc, uri *URI
 				Xattrs: map[string]string{
 					"user.swarm.content-type": entry.ContentType,
 				},
+				PAXRecords: MetadataPAXRecords(entry.Metadata),
 			}
 
 			if err := tw.WriteHeader(hdr); err != nil {
@@ -509,7 +642,7 @@ func (a *API) GetDirectoryTar(ctx context.Context, decrypt DecryptFunc, uri *URI
 			}
 
 			// copy the file into the tar stream
-			n, err := io.Copy(tw, io.LimitReader(reader, hdr.Size))
+			n, err := io.Copy(tw, io.LimitReader(content, hdr.Size))
 			if err != nil {
 				return err
 			} else if n != size {
@@ -730,6 +863,7 @@ func (a *API) UploadTar(ctx context.Context, bodyReader io.ReadCloser, manifestP
 			Mode:        hdr.Mode,
 			Size:        hdr.Size,
 			ModTime:     hdr.ModTime,
+			Metadata:    MetadataFromPAX(hdr.PAXRecords),
 		}
 		contentKey, err = mw.AddEntry(ctx, tr, entry)
 		if err != nil {
@@ -749,6 +883,9 @@ func (a *API) UploadTar(ctx context.Context, bodyReader io.ReadCloser, manifestP
 				Mode:        hdr.Mode,
 				Size:        hdr.Size,
 				ModTime:     hdr.ModTime,
+				// the default entry refers to the content as stored for the file entry
+				ContentEncoding: mw.contentEncoding,
+				Metadata:        MetadataFromPAX(hdr.PAXRecords),
 			}
 			contentKey, err = mw.AddEntry(ctx, nil, entry)
 			if err != nil {
@@ -146,6 +146,60 @@ func TestApiPut(t *testing.T) {
 	})
 }
 
+// TestLoadedManifest tests looking up several paths in a manifest loaded once
+func TestLoadedManifest(t *testing.T) {
+	testAPI(t, func(api *API, tags *chunk.Tags, toEncrypt bool) {
+		ctx := context.TODO()
+		content := "<html>home</html>"
+		contentAddr, wait, err := api.Store(ctx, strings.NewReader(content), int64(len(content)), toEncrypt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := wait(ctx); err != nil {
+			t.Fatal(err)
+		}
+		manifest := fmt.Sprintf(`{"entries":[{"hash":"%v","path":"index.html","contentType":"text/html"}],"website":{"indexDocument":"index.html"}}`, contentAddr)
+		manifestAddr, wait, err := api.Store(ctx, strings.NewReader(manifest), int64(len(manifest)), toEncrypt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := wait(ctx); err != nil {
+			t.Fatal(err)
+		}
+
+		m, err := api.LoadManifest(ctx, NOOPDecrypt, manifestAddr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if website := m.Website(); website == nil || website.IndexDocument != "index.html" {
+			t.Fatalf("expected website with index document index.html, got %+v", website)
+		}
+		if !m.HasEntry("index.html") {
+			t.Fatal("expected entry index.html")
+		}
+		if m.HasEntry("missing.html") {
+			t.Fatal("unexpected entry missing.html")
+		}
+		if isFeed, err := m.IsFeed(ctx, "index.html"); err != nil || isFeed {
+			t.Fatalf("expected index.html not to be a feed, got %v, %v", isFeed, err)
+		}
+		reader, entry, _, _, err := m.GetEntry(ctx, "index.html")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if entry.ContentType != "text/html" {
+			t.Fatalf("expected content type text/html, got %q", entry.ContentType)
+		}
+		got, err := ioutil.ReadAll(io.NewSectionReader(reader, 0, int64(len(content))))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != content {
+			t.Fatalf("expected content %q, got %q", content, got)
+		}
+	})
+}
+
 // TestApiTagLarge tests that the the number of chunks counted is larger for a larger input
 func TestApiTagLarge(t *testing.T) {
 	const contentLength = 4096 * 4095
@@ -0,0 +1,61 @@
+// Copyright 2019 The Swarm Authors
+// This file is part of the Swarm library.
+//
+// The Swarm library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Swarm library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Swarm library. If not, see <http://www.gnu.org/licenses/>.
+
+package auth
+
+// API exposes API key management over RPC.
+// It must only be registered as a private (admin) API.
+type API struct {
+	keys *Keys
+}
+
+// NewAPI creates a new API key management RPC service.
+func NewAPI(keys *Keys) *API {
+	return &API{keys: keys}
+}
+
+// NewKey is returned on key creation and contains the secret token.
+type NewKey struct {
+	*Key
+	Token string `json:"token"`
+}
+
+// Create creates a new API key with the given name and scopes.
+func (a *API) Create(name string, scopes []string) (*NewKey, error) {
+	s := make([]Scope, 0, len(scopes))
+	for _, scope := range scopes {
+		parsed, err := ParseScope(scope)
+		if err != nil {
+			return nil, err
+		}
+		s = append(s, parsed)
+	}
+	token, key, err := a.keys.Create(name, s)
+	if err != nil {
+		return nil, err
+	}
+	return &NewKey{Key: key, Token: token}, nil
+}
+
+// Revoke revokes the API key with the given id.
+func (a *API) Revoke(id string) error {
+	return a.keys.Revoke(id)
+}
+
+// List returns all API keys, without their tokens.
+func (a *API) List() []*Key {
+	return a.keys.List()
+}
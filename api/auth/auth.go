@@ -0,0 +1,208 @@
+// Copyright 2019 The Swarm Authors
+// This file is part of the Swarm library.
+//
+// The Swarm library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Swarm library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Swarm library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package auth implements API keys with scopes that are used to
+// authorize requests to the Swarm HTTP gateway.
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethersphere/swarm/log"
+	"github.com/ethersphere/swarm/state"
+)
+
+const (
+	Version = "1.0"
+
+	keyPrefix = "auth_key_" // state store key prefix for API keys
+	tokenSize = 32          // number of random bytes in a token
+	idLength  = 16          // number of hex characters of the token hash used as key id
+)
+
+var (
+	ErrInvalidToken = errors.New("invalid api key")
+	ErrKeyNotFound  = errors.New("api key not found")
+	ErrInvalidScope = errors.New("invalid scope")
+)
+
+// Scope is a permission granted to an API key.
+type Scope string
+
+const (
+	ScopeRead       Scope = "read"        // retrieve content, lists, tags and feeds
+	ScopeUpload     Scope = "upload"      // upload content and modify manifests
+	ScopePin        Scope = "pin"         // list, pin and unpin content
+	ScopeFeedUpdate Scope = "feed-update" // create feed manifests and post feed updates
+	ScopeAdmin      Scope = "admin"       // all of the above
+)
+
+// Scopes lists all valid scopes.
+var Scopes = []Scope{ScopeRead, ScopeUpload, ScopePin, ScopeFeedUpdate, ScopeAdmin}
+
+// ParseScope validates a string representation of a scope.
+func ParseScope(s string) (Scope, error) {
+	for _, scope := range Scopes {
+		if string(scope) == strings.TrimSpace(s) {
+			return scope, nil
+		}
+	}
+	return "", fmt.Errorf("%v: %q", ErrInvalidScope, s)
+}
+
+// Key holds the information about an API key.
+// The token itself is never stored, only its SHA256 hash.
+type Key struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Scopes    []Scope   `json:"scopes"`
+	Created   time.Time `json:"created"`
+	TokenHash string    `json:"tokenHash"`
+}
+
+// HasScope returns true if the key is granted the scope,
+// either directly or through the admin scope.
+func (k *Key) HasScope(scope Scope) bool {
+	for _, s := range k.Scopes {
+		if s == scope || s == ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+// Keys manages API keys persisted in the state store.
+// All keys are kept in memory so that authenticating a request
+// does not require a database lookup.
+type Keys struct {
+	state state.Store
+	keys  map[string]*Key // key id to key
+	mu    sync.RWMutex
+}
+
+// New loads all existing API keys from the state store.
+func New(stateStore state.Store) (*Keys, error) {
+	k := &Keys{
+		state: stateStore,
+		keys:  make(map[string]*Key),
+	}
+	err := stateStore.Iterate(keyPrefix, func(_, value []byte) (bool, error) {
+		key := new(Key)
+		if err := json.Unmarshal(value, key); err != nil {
+			return true, err
+		}
+		k.keys[key.ID] = key
+		return false, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	log.Debug("loaded api keys", "count", len(k.keys))
+	return k, nil
+}
+
+// Create generates a new API key with the given name and scopes.
+// The returned token is the only copy of the secret and must be
+// handed to the client.
+func (k *Keys) Create(name string, scopes []Scope) (token string, key *Key, err error) {
+	if len(scopes) == 0 {
+		return "", nil, fmt.Errorf("%v: no scopes given", ErrInvalidScope)
+	}
+	for _, s := range scopes {
+		if _, err := ParseScope(string(s)); err != nil {
+			return "", nil, err
+		}
+	}
+	b := make([]byte, tokenSize)
+	if _, err := rand.Read(b); err != nil {
+		return "", nil, err
+	}
+	token = hex.EncodeToString(b)
+	hash := hashToken(token)
+	key = &Key{
+		ID:        hash[:idLength],
+		Name:      name,
+		Scopes:    scopes,
+		Created:   time.Now().UTC(),
+		TokenHash: hash,
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if err := k.state.Put(keyPrefix+key.ID, key); err != nil {
+		return "", nil, err
+	}
+	k.keys[key.ID] = key
+	return token, key, nil
+}
+
+// Revoke removes the API key with the given id.
+func (k *Keys) Revoke(id string) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if _, ok := k.keys[id]; !ok {
+		return ErrKeyNotFound
+	}
+	if err := k.state.Delete(keyPrefix + id); err != nil {
+		return err
+	}
+	delete(k.keys, id)
+	return nil
+}
+
+// List returns all API keys sorted by creation time.
+func (k *Keys) List() []*Key {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	keys := make([]*Key, 0, len(k.keys))
+	for _, key := range k.keys {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].Created.Before(keys[j].Created)
+	})
+	return keys
+}
+
+// Authenticate returns the API key that matches the token.
+func (k *Keys) Authenticate(token string) (*Key, error) {
+	if token == "" {
+		return nil, ErrInvalidToken
+	}
+	hash := hashToken(token)
+	k.mu.RLock()
+	key, ok := k.keys[hash[:idLength]]
+	k.mu.RUnlock()
+	if !ok || subtle.ConstantTimeCompare([]byte(key.TokenHash), []byte(hash)) != 1 {
+		return nil, ErrInvalidToken
+	}
+	return key, nil
+}
+
+func hashToken(token string) string {
+	h := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(h[:])
+}
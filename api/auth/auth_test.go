@@ -0,0 +1,101 @@
+// Copyright 2019 The Swarm Authors
+// This file is part of the Swarm library.
+//
+// The Swarm library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Swarm library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Swarm library. If not, see <http://www.gnu.org/licenses/>.
+
+package auth
+
+import (
+	"testing"
+
+	"github.com/ethersphere/swarm/state"
+)
+
+// TestKeys creates, authenticates, persists and revokes API keys
+func TestKeys(t *testing.T) {
+	store := state.NewInmemoryStore()
+	defer store.Close()
+
+	keys, err := New(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := keys.Create("none", nil); err == nil {
+		t.Fatal("expected error creating a key without scopes")
+	}
+	if _, _, err := keys.Create("invalid", []Scope{"delete-everything"}); err == nil {
+		t.Fatal("expected error creating a key with an invalid scope")
+	}
+
+	token, key, err := keys.Create("uploader", []Scope{ScopeRead, ScopeUpload})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := keys.Authenticate(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.ID != key.ID {
+		t.Fatalf("expected key id %s, got %s", key.ID, got.ID)
+	}
+	for scope, want := range map[Scope]bool{
+		ScopeRead:       true,
+		ScopeUpload:     true,
+		ScopePin:        false,
+		ScopeFeedUpdate: false,
+		ScopeAdmin:      false,
+	} {
+		if has := got.HasScope(scope); has != want {
+			t.Errorf("scope %s: expected %v, got %v", scope, want, has)
+		}
+	}
+
+	if _, err := keys.Authenticate(token[:len(token)-1] + "x"); err != ErrInvalidToken {
+		t.Fatalf("expected error %v, got %v", ErrInvalidToken, err)
+	}
+
+	// keys must survive a reload from the state store
+	reloaded, err := New(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := reloaded.Authenticate(token); err != nil {
+		t.Fatal(err)
+	}
+	if l := len(reloaded.List()); l != 1 {
+		t.Fatalf("expected 1 key, got %d", l)
+	}
+
+	if err := reloaded.Revoke(key.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := reloaded.Authenticate(token); err != ErrInvalidToken {
+		t.Fatalf("expected error %v, got %v", ErrInvalidToken, err)
+	}
+	if err := reloaded.Revoke(key.ID); err != ErrKeyNotFound {
+		t.Fatalf("expected error %v, got %v", ErrKeyNotFound, err)
+	}
+}
+
+// TestAdminScope checks that the admin scope grants all other scopes
+func TestAdminScope(t *testing.T) {
+	key := &Key{Scopes: []Scope{ScopeAdmin}}
+	for _, scope := range Scopes {
+		if !key.HasScope(scope) {
+			t.Errorf("expected admin key to have scope %s", scope)
+		}
+	}
+}
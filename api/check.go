@@ -0,0 +1,171 @@
+// Copyright 2019 The Swarm Authors
+// This file is part of the Swarm library.
+//
+// The Swarm library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Swarm library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Swarm library. If not, see <http://www.gnu.org/licenses/>.
+
+package api
+
+import (
+	"context"
+	"sync"
+
+	"github.com/ethersphere/swarm/chunk"
+	"github.com/ethersphere/swarm/storage"
+)
+
+// checkConcurrency is the maximum number of chunks
+// probed at once by CheckAvailability
+const checkConcurrency = 16
+
+// ProbeFunc retrieves the chunk from the network without storing it. It is
+// implemented by retrieval.Retrieval Probe method.
+type ProbeFunc func(ctx context.Context, addr chunk.Address) (chunk.Chunk, error)
+
+// Availability holds the result of the check whether all chunks
+// of a file or a manifest are retrievable from the network.
+type Availability struct {
+	Root       storage.Address   `json:"root"`
+	Chunks     int               `json:"chunks"`     // number of checked chunks
+	Available  int               `json:"available"`  // number of chunks retrievable from the network
+	Missing    []storage.Address `json:"missing"`    // chunks that are not retrievable from the network
+	Percentage float64           `json:"percentage"` // percentage of the available chunks
+	Complete   bool              `json:"complete"`   // false if not all chunks are checked, as some of the intermediate chunks are missing
+}
+
+// CheckAvailability walks the chunk tree of the file or the manifest with
+// the root address and probes every chunk whether it is retrievable from
+// the network. Chunks of the tree are read from the local store, or probed
+// if they are not stored locally. If a chunk is not found, it is reported as
+// missing and the chunks below it are not checked, so the result is not
+// complete, while the rest of the content, also the remaining entries of a
+// manifest, is checked.
+// The root of encrypted content is the reference with the encryption key,
+// with which the intermediate chunks are decrypted, while the chunks are
+// checked and reported by their addresses.
+func CheckAvailability(ctx context.Context, store storage.ChunkStore, probe ProbeFunc, root storage.Address) (*Availability, error) {
+	s := &probeStore{
+		ChunkStore: store,
+		probe:      probe,
+		probed:     make(map[string]chunk.Chunk),
+	}
+
+	var (
+		addrs   []storage.Address
+		missing int // number of chunks that are not found while walking
+	)
+	seen := make(map[string]struct{})
+	add := func(addr storage.Address) error {
+		if _, ok := seen[string(addr)]; ok {
+			return nil
+		}
+		seen[string(addr)] = struct{}{}
+		addrs = append(addrs, addr)
+		return nil
+	}
+	walkErr := WalkChunksMissing(ctx, s, root, add, func(addr storage.Address) error {
+		missing++
+		return add(addr)
+	})
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	available := make([]bool, len(addrs))
+	sem := make(chan struct{}, checkConcurrency)
+	var wg sync.WaitGroup
+	for i, addr := range addrs {
+		if ok, probed := s.isProbed(addr); probed {
+			available[i] = ok
+			continue
+		}
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		wg.Add(1)
+		go func(i int, addr storage.Address) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			_, err := probe(ctx, addr)
+			available[i] = err == nil
+		}(i, addr)
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	a := &Availability{
+		Root:     root,
+		Chunks:   len(addrs),
+		Missing:  make([]storage.Address, 0),
+		Complete: walkErr == nil && missing == 0,
+	}
+	for i, ok := range available {
+		if ok {
+			a.Available++
+		} else {
+			a.Missing = append(a.Missing, addrs[i])
+		}
+	}
+	if a.Chunks > 0 {
+		a.Percentage = float64(a.Available) * 100 / float64(a.Chunks)
+	}
+	return a, nil
+}
+
+// probeStore is the store used to walk a chunk tree, which reads
+// chunks from the local store and probes the ones that are not
+// stored locally, remembering the results of probes.
+type probeStore struct {
+	storage.ChunkStore
+	probe  ProbeFunc
+	mu     sync.Mutex
+	probed map[string]chunk.Chunk // probed chunks by address, nil if not available
+}
+
+func (s *probeStore) Get(ctx context.Context, mode chunk.ModeGet, addr chunk.Address) (chunk.Chunk, error) {
+	ch, err := s.ChunkStore.Get(ctx, mode, addr)
+	if err == nil {
+		return ch, nil
+	}
+
+	s.mu.Lock()
+	pch, probed := s.probed[string(addr)]
+	s.mu.Unlock()
+	if !probed {
+		pch, _ = s.probe(ctx, addr)
+
+		s.mu.Lock()
+		s.probed[string(addr)] = pch
+		s.mu.Unlock()
+	}
+	if pch == nil {
+		return nil, err
+	}
+	return pch, nil
+}
+
+// isProbed returns the availability of the chunk
+// and true if it is already probed.
+func (s *probeStore) isProbed(addr chunk.Address) (available, probed bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ch, probed := s.probed[string(addr)]
+	return ch != nil, probed
+}
@@ -0,0 +1,209 @@
+// Copyright 2019 The Swarm Authors
+// This file is part of the Swarm library.
+//
+// The Swarm library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Swarm library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Swarm library. If not, see <http://www.gnu.org/licenses/>.
+
+package api
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/ethersphere/swarm/chunk"
+	"github.com/ethersphere/swarm/storage"
+	"github.com/ethersphere/swarm/testutil"
+)
+
+// TestCheckAvailability validates that missing chunks of files and
+// manifests are reported, including the intermediate chunks that
+// are neither in the local store nor retrievable from the network,
+// also of encrypted content.
+func TestCheckAvailability(t *testing.T) {
+	testAPI(t, func(a *API, _ *chunk.Tags, toEncrypt bool) {
+		ctx := context.Background()
+		store := a.fileStore.ChunkStore
+
+		data := testutil.RandomBytes(1, 3*chunk.DefaultSize)
+		content, wait, err := a.Store(ctx, bytes.NewReader(data), int64(len(data)), toEncrypt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := wait(ctx); err != nil {
+			t.Fatal(err)
+		}
+		m := fmt.Sprintf(`{"entries":[{"hash":"%v","path":"file"}]}`, content)
+		root, wait, err := a.Store(ctx, strings.NewReader(m), int64(len(m)), toEncrypt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := wait(ctx); err != nil {
+			t.Fatal(err)
+		}
+		contentChunks := walkedChunks(t, store, content)
+		manifestChunks := walkedChunks(t, store, root)
+		// the address of the root chunk, without the encryption key
+		contentAddr := contentChunks[0]
+
+		// the network has all chunks of the manifest
+		// and the file, except the missing ones
+		network := make(map[string]chunk.Chunk)
+		for _, addr := range append(contentChunks, manifestChunks...) {
+			ch, err := store.Get(ctx, chunk.ModeGetLookup, addr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			network[string(addr)] = ch
+		}
+		missing := make(map[string]bool)
+		probe := func(ctx context.Context, addr chunk.Address) (chunk.Chunk, error) {
+			ch, ok := network[string(addr)]
+			if !ok || missing[string(addr)] {
+				return nil, chunk.ErrChunkNotFound
+			}
+			return ch, nil
+		}
+
+		check := func(name string, root storage.Address, want *Availability) {
+			t.Helper()
+
+			got, err := CheckAvailability(ctx, store, probe, root)
+			if err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+			if got.Chunks != want.Chunks || got.Available != want.Available || got.Complete != want.Complete || got.Percentage != want.Percentage {
+				t.Errorf("%s: got %+v, want %+v", name, got, want)
+			}
+			if fmt.Sprint(got.Missing) != fmt.Sprint(want.Missing) {
+				t.Errorf("%s: got missing chunks %v, want %v", name, got.Missing, want.Missing)
+			}
+		}
+
+		n := len(contentChunks)
+		check("file", content, &Availability{
+			Chunks:     n,
+			Available:  n,
+			Missing:    []storage.Address{},
+			Percentage: 100,
+			Complete:   true,
+		})
+
+		dataChunk := contentChunks[n-1]
+		missing[string(dataChunk)] = true
+		total := len(manifestChunks) + n
+		check("manifest", root, &Availability{
+			Chunks:     total,
+			Available:  total - 1,
+			Missing:    []storage.Address{dataChunk},
+			Percentage: float64(total-1) * 100 / float64(total),
+			Complete:   true,
+		})
+
+		// the root chunk of the file is neither stored
+		// locally nor retrievable from the network
+		if err := store.Set(ctx, chunk.ModeSetRemove, contentAddr); err != nil {
+			t.Fatal(err)
+		}
+		missing[string(contentAddr)] = true
+		check("file without root", content, &Availability{
+			Chunks:    1,
+			Available: 0,
+			Missing:   []storage.Address{contentAddr},
+			Complete:  false,
+		})
+	})
+}
+
+// TestCheckAvailabilityMissing validates that chunks which are neither in
+// the local store nor retrievable from the network are reported as missing
+// without their subtrees, while the rest of the file and the remaining
+// manifest entries are checked.
+func TestCheckAvailabilityMissing(t *testing.T) {
+	testAPI(t, func(a *API, _ *chunk.Tags, toEncrypt bool) {
+		ctx := context.Background()
+		store := a.fileStore.ChunkStore
+
+		upload := func(data []byte) storage.Address {
+			t.Helper()
+
+			addr, wait, err := a.Store(ctx, bytes.NewReader(data), int64(len(data)), toEncrypt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := wait(ctx); err != nil {
+				t.Fatal(err)
+			}
+			return addr
+		}
+		// the network has no chunks that are not stored locally
+		probe := func(ctx context.Context, addr chunk.Address) (chunk.Chunk, error) {
+			return store.Get(ctx, chunk.ModeGetLookup, addr)
+		}
+		remove := func(addr storage.Address) {
+			t.Helper()
+
+			if err := store.Set(ctx, chunk.ModeSetRemove, addr); err != nil {
+				t.Fatal(err)
+			}
+		}
+		check := func(name string, root storage.Address, chunks int, missing storage.Address) {
+			t.Helper()
+
+			got, err := CheckAvailability(ctx, store, probe, root)
+			if err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+			if got.Chunks != chunks || got.Available != chunks-1 || got.Complete {
+				t.Errorf("%s: got %+v, want %v chunks with one missing, not complete", name, got, chunks)
+			}
+			if fmt.Sprint(got.Missing) != fmt.Sprint([]storage.Address{missing}) {
+				t.Errorf("%s: got missing chunks %v, want %v", name, got.Missing, missing)
+			}
+		}
+
+		// the file has two full intermediate chunks and one with a single
+		// data chunk, of which the first one is missing with its data chunks
+		branches := chunk.DefaultSize / chunk.AddressLength
+		if toEncrypt {
+			branches /= 2
+		}
+		file := upload(testutil.RandomBytes(1, (2*branches+1)*chunk.DefaultSize))
+		fileChunks := walkedChunks(t, store, file)
+		intermediate := fileChunks[1]
+		remove(intermediate)
+		check("file", file, len(fileChunks)-branches, intermediate)
+
+		// the content of the first of several manifest entries is missing
+		var entries []string
+		var total int
+		var first storage.Address
+		for i, path := range []string{"a", "b", "c"} {
+			content := upload(testutil.RandomBytes(i+2, 3*chunk.DefaultSize))
+			contentChunks := walkedChunks(t, store, content)
+			total += len(contentChunks)
+			if i == 0 {
+				first = contentChunks[0]
+				total -= len(contentChunks) - 1
+			}
+			entries = append(entries, fmt.Sprintf(`{"hash":"%v","path":"%s"}`, content, path))
+		}
+		m := fmt.Sprintf(`{"entries":[%s]}`, strings.Join(entries, ","))
+		root := upload([]byte(m))
+		total += len(walkedChunks(t, store, root))
+		remove(first)
+		check("manifest", root, total, first)
+	})
+}
@@ -64,8 +64,72 @@ func NewClient(gateway string) *Client {
 
 // Client wraps interaction with a swarm HTTP gateway.
 type Client struct {
-	Gateway    string
-	httpClient *http.Client
+	Gateway         string
+	httpClient      *http.Client
+	contentEncoding string            // encoding uploaded files are compressed with
+	metadata        map[string]string // metadata set on all uploaded files
+	searchIndex     bool              // build the search index of uploaded manifests
+}
+
+// SetContentEncoding sets the encoding the gateway compresses files uploaded
+// to manifests with, e.g. gzip. An empty encoding disables compression.
+func (c *Client) SetContentEncoding(encoding string) error {
+	if err := api.ValidateContentEncoding(encoding); err != nil {
+		return err
+	}
+	c.contentEncoding = encoding
+	return nil
+}
+
+// SetMetadata sets the metadata the gateway sets on all files uploaded to manifests,
+// in addition to the metadata of the files themselves.
+func (c *Client) SetMetadata(meta map[string]string) error {
+	if err := api.ValidateMetadata(meta); err != nil {
+		return err
+	}
+	c.metadata = meta
+	return nil
+}
+
+// SetSearchIndex sets whether the gateway builds the search index of manifests
+// files are uploaded to, so that they can be searched with Search.
+func (c *Client) SetSearchIndex(index bool) {
+	c.searchIndex = index
+}
+
+// setUploadHeaders sets the headers of the content encoding, metadata and
+// search index of uploads
+func (c *Client) setUploadHeaders(req *http.Request) {
+	if c.searchIndex {
+		req.Header.Set(swarmhttp.IndexHeaderName, "true")
+	}
+	if c.contentEncoding != "" {
+		req.Header.Set(swarmhttp.CompressHeaderName, c.contentEncoding)
+	}
+	for k, v := range c.metadata {
+		req.Header.Set(swarmhttp.MetaHeaderPrefix+k, v)
+	}
+}
+
+// SetAPIKey sets the API key sent with every request, which is required
+// by gateways with authorization enabled.
+func (c *Client) SetAPIKey(key string) {
+	c.httpClient.Transport = &apiKeyTransport{
+		key:  key,
+		base: http.DefaultTransport,
+	}
+}
+
+// apiKeyTransport is a http.RoundTripper that adds the API key header to requests
+type apiKeyTransport struct {
+	key  string
+	base http.RoundTripper
+}
+
+func (t *apiKeyTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	r = r.Clone(r.Context())
+	r.Header.Set(swarmhttp.APIKeyHeaderName, t.key)
+	return t.base.RoundTrip(r)
 }
 
 // UploadRaw uploads raw data to swarm and returns the resulting hash. If toEncrypt is true it
@@ -167,7 +231,7 @@ func (c *Client) Upload(file *File, manifest string, toEncrypt, toPin, anonymous
 	if file.Size <= 0 {
 		return "", errors.New("file size must be greater than zero")
 	}
-	return c.TarUpload(manifest, &FileUploader{file}, "", toEncrypt, toPin, anonymous)
+	return c.TarUpload(manifest, &FileUploader{file}, "", nil, toEncrypt, toPin, anonymous)
 }
 
 // Download downloads a file with the given path from the swarm manifest with
@@ -182,11 +246,21 @@ func (c *Client) Download(hash, path string) (*File, error) {
 		res.Body.Close()
 		return nil, fmt.Errorf("unexpected HTTP status: %s", res.Status)
 	}
+	var meta map[string]string
+	for k, v := range res.Header {
+		if strings.HasPrefix(k, swarmhttp.MetaHeaderPrefix) && len(v) > 0 {
+			if meta == nil {
+				meta = make(map[string]string)
+			}
+			meta[strings.ToLower(strings.TrimPrefix(k, swarmhttp.MetaHeaderPrefix))] = v[0]
+		}
+	}
 	return &File{
 		ReadCloser: res.Body,
 		ManifestEntry: api.ManifestEntry{
 			ContentType: res.Header.Get("Content-Type"),
 			Size:        res.ContentLength,
+			Metadata:    meta,
 		},
 	}, nil
 }
@@ -196,8 +270,9 @@ func (c *Client) Download(hash, path string) (*File, error) {
 // new manifest, returning the resulting manifest hash (files from the
 // directory will then be available at bzz:/<hash>/path/to/file), with
 // the file specified in defaultPath being uploaded to the root of the manifest
-// (i.e. bzz:/<hash>/)
-func (c *Client) UploadDirectory(dir, defaultPath, manifest string, toEncrypt, toPin, anonymous bool) (string, error) {
+// (i.e. bzz:/<hash>/), and the website configuration, if not nil, being
+// set on the manifest
+func (c *Client) UploadDirectory(dir, defaultPath, manifest string, website *api.WebsiteConfig, toEncrypt, toPin, anonymous bool) (string, error) {
 	stat, err := os.Stat(dir)
 	if err != nil {
 		return "", err
@@ -212,7 +287,7 @@ func (c *Client) UploadDirectory(dir, defaultPath, manifest string, toEncrypt, t
 			return "", fmt.Errorf("default path: %v", err)
 		}
 	}
-	return c.TarUpload(manifest, &DirectoryUploader{dir}, defaultPath, toEncrypt, toPin, anonymous)
+	return c.TarUpload(manifest, &DirectoryUploader{dir}, defaultPath, website, toEncrypt, toPin, anonymous)
 }
 
 // DownloadDirectory downloads the files contained in a swarm manifest under
@@ -369,9 +444,9 @@ func (c *Client) DownloadFile(hash, path, dest, credentials string) error {
 	return err
 }
 
-// UploadManifest uploads the given manifest to swarm
+// UploadManifest uploads the given manifest to swarm in the format of the manifest
 func (c *Client) UploadManifest(m *api.Manifest, toEncrypt, toPin, anonymous bool) (string, error) {
-	data, err := json.Marshal(m)
+	data, err := api.EncodeManifest(m)
 	if err != nil {
 		return "", err
 	}
@@ -379,18 +454,22 @@ func (c *Client) UploadManifest(m *api.Manifest, toEncrypt, toPin, anonymous boo
 	return c.UploadRaw(bytes.NewReader(data), int64(len(data)), toEncrypt, toPin, anonymous)
 }
 
-// DownloadManifest downloads a swarm manifest
+// DownloadManifest downloads a swarm manifest in either format
 func (c *Client) DownloadManifest(hash string) (*api.Manifest, bool, error) {
 	res, isEncrypted, err := c.DownloadRaw(hash)
 	if err != nil {
 		return nil, isEncrypted, err
 	}
 	defer res.Close()
-	var manifest api.Manifest
-	if err := json.NewDecoder(res).Decode(&manifest); err != nil {
+	data, err := ioutil.ReadAll(res)
+	if err != nil {
+		return nil, isEncrypted, err
+	}
+	manifest, err := api.DecodeManifest(data)
+	if err != nil {
 		return nil, isEncrypted, err
 	}
-	return &manifest, isEncrypted, nil
+	return manifest, isEncrypted, nil
 }
 
 // List list files in a swarm manifest which have the given prefix, grouping
@@ -437,6 +516,130 @@ func (c *Client) List(hash, prefix, credentials string) (*api.ManifestList, erro
 	return &list, nil
 }
 
+// ResolveHash resolves an ENS name or a manifest hash to the hash of the manifest
+func (c *Client) ResolveHash(addr string) (string, error) {
+	res, err := c.httpClient.Get(c.Gateway + "/bzz-hash:/" + addr)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected HTTP status: %s", res.Status)
+	}
+	data, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// DeleteEntry removes the entry with the given path from the manifest
+// and returns the hash of the resulting manifest
+func (c *Client) DeleteEntry(hash, path string) (string, error) {
+	req, err := http.NewRequest(http.MethodDelete, c.Gateway+"/bzz:/"+hash+"/"+path, nil)
+	if err != nil {
+		return "", err
+	}
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected HTTP status: %s", res.Status)
+	}
+	data, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// DiffManifests returns the paths that were added, removed or changed in the manifest
+// newHash compared to the manifest oldHash
+func (c *Client) DiffManifests(oldHash, newHash, credentials string) (*api.ManifestDiff, error) {
+	req, err := http.NewRequest(http.MethodGet, c.Gateway+"/bzz-diff:/"+oldHash+"/"+newHash, nil)
+	if err != nil {
+		return nil, err
+	}
+	if credentials != "" {
+		req.SetBasicAuth("", credentials)
+	}
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	switch res.StatusCode {
+	case http.StatusOK:
+	case http.StatusUnauthorized:
+		return nil, ErrUnauthorized
+	default:
+		return nil, fmt.Errorf("unexpected HTTP status: %s", res.Status)
+	}
+	var diff api.ManifestDiff
+	if err := json.NewDecoder(res.Body).Decode(&diff); err != nil {
+		return nil, err
+	}
+	return &diff, nil
+}
+
+// Search returns up to limit files of the manifest with the given hash matching the
+// query by their path or content, using the search index of the manifest
+func (c *Client) Search(hash, query string, limit int, credentials string) (*api.SearchResults, error) {
+	params := url.Values{"q": {query}}
+	if limit > 0 {
+		params.Set("limit", strconv.Itoa(limit))
+	}
+	req, err := http.NewRequest(http.MethodGet, c.Gateway+"/bzz-search:/"+hash+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if credentials != "" {
+		req.SetBasicAuth("", credentials)
+	}
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	switch res.StatusCode {
+	case http.StatusOK:
+	case http.StatusUnauthorized:
+		return nil, ErrUnauthorized
+	default:
+		return nil, fmt.Errorf("unexpected HTTP status: %s", res.Status)
+	}
+	var results api.SearchResults
+	if err := json.NewDecoder(res.Body).Decode(&results); err != nil {
+		return nil, err
+	}
+	return &results, nil
+}
+
+// MergeManifests adds the entries of the manifest otherHash to the manifest baseHash,
+// resolving conflicting paths with the given strategy (fail, ours or theirs),
+// and returns the hash of the resulting manifest
+func (c *Client) MergeManifests(baseHash, otherHash, strategy string) (string, error) {
+	req, err := http.NewRequest(http.MethodPost, c.Gateway+"/bzz-merge:/"+baseHash+"/"+otherHash+"?strategy="+url.QueryEscape(strategy), nil)
+	if err != nil {
+		return "", err
+	}
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return "", err
+	}
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected HTTP status: %s: %s", res.Status, strings.TrimSpace(string(body)))
+	}
+	return string(body), nil
+}
+
 // Uploader uploads files to swarm using a provided UploadFn
 type Uploader interface {
 	Upload(UploadFn) error
@@ -511,7 +714,7 @@ type UploadFn func(file *File) error
 
 // TarUpload uses the given Uploader to upload files to swarm as a tar stream,
 // returning the resulting manifest hash
-func (c *Client) TarUpload(hash string, uploader Uploader, defaultPath string, toEncrypt, toPin, anonymous bool) (string, error) {
+func (c *Client) TarUpload(hash string, uploader Uploader, defaultPath string, website *api.WebsiteConfig, toEncrypt, toPin, anonymous bool) (string, error) {
 	ctx, sp := spancontext.StartSpan(context.Background(), "api.client.tarupload")
 	defer sp.Finish()
 
@@ -536,14 +739,29 @@ func (c *Client) TarUpload(hash string, uploader Uploader, defaultPath string, t
 	trace := GetClientTrace("swarm api client - upload tar", "api.client.uploadtar", uuid.New()[:8], &tn)
 
 	req = req.WithContext(httptrace.WithClientTrace(ctx, trace))
-	transport := http.DefaultTransport
+	transport := c.httpClient.Transport // set when an API key is used
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
 
 	req.Header.Set("Content-Type", "application/x-tar")
+	c.setUploadHeaders(req)
+	q := req.URL.Query()
 	if defaultPath != "" {
-		q := req.URL.Query()
 		q.Set("defaultpath", defaultPath)
-		req.URL.RawQuery = q.Encode()
 	}
+	if website != nil {
+		if website.IndexDocument != "" {
+			q.Set("indexdocument", website.IndexDocument)
+		}
+		if website.ErrorDocument != "" {
+			q.Set("errordocument", website.ErrorDocument)
+		}
+		if website.SPA {
+			q.Set("spa", "true")
+		}
+	}
+	req.URL.RawQuery = q.Encode()
 
 	tag := uploader.Tag()
 	if tag == "" {
@@ -574,6 +792,7 @@ func (c *Client) TarUpload(hash string, uploader Uploader, defaultPath string, t
 			Xattrs: map[string]string{
 				"user.swarm.content-type": file.ContentType,
 			},
+			PAXRecords: api.MetadataPAXRecords(file.Metadata),
 		}
 		if err := tw.WriteHeader(hdr); err != nil {
 			return err
@@ -624,6 +843,7 @@ func (c *Client) MultipartUpload(hash string, uploader Uploader, toPin, anonymou
 	mw := multipart.NewWriter(reqW)
 	req.Header.Set("Content-Type", fmt.Sprintf("multipart/form-data; boundary=%q", mw.Boundary()))
 	req.Header.Set(swarmhttp.TagHeaderName, fmt.Sprintf("multipart_upload_%d", time.Now().Unix()))
+	c.setUploadHeaders(req)
 	if toPin {
 		req.Header.Set(swarmhttp.PinHeaderName, "true")
 	}
@@ -634,6 +854,9 @@ func (c *Client) MultipartUpload(hash string, uploader Uploader, toPin, anonymou
 		hdr.Set("Content-Disposition", fmt.Sprintf("form-data; name=%q", file.Path))
 		hdr.Set("Content-Type", file.ContentType)
 		hdr.Set("Content-Length", strconv.FormatInt(file.Size, 10))
+		for k, v := range file.Metadata {
+			hdr.Set(swarmhttp.MetaHeaderPrefix+k, v)
+		}
 		w, err := mw.CreatePart(hdr)
 		if err != nil {
 			return err
@@ -777,7 +1000,7 @@ func (c *Client) queryFeed(query *feed.Query, manifestAddressOrDomain string, me
 		values.Set("meta", "1")
 	}
 	URL.RawQuery = values.Encode()
-	res, err := http.Get(URL.String())
+	res, err := c.httpClient.Get(URL.String())
 	if err != nil {
 		return nil, err
 	}
@@ -28,8 +28,10 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethersphere/swarm/api"
+	"github.com/ethersphere/swarm/api/auth"
 	swarmhttp "github.com/ethersphere/swarm/api/http"
 	chunktesting "github.com/ethersphere/swarm/chunk/testing"
+	"github.com/ethersphere/swarm/state"
 	"github.com/ethersphere/swarm/storage"
 	"github.com/ethersphere/swarm/storage/feed"
 	"github.com/ethersphere/swarm/storage/feed/lookup"
@@ -72,6 +74,60 @@ func TestClientUploadDownloadRawEncrypted(t *testing.T) {
 	chunktesting.CheckTag(t, tag, 1, 1, 0, 0, 0, 1)
 }
 
+// TestClientAPIKey tests that the client authorizes its requests
+// with the API key on a server with authorization enabled
+func TestClientAPIKey(t *testing.T) {
+	keys, err := auth.New(state.NewInmemoryStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, _, err := keys.Create("test", []auth.Scope{auth.ScopeRead, auth.ScopeUpload})
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := swarmhttp.NewTestSwarmServer(t, func(api *api.API, pinAPI *pin.API) swarmhttp.TestServer {
+		return swarmhttp.NewServer(api, pinAPI, "", swarmhttp.ServerOptionWithAuth(keys))
+	}, nil, nil)
+	defer srv.Close()
+
+	data := []byte("foo123")
+	client := NewClient(srv.URL)
+	if _, err := client.UploadRaw(bytes.NewReader(data), int64(len(data)), false, false, false); err == nil {
+		t.Fatal("expected upload without api key to fail")
+	}
+
+	client.SetAPIKey(token)
+	hash, err := client.UploadRaw(bytes.NewReader(data), int64(len(data)), false, false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, _, err := client.DownloadRaw(hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Close()
+	gotData, err := ioutil.ReadAll(res)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(gotData, data) {
+		t.Fatalf("expected downloaded data to be %q, got %q", data, gotData)
+	}
+
+	// files are uploaded as tar streams
+	file := &File{
+		ReadCloser: ioutil.NopCloser(bytes.NewReader(data)),
+		ManifestEntry: api.ManifestEntry{
+			Path:        "foo.txt",
+			ContentType: "text/plain",
+			Size:        int64(len(data)),
+		},
+	}
+	if _, err := client.Upload(file, "", false, false, false); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func testClientUploadDownloadRaw(srv *swarmhttp.TestSwarmServer, toEncrypt bool, t *testing.T, data []byte, toPin bool) string {
 	client := NewClient(srv.URL)
 
@@ -221,7 +277,7 @@ func TestClientUploadDownloadDirectory(t *testing.T) {
 	// upload the directory
 	client := NewClient(srv.URL)
 	defaultPath := testDirFiles[0]
-	hash, err := client.UploadDirectory(dir, defaultPath, "", false, false, true)
+	hash, err := client.UploadDirectory(dir, defaultPath, "", nil, false, false, true)
 	if err != nil {
 		t.Fatalf("error uploading directory: %s", err)
 	}
@@ -289,7 +345,7 @@ func testClientFileList(toEncrypt bool, t *testing.T) {
 	defer os.RemoveAll(dir)
 
 	client := NewClient(srv.URL)
-	hash, err := client.UploadDirectory(dir, "", "", toEncrypt, false, true)
+	hash, err := client.UploadDirectory(dir, "", "", nil, toEncrypt, false, true)
 	if err != nil {
 		t.Fatalf("error uploading directory: %s", err)
 	}
@@ -395,6 +451,85 @@ func TestClientMultipartUpload(t *testing.T) {
 	}
 }
 
+// TestClientMetadata tests that the metadata of uploaded files and the metadata set on
+// the client are kept for tar and multipart uploads and returned with downloads
+func TestClientMetadata(t *testing.T) {
+	srv := swarmhttp.NewTestSwarmServer(t, serverFunc, nil, nil)
+	defer srv.Close()
+
+	client := NewClient(srv.URL)
+	if err := client.SetMetadata(map[string]string{"author": "alice", "licence": "proprietary"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.SetMetadata(map[string]string{"Author": "alice"}); err == nil {
+		t.Fatal("expected error setting metadata with an uppercase key")
+	}
+
+	data := []byte("licensed")
+	uploader := UploaderFunc(func(upload UploadFn) error {
+		return upload(&File{
+			ReadCloser: ioutil.NopCloser(bytes.NewReader(data)),
+			ManifestEntry: api.ManifestEntry{
+				Path:        "licensed.txt",
+				ContentType: "text/plain",
+				Size:        int64(len(data)),
+				Metadata:    map[string]string{"licence": "MIT"},
+			},
+		})
+	})
+	expected := map[string]string{"author": "alice", "licence": "MIT"}
+
+	for name, upload := range map[string]func() (string, error){
+		"tar":       func() (string, error) { return client.TarUpload("", uploader, "", nil, false, false, true) },
+		"multipart": func() (string, error) { return client.MultipartUpload("", uploader, false, true) },
+	} {
+		hash, err := upload()
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		file, err := client.Download(hash, "licensed.txt")
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		file.Close()
+		if !reflect.DeepEqual(file.Metadata, expected) {
+			t.Fatalf("%s: expected metadata %v, got %v", name, expected, file.Metadata)
+		}
+	}
+}
+
+// TestClientSearch tests that manifests uploaded with a search index can be searched
+func TestClientSearch(t *testing.T) {
+	srv := swarmhttp.NewTestSwarmServer(t, serverFunc, nil, nil)
+	defer srv.Close()
+
+	client := NewClient(srv.URL)
+	client.SetSearchIndex(true)
+
+	data := []byte("notes about chunk storage")
+	uploader := UploaderFunc(func(upload UploadFn) error {
+		return upload(&File{
+			ReadCloser: ioutil.NopCloser(bytes.NewReader(data)),
+			ManifestEntry: api.ManifestEntry{
+				Path:        "notes.txt",
+				ContentType: "text/plain",
+				Size:        int64(len(data)),
+			},
+		})
+	})
+	hash, err := client.TarUpload("", uploader, "", nil, false, false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	results, err := client.Search(hash, "chunk", 10, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results.Results) != 1 || results.Results[0].Path != "notes.txt" || !results.Results[0].ContentMatch {
+		t.Fatalf("expected a content match of notes.txt, got %+v", results.Results)
+	}
+}
+
 // TestClientQueryTagByHash tests that the correct reply is received in regards to a hash of an ongoing upload
 func TestClientQueryTagByHash(t *testing.T) {
 	srv := swarmhttp.NewTestSwarmServer(t, serverFunc, nil, nil)
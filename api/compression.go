@@ -0,0 +1,196 @@
+// Copyright 2019 The Swarm Authors
+// This file is part of the Swarm library.
+//
+// The Swarm library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Swarm library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Swarm library. If not, see <http://www.gnu.org/licenses/>.
+
+package api
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+)
+
+// EncodingGzip is the content encoding of gzip compressed manifest entries,
+// named after the HTTP content coding. It is the only supported encoding.
+const EncodingGzip = "gzip"
+
+// ErrUnsupportedEncoding is returned for content encodings
+// that content can not be compressed or decompressed with
+type ErrUnsupportedEncoding string
+
+func (e ErrUnsupportedEncoding) Error() string {
+	return fmt.Sprintf("unsupported content encoding %q", string(e))
+}
+
+// ValidateContentEncoding returns an error if content can not be
+// compressed with the encoding
+func ValidateContentEncoding(encoding string) error {
+	switch encoding {
+	case "", EncodingGzip:
+		return nil
+	}
+	return ErrUnsupportedEncoding(encoding)
+}
+
+// Compress compresses data with the encoding into a temporary file and
+// returns it rewound to the start, along with the size of the uncompressed
+// and compressed data. The caller must close and remove the file.
+func Compress(data io.Reader, encoding string) (f *os.File, size, compressedSize int64, err error) {
+	if encoding != EncodingGzip {
+		return nil, 0, 0, ErrUnsupportedEncoding(encoding)
+	}
+	f, err = ioutil.TempFile("", "swarm-compress")
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	defer func() {
+		if err != nil {
+			f.Close()
+			os.Remove(f.Name())
+		}
+	}()
+	zw := gzip.NewWriter(f)
+	if size, err = io.Copy(zw, data); err != nil {
+		return nil, 0, 0, err
+	}
+	if err = zw.Close(); err != nil {
+		return nil, 0, 0, err
+	}
+	if compressedSize, err = f.Seek(0, io.SeekCurrent); err != nil {
+		return nil, 0, 0, err
+	}
+	if _, err = f.Seek(0, io.SeekStart); err != nil {
+		return nil, 0, 0, err
+	}
+	return f, size, compressedSize, nil
+}
+
+// Decompress returns a reader of the content read from r,
+// which is compressed with the encoding
+func Decompress(r io.Reader, encoding string) (io.ReadCloser, error) {
+	switch encoding {
+	case "":
+		return ioutil.NopCloser(r), nil
+	case EncodingGzip:
+		return gzip.NewReader(r)
+	}
+	return nil, ErrUnsupportedEncoding(encoding)
+}
+
+// DecompressReader reads the uncompressed content of compressed data
+// and implements io.Seeker using the known size of the uncompressed content,
+// so that range requests can be served from it. Seeking forward skips
+// decompressed data, and seeking backward restarts decompression
+// from the beginning.
+type DecompressReader struct {
+	r        *io.SectionReader // compressed data
+	encoding string
+	size     int64         // size of the uncompressed content
+	zr       io.ReadCloser // decompressing reader, nil until the first read
+	pos      int64         // position of zr in the uncompressed content
+	offset   int64         // position set by Seek
+}
+
+// NewDecompressReader returns a DecompressReader of the content compressed
+// with the encoding in the first compressedSize bytes of r, which is
+// size bytes long uncompressed.
+func NewDecompressReader(r io.ReaderAt, compressedSize, size int64, encoding string) (*DecompressReader, error) {
+	if err := ValidateContentEncoding(encoding); err != nil {
+		return nil, err
+	}
+	return &DecompressReader{
+		r:        io.NewSectionReader(r, 0, compressedSize),
+		encoding: encoding,
+		size:     size,
+	}, nil
+}
+
+// Read reads decompressed content from the current offset
+func (d *DecompressReader) Read(p []byte) (int, error) {
+	if d.offset >= d.size {
+		return 0, io.EOF
+	}
+	if d.zr == nil || d.offset < d.pos {
+		if err := d.reset(); err != nil {
+			return 0, err
+		}
+	}
+	if d.offset > d.pos {
+		n, err := io.CopyN(ioutil.Discard, d.zr, d.offset-d.pos)
+		d.pos += n
+		if err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return 0, err
+		}
+	}
+	if remaining := d.size - d.offset; int64(len(p)) > remaining {
+		p = p[:remaining]
+	}
+	n, err := d.zr.Read(p)
+	d.pos += int64(n)
+	d.offset = d.pos
+	if err == io.EOF && d.offset < d.size {
+		err = io.ErrUnexpectedEOF
+	}
+	return n, err
+}
+
+// Seek sets the offset of the next Read in the decompressed content
+func (d *DecompressReader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += d.offset
+	case io.SeekEnd:
+		offset += d.size
+	default:
+		return 0, fmt.Errorf("invalid whence %d", whence)
+	}
+	if offset < 0 {
+		return 0, fmt.Errorf("negative offset %d", offset)
+	}
+	d.offset = offset
+	return offset, nil
+}
+
+// Close releases the decompressing reader
+func (d *DecompressReader) Close() error {
+	if d.zr == nil {
+		return nil
+	}
+	return d.zr.Close()
+}
+
+// reset restarts decompression from the beginning of the content
+func (d *DecompressReader) reset() error {
+	if d.zr != nil {
+		d.zr.Close()
+		d.zr = nil
+	}
+	if _, err := d.r.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	zr, err := Decompress(d.r, d.encoding)
+	if err != nil {
+		return err
+	}
+	d.zr = zr
+	d.pos = 0
+	return nil
+}
@@ -0,0 +1,82 @@
+// Copyright 2019 The Swarm Authors
+// This file is part of the Swarm library.
+//
+// The Swarm library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Swarm library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Swarm library. If not, see <http://www.gnu.org/licenses/>.
+
+package api
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+// TestDecompressReader tests reading decompressed content at
+// arbitrary offsets, seeking both forward and backward
+func TestDecompressReader(t *testing.T) {
+	data := strings.Repeat("0123456789abcdef", 10000)
+	f, size, compressedSize, err := Compress(strings.NewReader(data), EncodingGzip)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+	if size != int64(len(data)) {
+		t.Fatalf("expected size %d, got %d", len(data), size)
+	}
+
+	d, err := NewDecompressReader(f, compressedSize, size, EncodingGzip)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+
+	if end, err := d.Seek(0, io.SeekEnd); err != nil || end != size {
+		t.Fatalf("expected end offset %d, got %d, %v", size, end, err)
+	}
+	for _, offset := range []int64{100000, 16, 159990, 0, 50000} {
+		if _, err := d.Seek(offset, io.SeekStart); err != nil {
+			t.Fatal(err)
+		}
+		buf := make([]byte, 20)
+		n, err := io.ReadFull(d, buf)
+		if err != nil && err != io.ErrUnexpectedEOF {
+			t.Fatalf("offset %d: %v", offset, err)
+		}
+		want := data[offset:]
+		if len(want) > len(buf) {
+			want = want[:len(buf)]
+		}
+		if string(buf[:n]) != want {
+			t.Fatalf("offset %d: expected %q, got %q", offset, want, buf[:n])
+		}
+	}
+
+	if _, err := d.Seek(0, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	all, err := ioutil.ReadAll(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(all) != data {
+		t.Fatal("decompressed content does not match")
+	}
+
+	if _, err := NewDecompressReader(f, compressedSize, size, "br"); err == nil {
+		t.Fatal("expected error for unsupported encoding")
+	}
+}
@@ -29,6 +29,9 @@ import (
 	"github.com/ethereum/go-ethereum/p2p/enode"
 	"github.com/ethersphere/swarm/contracts/ens"
 	"github.com/ethersphere/swarm/network"
+	"github.com/ethersphere/swarm/network/discovery"
+	"github.com/ethersphere/swarm/network/retrieval"
+	"github.com/ethersphere/swarm/network/stream"
 	"github.com/ethersphere/swarm/pss"
 	"github.com/ethersphere/swarm/storage"
 	"github.com/ethersphere/swarm/swap"
@@ -37,6 +40,10 @@ import (
 const (
 	DefaultHTTPListenAddr = "127.0.0.1"
 	DefaultHTTPPort       = "8500"
+
+	// DefaultLightNodeDbCapacity is the number of chunks cached by
+	// a light node in its local store, about 200MB of chunk data.
+	DefaultLightNodeDbCapacity = 50000
 )
 
 // separate bzz directories
@@ -46,10 +53,11 @@ type Config struct {
 	*storage.FileStoreParams
 
 	// LocalStore
-	ChunkDbPath   string
-	DbCapacity    uint64
-	CacheCapacity uint
-	BaseKey       []byte
+	ChunkDbPath         string
+	DbCapacity          uint64
+	LightNodeDbCapacity uint64 // local store capacity used instead of DbCapacity by a light node
+	CacheCapacity       uint
+	BaseKey             []byte
 
 	// Swap configs
 	SwapBackendURL          string         // Ethereum API endpoint
@@ -66,6 +74,12 @@ type Config struct {
 
 	*network.HiveParams
 	Pss                *pss.Params
+	Retrieval          *storage.RetrievalParams
+	RetrievalCache     *retrieval.CacheParams
+	SyncBudget         *stream.BudgetParams
+	Replication        *retrieval.ReplicationParams
+	DNSDiscovery       *discovery.DNSParams
+	LANDiscovery       *discovery.LANParams
 	EnsRoot            common.Address
 	EnsAPIs            []string
 	RnsAPI             string
@@ -82,16 +96,25 @@ type Config struct {
 	BootnodeMode       bool
 	DisableAutoConnect bool
 	EnablePinning      bool
+	EnableHTTPAuth     bool
 	Cors               string
+	GatewayHosts       []string // host=<ENS name or hash> mappings of sites served in gateway mode
+	GatewayDomain      string   // subdomains of this domain are served as ENS names in gateway mode
+	GatewayPublic      bool     // sites on gateway hosts are readable without an API key
 	BzzAccount         string
 	GlobalStoreAPI     string
 	privateKey         *ecdsa.PrivateKey
+
+	// retrieval strategies that all HTTP clients can request, not
+	// only those with an API key granted a scope other than read
+	PublicRetrievalStrategies []storage.RetrievalStrategy
 }
 
 //NewConfig creates a default config with all parameters to set to defaults
 func NewConfig() *Config {
 	return &Config{
 		FileStoreParams:         storage.NewFileStoreParams(),
+		LightNodeDbCapacity:     DefaultLightNodeDbCapacity,
 		SwapBackendURL:          "",
 		SwapEnabled:             false,
 		SwapSkipDeposit:         false,
@@ -102,6 +125,12 @@ func NewConfig() *Config {
 		SwapLogLevel:            swap.DefaultSwapLogLevel,
 		HiveParams:              network.NewHiveParams(),
 		Pss:                     pss.NewParams(),
+		Retrieval:               storage.NewRetrievalParams(),
+		RetrievalCache:          retrieval.NewCacheParams(),
+		SyncBudget:              stream.NewBudgetParams(),
+		Replication:             retrieval.NewReplicationParams(),
+		DNSDiscovery:            discovery.NewDNSParams(),
+		LANDiscovery:            discovery.NewLANParams(),
 		EnsRoot:                 ens.Address,
 		EnsAPIs:                 nil,
 		RnsAPI:                  "",
@@ -112,6 +141,7 @@ func NewConfig() *Config {
 		SyncEnabled:             true,
 		PushSyncEnabled:         true,
 		EnablePinning:           false,
+		EnableHTTPAuth:          false,
 	}
 }
 
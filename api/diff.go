@@ -0,0 +1,200 @@
+// Copyright 2019 The Swarm Authors
+// This file is part of the Swarm library.
+//
+// The Swarm library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Swarm library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Swarm library. If not, see <http://www.gnu.org/licenses/>.
+
+package api
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ethersphere/swarm/storage"
+)
+
+// ManifestDiff lists the paths that were added, removed or changed
+// between two manifests, sorted by path
+type ManifestDiff struct {
+	Added   []ManifestDiffEntry `json:"added"`
+	Removed []ManifestDiffEntry `json:"removed"`
+	Changed []ManifestDiffEntry `json:"changed"`
+}
+
+// ManifestDiffEntry is a path in a ManifestDiff. Removed entries have
+// the hash and size in the old manifest, added and changed entries
+// the hash and size in the new one. Changed entries also have the old
+// hash and size.
+type ManifestDiffEntry struct {
+	Path    string `json:"path"`
+	Hash    string `json:"hash"`
+	Size    int64  `json:"size,omitempty"`
+	OldHash string `json:"oldHash,omitempty"`
+	OldSize int64  `json:"oldSize,omitempty"`
+}
+
+// Empty reports whether the manifests are the same
+func (d *ManifestDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// MergeStrategy decides which entry is kept for paths that are in both merged manifests
+// with different entries
+type MergeStrategy string
+
+const (
+	MergeFail   = MergeStrategy("fail")   // the merge fails with a MergeConflictError
+	MergeOurs   = MergeStrategy("ours")   // the entry of the base manifest is kept
+	MergeTheirs = MergeStrategy("theirs") // the entry of the other manifest is kept
+)
+
+// ParseMergeStrategy parses a merge strategy, an empty string is MergeFail
+func ParseMergeStrategy(s string) (MergeStrategy, error) {
+	switch strategy := MergeStrategy(strings.ToLower(s)); strategy {
+	case "":
+		return MergeFail, nil
+	case MergeFail, MergeOurs, MergeTheirs:
+		return strategy, nil
+	}
+	return "", fmt.Errorf("unknown merge strategy %q, expected one of %s, %s or %s", s, MergeFail, MergeOurs, MergeTheirs)
+}
+
+// MergeConflictError is returned by MergeManifests with the MergeFail strategy
+// if paths are in both manifests with different entries
+type MergeConflictError struct {
+	Paths []string
+}
+
+func (e *MergeConflictError) Error() string {
+	return fmt.Sprintf("merge conflict in %d paths: %s", len(e.Paths), strings.Join(e.Paths, ", "))
+}
+
+// DiffManifests compares the entries of the manifest at newAddr with the entries of the manifest at oldAddr,
+// including the entries of their submanifests
+func (a *API) DiffManifests(ctx context.Context, decrypt DecryptFunc, oldAddr, newAddr storage.Address) (*ManifestDiff, error) {
+	oldEntries, err := a.manifestEntries(ctx, decrypt, oldAddr)
+	if err != nil {
+		return nil, err
+	}
+	newEntries, err := a.manifestEntries(ctx, decrypt, newAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := &ManifestDiff{
+		Added:   []ManifestDiffEntry{},
+		Removed: []ManifestDiffEntry{},
+		Changed: []ManifestDiffEntry{},
+	}
+	for path, e := range newEntries {
+		old, ok := oldEntries[path]
+		switch {
+		case !ok:
+			diff.Added = append(diff.Added, ManifestDiffEntry{Path: path, Hash: e.Hash, Size: e.Size})
+		case !sameEntry(old, e):
+			diff.Changed = append(diff.Changed, ManifestDiffEntry{Path: path, Hash: e.Hash, Size: e.Size, OldHash: old.Hash, OldSize: old.Size})
+		}
+	}
+	for path, e := range oldEntries {
+		if _, ok := newEntries[path]; !ok {
+			diff.Removed = append(diff.Removed, ManifestDiffEntry{Path: path, Hash: e.Hash, Size: e.Size})
+		}
+	}
+	for _, entries := range [][]ManifestDiffEntry{diff.Added, diff.Removed, diff.Changed} {
+		sort.Slice(entries, func(i, j int) bool { return entries[i].Path < entries[j].Path })
+	}
+	return diff, nil
+}
+
+// MergeManifests adds the entries of the manifest at otherAddr to the manifest at baseAddr
+// and returns the address of the resulting manifest. Paths that are in both manifests with
+// different entries are resolved with the given strategy. The website configuration of the
+// base manifest is kept.
+func (a *API) MergeManifests(ctx context.Context, baseAddr, otherAddr storage.Address, strategy MergeStrategy) (storage.Address, error) {
+	baseEntries, err := a.manifestEntries(ctx, NOOPDecrypt, baseAddr)
+	if err != nil {
+		return nil, err
+	}
+	otherEntries, err := a.manifestEntries(ctx, NOOPDecrypt, otherAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	var add, conflicts []string
+	for path, e := range otherEntries {
+		base, ok := baseEntries[path]
+		switch {
+		case !ok:
+			add = append(add, path)
+		case !sameEntry(base, e):
+			conflicts = append(conflicts, path)
+		}
+	}
+	sort.Strings(conflicts)
+	switch strategy {
+	case MergeOurs:
+	case MergeTheirs:
+		add = append(add, conflicts...)
+	case MergeFail:
+		if len(conflicts) > 0 {
+			return nil, &MergeConflictError{Paths: conflicts}
+		}
+	default:
+		return nil, fmt.Errorf("unknown merge strategy %q", strategy)
+	}
+	sort.Strings(add)
+
+	return a.UpdateManifest(ctx, baseAddr, func(mw *ManifestWriter) error {
+		for _, path := range add {
+			// entries are added as they are, they can be feeds without a hash
+			e := otherEntries[path]
+			if err := mw.trie.addEntry(newManifestTrieEntry(&e, nil), mw.quitC); err != nil {
+				return fmt.Errorf("error adding %s: %v", path, err)
+			}
+		}
+		return nil
+	})
+}
+
+// manifestEntries returns the entries of the manifest and its submanifests
+// other than the submanifests themselves by their full path
+func (a *API) manifestEntries(ctx context.Context, decrypt DecryptFunc, addr storage.Address) (map[string]ManifestEntry, error) {
+	walker, err := a.NewManifestWalker(ctx, addr, decrypt, nil)
+	if err != nil {
+		return nil, err
+	}
+	entries := make(map[string]ManifestEntry)
+	err = walker.Walk(func(entry *ManifestEntry) error {
+		if entry.ContentType != ManifestType {
+			entries[entry.Path] = *entry
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// sameEntry reports whether two manifest entries with the same path refer to the same content
+func sameEntry(a, b ManifestEntry) bool {
+	if a.Hash != b.Hash || a.ContentType != b.ContentType || a.Mode != b.Mode || a.ContentEncoding != b.ContentEncoding || !sameMetadata(a.Metadata, b.Metadata) {
+		return false
+	}
+	if a.Feed == nil || b.Feed == nil {
+		return a.Feed == b.Feed
+	}
+	return *a.Feed == *b.Feed
+}
@@ -210,8 +210,9 @@ func (fs *FileSystem) Download(bzzpath, localpath string) error {
 	}
 
 	type downloadListEntry struct {
-		addr storage.Address
-		path string
+		addr     storage.Address
+		path     string
+		encoding string
 	}
 
 	var list []*downloadListEntry
@@ -229,7 +230,7 @@ func (fs *FileSystem) Download(bzzpath, localpath string) error {
 			prevPath = dir
 		}
 		if (mde == nil) && (path != dir+"/") {
-			list = append(list, &downloadListEntry{addr: addr, path: path})
+			list = append(list, &downloadListEntry{addr: addr, path: path, encoding: entry.ContentEncoding})
 		}
 	})
 	if err != nil {
@@ -248,7 +249,7 @@ func (fs *FileSystem) Download(bzzpath, localpath string) error {
 		}
 		go func(i int, entry *downloadListEntry) {
 			defer wg.Done()
-			err := retrieveToFile(quitC, fs.api.fileStore, entry.addr, entry.path)
+			err := retrieveToFile(quitC, fs.api.fileStore, entry.addr, entry.path, entry.encoding)
 			if err != nil {
 				select {
 				case errC <- err:
@@ -271,7 +272,7 @@ func (fs *FileSystem) Download(bzzpath, localpath string) error {
 	}
 }
 
-func retrieveToFile(quitC chan bool, fileStore *storage.FileStore, addr storage.Address, path string) error {
+func retrieveToFile(quitC chan bool, fileStore *storage.FileStore, addr storage.Address, path, encoding string) error {
 	f, err := os.Create(path) // TODO: basePath separators
 	if err != nil {
 		return err
@@ -282,7 +283,13 @@ func retrieveToFile(quitC chan bool, fileStore *storage.FileStore, addr storage.
 	if err != nil {
 		return err
 	}
-	if _, err = io.CopyN(writer, reader, size); err != nil {
+	// compressed content is stored decompressed
+	content, err := Decompress(io.NewSectionReader(reader, 0, size), encoding)
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+	if _, err = io.Copy(writer, content); err != nil {
 		return err
 	}
 	if err := writer.Flush(); err != nil {
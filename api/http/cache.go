@@ -0,0 +1,110 @@
+// Copyright 2019 The Swarm Authors
+// This file is part of the Swarm library.
+//
+// The Swarm library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Swarm library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Swarm library. If not, see <http://www.gnu.org/licenses/>.
+
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethersphere/swarm/storage"
+)
+
+// DefaultNameCacheMaxAge is the default time for which responses to URLs
+// with ENS names or feeds may be cached, as they change on resolution updates
+const DefaultNameCacheMaxAge = time.Minute
+
+// immutableMaxAge is the max-age of responses to URLs with content hashes
+const immutableMaxAge = 365 * 24 * time.Hour
+
+// contentETag returns the strong entity tag of content with the given address
+func contentETag(addr storage.Address) string {
+	return fmt.Sprintf("%q", addr.Hex())
+}
+
+// encodedContentETag returns the strong entity tag of compressed content
+// with the given address served with its content encoding, which differs
+// from the entity tag of the decompressed content
+func encodedContentETag(addr storage.Address, encoding string) string {
+	return fmt.Sprintf("%q", addr.Hex()+"-"+encoding)
+}
+
+// acceptsEncoding reports whether the Accept-Encoding header of the request
+// allows responses with the content encoding
+func acceptsEncoding(r *http.Request, encoding string) bool {
+	for _, accepted := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		params := strings.Split(accepted, ";")
+		coding := strings.TrimSpace(params[0])
+		if coding != encoding && coding != "*" {
+			continue
+		}
+		for _, param := range params[1:] {
+			if q := strings.TrimSpace(param); strings.HasPrefix(q, "q=") {
+				if v, err := strconv.ParseFloat(q[2:], 64); err == nil && v == 0 {
+					return false
+				}
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// etagMatches reports whether an If-None-Match header value matches the entity tag,
+// using the weak comparison required for If-None-Match (RFC 7232, section 3.2)
+func etagMatches(ifNoneMatch, etag string) bool {
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, t := range strings.Split(ifNoneMatch, ",") {
+		t = strings.TrimSpace(t)
+		if t == "*" || strings.TrimPrefix(t, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// setCacheControl sets the Cache-Control header of a response. Content at URLs
+// with a content hash never changes and may be cached forever, while content
+// at URLs with ENS names or feeds is only cached for the name max age.
+// Responses to requests with credentials or on servers requiring API keys
+// must not be stored by shared caches.
+func (s *Server) setCacheControl(w http.ResponseWriter, r *http.Request, immutable bool) {
+	scope := "public"
+	if _, _, ok := r.BasicAuth(); ok || s.auth != nil {
+		scope = "private"
+	}
+	if immutable {
+		w.Header().Set("Cache-Control", fmt.Sprintf("%s, max-age=%d, immutable", scope, int64(immutableMaxAge.Seconds())))
+		return
+	}
+	w.Header().Set("Cache-Control", fmt.Sprintf("%s, max-age=%d", scope, int64(s.nameCacheMaxAge.Seconds())))
+}
+
+// setCacheHeaders sets the Cache-Control header and the ETag of a response,
+// and responds with 304 Not Modified if the request is conditional on
+// a matching entity tag, in which case it returns true.
+func (s *Server) setCacheHeaders(w http.ResponseWriter, r *http.Request, etag string, immutable bool) bool {
+	s.setCacheControl(w, r, immutable)
+	w.Header().Set("ETag", etag)
+	if ifNoneMatch := r.Header.Get("If-None-Match"); ifNoneMatch != "" && etagMatches(ifNoneMatch, etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return true
+	}
+	return false
+}
@@ -0,0 +1,115 @@
+// Copyright 2019 The Swarm Authors
+// This file is part of the Swarm library.
+//
+// The Swarm library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Swarm library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Swarm library. If not, see <http://www.gnu.org/licenses/>.
+
+package http
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestETagMatches(t *testing.T) {
+	etag := `"abcd"`
+	for _, tc := range []struct {
+		ifNoneMatch string
+		match       bool
+	}{
+		{ifNoneMatch: `"abcd"`, match: true},
+		{ifNoneMatch: `W/"abcd"`, match: true},
+		{ifNoneMatch: `"1234", "abcd"`, match: true},
+		{ifNoneMatch: `*`, match: true},
+		{ifNoneMatch: `abcd`, match: false},
+		{ifNoneMatch: `"1234"`, match: false},
+	} {
+		if match := etagMatches(tc.ifNoneMatch, etag); match != tc.match {
+			t.Errorf("If-None-Match %s: expected match %v, got %v", tc.ifNoneMatch, tc.match, match)
+		}
+	}
+}
+
+// TestCacheHeaders tests the caching headers of responses to hash and ENS name URLs
+// and conditional requests
+func TestCacheHeaders(t *testing.T) {
+	resolver := newTestResolveValidator("")
+	srv := NewTestSwarmServer(t, serverFunc, resolver, nil)
+	defer srv.Close()
+
+	res, hash := httpDo(http.MethodPost, srv.URL+"/bzz:/", strings.NewReader("cached content"), map[string]string{"Content-Type": "text/plain"}, false, t)
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("upload failed with status %s", res.Status)
+	}
+	h := common.HexToHash(hash)
+	resolver.hash = &h
+
+	res, _ = httpDo(http.MethodGet, srv.URL+"/bzz:/"+hash+"/", nil, nil, false, t)
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, res.StatusCode)
+	}
+	if cc := res.Header.Get("Cache-Control"); cc != "public, max-age=31536000, immutable" {
+		t.Fatalf("unexpected Cache-Control for hash URL: %q", cc)
+	}
+	etag := res.Header.Get("ETag")
+	if len(etag) != 66 || etag[0] != '"' || etag[65] != '"' {
+		t.Fatalf("expected strong content hash ETag, got %q", etag)
+	}
+	if lm := res.Header.Get("Last-Modified"); lm != "" {
+		t.Fatalf("unexpected Last-Modified %q", lm)
+	}
+
+	res, body := httpDo(http.MethodGet, srv.URL+"/bzz:/"+hash+"/", nil, map[string]string{"If-None-Match": etag}, false, t)
+	if res.StatusCode != http.StatusNotModified {
+		t.Fatalf("expected status %d, got %d", http.StatusNotModified, res.StatusCode)
+	}
+	if body != "" {
+		t.Fatalf("expected empty body, got %q", body)
+	}
+
+	res, _ = httpDo(http.MethodGet, srv.URL+"/bzz:/"+hash+"/", nil, map[string]string{"If-None-Match": `"1234"`}, false, t)
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, res.StatusCode)
+	}
+
+	res, _ = httpDo(http.MethodGet, srv.URL+"/bzz:/mysite.eth/", nil, nil, false, t)
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, res.StatusCode)
+	}
+	if cc := res.Header.Get("Cache-Control"); cc != "public, max-age=60" {
+		t.Fatalf("unexpected Cache-Control for ENS URL: %q", cc)
+	}
+	if res.Header.Get("ETag") != etag {
+		t.Fatalf("expected ETag %s, got %s", etag, res.Header.Get("ETag"))
+	}
+
+	res, _ = httpDo(http.MethodGet, srv.URL+"/bzz-raw:/"+hash, nil, map[string]string{"If-None-Match": `W/"` + hash + `"`}, false, t)
+	if res.StatusCode != http.StatusNotModified {
+		t.Fatalf("expected status %d, got %d", http.StatusNotModified, res.StatusCode)
+	}
+	res, _ = httpDo(http.MethodGet, srv.URL+"/bzz-raw:/mysite.eth", nil, nil, false, t)
+	if cc := res.Header.Get("Cache-Control"); cc != "public, max-age=60" {
+		t.Fatalf("unexpected Cache-Control for ENS URL: %q", cc)
+	}
+
+	res, _ = httpDo(http.MethodGet, srv.URL+"/bzz:/"+strings.Repeat("1", 64)+"/", nil, nil, false, t)
+	if res.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected status %d, got %d", http.StatusNotFound, res.StatusCode)
+	}
+	if cc := res.Header.Get("Cache-Control"); cc != "" {
+		t.Fatalf("unexpected Cache-Control for error response: %q", cc)
+	}
+}
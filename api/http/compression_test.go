@@ -0,0 +1,199 @@
+// Copyright 2019 The Swarm Authors
+// This file is part of the Swarm library.
+//
+// The Swarm library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Swarm library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Swarm library. If not, see <http://www.gnu.org/licenses/>.
+
+package http
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/ethersphere/swarm/api"
+)
+
+// TestCompression tests uploading compressed files and serving them
+// decompressed or with their content encoding depending on Accept-Encoding
+func TestCompression(t *testing.T) {
+	srv := NewTestSwarmServer(t, serverFunc, nil, nil)
+	defer srv.Close()
+
+	data := strings.Repeat(`{"key":"value"},`, 1000)
+
+	upload := func(encoding string) (*http.Response, string) {
+		buf := &bytes.Buffer{}
+		tw := tar.NewWriter(buf)
+		if err := tw.WriteHeader(&tar.Header{Name: "data.json", Mode: 0644, Size: int64(len(data))}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(data)); err != nil {
+			t.Fatal(err)
+		}
+		if err := tw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		return httpDo(http.MethodPost, srv.URL+"/bzz:/", buf, map[string]string{
+			"Content-Type":     "application/x-tar",
+			CompressHeaderName: encoding,
+		}, false, t)
+	}
+
+	res, _ := upload("brotli")
+	if res.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected status %d for unsupported encoding, got %d", http.StatusBadRequest, res.StatusCode)
+	}
+
+	res, hash := upload(api.EncodingGzip)
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("upload failed with status %s", res.Status)
+	}
+
+	// clients not accepting gzip get the decompressed content
+	res, body := httpDo(http.MethodGet, srv.URL+"/bzz:/"+hash+"/data.json", nil, map[string]string{"Accept-Encoding": "identity"}, false, t)
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, res.StatusCode)
+	}
+	if body != data {
+		t.Fatalf("unexpected decompressed content %q", body)
+	}
+	if ce := res.Header.Get("Content-Encoding"); ce != "" {
+		t.Fatalf("unexpected Content-Encoding %q", ce)
+	}
+	if cl := res.Header.Get("Content-Length"); cl != strconv.Itoa(len(data)) {
+		t.Fatalf("expected Content-Length %d, got %s", len(data), cl)
+	}
+	if v := strings.Join(res.Header["Vary"], ", "); !strings.Contains(v, "Accept-Encoding") {
+		t.Fatalf("expected Vary Accept-Encoding, got %q", v)
+	}
+	etag := res.Header.Get("ETag")
+
+	// range requests are served from the decompressed content
+	res, body = httpDo(http.MethodGet, srv.URL+"/bzz:/"+hash+"/data.json", nil, map[string]string{"Accept-Encoding": "identity", "Range": "bytes=5000-5015"}, false, t)
+	if res.StatusCode != http.StatusPartialContent {
+		t.Fatalf("expected status %d for range request, got %d", http.StatusPartialContent, res.StatusCode)
+	}
+	if body != data[5000:5016] {
+		t.Fatalf("expected range content %q, got %q", data[5000:5016], body)
+	}
+	if cr := res.Header.Get("Content-Range"); cr != "bytes 5000-5015/"+strconv.Itoa(len(data)) {
+		t.Fatalf("unexpected Content-Range %q", cr)
+	}
+	res, body = httpDo(http.MethodGet, srv.URL+"/bzz:/"+hash+"/data.json", nil, map[string]string{"Accept-Encoding": "identity", "Range": "bytes=-10"}, false, t)
+	if res.StatusCode != http.StatusPartialContent || body != data[len(data)-10:] {
+		t.Fatalf("expected suffix range content %q, got status %d and %q", data[len(data)-10:], res.StatusCode, body)
+	}
+	res, _ = httpDo(http.MethodGet, srv.URL+"/bzz:/"+hash+"/data.json", nil, map[string]string{"Accept-Encoding": "identity", "Range": "bytes=100000-"}, false, t)
+	if res.StatusCode != http.StatusRequestedRangeNotSatisfiable {
+		t.Fatalf("expected status %d for unsatisfiable range, got %d", http.StatusRequestedRangeNotSatisfiable, res.StatusCode)
+	}
+	// a range with a stale If-Range validator gets the whole content
+	res, body = httpDo(http.MethodGet, srv.URL+"/bzz:/"+hash+"/data.json", nil, map[string]string{"Accept-Encoding": "identity", "Range": "bytes=0-9", "If-Range": `"stale"`}, false, t)
+	if res.StatusCode != http.StatusOK || body != data {
+		t.Fatalf("expected whole content for stale If-Range, got status %d", res.StatusCode)
+	}
+	res, body = httpDo(http.MethodGet, srv.URL+"/bzz:/"+hash+"/data.json", nil, map[string]string{"Accept-Encoding": "identity", "Range": "bytes=0-9", "If-Range": etag}, false, t)
+	if res.StatusCode != http.StatusPartialContent || body != data[:10] {
+		t.Fatalf("expected range content for matching If-Range, got status %d and %q", res.StatusCode, body)
+	}
+
+	// clients accepting gzip get the stored content
+	res, body = httpDo(http.MethodGet, srv.URL+"/bzz:/"+hash+"/data.json", nil, map[string]string{"Accept-Encoding": "br, gzip;q=0.8"}, false, t)
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, res.StatusCode)
+	}
+	if ce := res.Header.Get("Content-Encoding"); ce != api.EncodingGzip {
+		t.Fatalf("expected Content-Encoding gzip, got %q", ce)
+	}
+	if len(body) >= len(data) {
+		t.Fatalf("expected compressed content smaller than %d bytes, got %d", len(data), len(body))
+	}
+	zr, err := gzip.NewReader(strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	decompressed, err := ioutil.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(decompressed) != data {
+		t.Fatalf("unexpected content %q", decompressed)
+	}
+	if res.Header.Get("ETag") == etag {
+		t.Fatal("expected compressed and decompressed content to have different ETags")
+	}
+
+	res, _ = httpDo(http.MethodGet, srv.URL+"/bzz:/"+hash+"/data.json", nil, map[string]string{"Accept-Encoding": "gzip;q=0"}, false, t)
+	if ce := res.Header.Get("Content-Encoding"); ce != "" {
+		t.Fatalf("unexpected Content-Encoding %q", ce)
+	}
+
+	// raw entries are decompressed or served with their content encoding as well
+	res, body = httpDo(http.MethodGet, srv.URL+"/bzz-raw:/"+hash+"/data.json", nil, map[string]string{"Accept-Encoding": "identity"}, false, t)
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, res.StatusCode)
+	}
+	if body != data {
+		t.Fatalf("unexpected decompressed raw content %q", body)
+	}
+	if ce := res.Header.Get("Content-Encoding"); ce != "" {
+		t.Fatalf("unexpected raw Content-Encoding %q", ce)
+	}
+	res, body = httpDo(http.MethodGet, srv.URL+"/bzz-raw:/"+hash+"/data.json", nil, map[string]string{"Accept-Encoding": "gzip"}, false, t)
+	if ce := res.Header.Get("Content-Encoding"); ce != api.EncodingGzip {
+		t.Fatalf("expected raw Content-Encoding gzip, got %q", ce)
+	}
+	if ct := res.Header.Get("Content-Type"); !strings.HasPrefix(ct, "application/json") {
+		t.Fatalf("expected raw Content-Type of the entry, got %q", ct)
+	}
+	if len(body) >= len(data) {
+		t.Fatalf("expected compressed raw content smaller than %d bytes, got %d", len(data), len(body))
+	}
+
+	// the listed size is the size of the decompressed content
+	res, body = httpDo(http.MethodGet, srv.URL+"/bzz-list:/"+hash+"/", nil, nil, false, t)
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, res.StatusCode)
+	}
+	var list api.ManifestList
+	if err := json.Unmarshal([]byte(body), &list); err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Entries) != 1 || list.Entries[0].Size != int64(len(data)) || list.Entries[0].ContentEncoding != api.EncodingGzip {
+		t.Fatalf("unexpected list entries %v", list.Entries)
+	}
+
+	// tar downloads contain the decompressed content
+	res, body = httpDo(http.MethodGet, srv.URL+"/bzz:/"+hash+"/", nil, map[string]string{"Accept": "application/x-tar"}, false, t)
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, res.StatusCode)
+	}
+	tr := tar.NewReader(bytes.NewReader([]byte(body)))
+	if _, err := tr.Next(); err != nil {
+		t.Fatal(err)
+	}
+	content, err := ioutil.ReadAll(tr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != data {
+		t.Fatalf("unexpected tar content %q", content)
+	}
+}
@@ -0,0 +1,122 @@
+// Copyright 2019 The Swarm Authors
+// This file is part of the Swarm library.
+//
+// The Swarm library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Swarm library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Swarm library. If not, see <http://www.gnu.org/licenses/>.
+
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/ethersphere/swarm/api"
+	"github.com/ethersphere/swarm/log"
+	"github.com/ethersphere/swarm/storage"
+)
+
+// HandleGetDiff responds to bzz-diff:/<old manifest>/<new manifest> requests
+// with the added, removed and changed paths of the new manifest as JSON
+func (s *Server) HandleGetDiff(w http.ResponseWriter, r *http.Request) {
+	ruid := GetRUID(r.Context())
+	uri := GetURI(r.Context())
+	_, credentials, _ := r.BasicAuth()
+	log.Debug("handle.get.diff", "ruid", ruid, "uri", uri)
+	getDiffCount.Inc(1)
+
+	oldAddr, newAddr, ok := s.resolveManifestPair(w, r, uri)
+	if !ok {
+		getDiffFail.Inc(1)
+		return
+	}
+
+	diff, err := s.api.DiffManifests(r.Context(), s.api.Decryptor(r.Context(), credentials), oldAddr, newAddr)
+	if err != nil {
+		getDiffFail.Inc(1)
+		if isDecryptError(err) {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf("Basic realm=%q", oldAddr))
+			respondError(w, r, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		respondError(w, r, fmt.Sprintf("cannot diff manifests: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	// the diff of two hashes never changes
+	other := &api.URI{Addr: strings.Trim(uri.Path, "/")}
+	s.setCacheControl(w, r, uri.Address() != nil && other.Address() != nil)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(diff)
+}
+
+// HandlePostMerge responds to bzz-merge:/<base manifest>/<other manifest>?strategy=<strategy>
+// requests by adding the entries of the other manifest to the base manifest and returning
+// the hash of the resulting manifest. Conflicting paths fail the merge with 409 Conflict
+// unless the strategy is ours or theirs.
+func (s *Server) HandlePostMerge(w http.ResponseWriter, r *http.Request) {
+	ruid := GetRUID(r.Context())
+	uri := GetURI(r.Context())
+	log.Debug("handle.post.merge", "ruid", ruid, "uri", uri)
+	postMergeCount.Inc(1)
+
+	strategy, err := api.ParseMergeStrategy(r.URL.Query().Get("strategy"))
+	if err != nil {
+		postMergeFail.Inc(1)
+		respondError(w, r, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	baseAddr, otherAddr, ok := s.resolveManifestPair(w, r, uri)
+	if !ok {
+		postMergeFail.Inc(1)
+		return
+	}
+
+	addr, err := s.api.MergeManifests(r.Context(), baseAddr, otherAddr, strategy)
+	if err != nil {
+		postMergeFail.Inc(1)
+		if _, ok := err.(*api.MergeConflictError); ok {
+			respondError(w, r, err.Error(), http.StatusConflict)
+			return
+		}
+		respondError(w, r, fmt.Sprintf("cannot merge manifests: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, addr)
+}
+
+// resolveManifestPair resolves the address and the path of the URI as the addresses of two manifests,
+// responding with an error if either can not be resolved
+func (s *Server) resolveManifestPair(w http.ResponseWriter, r *http.Request, uri *api.URI) (first, second storage.Address, ok bool) {
+	other := strings.Trim(uri.Path, "/")
+	if uri.Addr == "" || other == "" || strings.Contains(other, "/") {
+		respondError(w, r, "expected two manifests in the path", http.StatusBadRequest)
+		return nil, nil, false
+	}
+	first, err := s.api.Resolve(r.Context(), uri.Addr)
+	if err != nil {
+		respondError(w, r, fmt.Sprintf("cannot resolve %s: %s", uri.Addr, err), http.StatusNotFound)
+		return nil, nil, false
+	}
+	second, err = s.api.Resolve(r.Context(), other)
+	if err != nil {
+		respondError(w, r, fmt.Sprintf("cannot resolve %s: %s", other, err), http.StatusNotFound)
+		return nil, nil, false
+	}
+	return first, second, true
+}
@@ -0,0 +1,143 @@
+// Copyright 2019 The Swarm Authors
+// This file is part of the Swarm library.
+//
+// The Swarm library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Swarm library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Swarm library. If not, see <http://www.gnu.org/licenses/>.
+
+package http
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/ethersphere/swarm/api"
+)
+
+// TestManifestDiffMerge tests the diff and merge of two manifests
+func TestManifestDiffMerge(t *testing.T) {
+	srv := NewTestSwarmServer(t, serverFunc, nil, nil)
+	defer srv.Close()
+
+	upload := func(files map[string]string) string {
+		buf := &bytes.Buffer{}
+		tw := tar.NewWriter(buf)
+		for name, content := range files {
+			if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content))}); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := tw.Write([]byte(content)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := tw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		res, hash := httpDo(http.MethodPost, srv.URL+"/bzz:/", buf, map[string]string{"Content-Type": "application/x-tar"}, false, t)
+		if res.StatusCode != http.StatusOK {
+			t.Fatalf("upload failed with status %s", res.Status)
+		}
+		return hash
+	}
+	diffPaths := func(oldHash, newHash string) (added, removed, changed []string) {
+		res, body := httpDo(http.MethodGet, srv.URL+"/bzz-diff:/"+oldHash+"/"+newHash, nil, nil, false, t)
+		if res.StatusCode != http.StatusOK {
+			t.Fatalf("diff failed with status %s", res.Status)
+		}
+		var diff api.ManifestDiff
+		if err := json.Unmarshal([]byte(body), &diff); err != nil {
+			t.Fatal(err)
+		}
+		for _, e := range diff.Added {
+			added = append(added, e.Path)
+		}
+		for _, e := range diff.Removed {
+			removed = append(removed, e.Path)
+		}
+		for _, e := range diff.Changed {
+			if e.OldHash == e.Hash || e.OldHash == "" {
+				t.Fatalf("expected changed entry %s to have different hashes, got %s and %s", e.Path, e.OldHash, e.Hash)
+			}
+			changed = append(changed, e.Path)
+		}
+		return added, removed, changed
+	}
+	get := func(hash, path string) string {
+		res, body := httpDo(http.MethodGet, srv.URL+"/bzz:/"+hash+"/"+path, nil, nil, false, t)
+		if res.StatusCode != http.StatusOK {
+			t.Fatalf("get %s failed with status %s", path, res.Status)
+		}
+		return body
+	}
+
+	a := upload(map[string]string{
+		"index.html":      "index",
+		"css/style.css":   "body {}",
+		"img/logo.png":    "logo",
+		"docs/readme.txt": "readme",
+	})
+	b := upload(map[string]string{
+		"index.html":      "new index",
+		"css/style.css":   "body {}",
+		"docs/readme.txt": "readme",
+		"docs/api.txt":    "api",
+	})
+
+	added, removed, changed := diffPaths(a, b)
+	if !reflect.DeepEqual(added, []string{"docs/api.txt"}) {
+		t.Fatalf("unexpected added paths %v", added)
+	}
+	if !reflect.DeepEqual(removed, []string{"img/logo.png"}) {
+		t.Fatalf("unexpected removed paths %v", removed)
+	}
+	if !reflect.DeepEqual(changed, []string{"index.html"}) {
+		t.Fatalf("unexpected changed paths %v", changed)
+	}
+	if added, removed, changed := diffPaths(a, a); len(added)+len(removed)+len(changed) != 0 {
+		t.Fatalf("expected no differences, got %v %v %v", added, removed, changed)
+	}
+
+	// index.html conflicts
+	res, _ := httpDo(http.MethodPost, srv.URL+"/bzz-merge:/"+a+"/"+b, nil, nil, false, t)
+	if res.StatusCode != http.StatusConflict {
+		t.Fatalf("expected status %d, got %d", http.StatusConflict, res.StatusCode)
+	}
+	res, _ = httpDo(http.MethodPost, srv.URL+"/bzz-merge:/"+a+"/"+b+"?strategy=unknown", nil, nil, false, t)
+	if res.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected status %d, got %d", http.StatusBadRequest, res.StatusCode)
+	}
+
+	for _, tc := range []struct {
+		strategy string
+		index    string
+	}{
+		{strategy: "ours", index: "index"},
+		{strategy: "theirs", index: "new index"},
+	} {
+		res, merged := httpDo(http.MethodPost, srv.URL+"/bzz-merge:/"+a+"/"+b+"?strategy="+tc.strategy, nil, nil, false, t)
+		if res.StatusCode != http.StatusOK {
+			t.Fatalf("%s: merge failed with status %s", tc.strategy, res.Status)
+		}
+		if got := get(merged, "index.html"); got != tc.index {
+			t.Fatalf("%s: expected index.html %q, got %q", tc.strategy, tc.index, got)
+		}
+		for path, content := range map[string]string{"img/logo.png": "logo", "docs/api.txt": "api", "css/style.css": "body {}"} {
+			if got := get(merged, path); got != content {
+				t.Fatalf("%s: expected %s %q, got %q", tc.strategy, path, content, got)
+			}
+		}
+	}
+}
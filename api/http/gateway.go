@@ -0,0 +1,106 @@
+// Copyright 2019 The Swarm Authors
+// This file is part of the Swarm library.
+//
+// The Swarm library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Swarm library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Swarm library. If not, see <http://www.gnu.org/licenses/>.
+
+package http
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/ethersphere/swarm/log"
+)
+
+// Gateway maps request hosts to the ENS names or hashes of the sites
+// that are served on them from the manifest root, without the bzz:/ scheme
+// in the URL path.
+type Gateway struct {
+	hosts  map[string]string // host to ENS name or manifest hash
+	domain string            // subdomains of domain are resolved as ENS names
+	public bool              // sites on gateway hosts can be read without an API key
+}
+
+// NewGateway creates a Gateway from a list of host=<ENS name or hash>
+// mappings and a domain whose subdomains are served as ENS names,
+// so that mysite.eth.<domain> serves the site mysite.eth.
+// Either of them can be empty.
+// If public is true, sites on gateway hosts are readable without an API key
+// when HTTP authorization is enabled, as browsers visiting them cannot send one.
+func NewGateway(hosts []string, domain string, public bool) (*Gateway, error) {
+	g := &Gateway{
+		hosts:  make(map[string]string),
+		domain: strings.ToLower(strings.Trim(domain, ".")),
+		public: public,
+	}
+	for _, h := range hosts {
+		i := strings.Index(h, "=")
+		if i <= 0 || i == len(h)-1 {
+			return nil, fmt.Errorf("invalid gateway host %q, expected format host=<ENS name or hash>", h)
+		}
+		g.hosts[strings.ToLower(strings.TrimSpace(h[:i]))] = strings.TrimSpace(h[i+1:])
+	}
+	return g, nil
+}
+
+// Lookup returns the ENS name or hash of the site served on the host,
+// which may contain a port.
+func (g *Gateway) Lookup(host string) (addr string, ok bool) {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if addr, ok := g.hosts[host]; ok {
+		return addr, true
+	}
+	if g.domain != "" && strings.HasSuffix(host, "."+g.domain) {
+		if name := strings.TrimSuffix(host, "."+g.domain); name != "" {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// GatewayRouting is a middleware that rewrites requests to gateway hosts
+// to the bzz:/ URI of the site they serve and marks them as gateway requests
+// in the request context. Requests to public gateways are also marked as
+// exempt from the read scope check. Requests to other hosts are passed through unchanged.
+func GatewayRouting(h http.Handler, g *Gateway) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		addr, ok := g.Lookup(r.Host)
+		if !ok {
+			h.ServeHTTP(w, r)
+			return
+		}
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		log.Debug("gateway request", "host", r.Host, "addr", addr, "path", r.URL.Path)
+
+		ctx := SetGatewayAddr(r.Context(), addr)
+		if g.public {
+			ctx = SetPublicRead(ctx)
+		}
+		r = r.WithContext(ctx)
+		u := *r.URL
+		u.Path = "/bzz:/" + addr + "/" + strings.TrimPrefix(r.URL.Path, "/")
+		u.RawPath = ""
+		r.URL = &u
+
+		h.ServeHTTP(w, r)
+	})
+}
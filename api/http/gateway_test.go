@@ -0,0 +1,220 @@
+// Copyright 2019 The Swarm Authors
+// This file is part of the Swarm library.
+//
+// The Swarm library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Swarm library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Swarm library. If not, see <http://www.gnu.org/licenses/>.
+
+package http
+
+import (
+	"archive/tar"
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethersphere/swarm/api"
+	"github.com/ethersphere/swarm/api/auth"
+	"github.com/ethersphere/swarm/state"
+	"github.com/ethersphere/swarm/storage/pin"
+)
+
+// TestGatewayLookup tests the mapping of request hosts to site addresses
+func TestGatewayLookup(t *testing.T) {
+	if _, err := NewGateway([]string{"example.com"}, "", false); err == nil {
+		t.Fatal("expected error for gateway host without address")
+	}
+
+	g, err := NewGateway([]string{"Example.com=example.eth", "hash.example.com=1234"}, "gateway.example.", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		host string
+		addr string
+		ok   bool
+	}{
+		{host: "example.com", addr: "example.eth", ok: true},
+		{host: "EXAMPLE.com:8500", addr: "example.eth", ok: true},
+		{host: "hash.example.com", addr: "1234", ok: true},
+		{host: "mysite.eth.gateway.example", addr: "mysite.eth", ok: true},
+		{host: "mysite.eth.gateway.example:80", addr: "mysite.eth", ok: true},
+		{host: "gateway.example", ok: false},
+		{host: "other.com", ok: false},
+		{host: "localhost:8500", ok: false},
+	} {
+		addr, ok := g.Lookup(tc.host)
+		if ok != tc.ok || addr != tc.addr {
+			t.Errorf("host %s: expected (%q, %v), got (%q, %v)", tc.host, tc.addr, tc.ok, addr, ok)
+		}
+	}
+}
+
+// TestGateway tests serving a site resolved through ENS on a gateway host
+func TestGateway(t *testing.T) {
+	gateway, err := NewGateway([]string{"mysite.test=mysite.eth"}, "gateway.test", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resolver := newTestResolveValidator("")
+	srv := NewTestSwarmServer(t, func(api *api.API, pinAPI *pin.API) TestServer {
+		return NewServer(api, pinAPI, "", ServerOptionWithGateway(gateway))
+	}, resolver, nil)
+	defer srv.Close()
+
+	files := map[string]string{
+		"index.html":       "<html>home</html>",
+		"about/index.html": "<html>about</html>",
+		"css/style.css":    "body {}",
+		"404.html":         "<html>not found</html>",
+	}
+	buf := &bytes.Buffer{}
+	tw := tar.NewWriter(buf)
+	for name, content := range files {
+		hdr := &tar.Header{
+			Name:    name,
+			Mode:    0644,
+			Size:    int64(len(content)),
+			ModTime: time.Now(),
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	res, hash := httpDo(http.MethodPost, srv.URL+"/bzz:/?defaultpath=index.html", buf, map[string]string{"Content-Type": "application/x-tar"}, false, t)
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("upload failed with status %s", res.Status)
+	}
+	h := common.HexToHash(hash)
+	resolver.hash = &h
+
+	for _, tc := range []struct {
+		host   string
+		method string
+		path   string
+		code   int
+		body   string
+	}{
+		{host: "mysite.test", path: "/", code: http.StatusOK, body: files["index.html"]},
+		{host: "mysite.test", path: "/index.html", code: http.StatusOK, body: files["index.html"]},
+		{host: "mysite.test", path: "/css/style.css", code: http.StatusOK, body: files["css/style.css"]},
+		{host: "mysite.test", path: "/about/", code: http.StatusOK, body: files["about/index.html"]},
+		{host: "mysite.test", path: "/about", code: http.StatusOK, body: files["about/index.html"]},
+		{host: "mysite.test", path: "/missing.html", code: http.StatusNotFound, body: files["404.html"]},
+		{host: "mysite.eth.gateway.test", path: "/css/style.css", code: http.StatusOK, body: files["css/style.css"]},
+		{host: "mysite.test", method: http.MethodPost, path: "/", code: http.StatusMethodNotAllowed},
+	} {
+		method := tc.method
+		if method == "" {
+			method = http.MethodGet
+		}
+		req, err := http.NewRequest(method, srv.URL+tc.path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Host = tc.host
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, err := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.StatusCode != tc.code {
+			t.Fatalf("%s %s%s: expected status %d, got %d", method, tc.host, tc.path, tc.code, res.StatusCode)
+		}
+		if tc.body != "" && string(body) != tc.body {
+			t.Fatalf("%s %s%s: expected body %q, got %q", method, tc.host, tc.path, tc.body, body)
+		}
+	}
+
+	// requests to other hosts are served as before
+	res, _ = httpDo(http.MethodGet, srv.URL+"/bzz:/"+hash+"/css/style.css", nil, nil, false, t)
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, res.StatusCode)
+	}
+}
+
+// TestGatewayAuthorization tests that sites on public gateway hosts are
+// readable without an API key while all other requests still require one
+func TestGatewayAuthorization(t *testing.T) {
+	for _, public := range []bool{false, true} {
+		keys, err := auth.New(state.NewInmemoryStore())
+		if err != nil {
+			t.Fatal(err)
+		}
+		token, _, err := keys.Create("uploader", []auth.Scope{auth.ScopeUpload})
+		if err != nil {
+			t.Fatal(err)
+		}
+		gateway, err := NewGateway([]string{"mysite.test=mysite.eth"}, "", public)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resolver := newTestResolveValidator("")
+		srv := NewTestSwarmServer(t, func(api *api.API, pinAPI *pin.API) TestServer {
+			return NewServer(api, pinAPI, "", ServerOptionWithGateway(gateway), ServerOptionWithAuth(keys))
+		}, resolver, nil)
+
+		content := "<html>home</html>"
+		res, hash := httpDo(http.MethodPost, srv.URL+"/bzz:/", bytes.NewReader([]byte(content)), map[string]string{"Content-Type": "text/html", APIKeyHeaderName: token}, false, t)
+		if res.StatusCode != http.StatusOK {
+			srv.Close()
+			t.Fatalf("upload failed with status %s", res.Status)
+		}
+		h := common.HexToHash(hash)
+		resolver.hash = &h
+
+		req, err := http.NewRequest(http.MethodGet, srv.URL+"/", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Host = "mysite.test"
+		res, err = http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, err := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if public {
+			if res.StatusCode != http.StatusOK {
+				t.Fatalf("public gateway: expected status %d, got %d", http.StatusOK, res.StatusCode)
+			}
+			if string(body) != content {
+				t.Fatalf("public gateway: expected body %q, got %q", content, body)
+			}
+		} else if res.StatusCode != http.StatusUnauthorized {
+			t.Fatalf("private gateway: expected status %d, got %d", http.StatusUnauthorized, res.StatusCode)
+		}
+
+		// the exemption only applies to gateway hosts
+		res, _ = httpDo(http.MethodGet, srv.URL+"/bzz:/"+hash+"/", nil, nil, false, t)
+		if res.StatusCode != http.StatusUnauthorized {
+			t.Fatalf("public %v: expected status %d on the bzz: scheme, got %d", public, http.StatusUnauthorized, res.StatusCode)
+		}
+		srv.Close()
+	}
+}
@@ -0,0 +1,133 @@
+// Copyright 2019 The Swarm Authors
+// This file is part of the Swarm library.
+//
+// The Swarm library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Swarm library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Swarm library. If not, see <http://www.gnu.org/licenses/>.
+
+package http
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ethersphere/swarm/api"
+)
+
+// TestMetadata tests that metadata set with Swarm-Meta-* headers and tar PAX records
+// is listed, served as response headers and written to tar downloads
+func TestMetadata(t *testing.T) {
+	srv := NewTestSwarmServer(t, serverFunc, nil, nil)
+	defer srv.Close()
+
+	buf := &bytes.Buffer{}
+	tw := tar.NewWriter(buf)
+	for _, f := range []struct {
+		name, content string
+		meta          map[string]string
+	}{
+		{name: "licensed.txt", content: "licensed", meta: map[string]string{"licence": "MIT", "checksum": "abc"}},
+		{name: "plain.txt", content: "plain"},
+	} {
+		hdr := &tar.Header{
+			Name:       f.name,
+			Mode:       0644,
+			Size:       int64(len(f.content)),
+			PAXRecords: api.MetadataPAXRecords(f.meta),
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(f.content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the header metadata is set on all files, PAX records take precedence
+	res, hash := httpDo(http.MethodPost, srv.URL+"/bzz:/", buf, map[string]string{
+		"Content-Type":               "application/x-tar",
+		MetaHeaderPrefix + "Author":  "alice",
+		MetaHeaderPrefix + "Licence": "proprietary",
+	}, false, t)
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("upload failed with status %s", res.Status)
+	}
+	expected := map[string]map[string]string{
+		"licensed.txt": {"licence": "MIT", "checksum": "abc", "author": "alice"},
+		"plain.txt":    {"licence": "proprietary", "author": "alice"},
+	}
+
+	res, body := httpDo(http.MethodGet, srv.URL+"/bzz-list:/"+hash+"/", nil, nil, false, t)
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("list failed with status %s", res.Status)
+	}
+	var list api.ManifestList
+	if err := json.Unmarshal([]byte(body), &list); err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Entries) != len(expected) {
+		t.Fatalf("expected %d entries, got %d", len(expected), len(list.Entries))
+	}
+	for _, e := range list.Entries {
+		if !reflect.DeepEqual(e.Metadata, expected[e.Path]) {
+			t.Fatalf("expected %s metadata %v, got %v", e.Path, expected[e.Path], e.Metadata)
+		}
+	}
+
+	res, _ = httpDo(http.MethodGet, srv.URL+"/bzz:/"+hash+"/licensed.txt", nil, nil, false, t)
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("get failed with status %s", res.Status)
+	}
+	for k, v := range expected["licensed.txt"] {
+		if got := res.Header.Get(MetaHeaderPrefix + k); got != v {
+			t.Fatalf("expected header %s%s %q, got %q", MetaHeaderPrefix, k, v, got)
+		}
+	}
+	if !strings.Contains(res.Header.Get("Access-Control-Expose-Headers"), "Swarm-Meta-Licence") {
+		t.Fatalf("expected metadata headers to be exposed, got %q", res.Header.Get("Access-Control-Expose-Headers"))
+	}
+
+	res, body = httpDo(http.MethodGet, srv.URL+"/bzz:/"+hash+"/", nil, map[string]string{"Accept": "application/x-tar"}, false, t)
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("tar download failed with status %s", res.Status)
+	}
+	tr := tar.NewReader(strings.NewReader(body))
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		if got := api.MetadataFromPAX(hdr.PAXRecords); !reflect.DeepEqual(got, expected[hdr.Name]) {
+			t.Fatalf("expected %s tar metadata %v, got %v", hdr.Name, expected[hdr.Name], got)
+		}
+	}
+
+	// invalid metadata is refused
+	res, _ = httpDo(http.MethodPost, srv.URL+"/bzz:/", strings.NewReader("data"), map[string]string{
+		"Content-Type": "text/plain",
+		MetaHeaderPrefix + strings.Repeat("k", 100): "MIT",
+	}, false, t)
+	if res.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected status %d, got %d", http.StatusBadRequest, res.StatusCode)
+	}
+}
@@ -10,10 +10,12 @@ import (
 
 	"github.com/ethereum/go-ethereum/metrics"
 	"github.com/ethersphere/swarm/api"
+	"github.com/ethersphere/swarm/api/auth"
 	"github.com/ethersphere/swarm/chunk"
 	"github.com/ethersphere/swarm/log"
 	"github.com/ethersphere/swarm/sctx"
 	"github.com/ethersphere/swarm/spancontext"
+	"github.com/ethersphere/swarm/storage"
 	"github.com/ethersphere/swarm/storage/pin"
 	"github.com/pborman/uuid"
 )
@@ -185,6 +187,98 @@ func PinningEnabledPassthrough(h http.Handler, api *pin.API, checkHeader bool) h
 	})
 }
 
+// Authorize is a middleware that lets the request through only if it carries an
+// API key that is granted the given scope. The key is read from the APIKeyHeaderName
+// header or from a bearer token in the Authorization header, as basic auth
+// credentials are used for access control decryption.
+// If keys is nil, authorization is disabled and all requests are allowed.
+func Authorize(h http.Handler, keys *auth.Keys, scope auth.Scope) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if keys == nil || (scope == auth.ScopeRead && IsPublicRead(r.Context())) {
+			h.ServeHTTP(w, r)
+			return
+		}
+		key, err := keys.Authenticate(apiKeyToken(r))
+		if err != nil {
+			log.Debug("request not authenticated", "ruid", GetRUID(r.Context()), "err", err)
+			w.Header().Set("WWW-Authenticate", `Bearer realm="swarm"`)
+			respondError(w, r, "Missing or invalid API key", http.StatusUnauthorized)
+			return
+		}
+		if !key.HasScope(scope) {
+			log.Debug("request not authorized", "ruid", GetRUID(r.Context()), "key", key.ID, "scope", scope)
+			respondError(w, r, fmt.Sprintf("API key is not granted the %q scope", scope), http.StatusForbidden)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// apiKeyToken returns the API key of the request from the APIKeyHeaderName
+// header or from a bearer token in the Authorization header.
+func apiKeyToken(r *http.Request) string {
+	token := r.Header.Get(APIKeyHeaderName)
+	if token == "" {
+		if authHeader := r.Header.Get("Authorization"); strings.HasPrefix(authHeader, "Bearer ") {
+			token = strings.TrimPrefix(authHeader, "Bearer ")
+		}
+	}
+	return token
+}
+
+// SetRetrievalStrategy is a middleware that sets the strategy of chunk
+// retrieval from peers in the request context, from the RetrievalHeaderName
+// header. Without the header the strategy of the node is used.
+// As hedged and fan-out retrieval send extra requests that the node may have
+// to pay for, these strategies are set only if they are in the allowed list,
+// or if the request carries an API key that is granted a scope other than read.
+// Otherwise the header is ignored.
+func SetRetrievalStrategy(h http.Handler, keys *auth.Keys, allowed []storage.RetrievalStrategy) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		strategy := storage.RetrievalDefault
+		if header := r.Header.Get(RetrievalHeaderName); header != "" {
+			s, err := storage.ParseRetrievalStrategy(header)
+			if err != nil {
+				respondError(w, r, err.Error(), http.StatusBadRequest)
+				return
+			}
+			strategy = s
+		}
+		if strategy != storage.RetrievalDefault && strategy != storage.RetrievalSequential && !retrievalStrategyAllowed(r, strategy, keys, allowed) {
+			log.Debug("retrieval strategy not allowed", "ruid", GetRUID(r.Context()), "strategy", strategy)
+			strategy = storage.RetrievalDefault
+		}
+		if strategy != storage.RetrievalDefault {
+			r = r.WithContext(storage.WithRetrievalStrategy(r.Context(), strategy))
+			log.Debug("setting retrieval strategy", "ruid", GetRUID(r.Context()), "strategy", strategy)
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// retrievalStrategyAllowed returns true if the strategy is in the allowed
+// list or if the request carries an API key with a scope other than read.
+func retrievalStrategyAllowed(r *http.Request, strategy storage.RetrievalStrategy, keys *auth.Keys, allowed []storage.RetrievalStrategy) bool {
+	for _, s := range allowed {
+		if s == strategy {
+			return true
+		}
+	}
+	if keys == nil {
+		return false
+	}
+	key, err := keys.Authenticate(apiKeyToken(r))
+	if err != nil {
+		return false
+	}
+	for _, scope := range key.Scopes {
+		if scope != auth.ScopeRead {
+			return true
+		}
+	}
+	return false
+}
+
 // RecoverPanic is a middleware intended to catch possible panic in the call stack
 // and log them when they occur, failing gracefully to the client
 func RecoverPanic(h http.Handler) http.Handler {
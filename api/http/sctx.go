@@ -8,6 +8,8 @@ import (
 )
 
 type uriKey struct{}
+type gatewayAddrKey struct{}
+type publicReadKey struct{}
 
 func GetRUID(ctx context.Context) string {
 	v, ok := ctx.Value(sctx.HTTPRequestIDKey{}).(string)
@@ -32,3 +34,27 @@ func GetURI(ctx context.Context) *api.URI {
 func SetURI(ctx context.Context, uri *api.URI) context.Context {
 	return context.WithValue(ctx, uriKey{}, uri)
 }
+
+func GetGatewayAddr(ctx context.Context) string {
+	v, ok := ctx.Value(gatewayAddrKey{}).(string)
+	if ok {
+		return v
+	}
+	return ""
+}
+
+func SetGatewayAddr(ctx context.Context, addr string) context.Context {
+	return context.WithValue(ctx, gatewayAddrKey{}, addr)
+}
+
+// IsPublicRead reports whether the request can be served
+// without an API key granting the read scope.
+func IsPublicRead(ctx context.Context) bool {
+	v, _ := ctx.Value(publicReadKey{}).(bool)
+	return v
+}
+
+// SetPublicRead marks the request as readable without an API key.
+func SetPublicRead(ctx context.Context) context.Context {
+	return context.WithValue(ctx, publicReadKey{}, true)
+}
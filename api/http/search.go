@@ -0,0 +1,88 @@
+// Copyright 2019 The Swarm Authors
+// This file is part of the Swarm library.
+//
+// The Swarm library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Swarm library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Swarm library. If not, see <http://www.gnu.org/licenses/>.
+
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/ethersphere/swarm/api"
+	"github.com/ethersphere/swarm/log"
+)
+
+const (
+	defaultSearchLimit = 100
+	maxSearchLimit     = 1000
+)
+
+// HandleGetSearch responds to bzz-search:/<manifest>?q=<query>&limit=<limit> requests
+// with the files of the manifest matching the query as JSON, using the search index
+// built for the manifest at upload time
+func (s *Server) HandleGetSearch(w http.ResponseWriter, r *http.Request) {
+	ruid := GetRUID(r.Context())
+	uri := GetURI(r.Context())
+	_, credentials, _ := r.BasicAuth()
+	log.Debug("handle.get.search", "ruid", ruid, "uri", uri)
+	getSearchCount.Inc(1)
+
+	query := r.URL.Query().Get("q")
+	if query == "" {
+		getSearchFail.Inc(1)
+		respondError(w, r, "missing search query", http.StatusBadRequest)
+		return
+	}
+	limit := defaultSearchLimit
+	if l := r.URL.Query().Get("limit"); l != "" {
+		var err error
+		limit, err = strconv.Atoi(l)
+		if err != nil || limit < 1 || limit > maxSearchLimit {
+			getSearchFail.Inc(1)
+			respondError(w, r, fmt.Sprintf("invalid limit %q, expected 1 to %d", l, maxSearchLimit), http.StatusBadRequest)
+			return
+		}
+	}
+
+	addr, err := s.api.Resolve(r.Context(), uri.Addr)
+	if err != nil {
+		getSearchFail.Inc(1)
+		respondError(w, r, fmt.Sprintf("cannot resolve %s: %s", uri.Addr, err), http.StatusNotFound)
+		return
+	}
+
+	results, err := s.api.Search(r.Context(), s.api.Decryptor(r.Context(), credentials), addr, query, limit)
+	if err != nil {
+		getSearchFail.Inc(1)
+		if isDecryptError(err) {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf("Basic realm=%q", addr.String()))
+			respondError(w, r, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		if err == api.ErrNoSearchIndex {
+			respondError(w, r, err.Error(), http.StatusNotFound)
+			return
+		}
+		respondError(w, r, fmt.Sprintf("cannot search %s: %v", uri.Addr, err), http.StatusInternalServerError)
+		return
+	}
+
+	// the results of a search in a hash never change
+	s.setCacheControl(w, r, uri.Address() != nil)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(results)
+}
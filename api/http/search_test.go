@@ -0,0 +1,143 @@
+// Copyright 2019 The Swarm Authors
+// This file is part of the Swarm library.
+//
+// The Swarm library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Swarm library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Swarm library. If not, see <http://www.gnu.org/licenses/>.
+
+package http
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/ethersphere/swarm/api"
+)
+
+// TestSearch tests that manifests uploaded with the index header can be searched
+// by file path and text content, and that the index is dropped on modification
+func TestSearch(t *testing.T) {
+	srv := NewTestSwarmServer(t, serverFunc, nil, nil)
+	defer srv.Close()
+
+	files := map[string]string{
+		"docs/readme.txt":    "Swarm is a decentralised storage network",
+		"docs/licence.txt":   "Licensed under the GNU LGPL",
+		"images/storage.png": "decentralised storage",
+		"notes.txt":          "Meeting notes about chunk storage",
+	}
+	upload := func(headers map[string]string) string {
+		buf := &bytes.Buffer{}
+		tw := tar.NewWriter(buf)
+		for name, content := range files {
+			if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content))}); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := tw.Write([]byte(content)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := tw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		headers["Content-Type"] = "application/x-tar"
+		res, hash := httpDo(http.MethodPost, srv.URL+"/bzz:/", buf, headers, false, t)
+		if res.StatusCode != http.StatusOK {
+			t.Fatalf("upload failed with status %s", res.Status)
+		}
+		return hash
+	}
+	search := func(hash, query string, limit string) (int, *api.SearchResults) {
+		params := url.Values{"q": {query}}
+		if limit != "" {
+			params.Set("limit", limit)
+		}
+		res, body := httpDo(http.MethodGet, srv.URL+"/bzz-search:/"+hash+"?"+params.Encode(), nil, nil, false, t)
+		if res.StatusCode != http.StatusOK {
+			return res.StatusCode, nil
+		}
+		var results api.SearchResults
+		if err := json.Unmarshal([]byte(body), &results); err != nil {
+			t.Fatal(err)
+		}
+		return res.StatusCode, &results
+	}
+	paths := func(results *api.SearchResults) []string {
+		var paths []string
+		for _, r := range results.Results {
+			paths = append(paths, r.Path)
+		}
+		return paths
+	}
+
+	hash := upload(map[string]string{IndexHeaderName: "true"})
+
+	for _, tc := range []struct {
+		query    string
+		expected []string
+	}{
+		// path substrings
+		{query: "docs", expected: []string{"docs/licence.txt", "docs/readme.txt"}},
+		{query: "DOCS read", expected: []string{"docs/readme.txt"}},
+		// content token prefixes of text files, the png content is not indexed
+		{query: "decentral", expected: []string{"docs/readme.txt"}},
+		{query: "stor", expected: []string{"docs/readme.txt", "images/storage.png", "notes.txt"}},
+		{query: "chunk storage", expected: []string{"notes.txt"}},
+		{query: "missing", expected: nil},
+	} {
+		status, results := search(hash, tc.query, "")
+		if status != http.StatusOK {
+			t.Fatalf("search %q failed with status %d", tc.query, status)
+		}
+		got := paths(results)
+		if len(got) != len(tc.expected) {
+			t.Fatalf("search %q: expected %v, got %v", tc.query, tc.expected, got)
+		}
+		for i := range got {
+			if got[i] != tc.expected[i] {
+				t.Fatalf("search %q: expected %v, got %v", tc.query, tc.expected, got)
+			}
+		}
+	}
+
+	status, results := search(hash, "stor", "1")
+	if status != http.StatusOK || len(results.Results) != 1 || !results.Truncated {
+		t.Fatalf("expected one truncated result, got status %d and %+v", status, results)
+	}
+	if r := results.Results[0]; r.PathMatch || !r.ContentMatch {
+		t.Fatalf("expected a content match only, got %+v", r)
+	}
+	if status, _ := search(hash, "stor", "0"); status != http.StatusBadRequest {
+		t.Fatalf("expected status %d for invalid limit, got %d", http.StatusBadRequest, status)
+	}
+	if status, _ := search(hash, "", ""); status != http.StatusBadRequest {
+		t.Fatalf("expected status %d for empty query, got %d", http.StatusBadRequest, status)
+	}
+
+	// manifests without an index can not be searched
+	if status, _ := search(upload(map[string]string{}), "docs", ""); status != http.StatusNotFound {
+		t.Fatalf("expected status %d without index, got %d", http.StatusNotFound, status)
+	}
+
+	// modifying the manifest drops the outdated index
+	res, newHash := httpDo(http.MethodDelete, srv.URL+"/bzz:/"+hash+"/notes.txt", nil, nil, false, t)
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("delete failed with status %s", res.Status)
+	}
+	if status, _ := search(newHash, "docs", ""); status != http.StatusNotFound {
+		t.Fatalf("expected status %d after modification, got %d", http.StatusNotFound, status)
+	}
+}
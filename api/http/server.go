@@ -31,14 +31,15 @@ import (
 	"net/http"
 	"os"
 	"path"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
-	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/metrics"
 	"github.com/ethersphere/swarm/api"
+	"github.com/ethersphere/swarm/api/auth"
 	"github.com/ethersphere/swarm/api/http/langos"
 	"github.com/ethersphere/swarm/chunk"
 	"github.com/ethersphere/swarm/log"
@@ -73,12 +74,23 @@ var (
 	postPinFail     = metrics.NewRegisteredCounter("api/http/post/pin/fail", nil)
 	deletePinCount  = metrics.NewRegisteredCounter("api/http/delete/pin/count", nil)
 	deletePinFail   = metrics.NewRegisteredCounter("api/http/delete/pin/fail", nil)
+	getDiffCount    = metrics.NewRegisteredCounter("api/http/get/diff/count", nil)
+	getDiffFail     = metrics.NewRegisteredCounter("api/http/get/diff/fail", nil)
+	postMergeCount  = metrics.NewRegisteredCounter("api/http/post/merge/count", nil)
+	postMergeFail   = metrics.NewRegisteredCounter("api/http/post/merge/fail", nil)
+	getSearchCount  = metrics.NewRegisteredCounter("api/http/get/search/count", nil)
+	getSearchFail   = metrics.NewRegisteredCounter("api/http/get/search/fail", nil)
 )
 
 const (
 	TagHeaderName       = "x-swarm-tag"       // Presence of this in header indicates the tag
 	AnonymousHeaderName = "x-swarm-anonymous" // Presence of this in header indicates only pull sync should be used for upload
 	PinHeaderName       = "x-swarm-pin"       // Presence of this in header indicates pinning required
+	APIKeyHeaderName    = "x-swarm-api-key"   // API key used to authorize the request when authorization is enabled
+	CompressHeaderName  = "x-swarm-compress"  // Content encoding to compress uploaded files with, e.g. gzip
+	MetaHeaderPrefix    = "Swarm-Meta-"       // Prefix of the headers holding the metadata of uploaded and served files
+	IndexHeaderName     = "x-swarm-index"     // Presence of this in header indicates a search index of the uploaded manifest is built
+	RetrievalHeaderName = "x-swarm-retrieval" // Strategy of chunk retrieval from peers: sequential, hedged or fanout
 
 	encryptAddr    = "encrypt"
 	tarContentType = "application/x-tar"
@@ -95,7 +107,45 @@ func (m methodHandler) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
 	rw.WriteHeader(http.StatusMethodNotAllowed)
 }
 
-func NewServer(api *api.API, pinAPI *pin.API, corsString string) *Server {
+// ServerOption sets optional parameters of the Server and is used as
+// arguments for its constructor.
+type ServerOption func(*Server)
+
+// ServerOptionWithAuth enables API key authorization on all routes
+// except the root paths, using the given keys.
+func ServerOptionWithAuth(keys *auth.Keys) ServerOption {
+	return func(s *Server) {
+		s.auth = keys
+	}
+}
+
+// ServerOptionWithGateway enables gateway mode, serving sites on the hosts
+// configured in the gateway from their manifest root.
+func ServerOptionWithGateway(g *Gateway) ServerOption {
+	return func(s *Server) {
+		s.gateway = g
+	}
+}
+
+// ServerOptionWithRetrievalStrategies allows all clients to request the given
+// strategies of chunk retrieval with the RetrievalHeaderName header. Without
+// it, hedged and fan-out retrieval can be requested only with an API key that
+// is granted a scope other than read.
+func ServerOptionWithRetrievalStrategies(strategies []storage.RetrievalStrategy) ServerOption {
+	return func(s *Server) {
+		s.retrievalStrategies = strategies
+	}
+}
+
+// ServerOptionWithNameCacheMaxAge sets the time for which responses to URLs
+// with ENS names or feeds may be cached, DefaultNameCacheMaxAge by default.
+func ServerOptionWithNameCacheMaxAge(d time.Duration) ServerOption {
+	return func(s *Server) {
+		s.nameCacheMaxAge = d
+	}
+}
+
+func NewServer(api *api.API, pinAPI *pin.API, corsString string, opts ...ServerOption) *Server {
 	var allowedOrigins []string
 	for _, domain := range strings.Split(corsString, ",") {
 		allowedOrigins = append(allowedOrigins, strings.TrimSpace(domain))
@@ -107,7 +157,10 @@ func NewServer(api *api.API, pinAPI *pin.API, corsString string) *Server {
 		AllowedHeaders: []string{"*"},
 	})
 
-	server := &Server{api: api, pinAPI: pinAPI}
+	server := &Server{api: api, pinAPI: pinAPI, nameCacheMaxAge: DefaultNameCacheMaxAge}
+	for _, o := range opts {
+		o(server)
+	}
 
 	defaultMiddlewares := []Adapter{
 		RecoverPanic,
@@ -128,85 +181,123 @@ func NewServer(api *api.API, pinAPI *pin.API, corsString string) *Server {
 		})
 	}
 
-	defaultPostMiddlewares := append(defaultMiddlewares, tagAdapter)
+	authAdapter := func(scope auth.Scope) Adapter {
+		return Adapter(func(h http.Handler) http.Handler {
+			return Authorize(h, server.auth, scope)
+		})
+	}
+
+	// each chain gets its own backing array so that extending one
+	// can never overwrite the adapters of another
+	chain := func(base []Adapter, adapters ...Adapter) []Adapter {
+		return append(append([]Adapter{}, base...), adapters...)
+	}
+
+	retrievalAdapter := Adapter(func(h http.Handler) http.Handler {
+		return SetRetrievalStrategy(h, server.auth, server.retrievalStrategies)
+	})
+
+	readMiddlewares := chain(defaultMiddlewares, authAdapter(auth.ScopeRead), retrievalAdapter)
+	uploadMiddlewares := chain(defaultMiddlewares, authAdapter(auth.ScopeUpload))
+	pinMiddlewares := chain(defaultMiddlewares, authAdapter(auth.ScopePin), pinAdapter(false))
+	feedUpdateMiddlewares := chain(defaultMiddlewares, authAdapter(auth.ScopeFeedUpdate))
+	defaultPostMiddlewares := chain(uploadMiddlewares, tagAdapter, pinAdapter(true))
 
 	mux := http.NewServeMux()
 	mux.Handle("/bzz:/", methodHandler{
 		"GET": Adapt(
 			http.HandlerFunc(server.HandleBzzGet),
-			defaultMiddlewares...,
+			readMiddlewares...,
 		),
 		"POST": Adapt(
 			http.HandlerFunc(server.HandlePostFiles),
-			append(defaultPostMiddlewares, pinAdapter(true))...,
+			defaultPostMiddlewares...,
 		),
 		"DELETE": Adapt(
 			http.HandlerFunc(server.HandleDelete),
-			defaultMiddlewares...,
+			uploadMiddlewares...,
 		),
 	})
 	mux.Handle("/bzz-raw:/", methodHandler{
 		"GET": Adapt(
 			http.HandlerFunc(server.HandleGet),
-			defaultMiddlewares...,
+			readMiddlewares...,
 		),
 		"POST": Adapt(
 			http.HandlerFunc(server.HandlePostRaw),
-			append(defaultPostMiddlewares, pinAdapter(true))...,
+			defaultPostMiddlewares...,
 		),
 	})
 	mux.Handle("/bzz-immutable:/", methodHandler{
 		"GET": Adapt(
 			http.HandlerFunc(server.HandleBzzGet),
-			defaultMiddlewares...,
+			readMiddlewares...,
 		),
 	})
 	mux.Handle("/bzz-hash:/", methodHandler{
 		"GET": Adapt(
 			http.HandlerFunc(server.HandleGet),
-			defaultMiddlewares...,
+			readMiddlewares...,
 		),
 	})
 	mux.Handle("/bzz-list:/", methodHandler{
 		"GET": Adapt(
 			http.HandlerFunc(server.HandleGetList),
-			defaultMiddlewares...,
+			readMiddlewares...,
 		),
 	})
 	mux.Handle("/bzz-feed:/", methodHandler{
 		"GET": Adapt(
 			http.HandlerFunc(server.HandleGetFeed),
-			defaultMiddlewares...,
+			readMiddlewares...,
 		),
 		"POST": Adapt(
 			http.HandlerFunc(server.HandlePostFeed),
-			defaultMiddlewares...,
+			feedUpdateMiddlewares...,
 		),
 	})
 	mux.Handle("/bzz-tag:/", methodHandler{
 		"GET": Adapt(
 			http.HandlerFunc(server.HandleGetTag),
-			defaultMiddlewares...,
+			readMiddlewares...,
 		),
 	})
 	mux.Handle("/bzz-feed-raw:/", methodHandler{
 		"GET": Adapt(
 			http.HandlerFunc(server.HandleGetFeedRaw),
-			defaultMiddlewares...,
+			readMiddlewares...,
+		),
+	})
+	mux.Handle("/bzz-diff:/", methodHandler{
+		"GET": Adapt(
+			http.HandlerFunc(server.HandleGetDiff),
+			readMiddlewares...,
+		),
+	})
+	mux.Handle("/bzz-merge:/", methodHandler{
+		"POST": Adapt(
+			http.HandlerFunc(server.HandlePostMerge),
+			uploadMiddlewares...,
+		),
+	})
+	mux.Handle("/bzz-search:/", methodHandler{
+		"GET": Adapt(
+			http.HandlerFunc(server.HandleGetSearch),
+			readMiddlewares...,
 		),
 	})
 	mux.Handle("/bzz-pin:/", methodHandler{
 		"GET": Adapt(
 			http.HandlerFunc(server.HandleGetPins),
-			append(defaultMiddlewares, pinAdapter(false))...,
+			pinMiddlewares...,
 		),
 		"POST": Adapt(
 			http.HandlerFunc(server.HandlePin),
-			append(defaultMiddlewares, pinAdapter(false))...,
+			pinMiddlewares...,
 		),
 		"DELETE": Adapt(
 			http.HandlerFunc(server.HandleUnpin),
-			append(defaultMiddlewares, pinAdapter(false))...,
+			pinMiddlewares...,
 		),
 	})
 	mux.Handle("/", methodHandler{
@@ -216,7 +307,11 @@ func NewServer(api *api.API, pinAPI *pin.API, corsString string) *Server {
 			InitLoggingResponseWriter,
 		),
 	})
-	server.Handler = c.Handler(mux)
+	var handler http.Handler = mux
+	if server.gateway != nil {
+		handler = GatewayRouting(mux, server.gateway)
+	}
+	server.Handler = c.Handler(handler)
 
 	return server
 }
@@ -234,7 +329,13 @@ type Server struct {
 	http.Handler
 	api        *api.API
 	pinAPI     *pin.API
+	auth       *auth.Keys // nil if authorization is disabled
+	gateway    *Gateway   // nil if gateway mode is disabled
 	listenAddr string
+
+	retrievalStrategies []storage.RetrievalStrategy // strategies that all clients can request
+
+	nameCacheMaxAge time.Duration // max-age of responses to URLs with ENS names or feeds
 }
 
 func (s *Server) HandleBzzGet(w http.ResponseWriter, r *http.Request) {
@@ -244,6 +345,10 @@ func (s *Server) HandleBzzGet(w http.ResponseWriter, r *http.Request) {
 		_, credentials, _ := r.BasicAuth()
 		reader, err := s.api.GetDirectoryTar(r.Context(), s.api.Decryptor(r.Context(), credentials), uri)
 		if err != nil {
+			if isGrantExpiredError(err) {
+				respondError(w, r, err.Error(), http.StatusForbidden)
+				return
+			}
 			if isDecryptError(err) {
 				w.Header().Set("WWW-Authenticate", fmt.Sprintf("Basic realm=%q", uri.Address().String()))
 				respondError(w, r, err.Error(), http.StatusUnauthorized)
@@ -395,6 +500,22 @@ func (s *Server) HandlePostFiles(w http.ResponseWriter, r *http.Request) {
 	// Set the pinCounter if there is a pin header present in the request
 	headerPin := r.Header.Get(PinHeaderName)
 
+	// compress the uploaded files if there is a compress header present in the request
+	contentEncoding := r.Header.Get(CompressHeaderName)
+	if err := api.ValidateContentEncoding(contentEncoding); err != nil {
+		postFilesFail.Inc(1)
+		respondError(w, r, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// the metadata headers of the request are set on all uploaded files
+	metadata := metadataFromHeader(r.Header)
+	if err := api.ValidateMetadata(metadata); err != nil {
+		postFilesFail.Inc(1)
+		respondError(w, r, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	var addr storage.Address
 	if uri.Addr != "" && uri.Addr != encryptAddr {
 		addr, err = s.api.Resolve(r.Context(), uri.Addr)
@@ -413,7 +534,22 @@ func (s *Server) HandlePostFiles(w http.ResponseWriter, r *http.Request) {
 		}
 		log.Debug("new manifest", "ruid", ruid, "key", addr)
 	}
+	website, err := websiteFromQuery(r.URL.Query())
+	if err != nil {
+		postFilesFail.Inc(1)
+		respondError(w, r, err.Error(), http.StatusBadRequest)
+		return
+	}
 	newAddr, err := s.api.UpdateManifest(r.Context(), addr, func(mw *api.ManifestWriter) error {
+		if website != nil {
+			mw.SetWebsite(website)
+		}
+		if err := mw.SetContentEncoding(contentEncoding); err != nil {
+			return err
+		}
+		if err := mw.SetMetadata(metadata); err != nil {
+			return err
+		}
 		switch contentType {
 		case tarContentType:
 			_, err := s.handleTarUpload(r, mw)
@@ -435,6 +571,16 @@ func (s *Server) HandlePostFiles(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// build the search index of the resulting manifest if there is an index header present in the request
+	if strings.ToLower(r.Header.Get(IndexHeaderName)) == "true" {
+		newAddr, err = s.api.IndexManifest(r.Context(), newAddr)
+		if err != nil {
+			postFilesFail.Inc(1)
+			respondError(w, r, fmt.Sprintf("cannot build search index: %s", err), http.StatusInternalServerError)
+			return
+		}
+	}
+
 	tagUID = sctx.GetTag(r.Context())
 	tag, err = s.api.Tags.Get(tagUID)
 	if err != nil {
@@ -525,6 +671,7 @@ func (s *Server) handleMultipartUpload(r *http.Request, boundary string, mw *api
 			Path:        path,
 			ContentType: part.Header.Get("Content-Type"),
 			Size:        size,
+			Metadata:    metadataFromHeader(http.Header(part.Header)),
 		}
 		log.Debug("adding path to new manifest", "ruid", ruid, "bytes", entry.Size, "path", entry.Path)
 		contentKey, err := mw.AddEntry(r.Context(), reader, entry)
@@ -716,6 +863,8 @@ func (s *Server) HandleGetFeed(w http.ResponseWriter, r *http.Request) {
 
 	// All ok, serve the retrieved update
 	log.Debug("Found update", "feed", fd.Hex(), "ruid", ruid)
+	// feed updates change the content, so it is only cached for a short time
+	s.setCacheControl(w, r, false)
 	w.Header().Set("Content-Type", api.MimeOctetStream)
 	http.ServeContent(w, r, "", time.Now(), bytes.NewReader(data))
 }
@@ -776,33 +925,41 @@ func (s *Server) HandleGet(w http.ResponseWriter, r *http.Request) {
 	getCount.Inc(1)
 	_, pass, _ := r.BasicAuth()
 
-	addr, err := s.api.ResolveURI(r.Context(), uri, pass)
+	addr, entry, err := s.api.ResolveURIEntry(r.Context(), uri, pass)
 	if err != nil {
 		getFail.Inc(1)
 		respondError(w, r, fmt.Sprintf("cannot resolve %s: %s", uri.Addr, err), http.StatusNotFound)
 		return
 	}
-	w.Header().Set("Cache-Control", "max-age=2147483648, immutable") // url was of type bzz://<hex key>/path, so we are sure it is immutable.
 
 	log.Debug("handle.get: resolved", "ruid", ruid, "key", addr)
 
-	// if path is set, interpret <key> as a manifest and return the
-	// raw entry at the given path
-	etag := common.Bytes2Hex(addr)
-	noneMatchEtag := r.Header.Get("If-None-Match")
-	w.Header().Set("ETag", fmt.Sprintf("%q", etag)) // set etag to manifest key or raw entry key.
-	if noneMatchEtag != "" {
-		if bytes.Equal(storage.Address(common.Hex2Bytes(noneMatchEtag)), addr) {
-			w.WriteHeader(http.StatusNotModified)
-			return
-		}
+	// raw compressed entries are served as bzz: serves them, with their
+	// content encoding to clients accepting it and decompressed to others
+	var encoding string
+	if entry != nil && uri.Raw() {
+		encoding = entry.ContentEncoding
+	}
+	passthrough := encoding != "" && acceptsEncoding(r, encoding)
+	if encoding != "" {
+		w.Header().Add("Vary", "Accept-Encoding")
+	}
+
+	// the etag is the manifest key or, if path is set, the raw entry key
+	etag := contentETag(addr)
+	if passthrough {
+		etag = encodedContentETag(addr, encoding)
+	}
+	if s.setCacheHeaders(w, r, etag, uri.Address() != nil) {
+		return
 	}
 
 	switch {
 	case uri.Raw():
 		// check the root chunk exists by retrieving the file's size
 		reader, isEncrypted := s.api.Retrieve(r.Context(), addr)
-		if _, err := reader.Size(r.Context(), nil); err != nil {
+		size, err := reader.Size(r.Context(), nil)
+		if err != nil {
 			getFail.Inc(1)
 			respondError(w, r, fmt.Sprintf("root chunk not found %s: %s", addr, err), http.StatusNotFound)
 			return
@@ -814,6 +971,9 @@ func (s *Server) HandleGet(w http.ResponseWriter, r *http.Request) {
 		// parameter
 		if typ := r.URL.Query().Get("content_type"); typ != "" {
 			w.Header().Set("Content-Type", typ)
+		} else if encoding != "" && entry.ContentType != "" {
+			// the type of compressed content can not be detected from its bytes
+			w.Header().Set("Content-Type", entry.ContentType)
 		}
 
 		fileName := uri.Addr
@@ -821,7 +981,21 @@ func (s *Server) HandleGet(w http.ResponseWriter, r *http.Request) {
 			fileName = found
 		}
 		w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=\"%s\"", fileName))
-		http.ServeContent(w, r, fileName, time.Now(), langos.NewBufferedReadSeeker(reader, getFileBufferSize))
+
+		if passthrough {
+			w.Header().Set("Content-Encoding", encoding)
+		} else if encoding != "" {
+			content, err := api.NewDecompressReader(reader, size, entry.Size, encoding)
+			if err != nil {
+				getFail.Inc(1)
+				respondError(w, r, fmt.Sprintf("cannot decompress %s: %s", uri, err), http.StatusInternalServerError)
+				return
+			}
+			defer content.Close()
+			http.ServeContent(w, r, fileName, time.Time{}, content)
+			return
+		}
+		http.ServeContent(w, r, fileName, time.Time{}, langos.NewBufferedReadSeeker(reader, getFileBufferSize))
 
 	case uri.Hash():
 		w.Header().Set("Content-Type", "text/plain")
@@ -854,10 +1028,16 @@ func (s *Server) HandleGetList(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	log.Debug("handle.get.list: resolved", "ruid", ruid, "key", addr)
+	s.setCacheControl(w, r, uri.Address() != nil)
+	w.Header().Set("Vary", "Accept") // the list is rendered as HTML or JSON
 
 	list, err := s.api.GetManifestList(r.Context(), s.api.Decryptor(r.Context(), credentials), addr, uri.Path)
 	if err != nil {
 		getListFail.Inc(1)
+		if isGrantExpiredError(err) {
+			respondError(w, r, err.Error(), http.StatusForbidden)
+			return
+		}
 		if isDecryptError(err) {
 			w.Header().Set("WWW-Authenticate", fmt.Sprintf("Basic realm=%q", addr.String()))
 			respondError(w, r, err.Error(), http.StatusUnauthorized)
@@ -914,25 +1094,38 @@ func (s *Server) HandleGetFile(w http.ResponseWriter, r *http.Request) {
 			respondError(w, r, fmt.Sprintf("cannot resolve %s: %s", uri.Addr, err), http.StatusNotFound)
 			return
 		}
-	} else {
-		w.Header().Set("Cache-Control", "max-age=2147483648, immutable") // url was of type bzz://<hex key>/path, so we are sure it is immutable.
 	}
 
 	log.Debug("handle.get.file: resolved", "ruid", ruid, "key", manifestAddr)
 
-	reader, contentType, status, contentKey, err := s.api.Get(r.Context(), s.api.Decryptor(r.Context(), credentials), manifestAddr, uri.Path)
+	// the manifest is loaded once and used for all lookups of the request
+	decrypt := s.api.Decryptor(r.Context(), credentials)
+	var (
+		reader     storage.LazySectionReader
+		entry      *api.ManifestEntry
+		status     int
+		contentKey storage.Address
+	)
+	responseStatus := http.StatusOK
+	manifest, err := s.api.LoadManifest(r.Context(), decrypt, manifestAddr)
+	if err != nil {
+		status = http.StatusNotFound
+	} else {
+		reader, entry, status, contentKey, err = manifest.GetEntry(r.Context(), uri.Path)
 
-	etag := common.Bytes2Hex(contentKey)
-	noneMatchEtag := r.Header.Get("If-None-Match")
-	w.Header().Set("ETag", fmt.Sprintf("%q", etag)) // set etag to actual content key.
-	if noneMatchEtag != "" {
-		if bytes.Equal(storage.Address(common.Hex2Bytes(noneMatchEtag)), contentKey) {
-			w.WriteHeader(http.StatusNotModified)
-			return
+		// websites get their index and error documents instead of the built-in pages
+		if err == nil || !(isDecryptError(err) || isGrantExpiredError(err)) {
+			if website := s.getWebsite(r, manifest); website != nil && !manifest.HasEntry(uri.Path) {
+				reader, entry, status, contentKey, responseStatus, err = s.getWebsiteFallback(r, manifest, uri.Path, website)
+			}
 		}
 	}
 
 	if err != nil {
+		if isGrantExpiredError(err) {
+			respondError(w, r, err.Error(), http.StatusForbidden)
+			return
+		}
 		if isDecryptError(err) {
 			w.Header().Set("WWW-Authenticate", fmt.Sprintf("Basic realm=%q", manifestAddr))
 			respondError(w, r, err.Error(), http.StatusUnauthorized)
@@ -953,7 +1146,7 @@ func (s *Server) HandleGetFile(w http.ResponseWriter, r *http.Request) {
 	//the request results in ambiguous files
 	//e.g. /read with readme.md and readinglist.txt available in manifest
 	if status == http.StatusMultipleChoices {
-		list, err := s.api.GetManifestList(r.Context(), s.api.Decryptor(r.Context(), credentials), manifestAddr, uri.Path)
+		list, err := s.api.GetManifestList(r.Context(), decrypt, manifestAddr, uri.Path)
 		if err != nil {
 			getFileFail.Inc(1)
 			if isDecryptError(err) {
@@ -971,16 +1164,43 @@ func (s *Server) HandleGetFile(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// compressed content is passed through to clients accepting its encoding,
+	// and decompressed for other clients
+	encoding := entry.ContentEncoding
+	passthrough := encoding != "" && acceptsEncoding(r, encoding)
+	if encoding != "" {
+		w.Header().Add("Vary", "Accept-Encoding")
+	}
+
+	// content at hash URLs is immutable unless it is resolved through a feed
+	if responseStatus == http.StatusOK {
+		immutable := uri.Address() != nil
+		if immutable {
+			if isFeed, _ := manifest.IsFeed(r.Context(), uri.Path); isFeed {
+				immutable = false
+			}
+		}
+		etag := contentETag(contentKey)
+		if passthrough {
+			etag = encodedContentETag(contentKey, encoding)
+		}
+		if s.setCacheHeaders(w, r, etag, immutable) {
+			return
+		}
+	}
+
 	// check the root chunk exists by retrieving the file's size
-	if _, err := reader.Size(r.Context(), nil); err != nil {
+	size, err := reader.Size(r.Context(), nil)
+	if err != nil {
 		getFileNotFound.Inc(1)
 		respondError(w, r, fmt.Sprintf("file not found %s: %s", uri, err), http.StatusNotFound)
 		return
 	}
 
-	if contentType != "" {
-		w.Header().Set("Content-Type", contentType)
+	if entry.ContentType != "" {
+		w.Header().Set("Content-Type", entry.ContentType)
 	}
+	setMetadataHeaders(w, entry.Metadata)
 
 	fileName := uri.Addr
 	if found := path.Base(uri.Path); found != "" && found != "." && found != "/" {
@@ -988,7 +1208,35 @@ func (s *Server) HandleGetFile(w http.ResponseWriter, r *http.Request) {
 	}
 	w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=\"%s\"", fileName))
 
-	http.ServeContent(w, r, fileName, time.Now(), langos.NewBufferedReadSeeker(reader, getFileBufferSize))
+	if passthrough {
+		w.Header().Set("Content-Encoding", encoding)
+	} else if encoding != "" {
+		// the decompressed content is seekable using the uncompressed size
+		// recorded in the entry, so range requests are served as for other content
+		content, err := api.NewDecompressReader(reader, size, entry.Size, encoding)
+		if err != nil {
+			getFileFail.Inc(1)
+			respondError(w, r, fmt.Sprintf("cannot decompress %s: %s", uri, err), http.StatusInternalServerError)
+			return
+		}
+		defer content.Close()
+		if responseStatus != http.StatusOK {
+			w.Header().Set("Content-Length", strconv.FormatInt(entry.Size, 10))
+			w.WriteHeader(responseStatus)
+			io.Copy(w, content)
+			return
+		}
+		http.ServeContent(w, r, fileName, time.Time{}, content)
+		return
+	}
+
+	if responseStatus != http.StatusOK {
+		w.WriteHeader(responseStatus)
+		io.Copy(w, reader)
+		return
+	}
+
+	http.ServeContent(w, r, fileName, time.Time{}, langos.NewBufferedReadSeeker(reader, getFileBufferSize))
 }
 
 // HandleGetTag responds to the following request
@@ -1169,6 +1417,42 @@ func (lrw *loggingResponseWriter) WriteHeader(code int) {
 	lrw.ResponseWriter.WriteHeader(code)
 }
 
+// metadataFromHeader returns the metadata in the Swarm-Meta-* headers,
+// with the keys in lowercase
+func metadataFromHeader(h http.Header) map[string]string {
+	var meta map[string]string
+	for k, v := range h {
+		if !strings.HasPrefix(k, MetaHeaderPrefix) || len(v) == 0 {
+			continue
+		}
+		if meta == nil {
+			meta = make(map[string]string)
+		}
+		meta[strings.ToLower(strings.TrimPrefix(k, MetaHeaderPrefix))] = v[0]
+	}
+	return meta
+}
+
+// setMetadataHeaders sets a Swarm-Meta-* header for each metadata value
+// and exposes them to cross-origin requests
+func setMetadataHeaders(w http.ResponseWriter, meta map[string]string) {
+	if len(meta) == 0 {
+		return
+	}
+	names := make([]string, 0, len(meta))
+	for k, v := range meta {
+		name := http.CanonicalHeaderKey(MetaHeaderPrefix + k)
+		w.Header().Set(name, v)
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	w.Header().Add("Access-Control-Expose-Headers", strings.Join(names, ", "))
+}
+
 func isDecryptError(err error) bool {
 	return strings.Contains(err.Error(), api.ErrDecrypt.Error())
 }
+
+func isGrantExpiredError(err error) bool {
+	return strings.Contains(err.Error(), api.ErrGrantExpired.Error())
+}
@@ -30,6 +30,7 @@ import (
 	"math/big"
 	"mime/multipart"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"os"
 	"path"
@@ -43,8 +44,10 @@ import (
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethersphere/swarm/api"
+	"github.com/ethersphere/swarm/api/auth"
 	"github.com/ethersphere/swarm/chunk"
 	chunktesting "github.com/ethersphere/swarm/chunk/testing"
+	"github.com/ethersphere/swarm/state"
 	"github.com/ethersphere/swarm/storage"
 	"github.com/ethersphere/swarm/storage/feed"
 	"github.com/ethersphere/swarm/storage/feed/lookup"
@@ -1275,6 +1278,75 @@ func TestMethodsNotAllowed(t *testing.T) {
 
 }
 
+// TestAuthorization checks that API keys are required and their
+// scopes enforced on routes when authorization is enabled
+func TestAuthorization(t *testing.T) {
+	keys, err := auth.New(state.NewInmemoryStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+	readToken, _, err := keys.Create("reader", []auth.Scope{auth.ScopeRead})
+	if err != nil {
+		t.Fatal(err)
+	}
+	adminToken, _, err := keys.Create("admin", []auth.Scope{auth.ScopeAdmin})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := NewTestSwarmServer(t, func(api *api.API, pinAPI *pin.API) TestServer {
+		return NewServer(api, pinAPI, "", ServerOptionWithAuth(keys))
+	}, nil, nil)
+	defer srv.Close()
+
+	data := []byte("foo")
+	uploadURL := fmt.Sprintf("%s/bzz-raw:/", srv.URL)
+
+	res, _ := httpDo(http.MethodPost, uploadURL, bytes.NewReader(data), nil, false, t)
+	if res.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected status %d without api key, got %d", http.StatusUnauthorized, res.StatusCode)
+	}
+
+	res, _ = httpDo(http.MethodPost, uploadURL, bytes.NewReader(data), map[string]string{"Authorization": "Bearer invalid"}, false, t)
+	if res.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected status %d with invalid api key, got %d", http.StatusUnauthorized, res.StatusCode)
+	}
+
+	res, _ = httpDo(http.MethodPost, uploadURL, bytes.NewReader(data), map[string]string{APIKeyHeaderName: readToken}, false, t)
+	if res.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected status %d for key without upload scope, got %d", http.StatusForbidden, res.StatusCode)
+	}
+
+	res, hash := httpDo(http.MethodPost, uploadURL, bytes.NewReader(data), map[string]string{"Authorization": "Bearer " + adminToken}, false, t)
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("expected status %d for admin key, got %d", http.StatusOK, res.StatusCode)
+	}
+
+	downloadURL := fmt.Sprintf("%s/bzz-raw:/%s", srv.URL, hash)
+	res, _ = httpDo(http.MethodGet, downloadURL, nil, nil, false, t)
+	if res.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected status %d without api key, got %d", http.StatusUnauthorized, res.StatusCode)
+	}
+
+	res, body := httpDo(http.MethodGet, downloadURL, nil, map[string]string{APIKeyHeaderName: readToken}, false, t)
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("expected status %d for key with read scope, got %d", http.StatusOK, res.StatusCode)
+	}
+	if body != string(data) {
+		t.Fatalf("expected body %q, got %q", data, body)
+	}
+
+	res, _ = httpDo(http.MethodGet, fmt.Sprintf("%s/bzz-pin:/", srv.URL), nil, map[string]string{APIKeyHeaderName: readToken}, false, t)
+	if res.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected status %d for key without pin scope, got %d", http.StatusForbidden, res.StatusCode)
+	}
+
+	res, _ = httpDo(http.MethodGet, srv.URL+"/", nil, nil, false, t)
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("expected root path to be served without api key, got status %d", res.StatusCode)
+	}
+}
+
 func httpDo(httpMethod string, url string, reqBody io.Reader, headers map[string]string, verbose bool, t *testing.T) (*http.Response, string) {
 	// Build the Request
 	req, err := http.NewRequest(httpMethod, url, reqBody)
@@ -1759,3 +1831,53 @@ func unpinFile(t *testing.T, srv *TestSwarmServer, rootHash []byte) []byte {
 	}
 	return unpinMessage
 }
+
+// TestSetRetrievalStrategy validates that the retrieval strategy is set
+// in the request context only from the header, also for range requests,
+// and that strategies with extra requests are set only if they are allowed
+// for all clients or for the API key of the request.
+func TestSetRetrievalStrategy(t *testing.T) {
+	keys, err := auth.New(state.NewInmemoryStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+	readToken, _, err := keys.Create("reader", []auth.Scope{auth.ScopeRead})
+	if err != nil {
+		t.Fatal(err)
+	}
+	uploadToken, _, err := keys.Create("uploader", []auth.Scope{auth.ScopeRead, auth.ScopeUpload})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got storage.RetrievalStrategy
+	srv := httptest.NewServer(SetRetrievalStrategy(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = storage.GetRetrievalStrategy(r.Context())
+	}), keys, []storage.RetrievalStrategy{storage.RetrievalHedged}))
+	defer srv.Close()
+
+	for _, tc := range []struct {
+		headers map[string]string
+		status  int
+		want    storage.RetrievalStrategy
+	}{
+		{headers: nil, status: http.StatusOK, want: storage.RetrievalDefault},
+		{headers: map[string]string{RetrievalHeaderName: "hedged"}, status: http.StatusOK, want: storage.RetrievalHedged},
+		{headers: map[string]string{"Range": "bytes=0-99"}, status: http.StatusOK, want: storage.RetrievalDefault},
+		{headers: map[string]string{"Range": "bytes=0-99", RetrievalHeaderName: "sequential"}, status: http.StatusOK, want: storage.RetrievalSequential},
+		{headers: map[string]string{RetrievalHeaderName: "fastest"}, status: http.StatusBadRequest},
+		{headers: map[string]string{RetrievalHeaderName: "fanout"}, status: http.StatusOK, want: storage.RetrievalDefault},
+		{headers: map[string]string{RetrievalHeaderName: "fanout", APIKeyHeaderName: readToken}, status: http.StatusOK, want: storage.RetrievalDefault},
+		{headers: map[string]string{RetrievalHeaderName: "fanout", APIKeyHeaderName: uploadToken}, status: http.StatusOK, want: storage.RetrievalFanOut},
+		{headers: map[string]string{RetrievalHeaderName: "fanout", "Authorization": "Bearer " + uploadToken}, status: http.StatusOK, want: storage.RetrievalFanOut},
+	} {
+		got = storage.RetrievalDefault
+		res, _ := httpDo(http.MethodGet, srv.URL, nil, tc.headers, false, t)
+		if res.StatusCode != tc.status {
+			t.Fatalf("headers %v: got status %v, want %v", tc.headers, res.StatusCode, tc.status)
+		}
+		if got != tc.want {
+			t.Errorf("headers %v: got strategy %v, want %v", tc.headers, got, tc.want)
+		}
+	}
+}
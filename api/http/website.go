@@ -0,0 +1,110 @@
+// Copyright 2019 The Swarm Authors
+// This file is part of the Swarm library.
+//
+// The Swarm library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Swarm library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Swarm library. If not, see <http://www.gnu.org/licenses/>.
+
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+
+	"github.com/ethersphere/swarm/api"
+	"github.com/ethersphere/swarm/log"
+	"github.com/ethersphere/swarm/storage"
+)
+
+const (
+	defaultIndexDocument = "index.html"
+	defaultErrorDocument = "404.html"
+)
+
+// gatewayWebsite is the website configuration of sites served on gateway hosts
+// whose manifest does not have one
+var gatewayWebsite = &api.WebsiteConfig{
+	IndexDocument: defaultIndexDocument,
+	ErrorDocument: defaultErrorDocument,
+}
+
+// websiteFromQuery returns the website configuration set by the indexdocument,
+// errordocument and spa query parameters of an upload request,
+// or nil if none of them is set
+func websiteFromQuery(q url.Values) (*api.WebsiteConfig, error) {
+	website := &api.WebsiteConfig{
+		IndexDocument: q.Get("indexdocument"),
+		ErrorDocument: q.Get("errordocument"),
+	}
+	if spa := q.Get("spa"); spa != "" {
+		b, err := strconv.ParseBool(spa)
+		if err != nil {
+			return nil, fmt.Errorf("invalid spa parameter %q: %v", spa, err)
+		}
+		website.SPA = b
+	}
+	if *website == (api.WebsiteConfig{}) {
+		return nil, nil
+	}
+	if website.SPA && website.IndexDocument == "" {
+		website.IndexDocument = defaultIndexDocument
+	}
+	return website, nil
+}
+
+// getWebsite returns the website configuration of the manifest, falling back
+// to the gateway defaults for sites served on gateway hosts.
+// It returns nil if the manifest is not served as a website.
+func (s *Server) getWebsite(r *http.Request, manifest *api.LoadedManifest) *api.WebsiteConfig {
+	if website := manifest.Website(); website != nil {
+		return website
+	}
+	if GetGatewayAddr(r.Context()) != "" {
+		return gatewayWebsite
+	}
+	return nil
+}
+
+// getWebsiteFallback looks up the document to serve for a path that is not found
+// in the manifest of a website: the index document under the path, the root index
+// document in single-page-app mode, and then the error document,
+// which is returned with the status to respond with.
+func (s *Server) getWebsiteFallback(r *http.Request, manifest *api.LoadedManifest, p string, website *api.WebsiteConfig) (reader storage.LazySectionReader, entry *api.ManifestEntry, status int, contentKey storage.Address, responseStatus int, err error) {
+	type fallback struct {
+		path   string
+		status int
+	}
+	var fallbacks []fallback
+	if website.IndexDocument != "" {
+		fallbacks = append(fallbacks, fallback{path: path.Join(p, website.IndexDocument), status: http.StatusOK})
+		if website.SPA {
+			fallbacks = append(fallbacks, fallback{path: website.IndexDocument, status: http.StatusOK})
+		}
+	}
+	if website.ErrorDocument != "" {
+		fallbacks = append(fallbacks, fallback{path: website.ErrorDocument, status: http.StatusNotFound})
+	}
+	for _, f := range fallbacks {
+		if !manifest.HasEntry(f.path) {
+			continue
+		}
+		reader, entry, status, contentKey, err = manifest.GetEntry(r.Context(), f.path)
+		if err == nil {
+			log.Debug("serving website fallback document", "ruid", GetRUID(r.Context()), "path", p, "fallback", f.path)
+			return reader, entry, status, contentKey, f.status, nil
+		}
+	}
+	return nil, nil, http.StatusNotFound, nil, http.StatusNotFound, fmt.Errorf("Not found: could not find resource '%s'", p)
+}
@@ -0,0 +1,116 @@
+// Copyright 2019 The Swarm Authors
+// This file is part of the Swarm library.
+//
+// The Swarm library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Swarm library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Swarm library. If not, see <http://www.gnu.org/licenses/>.
+
+package http
+
+import (
+	"archive/tar"
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestWebsite tests serving index and error documents and single-page-app
+// fallbacks set in the website configuration of a manifest
+func TestWebsite(t *testing.T) {
+	srv := NewTestSwarmServer(t, serverFunc, nil, nil)
+	defer srv.Close()
+
+	files := map[string]string{
+		"home.html":       "<html>home</html>",
+		"docs/home.html":  "<html>docs</html>",
+		"app.js":          "app()",
+		"error.html":      "<html>error</html>",
+		"assets/logo.svg": "<svg/>",
+	}
+	upload := func(query string) string {
+		buf := &bytes.Buffer{}
+		tw := tar.NewWriter(buf)
+		for name, content := range files {
+			hdr := &tar.Header{
+				Name:    name,
+				Mode:    0644,
+				Size:    int64(len(content)),
+				ModTime: time.Now(),
+			}
+			if err := tw.WriteHeader(hdr); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := tw.Write([]byte(content)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := tw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		res, hash := httpDo(http.MethodPost, srv.URL+"/bzz:/"+query, buf, map[string]string{"Content-Type": "application/x-tar"}, false, t)
+		if res.StatusCode != http.StatusOK {
+			t.Fatalf("upload failed with status %s", res.Status)
+		}
+		return hash
+	}
+	get := func(url string, code int, body string) {
+		t.Helper()
+		res, err := http.Get(url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.StatusCode != code {
+			t.Fatalf("GET %s: expected status %d, got %d", url, code, res.StatusCode)
+		}
+		if body != "" && string(b) != body {
+			t.Fatalf("GET %s: expected body %q, got %q", url, body, b)
+		}
+	}
+
+	res, _ := httpDo(http.MethodPost, srv.URL+"/bzz:/?spa=maybe", strings.NewReader(""), map[string]string{"Content-Type": "application/x-tar"}, false, t)
+	if res.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected status %d for invalid spa parameter, got %d", http.StatusBadRequest, res.StatusCode)
+	}
+
+	// index and error documents
+	hash := upload("?indexdocument=home.html&errordocument=error.html")
+	get(srv.URL+"/bzz:/"+hash+"/", http.StatusOK, files["home.html"])
+	get(srv.URL+"/bzz:/"+hash+"/docs/", http.StatusOK, files["docs/home.html"])
+	get(srv.URL+"/bzz:/"+hash+"/app.js", http.StatusOK, files["app.js"])
+	get(srv.URL+"/bzz:/"+hash+"/users/42", http.StatusNotFound, files["error.html"])
+
+	// the website configuration is kept when files are added to the manifest
+	res, newHash := httpDo(http.MethodPost, srv.URL+"/bzz:/"+hash+"/new.txt", strings.NewReader("new"), map[string]string{"Content-Type": "text/plain"}, false, t)
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("upload failed with status %s", res.Status)
+	}
+	get(srv.URL+"/bzz:/"+newHash+"/new.txt", http.StatusOK, "new")
+	get(srv.URL+"/bzz:/"+newHash+"/users/42", http.StatusNotFound, files["error.html"])
+
+	// single-page app
+	hash = upload("?indexdocument=home.html&spa=true")
+	get(srv.URL+"/bzz:/"+hash+"/users/42", http.StatusOK, files["home.html"])
+	get(srv.URL+"/bzz:/"+hash+"/docs/", http.StatusOK, files["docs/home.html"])
+	get(srv.URL+"/bzz:/"+hash+"/assets/logo.svg", http.StatusOK, files["assets/logo.svg"])
+
+	// manifests without a website configuration respond as before
+	hash = upload("")
+	get(srv.URL+"/bzz:/"+hash+"/users/42", http.StatusNotFound, "")
+}
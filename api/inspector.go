@@ -27,6 +27,7 @@ import (
 	"github.com/ethersphere/swarm/chunk"
 	"github.com/ethersphere/swarm/log"
 	"github.com/ethersphere/swarm/network"
+	"github.com/ethersphere/swarm/network/retrieval"
 	"github.com/ethersphere/swarm/network/stream"
 	"github.com/ethersphere/swarm/storage"
 	"github.com/ethersphere/swarm/storage/localstore"
@@ -35,15 +36,16 @@ import (
 const InspectorIsPullSyncingTolerance = 15 * time.Second
 
 type Inspector struct {
-	api      *API
-	hive     *network.Hive
-	netStore *storage.NetStore
-	stream   *stream.Registry
-	ls       *localstore.DB
+	api       *API
+	hive      *network.Hive
+	netStore  *storage.NetStore
+	stream    *stream.Registry
+	ls        *localstore.DB
+	retrieval *retrieval.Retrieval
 }
 
-func NewInspector(api *API, hive *network.Hive, netStore *storage.NetStore, pullSyncer *stream.Registry, ls *localstore.DB) *Inspector {
-	return &Inspector{api, hive, netStore, pullSyncer, ls}
+func NewInspector(api *API, hive *network.Hive, netStore *storage.NetStore, pullSyncer *stream.Registry, ls *localstore.DB, retrieval *retrieval.Retrieval) *Inspector {
+	return &Inspector{api, hive, netStore, pullSyncer, ls, retrieval}
 }
 
 // Hive prints the kademlia table
@@ -131,3 +133,33 @@ func (i *Inspector) PeerStreams() (string, error) {
 func (i *Inspector) StorageIndices() (map[string]int, error) {
 	return i.ls.DebugIndices()
 }
+
+// StorageStats returns the statistics of local store indexes and chunks
+// in proximity order bins, and the status of the last compaction
+func (i *Inspector) StorageStats() (*localstore.Stats, error) {
+	return i.ls.Stats()
+}
+
+// CompactStorage starts compacting the local store in the background to
+// reclaim the disk space of deleted chunks, while the node keeps running.
+// The progress is reported by StorageStats.
+func (i *Inspector) CompactStorage() error {
+	return i.ls.StartCompaction()
+}
+
+// SyncProgress returns the progress of historical syncing by peer and bin,
+// with the estimated number of remaining chunks and time to sync them
+func (i *Inspector) SyncProgress() (*stream.SyncProgress, error) {
+	return i.stream.SyncProgress()
+}
+
+// CheckAvailability checks whether all chunks of the file or the manifest
+// with the address are retrievable from the network, without storing them.
+// The address can be a hash or an ENS name.
+func (i *Inspector) CheckAvailability(ctx context.Context, address string) (*Availability, error) {
+	addr, err := i.api.Resolve(ctx, address)
+	if err != nil {
+		return nil, err
+	}
+	return CheckAvailability(ctx, i.netStore.Store, i.retrieval.Probe, addr)
+}
@@ -7,6 +7,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/ethersphere/swarm/chunk"
 	"github.com/ethersphere/swarm/network"
 	"github.com/ethersphere/swarm/network/stream"
 	"github.com/ethersphere/swarm/storage"
@@ -42,7 +43,7 @@ func TestInspectorPeerStreams(t *testing.T) {
 	i := NewInspector(nil, nil, netStore, stream.New(state.NewInmemoryStore(), baseAddress, stream.NewSyncProvider(netStore, network.NewKademlia(
 		baseKey,
 		network.NewKadParams(),
-	), baseAddress, false, false)), localStore)
+	), baseAddress, false, false)), localStore, nil)
 
 	server := rpc.NewServer()
 	if err := server.RegisterName("inspector", i); err != nil {
@@ -88,7 +89,7 @@ func TestInspectorStorageIndices(t *testing.T) {
 	i := NewInspector(nil, nil, netStore, stream.New(state.NewInmemoryStore(), network.NewBzzAddr(baseKey, baseKey), stream.NewSyncProvider(netStore, network.NewKademlia(
 		baseKey,
 		network.NewKadParams(),
-	), baseAddress, false, false)), localStore)
+	), baseAddress, false, false)), localStore, nil)
 
 	server := rpc.NewServer()
 	if err := server.RegisterName("inspector", i); err != nil {
@@ -107,3 +108,53 @@ func TestInspectorStorageIndices(t *testing.T) {
 		t.Fatalf("expected gcSize to be %d but got %d", 0, indiceInfo["gcSize"])
 	}
 }
+
+// TestInspectorStorageStats validates that RPC storageStats returns the
+// statistics and compactStorage starts the compaction
+func TestInspectorStorageStats(t *testing.T) {
+	dir, err := ioutil.TempDir("", "swarm-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	baseKey := make([]byte, 32)
+	_, err = rand.Read(baseKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	localStore, err := localstore.New(dir, baseKey, &localstore.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer localStore.Close()
+
+	i := NewInspector(nil, nil, nil, nil, localStore, nil)
+
+	server := rpc.NewServer()
+	if err := server.RegisterName("inspector", i); err != nil {
+		t.Fatal(err)
+	}
+
+	client := rpc.DialInProc(server)
+
+	if err := client.Call(nil, "inspector_compactStorage"); err != nil {
+		t.Fatal(err)
+	}
+
+	var stats localstore.Stats
+	err = client.Call(&stats, "inspector_storageStats")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := stats.Indexes["retrievalDataIndex"]; !ok {
+		t.Fatal("expected retrievalDataIndex statistics")
+	}
+	if len(stats.Bins) != chunk.MaxPO+1 {
+		t.Fatalf("expected %d bins, got %d", chunk.MaxPO+1, len(stats.Bins))
+	}
+	if stats.Compaction.Started.IsZero() {
+		t.Fatal("expected compaction to be started")
+	}
+}
@@ -19,16 +19,19 @@ package api
 import (
 	"bytes"
 	"context"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 
 	"io"
 	"net/http"
+	"os"
 	"strings"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethersphere/swarm/chunk"
 	"github.com/ethersphere/swarm/log"
 	"github.com/ethersphere/swarm/storage"
 	"github.com/ethersphere/swarm/storage/feed"
@@ -38,12 +41,26 @@ const (
 	ManifestType    = "application/bzz-manifest+json"
 	FeedContentType = "application/bzz-feed"
 
-	manifestSizeLimit = 5 * 1024 * 1024
+	manifestSizeLimit       = 5 * 1024 * 1024
+	binaryManifestSizeLimit = 256 * 1024 * 1024
 )
 
 // Manifest represents a swarm manifest
 type Manifest struct {
 	Entries []ManifestEntry `json:"entries,omitempty"`
+	Website *WebsiteConfig  `json:"website,omitempty"`
+	// Index is the address of the search index of the manifest, see IndexManifest
+	Index string `json:"index,omitempty"`
+	// Format is the format the manifest is encoded in, see EncodeManifest
+	Format ManifestFormat `json:"-"`
+}
+
+// WebsiteConfig holds the manifest level settings used when the manifest
+// is served as a website, for paths that are not found in the manifest
+type WebsiteConfig struct {
+	IndexDocument string `json:"indexDocument,omitempty"` // served for directory paths, e.g. index.html
+	ErrorDocument string `json:"errorDocument,omitempty"` // served with 404 status for missing paths, e.g. 404.html
+	SPA           bool   `json:"spa,omitempty"`           // serve the root index document for all missing paths
 }
 
 // ManifestEntry represents an entry in a swarm manifest
@@ -57,6 +74,12 @@ type ManifestEntry struct {
 	Status      int          `json:"status,omitempty"`
 	Access      *AccessEntry `json:"access,omitempty"`
 	Feed        *feed.Feed   `json:"feed,omitempty"`
+	// ContentEncoding is the encoding the content is compressed with,
+	// in which case Size is the size of the uncompressed content
+	ContentEncoding string `json:"contentEncoding,omitempty"`
+	// Metadata holds the attributes applications attach to the entry,
+	// see ValidateMetadata
+	Metadata map[string]string `json:"metadata,omitempty"`
 }
 
 // ManifestList represents the result of listing files in a manifest
@@ -105,9 +128,11 @@ func (a *API) NewFeedManifest(ctx context.Context, feed *feed.Feed) (storage.Add
 
 // ManifestWriter is used to add and remove entries from an underlying manifest
 type ManifestWriter struct {
-	api   *API
-	trie  *manifestTrie
-	quitC chan bool
+	api             *API
+	trie            *manifestTrie
+	quitC           chan bool
+	contentEncoding string            // encoding the data of added entries is compressed with
+	metadata        map[string]string // metadata of added entries, unless they have their own value
 }
 
 func (a *API) NewManifestWriter(ctx context.Context, addr storage.Address, quitC chan bool) (*ManifestWriter, error) {
@@ -115,15 +140,61 @@ func (a *API) NewManifestWriter(ctx context.Context, addr storage.Address, quitC
 	if err != nil {
 		return nil, fmt.Errorf("error loading manifest %s: %s", addr, err)
 	}
-	return &ManifestWriter{a, trie, quitC}, nil
+	return &ManifestWriter{api: a, trie: trie, quitC: quitC}, nil
+}
+
+// SetContentEncoding sets the encoding the data of entries added
+// to the manifest is compressed with, an empty encoding disables compression
+func (m *ManifestWriter) SetContentEncoding(encoding string) error {
+	if err := ValidateContentEncoding(encoding); err != nil {
+		return err
+	}
+	m.contentEncoding = encoding
+	return nil
+}
+
+// SetMetadata sets the metadata of entries added to the manifest,
+// values in the metadata of an added entry take precedence
+func (m *ManifestWriter) SetMetadata(meta map[string]string) error {
+	if err := ValidateMetadata(meta); err != nil {
+		return err
+	}
+	m.metadata = meta
+	return nil
 }
 
-// AddEntry stores the given data and adds the resulting address to the manifest
+// AddEntry stores the given data and adds the resulting address to the manifest.
+// The data is compressed if a content encoding is set on the writer.
 func (m *ManifestWriter) AddEntry(ctx context.Context, data io.Reader, e *ManifestEntry) (addr storage.Address, err error) {
 	entry := newManifestTrieEntry(e, nil)
+	if len(m.metadata) > 0 {
+		meta := make(map[string]string, len(m.metadata)+len(e.Metadata))
+		for k, v := range m.metadata {
+			meta[k] = v
+		}
+		for k, v := range e.Metadata {
+			meta[k] = v
+		}
+		entry.Metadata = meta
+	}
+	if err := ValidateMetadata(entry.Metadata); err != nil {
+		return nil, err
+	}
 	if data != nil {
+		size := e.Size
+		if m.contentEncoding != "" {
+			f, uncompressedSize, compressedSize, err := Compress(data, m.contentEncoding)
+			if err != nil {
+				return nil, err
+			}
+			defer os.Remove(f.Name())
+			defer f.Close()
+			data, size = f, compressedSize
+			entry.Size = uncompressedSize
+			entry.ContentEncoding = m.contentEncoding
+		}
 		var wait func(context.Context) error
-		addr, wait, err = m.api.Store(ctx, data, e.Size, m.trie.encrypted)
+		addr, wait, err = m.api.Store(ctx, data, size, m.trie.encrypted)
 		if err != nil {
 			return nil, err
 		}
@@ -146,6 +217,21 @@ func (m *ManifestWriter) RemoveEntry(path string) error {
 	return nil
 }
 
+// SetWebsite sets the website configuration of the manifest,
+// a nil config removes it
+func (m *ManifestWriter) SetWebsite(website *WebsiteConfig) {
+	m.trie.ref = nil // trie modified, hash needs to be re-calculated on demand
+	m.trie.website = website
+}
+
+// SetIndex sets the address of the search index of the manifest. The index
+// is removed when entries are added or removed afterwards, as it is no
+// longer up to date.
+func (m *ManifestWriter) SetIndex(index string) {
+	m.trie.ref = nil // trie modified, hash needs to be re-calculated on demand
+	m.trie.index = index
+}
+
 // Store stores the manifest, returning the resulting storage address
 func (m *ManifestWriter) Store() (storage.Address, error) {
 	return m.trie.ref, m.trie.recalcAndStore()
@@ -175,6 +261,12 @@ var ErrSkipManifest = errors.New("skip this manifest")
 // manifest walk
 type WalkFn func(entry *ManifestEntry) error
 
+// Index returns the address of the search index of the walked manifest,
+// or an empty string if it has none
+func (m *ManifestWalker) Index() string {
+	return m.trie.index
+}
+
 // Walk recursively walks the manifest calling walkFn for each entry in the
 // manifest, including submanifests
 func (m *ManifestWalker) Walk(walkFn WalkFn) error {
@@ -207,12 +299,101 @@ func (m *ManifestWalker) walk(trie *manifestTrie, prefix string, walkFn WalkFn)
 	return nil
 }
 
+// WalkChunks calls walkFn with the addresses of all chunks of the content
+// with the root address in the store. If the content is a manifest, chunks
+// of all of its entries and submanifests are walked, too, and of its search
+// index. Of access controlled entries, only the ACT and feed manifests are
+// walked, as their reference is encrypted. The same chunk can be passed to
+// walkFn more than once.
+func WalkChunks(ctx context.Context, store storage.ChunkStore, root storage.Address, walkFn func(storage.Address) error) error {
+	return WalkChunksMissing(ctx, store, root, walkFn, nil)
+}
+
+// WalkChunksMissing walks the chunks like WalkChunks, but if missingFn is
+// not nil, it is called with the address of every chunk that is not found
+// in the store instead of returning an error. The chunks below the missing
+// one are skipped and the walk continues with the rest of the content, also
+// with the remaining entries of a manifest. Submanifests and manifests with
+// missing chunks are not walked, as they can not be loaded.
+func WalkChunksMissing(ctx context.Context, store storage.ChunkStore, root storage.Address, walkFn, missingFn func(storage.Address) error) error {
+	var missing int
+	if missingFn != nil {
+		fn := missingFn
+		missingFn = func(addr storage.Address) error {
+			missing++
+			return fn(addr)
+		}
+	}
+	if err := storage.WalkChunkTreeMissing(ctx, store, root, walkFn, missingFn); err != nil {
+		return err
+	}
+	if missing > 0 {
+		return nil
+	}
+	// all chunks of the root tree are in the store,
+	// so a manifest can not be loaded only if it is not one
+	fileStore := storage.NewFileStore(store, store, &storage.FileStoreParams{Hash: storage.DefaultHash}, chunk.NewTags())
+	trie, err := loadManifest(ctx, fileStore, root, nil, NOOPDecrypt)
+	if err != nil {
+		return nil
+	}
+	// walkRef walks the tree of the reference and
+	// returns false if any of its chunks is missing
+	walkRef := func(ref, name string) (complete bool, err error) {
+		addr, err := hex.DecodeString(ref)
+		if err != nil {
+			return false, fmt.Errorf("%s: %w", name, err)
+		}
+		m := missing
+		if err := storage.WalkChunkTreeMissing(ctx, store, addr, walkFn, missingFn); err != nil {
+			return false, err
+		}
+		return missing == m, nil
+	}
+	if trie.index != "" {
+		if _, err := walkRef(trie.index, "search index"); err != nil {
+			return err
+		}
+	}
+	walker := &ManifestWalker{trie: trie}
+	return walker.Walk(func(entry *ManifestEntry) error {
+		if entry.Access != nil {
+			for _, ref := range []string{entry.Access.Act, entry.Access.Feed} {
+				if ref == "" {
+					continue
+				}
+				if _, err := walkRef(ref, "access of manifest entry "+entry.Path); err != nil {
+					return err
+				}
+			}
+			if entry.ContentType == ManifestType {
+				return ErrSkipManifest
+			}
+			return nil
+		}
+		if entry.Hash == "" {
+			return nil
+		}
+		complete, err := walkRef(entry.Hash, "manifest entry "+entry.Path)
+		if err != nil {
+			return err
+		}
+		if !complete && entry.ContentType == ManifestType {
+			return ErrSkipManifest
+		}
+		return nil
+	})
+}
+
 type manifestTrie struct {
 	fileStore *storage.FileStore
 	entries   [257]*manifestTrieEntry // indexed by first character of basePath, entries[256] is the empty basePath entry
 	ref       storage.Address         // if ref != nil, it is stored
 	encrypted bool
 	decrypt   DecryptFunc
+	website   *WebsiteConfig // only set on the root trie of a manifest
+	index     string         // address of the search index, only set on the root trie of an unmodified manifest
+	format    ManifestFormat // the format the manifest is stored in, kept when it is modified
 }
 
 func newManifestTrieEntry(entry *ManifestEntry, subtrie *manifestTrie) *manifestTrieEntry {
@@ -245,9 +426,14 @@ func readManifest(mr storage.LazySectionReader, addr storage.Address, fileStore
 		err = fmt.Errorf("Manifest not Found")
 		return
 	}
-	if size > manifestSizeLimit {
-		log.Warn("manifest exceeds size limit", "addr", addr, "size", size, "limit", manifestSizeLimit)
-		err = fmt.Errorf("Manifest size of %v bytes exceeds the %v byte limit", size, manifestSizeLimit)
+	limit := int64(manifestSizeLimit)
+	header := make([]byte, len(binaryManifestMagic))
+	if n, _ := mr.ReadAt(header, 0); n == len(header) && isBinaryManifest(header) {
+		limit = binaryManifestSizeLimit
+	}
+	if size > limit {
+		log.Warn("manifest exceeds size limit", "addr", addr, "size", size, "limit", limit)
+		err = fmt.Errorf("Manifest size of %v bytes exceeds the %v byte limit", size, limit)
 		return
 	}
 	manifestData := make([]byte, size)
@@ -261,10 +447,7 @@ func readManifest(mr storage.LazySectionReader, addr storage.Address, fileStore
 	}
 
 	log.Debug("manifest retrieved", "addr", addr)
-	var man struct {
-		Entries []*manifestTrieEntry `json:"entries"`
-	}
-	err = json.Unmarshal(manifestData, &man)
+	man, err := DecodeManifest(manifestData)
 	if err != nil {
 		err = fmt.Errorf("Manifest %v is malformed: %v", addr.Log(), err)
 		log.Trace("malformed manifest", "addr", addr)
@@ -277,18 +460,23 @@ func readManifest(mr storage.LazySectionReader, addr storage.Address, fileStore
 		fileStore: fileStore,
 		encrypted: isEncrypted,
 		decrypt:   decrypt,
+		website:   man.Website,
+		format:    man.Format,
 	}
-	for _, entry := range man.Entries {
-		err = trie.addEntry(entry, quitC)
+	for i := range man.Entries {
+		err = trie.addEntry(newManifestTrieEntry(&man.Entries[i], nil), quitC)
 		if err != nil {
 			return
 		}
 	}
+	// set after the entries are added, which removes the index
+	trie.index = man.Index
 	return
 }
 
 func (mt *manifestTrie) addEntry(entry *manifestTrieEntry, quitC chan bool) error {
-	mt.ref = nil // trie modified, hash needs to be re-calculated on demand
+	mt.ref = nil  // trie modified, hash needs to be re-calculated on demand
+	mt.index = "" // the search index no longer matches the entries
 
 	if entry.ManifestEntry.Access != nil {
 		if mt.decrypt == nil {
@@ -333,6 +521,7 @@ func (mt *manifestTrie) addEntry(entry *manifestTrieEntry, quitC chan bool) erro
 	subtrie := &manifestTrie{
 		fileStore: mt.fileStore,
 		encrypted: mt.encrypted,
+		format:    mt.format,
 	}
 	entry.Path = entry.Path[cpl:]
 	oldentry.Path = oldentry.Path[cpl:]
@@ -357,7 +546,8 @@ func (mt *manifestTrie) getCountLast() (cnt int, entry *manifestTrieEntry) {
 }
 
 func (mt *manifestTrie) deleteEntry(path string, quitC chan bool) {
-	mt.ref = nil // trie modified, hash needs to be re-calculated on demand
+	mt.ref = nil  // trie modified, hash needs to be re-calculated on demand
+	mt.index = "" // the search index no longer matches the entries
 
 	if len(path) == 0 {
 		mt.entries[256] = nil
@@ -400,7 +590,7 @@ func (mt *manifestTrie) recalcAndStore() error {
 	var buffer bytes.Buffer
 	buffer.WriteString(`{"entries":[`)
 
-	list := &Manifest{}
+	list := &Manifest{Website: mt.website, Index: mt.index, Format: mt.format}
 	for _, entry := range &mt.entries {
 		if entry != nil {
 			if entry.Hash == "" { // TODO: paralellize
@@ -415,7 +605,7 @@ func (mt *manifestTrie) recalcAndStore() error {
 
 	}
 
-	manifest, err := json.Marshal(list)
+	manifest, err := EncodeManifest(list)
 	if err != nil {
 		return err
 	}
@@ -584,3 +774,25 @@ func (mt *manifestTrie) getEntry(spath string) (entry *manifestTrieEntry, fullpa
 	entry, pos = mt.findPrefixOf(path, quitC)
 	return entry, path[:pos]
 }
+
+// getExactEntry returns the entry with exactly the given path, without falling
+// back to the default entry or matching a prefix of the path as getEntry does
+func (mt *manifestTrie) getExactEntry(path string, quitC chan bool) *manifestTrieEntry {
+	if len(path) == 0 {
+		return mt.entries[256]
+	}
+	entry := mt.entries[path[0]]
+	if entry == nil {
+		return nil
+	}
+	if entry.ContentType == ManifestType && strings.HasPrefix(path, entry.Path) {
+		if err := mt.loadSubTrie(entry, quitC); err != nil {
+			return nil
+		}
+		return entry.subtrie.getExactEntry(path[len(entry.Path):], quitC)
+	}
+	if entry.Path != path {
+		return nil
+	}
+	return entry
+}
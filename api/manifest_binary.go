@@ -0,0 +1,540 @@
+// Copyright 2019 The Swarm Authors
+// This file is part of the Swarm library.
+//
+// The Swarm library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Swarm library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Swarm library. If not, see <http://www.gnu.org/licenses/>.
+
+package api
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ethersphere/swarm/storage/feed"
+)
+
+// ManifestFormat is the encoding a manifest is stored in
+type ManifestFormat string
+
+const (
+	ManifestFormatJSON   = ManifestFormat("json")   // the JSON encoding of Manifest
+	ManifestFormatBinary = ManifestFormat("binary") // the versioned binary encoding
+)
+
+// ParseManifestFormat parses a manifest format, an empty string is ManifestFormatJSON
+func ParseManifestFormat(s string) (ManifestFormat, error) {
+	switch format := ManifestFormat(strings.ToLower(s)); format {
+	case "":
+		return ManifestFormatJSON, nil
+	case ManifestFormatJSON, ManifestFormatBinary:
+		return format, nil
+	}
+	return "", fmt.Errorf("unknown manifest format %q, expected %s or %s", s, ManifestFormatJSON, ManifestFormatBinary)
+}
+
+// The binary encoding starts with a zero byte, which JSON documents never
+// do, followed by "bzzm" and the version of the encoding.
+//
+// Version 1 is, with integers as varints:
+//
+//	flags                                  bit 0: the manifest has a website configuration
+//	                                       bit 1: the manifest has a search index
+//	[website]                              length prefixed JSON of the WebsiteConfig
+//	[index]                                length prefixed address of the search index
+//	string table                           count, then length prefixed strings
+//	entries                                count, then for each entry:
+//	  path                                 length of the prefix shared with the previous path,
+//	                                       then the length prefixed rest of the path
+//	  hash                                 kind (none, 32 or 64 byte reference, or string),
+//	                                       then the reference bytes or the length prefixed string
+//	  content type, content encoding       index in the string table plus one, zero if empty
+//	  fields                               bits of the fields that follow if set:
+//	                                       mode, size, mod time (seconds and nanoseconds), status, metadata
+//	  [metadata]                           count, then length prefixed keys and values
+//
+// Entry fields without a compact encoding, the access entry and the feed,
+// are kept as JSON in the metadata map of the entry, along with the
+// ManifestEntry.Metadata values under keys prefixed with "meta.".
+const binaryManifestVersion = 1
+
+var binaryManifestMagic = []byte("\x00bzzm")
+
+const (
+	binaryManifestWebsite = 1 << iota
+	binaryManifestIndex
+)
+
+const (
+	binaryHashNone = iota
+	binaryHash32
+	binaryHash64
+	binaryHashString
+)
+
+const (
+	binaryFieldMode = 1 << iota
+	binaryFieldSize
+	binaryFieldModTime
+	binaryFieldStatus
+	binaryFieldMetadata
+)
+
+const (
+	binaryMetaAccess = "access"
+	binaryMetaFeed   = "feed"
+	binaryMetaPrefix = "meta."
+)
+
+// errBinaryManifestTruncated is returned when a binary manifest ends before its last entry
+var errBinaryManifestTruncated = errors.New("binary manifest truncated")
+
+// isBinaryManifest reports whether data starts with the header of the binary encoding
+func isBinaryManifest(data []byte) bool {
+	return bytes.HasPrefix(data, binaryManifestMagic)
+}
+
+// EncodeManifest encodes the manifest in its format
+func EncodeManifest(m *Manifest) ([]byte, error) {
+	switch m.Format {
+	case ManifestFormatJSON, "":
+		return json.Marshal(m)
+	case ManifestFormatBinary:
+		return encodeBinaryManifest(m)
+	}
+	return nil, fmt.Errorf("unknown manifest format %q", m.Format)
+}
+
+// DecodeManifest decodes a manifest in either format, setting the format of
+// the manifest to the one it was encoded in
+func DecodeManifest(data []byte) (*Manifest, error) {
+	if isBinaryManifest(data) {
+		return decodeBinaryManifest(data)
+	}
+	m := &Manifest{Format: ManifestFormatJSON}
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+type binaryManifestWriter struct {
+	bytes.Buffer
+	scratch [binary.MaxVarintLen64]byte
+}
+
+func (w *binaryManifestWriter) uvarint(v uint64) {
+	w.Write(w.scratch[:binary.PutUvarint(w.scratch[:], v)])
+}
+
+func (w *binaryManifestWriter) varint(v int64) {
+	w.Write(w.scratch[:binary.PutVarint(w.scratch[:], v)])
+}
+
+func (w *binaryManifestWriter) bytes(b []byte) {
+	w.uvarint(uint64(len(b)))
+	w.Write(b)
+}
+
+func (w *binaryManifestWriter) string(s string) {
+	w.uvarint(uint64(len(s)))
+	w.WriteString(s)
+}
+
+func encodeBinaryManifest(m *Manifest) ([]byte, error) {
+	w := &binaryManifestWriter{}
+	w.Write(binaryManifestMagic)
+	w.WriteByte(binaryManifestVersion)
+
+	var flags uint64
+	if m.Website != nil {
+		flags |= binaryManifestWebsite
+	}
+	if m.Index != "" {
+		flags |= binaryManifestIndex
+	}
+	w.uvarint(flags)
+	if m.Website != nil {
+		website, err := json.Marshal(m.Website)
+		if err != nil {
+			return nil, err
+		}
+		w.bytes(website)
+	}
+	if m.Index != "" {
+		w.string(m.Index)
+	}
+
+	// content types and encodings repeat across entries and are written once
+	var strs []string
+	index := make(map[string]uint64)
+	for _, e := range m.Entries {
+		for _, s := range []string{e.ContentType, e.ContentEncoding} {
+			if _, ok := index[s]; !ok && s != "" {
+				strs = append(strs, s)
+				index[s] = uint64(len(strs))
+			}
+		}
+	}
+	w.uvarint(uint64(len(strs)))
+	for _, s := range strs {
+		w.string(s)
+	}
+
+	w.uvarint(uint64(len(m.Entries)))
+	var prev string
+	for i := range m.Entries {
+		e := &m.Entries[i]
+		shared := 0
+		for shared < len(prev) && shared < len(e.Path) && prev[shared] == e.Path[shared] {
+			shared++
+		}
+		w.uvarint(uint64(shared))
+		w.string(e.Path[shared:])
+		prev = e.Path
+
+		ref, err := hex.DecodeString(e.Hash)
+		switch {
+		case e.Hash == "":
+			w.WriteByte(binaryHashNone)
+		case err == nil && len(ref) == 32:
+			w.WriteByte(binaryHash32)
+			w.Write(ref)
+		case err == nil && len(ref) == 64:
+			w.WriteByte(binaryHash64)
+			w.Write(ref)
+		default:
+			w.WriteByte(binaryHashString)
+			w.string(e.Hash)
+		}
+		w.uvarint(index[e.ContentType])
+		w.uvarint(index[e.ContentEncoding])
+
+		meta, err := binaryEntryMetadata(e)
+		if err != nil {
+			return nil, fmt.Errorf("error encoding entry %q: %v", e.Path, err)
+		}
+		var fields uint64
+		if e.Mode != 0 {
+			fields |= binaryFieldMode
+		}
+		if e.Size != 0 {
+			fields |= binaryFieldSize
+		}
+		if !e.ModTime.IsZero() {
+			fields |= binaryFieldModTime
+		}
+		if e.Status != 0 {
+			fields |= binaryFieldStatus
+		}
+		if len(meta) > 0 {
+			fields |= binaryFieldMetadata
+		}
+		w.uvarint(fields)
+		if e.Mode != 0 {
+			w.varint(e.Mode)
+		}
+		if e.Size != 0 {
+			w.varint(e.Size)
+		}
+		if !e.ModTime.IsZero() {
+			w.varint(e.ModTime.Unix())
+			w.uvarint(uint64(e.ModTime.Nanosecond()))
+		}
+		if e.Status != 0 {
+			w.varint(int64(e.Status))
+		}
+		if len(meta) > 0 {
+			keys := make([]string, 0, len(meta))
+			for k := range meta {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			w.uvarint(uint64(len(keys)))
+			for _, k := range keys {
+				w.string(k)
+				w.bytes(meta[k])
+			}
+		}
+	}
+	return w.Bytes(), nil
+}
+
+// binaryEntryMetadata returns the fields of the entry that are kept in its metadata map
+func binaryEntryMetadata(e *ManifestEntry) (map[string][]byte, error) {
+	meta := make(map[string][]byte)
+	if e.Access != nil {
+		access, err := json.Marshal(e.Access)
+		if err != nil {
+			return nil, err
+		}
+		meta[binaryMetaAccess] = access
+	}
+	if e.Feed != nil {
+		fd, err := json.Marshal(e.Feed)
+		if err != nil {
+			return nil, err
+		}
+		meta[binaryMetaFeed] = fd
+	}
+	for k, v := range e.Metadata {
+		meta[binaryMetaPrefix+k] = []byte(v)
+	}
+	return meta, nil
+}
+
+type binaryManifestReader struct {
+	*bytes.Reader
+}
+
+func (r *binaryManifestReader) uvarint() (uint64, error) {
+	v, err := binary.ReadUvarint(r)
+	if err == io.EOF {
+		return 0, errBinaryManifestTruncated
+	}
+	return v, err
+}
+
+func (r *binaryManifestReader) varint() (int64, error) {
+	v, err := binary.ReadVarint(r)
+	if err == io.EOF {
+		return 0, errBinaryManifestTruncated
+	}
+	return v, err
+}
+
+// count reads a count of items that are at least one byte each
+func (r *binaryManifestReader) count() (int, error) {
+	n, err := r.uvarint()
+	if err != nil {
+		return 0, err
+	}
+	if n > uint64(r.Len()) {
+		return 0, errBinaryManifestTruncated
+	}
+	return int(n), nil
+}
+
+func (r *binaryManifestReader) fixed(n int) ([]byte, error) {
+	if n > r.Len() {
+		return nil, errBinaryManifestTruncated
+	}
+	b := make([]byte, n)
+	r.Read(b)
+	return b, nil
+}
+
+func (r *binaryManifestReader) bytes() ([]byte, error) {
+	n, err := r.uvarint()
+	if err != nil {
+		return nil, err
+	}
+	if n > uint64(r.Len()) {
+		return nil, errBinaryManifestTruncated
+	}
+	return r.fixed(int(n))
+}
+
+func (r *binaryManifestReader) string() (string, error) {
+	b, err := r.bytes()
+	return string(b), err
+}
+
+func decodeBinaryManifest(data []byte) (*Manifest, error) {
+	if !isBinaryManifest(data) || len(data) <= len(binaryManifestMagic) {
+		return nil, errors.New("not a binary manifest")
+	}
+	if version := data[len(binaryManifestMagic)]; version != binaryManifestVersion {
+		return nil, fmt.Errorf("unsupported binary manifest version %d", version)
+	}
+	r := &binaryManifestReader{bytes.NewReader(data[len(binaryManifestMagic)+1:])}
+
+	m := &Manifest{Format: ManifestFormatBinary}
+	flags, err := r.uvarint()
+	if err != nil {
+		return nil, err
+	}
+	if flags&binaryManifestWebsite != 0 {
+		website, err := r.bytes()
+		if err != nil {
+			return nil, err
+		}
+		m.Website = &WebsiteConfig{}
+		if err := json.Unmarshal(website, m.Website); err != nil {
+			return nil, fmt.Errorf("invalid website configuration: %v", err)
+		}
+	}
+	if flags&binaryManifestIndex != 0 {
+		if m.Index, err = r.string(); err != nil {
+			return nil, err
+		}
+	}
+
+	n, err := r.count()
+	if err != nil {
+		return nil, err
+	}
+	strs := make([]string, n)
+	for i := range strs {
+		if strs[i], err = r.string(); err != nil {
+			return nil, err
+		}
+	}
+	str := func() (string, error) {
+		i, err := r.uvarint()
+		if err != nil || i == 0 {
+			return "", err
+		}
+		if i > uint64(len(strs)) {
+			return "", fmt.Errorf("invalid string index %d", i)
+		}
+		return strs[i-1], nil
+	}
+
+	if n, err = r.count(); err != nil {
+		return nil, err
+	}
+	m.Entries = make([]ManifestEntry, n)
+	var prev string
+	for i := range m.Entries {
+		e := &m.Entries[i]
+		shared, err := r.uvarint()
+		if err != nil {
+			return nil, err
+		}
+		if shared > uint64(len(prev)) {
+			return nil, fmt.Errorf("invalid shared path prefix length %d", shared)
+		}
+		suffix, err := r.string()
+		if err != nil {
+			return nil, err
+		}
+		e.Path = prev[:shared] + suffix
+		prev = e.Path
+
+		kind, err := r.ReadByte()
+		if err != nil {
+			return nil, errBinaryManifestTruncated
+		}
+		switch kind {
+		case binaryHashNone:
+		case binaryHash32, binaryHash64:
+			size := 32
+			if kind == binaryHash64 {
+				size = 64
+			}
+			ref, err := r.fixed(size)
+			if err != nil {
+				return nil, err
+			}
+			e.Hash = hex.EncodeToString(ref)
+		case binaryHashString:
+			if e.Hash, err = r.string(); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("invalid hash kind %d", kind)
+		}
+		if e.ContentType, err = str(); err != nil {
+			return nil, err
+		}
+		if e.ContentEncoding, err = str(); err != nil {
+			return nil, err
+		}
+
+		fields, err := r.uvarint()
+		if err != nil {
+			return nil, err
+		}
+		if fields&binaryFieldMode != 0 {
+			if e.Mode, err = r.varint(); err != nil {
+				return nil, err
+			}
+		}
+		if fields&binaryFieldSize != 0 {
+			if e.Size, err = r.varint(); err != nil {
+				return nil, err
+			}
+		}
+		if fields&binaryFieldModTime != 0 {
+			sec, err := r.varint()
+			if err != nil {
+				return nil, err
+			}
+			nsec, err := r.uvarint()
+			if err != nil {
+				return nil, err
+			}
+			e.ModTime = time.Unix(sec, int64(nsec))
+		}
+		if fields&binaryFieldStatus != 0 {
+			status, err := r.varint()
+			if err != nil {
+				return nil, err
+			}
+			e.Status = int(status)
+		}
+		if fields&binaryFieldMetadata != 0 {
+			if err := decodeBinaryEntryMetadata(r, e); err != nil {
+				return nil, fmt.Errorf("error decoding entry %q: %v", e.Path, err)
+			}
+		}
+	}
+	return m, nil
+}
+
+// decodeBinaryEntryMetadata reads the metadata map of an entry, ignoring
+// keys that are not known to this version
+func decodeBinaryEntryMetadata(r *binaryManifestReader, e *ManifestEntry) error {
+	n, err := r.count()
+	if err != nil {
+		return err
+	}
+	for i := 0; i < n; i++ {
+		key, err := r.string()
+		if err != nil {
+			return err
+		}
+		value, err := r.bytes()
+		if err != nil {
+			return err
+		}
+		switch key {
+		case binaryMetaAccess:
+			e.Access = &AccessEntry{}
+			if err := json.Unmarshal(value, e.Access); err != nil {
+				return err
+			}
+		case binaryMetaFeed:
+			e.Feed = &feed.Feed{}
+			if err := json.Unmarshal(value, e.Feed); err != nil {
+				return err
+			}
+		default:
+			if strings.HasPrefix(key, binaryMetaPrefix) {
+				if e.Metadata == nil {
+					e.Metadata = make(map[string]string)
+				}
+				e.Metadata[strings.TrimPrefix(key, binaryMetaPrefix)] = string(value)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+// Copyright 2019 The Swarm Authors
+// This file is part of the Swarm library.
+//
+// The Swarm library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Swarm library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Swarm library. If not, see <http://www.gnu.org/licenses/>.
+
+package api
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethersphere/swarm/chunk"
+	"github.com/ethersphere/swarm/storage"
+	"github.com/ethersphere/swarm/storage/feed"
+)
+
+// TestBinaryManifest tests that manifests are encoded and decoded in the binary format
+// with all entry fields, and that the encoding is smaller than JSON
+func TestBinaryManifest(t *testing.T) {
+	ref := hex.EncodeToString(crypto.Keccak256([]byte("content")))
+	m := &Manifest{
+		Website: &WebsiteConfig{IndexDocument: "index.html", SPA: true},
+		Index:   ref,
+		Format:  ManifestFormatBinary,
+		Entries: []ManifestEntry{
+			{Path: "", Hash: ref, ContentType: "text/html; charset=utf-8"},
+			{Path: "css/", Hash: ref, ContentType: ManifestType},
+			{
+				Path:            "index.html",
+				Hash:            ref,
+				ContentType:     "text/html; charset=utf-8",
+				Mode:            0644,
+				Size:            1234,
+				ModTime:         time.Unix(1546300800, 123),
+				ContentEncoding: EncodingGzip,
+			},
+			{Path: "index.htm", Hash: ref + ref, Status: 301, Metadata: map[string]string{"licence": "MIT", "author": "swarm"}},
+			{Path: "feed", ContentType: FeedContentType, Feed: &feed.Feed{}},
+			{Path: "private", Hash: "not a reference", Access: &AccessEntry{Type: "pass", Salt: make([]byte, 32)}},
+		},
+	}
+	for i := 0; i < 1000; i++ {
+		m.Entries = append(m.Entries, ManifestEntry{
+			Path:        fmt.Sprintf("data/file%04d.json", i),
+			Hash:        ref,
+			ContentType: "application/json",
+			Mode:        0644,
+			Size:        int64(i),
+		})
+	}
+
+	data, err := EncodeManifest(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !isBinaryManifest(data) {
+		t.Fatal("expected binary manifest header")
+	}
+	got, err := DecodeManifest(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, m) {
+		t.Fatalf("expected decoded manifest %+v, got %+v", m, got)
+	}
+
+	m.Format = ManifestFormatJSON
+	jsonData, err := EncodeManifest(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data)*2 > len(jsonData) {
+		t.Fatalf("expected binary manifest of %d bytes to be less than half of the json manifest of %d bytes", len(data), len(jsonData))
+	}
+	if got, err := DecodeManifest(jsonData); err != nil || got.Format != ManifestFormatJSON {
+		t.Fatalf("expected json manifest, got %v %v", got, err)
+	}
+
+	for _, n := range []int{len(binaryManifestMagic) + 1, len(data) / 2, len(data) - 1} {
+		if _, err := DecodeManifest(data[:n]); err == nil {
+			t.Fatalf("expected error decoding manifest truncated to %d bytes", n)
+		}
+	}
+	data[len(binaryManifestMagic)] = binaryManifestVersion + 1
+	if _, err := DecodeManifest(data); err == nil {
+		t.Fatal("expected error decoding unknown version")
+	}
+}
+
+// TestReadBinaryManifest tests that readManifest detects the binary format and keeps it
+// for the trie, with the binary size limit
+func TestReadBinaryManifest(t *testing.T) {
+	manifest, err := EncodeManifest(&Manifest{
+		Format: ManifestFormatBinary,
+		Entries: []ManifestEntry{
+			{Path: "ab", Hash: "ab"},
+			{Path: "ac", Hash: "ac"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	reader := &storage.LazyTestSectionReader{
+		SectionReader: io.NewSectionReader(bytes.NewReader(manifest), 0, int64(len(manifest))),
+	}
+	fileStore := storage.NewFileStore(nil, nil, storage.NewFileStoreParams(), chunk.NewTags())
+	ref := make([]byte, fileStore.HashSize())
+	trie, err := readManifest(reader, ref, fileStore, false, nil, NOOPDecrypt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if trie.format != ManifestFormatBinary {
+		t.Fatalf("expected format %s, got %s", ManifestFormatBinary, trie.format)
+	}
+	checkEntry(t, "ab", "ab", false, trie)
+	checkEntry(t, "ac", "ac", false, trie)
+	if sub := trie.entries['a'].subtrie; sub == nil || sub.format != ManifestFormatBinary {
+		t.Fatal("expected the submanifest to keep the binary format")
+	}
+
+	// binary manifests over the json size limit are read
+	large := make([]byte, manifestSizeLimit+1)
+	copy(large, manifest)
+	reader = &storage.LazyTestSectionReader{
+		SectionReader: io.NewSectionReader(bytes.NewReader(large), 0, int64(len(large))),
+	}
+	if _, err := readManifest(reader, ref, fileStore, false, nil, NOOPDecrypt); err != nil {
+		t.Fatal(err)
+	}
+}
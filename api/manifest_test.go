@@ -18,6 +18,8 @@ package api
 
 import (
 	"bytes"
+	"context"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -27,6 +29,7 @@ import (
 
 	"github.com/ethersphere/swarm/chunk"
 	"github.com/ethersphere/swarm/storage"
+	"github.com/ethersphere/swarm/testutil"
 )
 
 func manifest(paths ...string) (manifestReader storage.LazySectionReader) {
@@ -97,6 +100,35 @@ func TestGetEntry(t *testing.T) {
 	testGetEntry(t, "//a//b//", "a/b", false, "a", "a/b", "a/bb", "a/b/c")
 }
 
+func TestGetExactEntry(t *testing.T) {
+	fileStore := storage.NewFileStore(nil, nil, storage.NewFileStoreParams(), chunk.NewTags())
+	ref := make([]byte, fileStore.HashSize())
+	trie, err := readManifest(manifest("", "readme.md", "readit.md", "a/b", "a/bc", "a"), ref, fileStore, false, nil, NOOPDecrypt)
+	if err != nil {
+		t.Fatalf("unexpected error making manifest: %v", err)
+	}
+	for path, found := range map[string]bool{
+		"":          true,
+		"readme.md": true,
+		"readit.md": true,
+		"a":         true,
+		"a/b":       true,
+		"a/bc":      true,
+		"read":      false,
+		"readme":    false,
+		"a/":        false,
+		"a/bcd":     false,
+		"missing":   false,
+	} {
+		entry := trie.getExactEntry(path, nil)
+		if found && entry == nil {
+			t.Errorf("expected entry for path %q", path)
+		} else if !found && entry != nil {
+			t.Errorf("expected no entry for path %q, got %q", path, entry.Path)
+		}
+	}
+}
+
 func TestExactMatch(t *testing.T) {
 	quitC := make(chan bool)
 	mf := manifest("shouldBeExactMatch.css", "shouldBeExactMatch.css.map")
@@ -174,3 +206,98 @@ func TestReadManifestOverSizeLimit(t *testing.T) {
 		t.Fatalf("got error mesage %q, expected %q", got, want)
 	}
 }
+
+// TestWalkChunks validates that chunks of manifest entries are
+// walked together with the manifest, also of encrypted content.
+func TestWalkChunks(t *testing.T) {
+	testAPI(t, func(a *API, _ *chunk.Tags, toEncrypt bool) {
+		ctx := context.Background()
+		chunkStore := a.fileStore.ChunkStore
+
+		store := func(seed, size int) storage.Address {
+			data := testutil.RandomBytes(seed, size)
+			addr, wait, err := a.Store(ctx, bytes.NewReader(data), int64(len(data)), toEncrypt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := wait(ctx); err != nil {
+				t.Fatal(err)
+			}
+			return addr
+		}
+		content := store(1, 3*chunk.DefaultSize)
+		index := store(2, 100)
+		act := store(3, 200)
+		salt := hex.EncodeToString(make([]byte, 32))
+		// the encrypted reference of the access controlled entry must not be walked
+		m := fmt.Sprintf(`{"index":"%v","entries":[{"hash":"%v","path":"file"},{"path":"feed","contentType":"%s"},`+
+			`{"hash":"%s","path":"private","contentType":"%s","access":{"type":"act","salt":"%s","act":"%v"}}]}`,
+			index, content, FeedContentType, strings.Repeat("ff", 64), ManifestType, salt, act)
+		root, wait, err := a.Store(ctx, strings.NewReader(m), int64(len(m)), toEncrypt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := wait(ctx); err != nil {
+			t.Fatal(err)
+		}
+		// three data chunks and the intermediate chunk
+		if n := len(walkedChunks(t, chunkStore, content)); n != 4 {
+			t.Fatalf("got %v walked chunks of the file, want 4", n)
+		}
+
+		for _, tc := range []struct {
+			name string
+			root storage.Address
+			want []storage.Address
+		}{
+			{
+				name: "file",
+				root: content,
+				want: walkedChunks(t, chunkStore, content),
+			},
+			{
+				name: "manifest",
+				root: root,
+				want: concatAddresses(
+					walkedChunks(t, chunkStore, root),
+					walkedChunks(t, chunkStore, index),
+					walkedChunks(t, chunkStore, content),
+					walkedChunks(t, chunkStore, act),
+				),
+			},
+		} {
+			var got []storage.Address
+			err := WalkChunks(ctx, chunkStore, tc.root, func(addr storage.Address) error {
+				got = append(got, addr)
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("%s: %v", tc.name, err)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tc.want) {
+				t.Errorf("%s: got chunks %v, want %v", tc.name, got, tc.want)
+			}
+		}
+	})
+}
+
+func concatAddresses(lists ...[]storage.Address) (addrs []storage.Address) {
+	for _, l := range lists {
+		addrs = append(addrs, l...)
+	}
+	return addrs
+}
+
+// walkedChunks returns the addresses of chunks of the file tree with the root address.
+func walkedChunks(t *testing.T, store storage.ChunkStore, root storage.Address) (addrs []storage.Address) {
+	t.Helper()
+
+	err := storage.WalkChunkTree(context.Background(), store, root, func(addr storage.Address) error {
+		addrs = append(addrs, addr)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return addrs
+}
@@ -0,0 +1,98 @@
+// Copyright 2019 The Swarm Authors
+// This file is part of the Swarm library.
+//
+// The Swarm library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Swarm library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Swarm library. If not, see <http://www.gnu.org/licenses/>.
+
+package api
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MetadataPAXPrefix is the prefix of the tar PAX records holding
+// the metadata of uploaded and downloaded files
+const MetadataPAXPrefix = "SWARM.meta."
+
+const (
+	maxMetadataKeyLength = 64
+	maxMetadataSize      = 4096 // size of all keys and values of an entry
+)
+
+// ValidateMetadata returns an error if the metadata can not be kept in a manifest entry.
+// Keys are lowercase letters, digits, '-', '_' and '.', as they are sent in HTTP headers,
+// and values must not contain control characters.
+func ValidateMetadata(meta map[string]string) error {
+	size := 0
+	for k, v := range meta {
+		if k == "" || len(k) > maxMetadataKeyLength {
+			return fmt.Errorf("invalid metadata key %q: must be 1 to %d characters", k, maxMetadataKeyLength)
+		}
+		for _, c := range k {
+			if !(c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '-' || c == '_' || c == '.') {
+				return fmt.Errorf("invalid metadata key %q: unexpected character %q", k, c)
+			}
+		}
+		for _, c := range v {
+			if c < 0x20 && c != '\t' || c == 0x7f {
+				return fmt.Errorf("invalid metadata value for %q: unexpected control character", k)
+			}
+		}
+		size += len(k) + len(v)
+	}
+	if size > maxMetadataSize {
+		return fmt.Errorf("metadata of %d bytes exceeds the %d byte limit", size, maxMetadataSize)
+	}
+	return nil
+}
+
+// MetadataFromPAX returns the metadata in the PAX records of a tar header
+func MetadataFromPAX(records map[string]string) map[string]string {
+	var meta map[string]string
+	for k, v := range records {
+		if !strings.HasPrefix(k, MetadataPAXPrefix) {
+			continue
+		}
+		if meta == nil {
+			meta = make(map[string]string)
+		}
+		meta[strings.ToLower(strings.TrimPrefix(k, MetadataPAXPrefix))] = v
+	}
+	return meta
+}
+
+// MetadataPAXRecords returns the metadata as PAX records of a tar header
+func MetadataPAXRecords(meta map[string]string) map[string]string {
+	if len(meta) == 0 {
+		return nil
+	}
+	records := make(map[string]string, len(meta))
+	for k, v := range meta {
+		records[MetadataPAXPrefix+k] = v
+	}
+	return records
+}
+
+// sameMetadata reports whether two metadata maps have the same keys and values
+func sameMetadata(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if w, ok := b[k]; !ok || v != w {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,317 @@
+// Copyright 2019 The Swarm Authors
+// This file is part of the Swarm library.
+//
+// The Swarm library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Swarm library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Swarm library. If not, see <http://www.gnu.org/licenses/>.
+
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethersphere/swarm/storage"
+)
+
+const (
+	searchIndexVersion = 1
+
+	searchIndexSizeLimit = 256 * 1024 * 1024
+	maxSearchContentSize = 1024 * 1024 // bytes of the content of a file that are tokenized
+	minSearchTokenLength = 2
+	maxSearchTokenLength = 64
+
+	searchIndexCacheCapacity = 8 // number of decoded search indexes kept in memory
+)
+
+// ErrNoSearchIndex is returned when searching a manifest without a search index
+var ErrNoSearchIndex = errors.New("manifest has no search index")
+
+// SearchIndex is the search index of a manifest, stored as a separate
+// document the manifest refers to with Manifest.Index
+type SearchIndex struct {
+	Version int                `json:"version"`
+	Files   []SearchIndexFile  `json:"files"`  // sorted by path
+	Tokens  []SearchIndexToken `json:"tokens"` // sorted by token
+}
+
+// SearchIndexFile is a file entry of a manifest in a SearchIndex
+type SearchIndexFile struct {
+	Path        string `json:"path"`
+	Hash        string `json:"hash"`
+	Size        int64  `json:"size,omitempty"`
+	ContentType string `json:"contentType,omitempty"`
+}
+
+// SearchIndexToken lists the files a token of text content is found in
+type SearchIndexToken struct {
+	Token string `json:"token"`
+	Files []int  `json:"files"` // ascending indexes in SearchIndex.Files
+}
+
+// SearchResults are the files of a manifest matching a search query, sorted by path
+type SearchResults struct {
+	Query     string         `json:"query"`
+	Results   []SearchResult `json:"results"`
+	Truncated bool           `json:"truncated,omitempty"` // more files match than the limit
+}
+
+// SearchResult is a file matching a search query by its path, its content or both
+type SearchResult struct {
+	SearchIndexFile
+	PathMatch    bool `json:"pathMatch,omitempty"`
+	ContentMatch bool `json:"contentMatch,omitempty"`
+}
+
+// IndexManifest builds the search index of the manifest at addr, with the paths of all
+// files and the tokens of files with text content, and returns the address of the manifest
+// referring to it. The index is removed from the manifest when its entries change.
+func (a *API) IndexManifest(ctx context.Context, addr storage.Address) (storage.Address, error) {
+	walker, err := a.NewManifestWalker(ctx, addr, NOOPDecrypt, nil)
+	if err != nil {
+		return nil, err
+	}
+	var entries []ManifestEntry
+	err = walker.Walk(func(entry *ManifestEntry) error {
+		// the default entry is a copy of another entry
+		if entry.ContentType != ManifestType && entry.Hash != "" && entry.Path != "" {
+			entries = append(entries, *entry)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Path < entries[j].Path })
+
+	index := &SearchIndex{Version: searchIndexVersion}
+	tokens := make(map[string][]int)
+	for i := range entries {
+		entry := &entries[i]
+		index.Files = append(index.Files, SearchIndexFile{
+			Path:        entry.Path,
+			Hash:        entry.Hash,
+			Size:        entry.Size,
+			ContentType: entry.ContentType,
+		})
+		if !isTextContentType(entry.ContentType) {
+			continue
+		}
+		content, err := a.searchContent(ctx, entry)
+		if err != nil {
+			return nil, fmt.Errorf("error reading %s: %v", entry.Path, err)
+		}
+		for _, token := range uniqueTokens(searchTokens(content)) {
+			tokens[token] = append(tokens[token], i)
+		}
+	}
+	index.Tokens = make([]SearchIndexToken, 0, len(tokens))
+	for token, files := range tokens {
+		index.Tokens = append(index.Tokens, SearchIndexToken{Token: token, Files: files})
+	}
+	sort.Slice(index.Tokens, func(i, j int) bool { return index.Tokens[i].Token < index.Tokens[j].Token })
+
+	data, err := json.Marshal(index)
+	if err != nil {
+		return nil, err
+	}
+	mw, err := a.NewManifestWriter(ctx, addr, nil)
+	if err != nil {
+		return nil, err
+	}
+	indexAddr, wait, err := a.Store(ctx, bytes.NewReader(data), int64(len(data)), mw.trie.encrypted)
+	if err != nil {
+		return nil, err
+	}
+	if err := wait(ctx); err != nil {
+		return nil, err
+	}
+	mw.SetIndex(indexAddr.Hex())
+	return mw.Store()
+}
+
+// searchContent returns the start of the decompressed content of an entry
+func (a *API) searchContent(ctx context.Context, entry *ManifestEntry) (string, error) {
+	reader, _ := a.Retrieve(ctx, storage.Address(common.Hex2Bytes(entry.Hash)))
+	size, err := reader.Size(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+	content, err := Decompress(io.NewSectionReader(reader, 0, size), entry.ContentEncoding)
+	if err != nil {
+		return "", err
+	}
+	defer content.Close()
+	data, err := ioutil.ReadAll(io.LimitReader(content, maxSearchContentSize))
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// Search returns up to limit files of the manifest at addr matching the query using its
+// search index. A file matches if its path contains all words of the query, or if its
+// content has tokens starting with each word of the query.
+func (a *API) Search(ctx context.Context, decrypt DecryptFunc, addr storage.Address, query string, limit int) (*SearchResults, error) {
+	trie, err := loadManifest(ctx, a.fileStore, addr, nil, decrypt)
+	if err != nil {
+		return nil, err
+	}
+	if trie.index == "" {
+		return nil, ErrNoSearchIndex
+	}
+	index, err := a.searchIndex(ctx, trie.index)
+	if err != nil {
+		return nil, fmt.Errorf("error loading search index: %v", err)
+	}
+
+	words := strings.Fields(strings.ToLower(query))
+	results := &SearchResults{Query: query, Results: []SearchResult{}}
+	if len(words) == 0 {
+		return results, nil
+	}
+	contentMatches := index.contentMatches(searchTokens(query))
+	for i, f := range index.Files {
+		pathMatch := true
+		path := strings.ToLower(f.Path)
+		for _, w := range words {
+			if !strings.Contains(path, w) {
+				pathMatch = false
+				break
+			}
+		}
+		if !pathMatch && !contentMatches[i] {
+			continue
+		}
+		if len(results.Results) == limit {
+			results.Truncated = true
+			break
+		}
+		results.Results = append(results.Results, SearchResult{
+			SearchIndexFile: f,
+			PathMatch:       pathMatch,
+			ContentMatch:    contentMatches[i],
+		})
+	}
+	return results, nil
+}
+
+// searchIndex returns the search index with the given reference, which is
+// cached as indexes are immutable and decoding large ones is expensive
+func (a *API) searchIndex(ctx context.Context, ref string) (*SearchIndex, error) {
+	if v, ok := a.searchIndexes.Get(ref); ok {
+		return v.(*SearchIndex), nil
+	}
+	index, err := a.loadSearchIndex(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	a.searchIndexes.Add(ref, index)
+	return index, nil
+}
+
+func (a *API) loadSearchIndex(ctx context.Context, ref string) (*SearchIndex, error) {
+	reader, _ := a.Retrieve(ctx, storage.Address(common.Hex2Bytes(ref)))
+	size, err := reader.Size(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	if size > searchIndexSizeLimit {
+		return nil, fmt.Errorf("search index size of %v bytes exceeds the %v byte limit", size, searchIndexSizeLimit)
+	}
+	data, err := ioutil.ReadAll(io.NewSectionReader(reader, 0, size))
+	if err != nil {
+		return nil, err
+	}
+	index := &SearchIndex{}
+	if err := json.Unmarshal(data, index); err != nil {
+		return nil, err
+	}
+	if index.Version != searchIndexVersion {
+		return nil, fmt.Errorf("unsupported search index version %d", index.Version)
+	}
+	return index, nil
+}
+
+// contentMatches returns the indexes of the files with tokens starting with each of the prefixes
+func (index *SearchIndex) contentMatches(prefixes []string) map[int]bool {
+	var matches map[int]bool
+	for _, prefix := range prefixes {
+		found := make(map[int]bool)
+		i := sort.Search(len(index.Tokens), func(i int) bool { return index.Tokens[i].Token >= prefix })
+		for ; i < len(index.Tokens) && strings.HasPrefix(index.Tokens[i].Token, prefix); i++ {
+			for _, f := range index.Tokens[i].Files {
+				if matches == nil || matches[f] {
+					found[f] = true
+				}
+			}
+		}
+		matches = found
+		if len(matches) == 0 {
+			break
+		}
+	}
+	return matches
+}
+
+// searchTokens splits text into lowercase words of letters and digits
+func searchTokens(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	tokens := words[:0]
+	for _, w := range words {
+		if len(w) >= minSearchTokenLength && len(w) <= maxSearchTokenLength {
+			tokens = append(tokens, w)
+		}
+	}
+	return tokens
+}
+
+func uniqueTokens(tokens []string) []string {
+	seen := make(map[string]bool, len(tokens))
+	unique := tokens[:0]
+	for _, t := range tokens {
+		if !seen[t] {
+			seen[t] = true
+			unique = append(unique, t)
+		}
+	}
+	return unique
+}
+
+// isTextContentType reports whether content of the type is indexed for search
+func isTextContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	if strings.HasPrefix(mediaType, "text/") || strings.HasSuffix(mediaType, "+json") || strings.HasSuffix(mediaType, "+xml") {
+		return true
+	}
+	switch mediaType {
+	case "application/json", "application/xml", "application/javascript", "application/x-yaml", "application/yaml":
+		return true
+	}
+	return false
+}
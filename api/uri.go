@@ -39,6 +39,11 @@ type URI struct {
 	// * bzz-immutable - immutable URI of an entry in a swarm manifest
 	//                   (address is not resolved)
 	// * bzz-list      -  list of all files contained in a swarm manifest
+	// * bzz-diff      - differences between two swarm manifests, the second
+	//                   one is given as the path
+	// * bzz-merge     - merge of two swarm manifests, the second one is
+	//                   given as the path
+	// * bzz-search    - files of a swarm manifest matching a search query
 	//
 	Scheme string
 
@@ -86,7 +91,7 @@ func Parse(rawuri string) (*URI, error) {
 
 	// check the scheme is valid
 	switch uri.Scheme {
-	case "bzz", "bzz-raw", "bzz-immutable", "bzz-list", "bzz-hash", "bzz-feed", "bzz-feed-raw", "bzz-tag", "bzz-pin":
+	case "bzz", "bzz-raw", "bzz-immutable", "bzz-list", "bzz-hash", "bzz-feed", "bzz-feed-raw", "bzz-tag", "bzz-pin", "bzz-diff", "bzz-merge", "bzz-search":
 	default:
 		return nil, fmt.Errorf("unknown scheme %q", u.Scheme)
 	}
@@ -134,6 +139,21 @@ func (u *URI) Hash() bool {
 	return u.Scheme == "bzz-hash"
 }
 
+// Diff returns true if the uri scheme is bzz-diff
+func (u *URI) Diff() bool {
+	return u.Scheme == "bzz-diff"
+}
+
+// Merge returns true if the uri scheme is bzz-merge
+func (u *URI) Merge() bool {
+	return u.Scheme == "bzz-merge"
+}
+
+// Search returns true if the uri scheme is bzz-search
+func (u *URI) Search() bool {
+	return u.Scheme == "bzz-search"
+}
+
 // Pin returns the string representation of the pin uri scheme
 func (u *URI) Pin() bool {
 	return u.Scheme == "bzz-pin"
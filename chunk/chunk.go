@@ -190,6 +190,8 @@ func (m ModePut) String() string {
 		return "Sync"
 	case ModePutUpload:
 		return "Upload"
+	case ModePutCache:
+		return "Cache"
 	default:
 		return "Unknown"
 	}
@@ -203,6 +205,10 @@ const (
 	ModePutSync
 	// ModePutUpload: when a chunk is created by local upload
 	ModePutUpload
+	// ModePutCache: when a chunk is received as a result of retrieve request
+	// and is admitted to the retrieval cache, which accounts for it instead
+	// of the garbage collection
+	ModePutCache
 )
 
 // ModeSet enumerates different Setter modes.
@@ -222,6 +228,12 @@ func (m ModeSet) String() string {
 		return "ModeSetPin"
 	case ModeSetUnpin:
 		return "ModeSetUnpin"
+	case ModeSetEvict:
+		return "Evict"
+	case ModeSetUncache:
+		return "Uncache"
+	case ModeSetReupload:
+		return "Reupload"
 	default:
 		return "Unknown"
 	}
@@ -241,6 +253,16 @@ const (
 	ModeSetPin
 	// ModeSetUnpin: when a chunk is unpinned using a command locally
 	ModeSetUnpin
+	// ModeSetEvict: when a chunk is evicted from the retrieval cache, it is
+	// removed unless it is pinned or not yet push synced, in which case it
+	// is garbage collected as other chunks. Chunks not in the cache are ignored.
+	ModeSetEvict
+	// ModeSetReupload: when a chunk needs to be push synced again,
+	// as it is not replicated enough in its neighbourhood
+	ModeSetReupload
+	// ModeSetUncache: when a chunk is removed from the retrieval cache,
+	// but it is kept and garbage collected as other chunks
+	ModeSetUncache
 )
 
 // Descriptor holds information required for Pull syncing. This struct
@@ -301,6 +323,12 @@ func (s *ValidatorStore) Put(ctx context.Context, mode ModePut, chs ...Chunk) (e
 	return s.Store.Put(ctx, mode, chs...)
 }
 
+// Validate implements the Validator interface, so that chunks
+// can be validated without storing them.
+func (s *ValidatorStore) Validate(ch Chunk) bool {
+	return s.validate(ch)
+}
+
 // validate returns true if one of the validators
 // return true. If all validators return false,
 // the chunk is considered invalid.
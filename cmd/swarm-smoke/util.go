@@ -212,7 +212,7 @@ func uploadWithTag(data []byte, endpoint string, tag string) (string, error) {
 		Tag: tag,
 	}
 
-	return swarm.TarUpload("", &client.FileUploader{File: f}, "", false, false, true)
+	return swarm.TarUpload("", &client.FileUploader{File: f}, "", nil, false, false, true)
 }
 
 func digest(r io.Reader) ([]byte, error) {
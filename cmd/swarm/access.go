@@ -16,16 +16,21 @@
 package main
 
 import (
+	"crypto/ecdsa"
 	"crypto/rand"
 	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"strings"
+	"time"
 
 	"github.com/ethereum/go-ethereum/cmd/utils"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethersphere/swarm/api"
-	"github.com/ethersphere/swarm/api/client"
+	swarm "github.com/ethersphere/swarm/api/client"
+	"github.com/ethersphere/swarm/storage/feed"
 	"gopkg.in/urfave/cli.v1"
 )
 
@@ -52,6 +57,7 @@ var (
 							utils.PasswordFileFlag,
 							SwarmDryRunFlag,
 							SwarmPinFlag,
+							SwarmAccessExpiresFlag,
 						},
 						Name:        "pass",
 						Usage:       "encrypts a reference with a password and embeds it into a root manifest",
@@ -66,6 +72,7 @@ var (
 							SwarmDryRunFlag,
 							SwarmAccessGrantKeyFlag,
 							SwarmPinFlag,
+							SwarmAccessExpiresFlag,
 						},
 						Name:        "pk",
 						Usage:       "encrypts a reference with the node's private key and a given grantee's public key and embeds it into a root manifest",
@@ -80,11 +87,82 @@ var (
 							SwarmDryRunFlag,
 							utils.PasswordFileFlag,
 							SwarmPinFlag,
+							SwarmAccessExpiresFlag,
+							SwarmAccessFeedFlag,
 						},
-						Name:        "act",
-						Usage:       "encrypts a reference with the node's private key and a given grantee's public key and embeds it into a root manifest",
-						ArgsUsage:   "<ref>",
-						Description: "encrypts a reference and embeds it into a root access manifest and prints the resulting manifest",
+						Name:      "act",
+						Usage:     "encrypts a reference with the node's private key and a given grantee's public key and embeds it into a root manifest",
+						ArgsUsage: "<ref>",
+						Description: `encrypts a reference and embeds it into a root access manifest and prints the resulting manifest.
+With --feed, the latest update of the feed is used as the encrypted reference, so the reference can be changed
+without changing the root access manifest by updating the feed with the output of 'swarm access act encrypt'.`,
+					},
+				},
+			},
+			{
+				CustomHelpTemplate: helpTemplate,
+				Name:               "act",
+				Usage:              "manages the grantees of an ACT root access manifest created by the node",
+				ArgsUsage:          "<root manifest>",
+				Description:        "manages the grantees of an ACT root access manifest created by the node",
+				Subcommands: []cli.Command{
+					{
+						Action:             accessACTAdd,
+						CustomHelpTemplate: helpTemplate,
+						Flags: []cli.Flag{
+							SwarmAccessGrantKeysFlag,
+							SwarmDryRunFlag,
+							utils.PasswordFileFlag,
+							SwarmPinFlag,
+						},
+						Name:      "add",
+						Usage:     "grants access to the reference in an ACT root access manifest to more grantees",
+						ArgsUsage: "<root manifest>",
+						Description: `adds grantee public keys and passwords to the ACT of a root access manifest and prints the resulting root access manifest.
+ACTs created by older versions have no list of their grantees, which is not added either, as the previous grantees are not known.`,
+					},
+					{
+						Action:             accessACTRemove,
+						CustomHelpTemplate: helpTemplate,
+						Flags: []cli.Flag{
+							SwarmAccessGrantKeysFlag,
+							SwarmAccessGranteesFlag,
+							SwarmDryRunFlag,
+							utils.PasswordFileFlag,
+							SwarmPinFlag,
+						},
+						Name:      "remove",
+						Usage:     "revokes the access of grantees to the reference in an ACT root access manifest",
+						ArgsUsage: "<root manifest>",
+						Description: `removes grantee public keys from the ACT of a root access manifest and prints the resulting root access manifest.
+The reference is encrypted with a new access key, so removed grantees can not access the new root access manifest.
+Password grantees are removed unless their passwords are given again with --password.
+For feed-backed grants, the latest update of the feed is re-encrypted with the new access key and published
+as a new update of the feed, which has to be owned by the node.
+ACTs created by older versions have no list of their grantees, so the full list of their public key grantees
+has to be given with --grantees, and the new ACT has the list. Their other grantees are taken as password grantees.`,
+					},
+					{
+						Action:             accessACTList,
+						CustomHelpTemplate: helpTemplate,
+						Flags: []cli.Flag{
+							utils.PasswordFileFlag,
+						},
+						Name:        "list",
+						Usage:       "lists the grantees of an ACT root access manifest",
+						ArgsUsage:   "<root manifest>",
+						Description: "prints the fingerprints and public keys of the grantees of an ACT root access manifest and the number of password grantees, which are not known for ACTs created by older versions",
+					},
+					{
+						Action:             accessACTEncrypt,
+						CustomHelpTemplate: helpTemplate,
+						Flags: []cli.Flag{
+							utils.PasswordFileFlag,
+						},
+						Name:        "encrypt",
+						Usage:       "encrypts a reference with the access key of an ACT root access manifest",
+						ArgsUsage:   "<root manifest> <ref>",
+						Description: "prints a reference encrypted with the access key of an ACT root access manifest, to update the feed of a feed-backed grant with 'swarm feed update'",
 					},
 				},
 			},
@@ -113,7 +191,7 @@ func accessNewPass(ctx *cli.Context) {
 		dryRun    = ctx.Bool(SwarmDryRunFlag.Name)
 		toPin     = ctx.Bool(SwarmPinFlag.Name)
 	)
-	accessKey, ae, err = api.DoPassword(password, salt)
+	accessKey, ae, err = api.DoPassword(password, salt, parseExpires(ctx))
 	if err != nil {
 		utils.Fatalf("error getting session key: %v", err)
 	}
@@ -150,7 +228,7 @@ func accessNewPK(ctx *cli.Context) {
 		dryRun           = ctx.Bool(SwarmDryRunFlag.Name)
 		toPin            = ctx.Bool(SwarmPinFlag.Name)
 	)
-	sessionKey, ae, err = api.DoPK(privateKey, granteePublicKey, salt)
+	sessionKey, ae, err = api.DoPK(privateKey, granteePublicKey, salt, parseExpires(ctx))
 	if err != nil {
 		utils.Fatalf("error getting session key: %v", err)
 	}
@@ -194,26 +272,12 @@ func accessNewACT(ctx *cli.Context) {
 	if pkGranteesFilename == "" && passGranteesFilename == "" {
 		utils.Fatalf("you have to provide either a grantee public-keys file or an encryption passwords file (or both)")
 	}
-
-	if pkGranteesFilename != "" {
-		bytes, err := ioutil.ReadFile(pkGranteesFilename)
-		if err != nil {
-			utils.Fatalf("had an error reading the grantee public key list")
-		}
-		pkGrantees = strings.Split(strings.Trim(string(bytes), "\n"), "\n")
-	}
-
-	if passGranteesFilename != "" {
-		bytes, err := ioutil.ReadFile(passGranteesFilename)
-		if err != nil {
-			utils.Fatalf("could not read password filename: %v", err)
-		}
-		passGrantees = strings.Split(strings.Trim(string(bytes), "\n"), "\n")
-	}
-	accessKey, ae, actManifest, err = api.DoACT(privateKey, salt, pkGrantees, passGrantees)
+	pkGrantees, passGrantees = readACTGrantees(pkGranteesFilename, passGranteesFilename)
+	accessKey, ae, actManifest, err = api.DoACT(privateKey, salt, pkGrantees, passGrantees, parseExpires(ctx))
 	if err != nil {
 		utils.Fatalf("error generating ACT manifest: %v", err)
 	}
+	ae.Feed = ctx.String(SwarmAccessFeedFlag.Name)
 
 	if err != nil {
 		utils.Fatalf("error getting session key: %v", err)
@@ -236,6 +300,227 @@ func accessNewACT(ctx *cli.Context) {
 	}
 }
 
+func accessACTAdd(ctx *cli.Context) {
+	args := ctx.Args()
+	if len(args) != 1 {
+		utils.Fatalf("Expected 1 argument - the root access manifest")
+	}
+
+	var (
+		pkGranteesFilename   = ctx.String(SwarmAccessGrantKeysFlag.Name)
+		passGranteesFilename = ctx.String(utils.PasswordFileFlag.Name)
+		dryRun               = ctx.Bool(SwarmDryRunFlag.Name)
+		toPin                = ctx.Bool(SwarmPinFlag.Name)
+	)
+	if pkGranteesFilename == "" && passGranteesFilename == "" {
+		utils.Fatalf("you have to provide either a grantee public-keys file or an encryption passwords file (or both)")
+	}
+	pkGrantees, passGrantees := readACTGrantees(pkGranteesFilename, passGranteesFilename)
+	m, actManifest := downloadACTManifests(ctx, args[0])
+	privateKey := getPrivKey(ctx)
+
+	actManifest, err := api.AddACTGrantees(privateKey, m.Entries[0].Access, actManifest, pkGrantees, passGrantees)
+	if err != nil {
+		utils.Fatalf("error adding grantees: %v", err)
+	}
+
+	if dryRun {
+		err = printManifests(m, actManifest)
+		if err != nil {
+			utils.Fatalf("had an error printing the manifests: %v", err)
+		}
+	} else {
+		err = uploadManifests(ctx, m, actManifest, toPin)
+		if err != nil {
+			utils.Fatalf("had an error uploading the manifests: %v", err)
+		}
+	}
+}
+
+func accessACTRemove(ctx *cli.Context) {
+	args := ctx.Args()
+	if len(args) != 1 {
+		utils.Fatalf("Expected 1 argument - the root access manifest")
+	}
+
+	var (
+		pkGranteesFilename   = ctx.String(SwarmAccessGrantKeysFlag.Name)
+		passGranteesFilename = ctx.String(utils.PasswordFileFlag.Name)
+		granteesFilename     = ctx.String(SwarmAccessGranteesFlag.Name)
+		dryRun               = ctx.Bool(SwarmDryRunFlag.Name)
+		toPin                = ctx.Bool(SwarmPinFlag.Name)
+	)
+	if pkGranteesFilename == "" {
+		utils.Fatalf("you have to provide a file with the grantee public keys to remove")
+	}
+	pkGrantees, passGrantees := readACTGrantees(pkGranteesFilename, passGranteesFilename)
+	currentGrantees, _ := readACTGrantees(granteesFilename, "")
+	m, actManifest := downloadACTManifests(ctx, args[0])
+	privateKey := getPrivKey(ctx)
+
+	// the latest reference served by the feed of a feed-backed grant
+	// is still encrypted with the access key known to the removed grantees
+	feedAddr := m.Entries[0].Access.Feed
+	var feedRef string
+	if feedAddr != "" {
+		feedRef = latestACTFeedReference(ctx, privateKey, m.Entries[0].Access, actManifest)
+	}
+
+	m, actManifest, err := api.RemoveACTGrantees(privateKey, m, actManifest, pkGrantees, currentGrantees, passGrantees)
+	if err == api.ErrNoGranteeList {
+		utils.Fatalf("error removing grantees: %v, give the full list of public key grantees with --%s", err, SwarmAccessGranteesFlag.Name)
+	}
+	if err != nil {
+		utils.Fatalf("error removing grantees: %v", err)
+	}
+
+	if dryRun {
+		err = printManifests(m, actManifest)
+		if err != nil {
+			utils.Fatalf("had an error printing the manifests: %v", err)
+		}
+		if feedRef != "" {
+			log.Warn("dry run: the access feed is not updated, removed grantees keep access until it is", "feed", feedAddr)
+		}
+		return
+	}
+	err = uploadManifests(ctx, m, actManifest, toPin)
+	if err != nil {
+		utils.Fatalf("had an error uploading the manifests: %v", err)
+	}
+	if feedRef != "" {
+		encrypted, err := api.EncryptACTReference(privateKey, m.Entries[0].Access, actManifest, feedRef)
+		if err != nil {
+			utils.Fatalf("error encrypting the feed reference: %v", err)
+		}
+		bzzapi := strings.TrimRight(ctx.GlobalString(SwarmApiFlag.Name), "/")
+		updateFeed(ctx, newClient(ctx, bzzapi), feed.NewGenericSigner(privateKey), feedAddr, encrypted)
+	}
+}
+
+// latestACTFeedReference returns the reference of the latest update of the feed of a feed-backed
+// grant, decrypted with the access key of the grant, or an empty string if the feed has no update
+func latestACTFeedReference(ctx *cli.Context, privateKey *ecdsa.PrivateKey, ae *api.AccessEntry, actManifest *api.Manifest) string {
+	bzzapi := strings.TrimRight(ctx.GlobalString(SwarmApiFlag.Name), "/")
+	client := newClient(ctx, bzzapi)
+
+	r, err := client.QueryFeed(nil, ae.Feed)
+	if err == swarm.ErrNoFeedUpdatesFound {
+		return ""
+	}
+	if err != nil {
+		utils.Fatalf("could not look up the access feed %s: %v", ae.Feed, err)
+	}
+	defer r.Close()
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		utils.Fatalf("could not read the access feed %s: %v", ae.Feed, err)
+	}
+	if len(data) == 0 {
+		return ""
+	}
+	ref, err := api.DecryptACTReference(privateKey, ae, actManifest, data)
+	if err != nil {
+		utils.Fatalf("could not decrypt the latest update of the access feed %s: %v", ae.Feed, err)
+	}
+	return ref
+}
+
+func accessACTList(ctx *cli.Context) {
+	args := ctx.Args()
+	if len(args) != 1 {
+		utils.Fatalf("Expected 1 argument - the root access manifest")
+	}
+
+	m, actManifest := downloadACTManifests(ctx, args[0])
+	privateKey := getPrivKey(ctx)
+
+	list, err := api.ListACTGrantees(privateKey, m.Entries[0].Access, actManifest)
+	if err != nil {
+		utils.Fatalf("error listing grantees: %v", err)
+	}
+	for _, pk := range list.PublicKeys {
+		fingerprint, err := api.GranteeFingerprint(pk)
+		if err != nil {
+			utils.Fatalf("invalid grantee public key %s: %v", pk, err)
+		}
+		fmt.Println(fingerprint, pk)
+	}
+	fmt.Printf("%d password grantees\n", list.Passwords)
+}
+
+func accessACTEncrypt(ctx *cli.Context) {
+	args := ctx.Args()
+	if len(args) != 2 {
+		utils.Fatalf("Expected 2 arguments - the root access manifest and the ref")
+	}
+
+	m, actManifest := downloadACTManifests(ctx, args[0])
+	privateKey := getPrivKey(ctx)
+
+	encrypted, err := api.EncryptACTReference(privateKey, m.Entries[0].Access, actManifest, args[1])
+	if err != nil {
+		utils.Fatalf("error encrypting the reference: %v", err)
+	}
+	fmt.Println(hexutil.Encode(encrypted))
+}
+
+// parseExpires returns the expiry of a new grant given as an RFC3339 time or a duration from now
+func parseExpires(ctx *cli.Context) time.Time {
+	expires := ctx.String(SwarmAccessExpiresFlag.Name)
+	if expires == "" {
+		return time.Time{}
+	}
+	if d, err := time.ParseDuration(expires); err == nil {
+		return time.Now().Add(d).Truncate(time.Second)
+	}
+	t, err := time.Parse(time.RFC3339, expires)
+	if err != nil {
+		utils.Fatalf("invalid --%s %q, expected an RFC3339 time or a duration", SwarmAccessExpiresFlag.Name, expires)
+	}
+	return t
+}
+
+// readACTGrantees reads the grantee public keys and passwords from the given files,
+// either of which can be empty
+func readACTGrantees(pkGranteesFilename, passGranteesFilename string) (pkGrantees, passGrantees []string) {
+	if pkGranteesFilename != "" {
+		bytes, err := ioutil.ReadFile(pkGranteesFilename)
+		if err != nil {
+			utils.Fatalf("had an error reading the grantee public key list")
+		}
+		pkGrantees = strings.Split(strings.Trim(string(bytes), "\n"), "\n")
+	}
+
+	if passGranteesFilename != "" {
+		bytes, err := ioutil.ReadFile(passGranteesFilename)
+		if err != nil {
+			utils.Fatalf("could not read password filename: %v", err)
+		}
+		passGrantees = strings.Split(strings.Trim(string(bytes), "\n"), "\n")
+	}
+	return pkGrantees, passGrantees
+}
+
+// downloadACTManifests downloads an ACT root access manifest and the ACT manifest it refers to
+func downloadACTManifests(ctx *cli.Context, hash string) (rootAccessManifest, actManifest *api.Manifest) {
+	bzzapi := strings.TrimRight(ctx.GlobalString(SwarmApiFlag.Name), "/")
+	client := newClient(ctx, bzzapi)
+
+	m, _, err := client.DownloadManifest(hash)
+	if err != nil {
+		utils.Fatalf("could not download the root access manifest: %v", err)
+	}
+	if len(m.Entries) != 1 || m.Entries[0].Access == nil || m.Entries[0].Access.Type != api.AccessTypeACT {
+		utils.Fatalf("%s is not an ACT root access manifest", hash)
+	}
+	actManifest, _, err = client.DownloadManifest(m.Entries[0].Access.Act)
+	if err != nil {
+		utils.Fatalf("could not download the ACT manifest: %v", err)
+	}
+	return m, actManifest
+}
+
 func printManifests(rootAccessManifest, actManifest *api.Manifest) error {
 	js, err := json.Marshal(rootAccessManifest)
 	if err != nil {
@@ -255,7 +540,7 @@ func printManifests(rootAccessManifest, actManifest *api.Manifest) error {
 
 func uploadManifests(ctx *cli.Context, rootAccessManifest, actManifest *api.Manifest, toPin bool) error {
 	bzzapi := strings.TrimRight(ctx.GlobalString(SwarmApiFlag.Name), "/")
-	client := client.NewClient(bzzapi)
+	client := newClient(ctx, bzzapi)
 
 	var (
 		key string
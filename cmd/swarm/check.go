@@ -0,0 +1,87 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/ethereum/go-ethereum/cmd/utils"
+	"github.com/ethersphere/swarm/api"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var checkCommand = cli.Command{
+	Action:             check,
+	CustomHelpTemplate: helpTemplate,
+	Name:               "check",
+	Usage:              "check if all chunks of a file or a manifest are retrievable from the network",
+	ArgsUsage:          "<hash>",
+	Description: `Walks the chunk tree of a file or a manifest with the given hash or ENS name on a locally running
+node and asks the network for every chunk, without storing the retrieved chunks. The hash of encrypted
+content must include the encryption key, as printed by swarm up --encrypt. Prints the chunks that
+are not retrievable and the percentage of the available ones. Exits with an error if any chunk is missing.
+This assumes you already have a Swarm node running locally. You must reference the correct path
+to your bzzd.ipc file.`,
+}
+
+func check(ctx *cli.Context) {
+	args := ctx.Args()
+	if len(args) != 1 {
+		utils.Fatalf("Usage: swarm check <hash>")
+	}
+
+	client, err := dialRPC(ctx)
+	if err != nil {
+		utils.Fatalf("Failed to dial the RPC endpoint: %v", err)
+	}
+	defer client.Close()
+
+	rctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+
+	var availability api.Availability
+	if err := client.CallContext(rctx, &availability, "bzz_checkAvailability", args[0]); err != nil {
+		utils.Fatalf("Failed to check the availability: %v", err)
+	}
+	printAvailability(os.Stdout, &availability)
+
+	if !availability.Complete || len(availability.Missing) > 0 {
+		utils.Fatalf("Not all chunks of %s are available", args[0])
+	}
+}
+
+// printAvailability writes the addresses of missing chunks,
+// followed by the number of checked and available chunks.
+func printAvailability(out io.Writer, a *api.Availability) {
+	for _, addr := range a.Missing {
+		fmt.Fprintf(out, "missing %s\n", addr)
+	}
+	if len(a.Missing) > 0 {
+		fmt.Fprintln(out)
+	}
+	fmt.Fprintf(out, "Root:      %s\n", a.Root)
+	fmt.Fprintf(out, "Chunks:    %d\n", a.Chunks)
+	fmt.Fprintf(out, "Available: %d (%.2f%%)\n", a.Available, a.Percentage)
+	fmt.Fprintf(out, "Missing:   %d\n", len(a.Missing))
+	if !a.Complete {
+		fmt.Fprintln(out, "Incomplete: intermediate chunks are missing, the chunks below them are not checked")
+	}
+}
@@ -0,0 +1,61 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/ethersphere/swarm/api"
+	"github.com/ethersphere/swarm/storage"
+)
+
+// TestPrintAvailability validates that missing chunks
+// and the percentage of available chunks are printed.
+func TestPrintAvailability(t *testing.T) {
+	root := storage.GenerateRandomChunk(0).Address()
+	missing := []storage.Address{
+		storage.GenerateRandomChunk(0).Address(),
+		storage.GenerateRandomChunk(0).Address(),
+	}
+	var buf bytes.Buffer
+	printAvailability(&buf, &api.Availability{
+		Root:       root,
+		Chunks:     8,
+		Available:  6,
+		Missing:    missing,
+		Percentage: 75,
+		Complete:   true,
+	})
+	out := buf.String()
+
+	for _, want := range []string{
+		"missing " + missing[0].String() + "\n",
+		"missing " + missing[1].String() + "\n",
+		"Root:      " + root.String() + "\n",
+		"Available: 6 (75.00%)\n",
+		"Missing:   2\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output does not contain %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "Incomplete") {
+		t.Errorf("output reports an incomplete check:\n%s", out)
+	}
+}
@@ -36,6 +36,7 @@ import (
 
 	bzzapi "github.com/ethersphere/swarm/api"
 	"github.com/ethersphere/swarm/network"
+	"github.com/ethersphere/swarm/storage"
 )
 
 var (
@@ -78,18 +79,32 @@ const (
 	SwarmEnvLightNodeEnable         = "SWARM_LIGHT_NODE_ENABLE"
 	SwarmEnvENSAPI                  = "SWARM_ENS_API"
 	SwarmEnvRNSAPI                  = "SWARM_RNS_API"
+	SwarmEnvRetrievalStrategy       = "SWARM_RETRIEVAL_STRATEGY"
+	SwarmEnvRetrievalPublic         = "SWARM_RETRIEVAL_PUBLIC"
+	SwarmEnvPrefetch                = "SWARM_PREFETCH"
+	SwarmEnvSyncRate                = "SWARM_SYNC_RATE"
+	SwarmEnvSyncPeerRate            = "SWARM_SYNC_PEER_RATE"
+	SwarmEnvReplicationMin          = "SWARM_REPLICATION_MIN"
+	SwarmEnvDiscoveryDNS            = "SWARM_DISCOVERY_DNS"
+	SwarmEnvDiscoveryLAN            = "SWARM_DISCOVERY_LAN"
 	SwarmEnvENSAddr                 = "SWARM_ENS_ADDR"
 	SwarmEnvCORS                    = "SWARM_CORS"
+	SwarmEnvGatewayHosts            = "SWARM_GATEWAY_HOSTS"
+	SwarmEnvGatewayDomain           = "SWARM_GATEWAY_DOMAIN"
+	SwarmEnvGatewayPublic           = "SWARM_GATEWAY_PUBLIC"
 	SwarmEnvBootnodes               = "SWARM_BOOTNODES"
 	SwarmEnvPSSEnable               = "SWARM_PSS_ENABLE"
 	SwarmEnvStorePath               = "SWARM_STORE_PATH"
 	SwarmEnvStoreCapacity           = "SWARM_STORE_CAPACITY"
 	SwarmEnvStoreCacheCapacity      = "SWARM_STORE_CACHE_CAPACITY"
+	SwarmEnvStoreLightCapacity      = "SWARM_STORE_LIGHT_CAPACITY"
 	SwarmEnvBootnodeMode            = "SWARM_BOOTNODE_MODE"
 	SwarmEnvNATInterface            = "SWARM_NAT_INTERFACE"
 	SwarmAccessPassword             = "SWARM_ACCESS_PASSWORD"
 	SwarmAutoDefaultPath            = "SWARM_AUTO_DEFAULTPATH"
 	SwarmGlobalstoreAPI             = "SWARM_GLOBALSTORE_API"
+	SwarmEnvEnableHTTPAuth          = "SWARM_ENABLE_HTTP_AUTH"
+	SwarmEnvAPIKey                  = "SWARM_API_KEY"
 	GethEnvDataDir                  = "GETH_DATADIR"
 )
 
@@ -252,11 +267,27 @@ func flagsOverride(currentConfig *bzzapi.Config, ctx *cli.Context) *bzzapi.Confi
 	if cors := ctx.GlobalString(CorsStringFlag.Name); cors != "" {
 		currentConfig.Cors = cors
 	}
+	if ctx.GlobalIsSet(SwarmGatewayHostsFlag.Name) {
+		currentConfig.GatewayHosts = ctx.GlobalStringSlice(SwarmGatewayHostsFlag.Name)
+	}
+	if domain := ctx.GlobalString(SwarmGatewayDomainFlag.Name); domain != "" {
+		currentConfig.GatewayDomain = domain
+	}
+	if ctx.GlobalBool(SwarmGatewayPublicFlag.Name) {
+		currentConfig.GatewayPublic = true
+	}
 	if storePath := ctx.GlobalString(SwarmStorePath.Name); storePath != "" {
 		currentConfig.ChunkDbPath = storePath
 	}
 	if storeCapacity := ctx.GlobalUint64(SwarmStoreCapacity.Name); storeCapacity != 0 {
 		currentConfig.DbCapacity = storeCapacity
+		if currentConfig.LightNodeEnabled {
+			// an explicit store size also applies to a light node
+			currentConfig.LightNodeDbCapacity = storeCapacity
+		}
+	}
+	if storeCapacity := ctx.GlobalUint64(SwarmStoreLightCapacity.Name); storeCapacity != 0 {
+		currentConfig.LightNodeDbCapacity = storeCapacity
 	}
 	if ctx.GlobalIsSet(SwarmStoreCacheCapacity.Name) {
 		currentConfig.CacheCapacity = ctx.GlobalUint(SwarmStoreCacheCapacity.Name)
@@ -273,6 +304,50 @@ func flagsOverride(currentConfig *bzzapi.Config, ctx *cli.Context) *bzzapi.Confi
 	if ctx.GlobalBool(SwarmEnablePinningFlag.Name) {
 		currentConfig.EnablePinning = true
 	}
+	if ctx.GlobalBool(SwarmEnableHTTPAuthFlag.Name) {
+		currentConfig.EnableHTTPAuth = true
+	}
+	if strategy := ctx.GlobalString(SwarmRetrievalStrategyFlag.Name); strategy != "" {
+		s, err := storage.ParseRetrievalStrategy(strategy)
+		if err != nil {
+			utils.Fatalf("invalid --%s: %v", SwarmRetrievalStrategyFlag.Name, err)
+		}
+		currentConfig.Retrieval.Strategy = s
+	}
+	if ctx.GlobalIsSet(SwarmRetrievalPublicFlag.Name) {
+		currentConfig.PublicRetrievalStrategies = nil
+		for _, strategy := range ctx.GlobalStringSlice(SwarmRetrievalPublicFlag.Name) {
+			s, err := storage.ParseRetrievalStrategy(strategy)
+			if err != nil {
+				utils.Fatalf("invalid --%s: %v", SwarmRetrievalPublicFlag.Name, err)
+			}
+			currentConfig.PublicRetrievalStrategies = append(currentConfig.PublicRetrievalStrategies, s)
+		}
+	}
+	if ctx.GlobalBool(SwarmPrefetchFlag.Name) {
+		if currentConfig.Prefetch == nil {
+			currentConfig.Prefetch = storage.NewPrefetchParams()
+		}
+		currentConfig.Prefetch.Enabled = true
+	}
+	if ctx.GlobalIsSet(SwarmSyncRateFlag.Name) {
+		currentConfig.SyncBudget.Rate = int(ctx.GlobalUint(SwarmSyncRateFlag.Name)) * 1024
+	}
+	if ctx.GlobalIsSet(SwarmSyncPeerRateFlag.Name) {
+		currentConfig.SyncBudget.PeerRate = int(ctx.GlobalUint(SwarmSyncPeerRateFlag.Name)) * 1024
+	}
+	if ctx.GlobalIsSet(SwarmSyncPauseLatencyFlag.Name) {
+		currentConfig.SyncBudget.PauseLatency = ctx.GlobalDuration(SwarmSyncPauseLatencyFlag.Name)
+	}
+	if ctx.GlobalIsSet(SwarmReplicationMinFlag.Name) {
+		currentConfig.Replication.MinReplicas = int(ctx.GlobalUint(SwarmReplicationMinFlag.Name))
+	}
+	if ctx.GlobalIsSet(SwarmDiscoveryDNSFlag.Name) {
+		currentConfig.DNSDiscovery.Trees = ctx.GlobalStringSlice(SwarmDiscoveryDNSFlag.Name)
+	}
+	if ctx.GlobalIsSet(SwarmDiscoveryLANFlag.Name) {
+		currentConfig.LANDiscovery.Enabled = ctx.GlobalBool(SwarmDiscoveryLANFlag.Name)
+	}
 	return currentConfig
 }
 
@@ -241,6 +241,9 @@ func TestConfigCmdLineOverrides(t *testing.T) {
 		fmt.Sprintf("--%s", SwarmSwapPaymentThresholdFlag.Name), strconv.FormatUint(swap.DefaultPaymentThreshold+1, 10),
 		fmt.Sprintf("--%s", SwarmSwapDisconnectThresholdFlag.Name), strconv.FormatUint(swap.DefaultDisconnectThreshold+1, 10),
 		fmt.Sprintf("--%s", SwarmEnablePinningFlag.Name),
+		fmt.Sprintf("--%s", SwarmPrefetchFlag.Name),
+		fmt.Sprintf("--%s", SwarmReplicationMinFlag.Name), "2",
+		fmt.Sprintf("--%s", SwarmStoreLightCapacity.Name), "1000",
 	}
 
 	node.Cmd = runSwarm(t, flags...)
@@ -299,6 +302,18 @@ func TestConfigCmdLineOverrides(t *testing.T) {
 		t.Fatalf("expected EnablePinning to be %t but got %t", true, info.EnablePinning)
 	}
 
+	if info.Prefetch == nil || !info.Prefetch.Enabled {
+		t.Fatalf("expected Prefetch to be enabled, got %+v", info.Prefetch)
+	}
+
+	if info.Replication == nil || info.Replication.MinReplicas != 2 {
+		t.Fatalf("expected Replication.MinReplicas to be 2, got %+v", info.Replication)
+	}
+
+	if info.LightNodeDbCapacity != 1000 {
+		t.Fatalf("expected LightNodeDbCapacity to be %d, got %d", 1000, info.LightNodeDbCapacity)
+	}
+
 	node.Shutdown()
 }
 
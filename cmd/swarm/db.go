@@ -19,18 +19,26 @@ package main
 import (
 	"archive/tar"
 	"bytes"
+	"context"
 	"encoding/binary"
 	"encoding/hex"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/ethereum/go-ethereum/cmd/utils"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethersphere/swarm/api"
 	"github.com/ethersphere/swarm/chunk"
+	"github.com/ethersphere/swarm/storage"
+	"github.com/ethersphere/swarm/storage/feed"
 	"github.com/ethersphere/swarm/storage/localstore"
 	"github.com/syndtr/goleveldb/leveldb"
 	"github.com/syndtr/goleveldb/leveldb/opt"
@@ -67,7 +75,22 @@ The export may be quite large, consider piping the output through the Unix
 pv(1) tool to get a progress bar:
 
     swarm db export ~/.ethereum/swarm/bzz-KEY/chunks - | pv > chunks.tar
+
+Only a part of the chunks can be exported, for example to seed a new neighbour
+with the chunks of the proximity order bins it is responsible for:
+
+    swarm db export --bins 8-31 ~/.ethereum/swarm/bzz-KEY/chunks chunks.tar KEY
+
+or only the pinned chunks (--pinned), chunks stored in a time range (--since
+and --until) or the chunks of a manifest or file (--root).
 `,
+			Flags: []cli.Flag{
+				SwarmExportBinsFlag,
+				SwarmExportPinnedFlag,
+				SwarmExportSinceFlag,
+				SwarmExportUntilFlag,
+				SwarmExportRootFlag,
+			},
 		},
 		{
 			Action:             dbImport,
@@ -82,14 +105,97 @@ pv(1) tool to get a progress bar:
 The import may be quite large, consider piping the input through the Unix
 pv(1) tool to get a progress bar:
 
-    pv chunks.tar | swarm db import ~/.ethereum/swarm/bzz-KEY/chunks -`,
+    pv chunks.tar | swarm db import ~/.ethereum/swarm/bzz-KEY/chunks -
+
+An interrupted import is resumed by running it again with the same archive
+and checkpoint file:
+
+    swarm db import --checkpoint import.checkpoint ~/.ethereum/swarm/bzz-KEY/chunks chunks.tar KEY`,
 			Flags: []cli.Flag{
 				SwarmLegacyFlag,
+				SwarmImportCheckpointFlag,
 			},
 		},
+		{
+			Action:             dbCheck,
+			CustomHelpTemplate: helpTemplate,
+			Name:               "check",
+			Usage:              "check the consistency of a local chunk database",
+			ArgsUsage:          "<chunkdb> <basekey>",
+			Description: `Check that the indexes of a local chunk database agree with each other and
+that the data of all chunks is valid, for example after an unclean shutdown.
+
+    swarm db check ~/.ethereum/swarm/bzz-KEY/chunks KEY
+
+The node must not be running. Found inconsistencies are fixed with swarm db repair.`,
+		},
+		{
+			Action:             dbRepair,
+			CustomHelpTemplate: helpTemplate,
+			Name:               "repair",
+			Usage:              "repair the inconsistencies of a local chunk database",
+			ArgsUsage:          "<chunkdb> <basekey>",
+			Description: `Check a local chunk database like swarm db check and fix the inconsistencies:
+remove orphan index entries and chunks with invalid data, add missing garbage
+collection entries, and correct the garbage collection size and bin ids.
+
+    swarm db repair ~/.ethereum/swarm/bzz-KEY/chunks KEY
+
+The node must not be running.`,
+		},
 	},
 }
 
+func dbCheck(ctx *cli.Context) {
+	if report := checkDB(ctx, false); !report.OK() {
+		utils.Fatalf("local chunk database is inconsistent, fix it with swarm db repair")
+	}
+}
+
+func dbRepair(ctx *cli.Context) {
+	// the report has the inconsistencies found before they are fixed
+	report := checkDB(ctx, true)
+	switch {
+	case !report.Repaired:
+		utils.Fatalf("local chunk database is not repaired")
+	case report.OK():
+		log.Info("local chunk database is consistent, nothing to repair")
+	default:
+		log.Info("successfully repaired local chunk database")
+	}
+	if report.MissingPinned > 0 {
+		log.Warn("pinned chunks without data can not be repaired, upload their content again", "count", report.MissingPinned)
+	}
+}
+
+// checkDB checks the local chunk database given in the arguments, validating
+// chunk data with the content address and feed validators, and prints the report
+func checkDB(ctx *cli.Context, repair bool) *localstore.CheckReport {
+	args := ctx.Args()
+	if len(args) != 2 {
+		utils.Fatalf("invalid arguments, please specify both <chunkdb> (path to a local chunk database) and the base key")
+	}
+
+	store, err := openLDBStore(args[0], common.Hex2Bytes(args[1]))
+	if err != nil {
+		utils.Fatalf("error opening local chunk database: %s", err)
+	}
+	defer store.Close()
+
+	report, err := store.Check(&localstore.CheckOptions{
+		Repair: repair,
+		Validators: []chunk.Validator{
+			storage.NewContentAddressValidator(storage.MakeHashFunc(storage.DefaultHash)),
+			feed.NewHandler(&feed.HandlerParams{}),
+		},
+	})
+	if err != nil {
+		utils.Fatalf("error checking local chunk database: %s", err)
+	}
+	fmt.Print(report)
+	return report
+}
+
 func dbExport(ctx *cli.Context) {
 	args := ctx.Args()
 	if len(args) != 3 {
@@ -125,7 +231,13 @@ func dbExport(ctx *cli.Context) {
 	}
 	defer store.Close()
 
-	count, err := store.Export(out)
+	options, err := exportOptions(ctx, store)
+	if err != nil {
+		utils.Fatalf("error exporting local chunk database: %s", err)
+	}
+	options.Progress = logProgress("exported chunks")
+
+	count, err := store.ExportWithOptions(out, options)
 	if err != nil {
 		utils.Fatalf("error exporting local chunk database: %s", err)
 	}
@@ -139,8 +251,6 @@ func dbImport(ctx *cli.Context) {
 		utils.Fatalf("invalid arguments, please specify both <chunkdb> (path to a local chunk database), <file> (path to read the tar archive from, - for stdin) and the base key")
 	}
 
-	legacy := ctx.IsSet(SwarmLegacyFlag.Name)
-
 	store, err := openLDBStore(args[0], common.Hex2Bytes(args[2]))
 	if err != nil {
 		utils.Fatalf("error opening local chunk database: %s", err)
@@ -159,14 +269,121 @@ func dbImport(ctx *cli.Context) {
 		in = f
 	}
 
-	count, err := store.Import(in, legacy)
+	options := &localstore.ImportOptions{
+		Legacy: ctx.Bool(SwarmLegacyFlag.Name),
+	}
+	progress := logProgress("imported chunks")
+	checkpoint := ctx.String(SwarmImportCheckpointFlag.Name)
+	if checkpoint != "" {
+		data, err := ioutil.ReadFile(checkpoint)
+		if err != nil && !os.IsNotExist(err) {
+			utils.Fatalf("error reading checkpoint: %s", err)
+		}
+		if err == nil {
+			options.Skip, err = strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+			if err != nil {
+				utils.Fatalf("invalid checkpoint %s: %s", checkpoint, err)
+			}
+			log.Info(fmt.Sprintf("resuming import after %d chunks", options.Skip))
+		}
+	}
+	var lastCheckpoint time.Time
+	options.Progress = func(count int64) {
+		progress(count)
+		if checkpoint == "" || time.Since(lastCheckpoint) < time.Second {
+			return
+		}
+		lastCheckpoint = time.Now()
+		// all chunks up to count are stored, so a stale checkpoint
+		// only makes a resumed import store some of them again
+		if err := ioutil.WriteFile(checkpoint, []byte(strconv.FormatInt(count, 10)), 0600); err != nil {
+			log.Warn("error writing checkpoint", "file", checkpoint, "err", err)
+		}
+	}
+
+	count, err := store.ImportWithOptions(in, options)
 	if err != nil {
 		utils.Fatalf("error importing local chunk database: %s", err)
 	}
+	if checkpoint != "" {
+		if err := os.Remove(checkpoint); err != nil && !os.IsNotExist(err) {
+			log.Warn("error removing checkpoint", "file", checkpoint, "err", err)
+		}
+	}
 
 	log.Info(fmt.Sprintf("successfully imported %d chunks", count))
 }
 
+// exportOptions returns the localstore export options set with command flags
+func exportOptions(ctx *cli.Context, store *localstore.DB) (*localstore.ExportOptions, error) {
+	options := &localstore.ExportOptions{
+		PinnedOnly: ctx.Bool(SwarmExportPinnedFlag.Name),
+	}
+	if bins := ctx.String(SwarmExportBinsFlag.Name); bins != "" {
+		var start, end uint8
+		if _, err := fmt.Sscanf(bins, "%d-%d", &start, &end); err != nil || start > end || end > chunk.MaxPO {
+			return nil, fmt.Errorf("invalid --%s %q, expected a range of bins from 0 to %d, e.g. 0-3", SwarmExportBinsFlag.Name, bins, chunk.MaxPO)
+		}
+		options.BinRange = &localstore.BinRange{Start: start, End: end}
+	}
+	for _, f := range []struct {
+		flag cli.StringFlag
+		ts   *int64
+	}{
+		{SwarmExportSinceFlag, &options.StoredSince},
+		{SwarmExportUntilFlag, &options.StoredUntil},
+	} {
+		if v := ctx.String(f.flag.Name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid --%s: %s", f.flag.Name, err)
+			}
+			*f.ts = t.UnixNano()
+		}
+	}
+	if root := ctx.String(SwarmExportRootFlag.Name); root != "" {
+		ref, err := hex.DecodeString(root)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --%s: %s", SwarmExportRootFlag.Name, err)
+		}
+		options.Addresses, err = reachableChunks(store, ref)
+		if err != nil {
+			return nil, fmt.Errorf("error walking %s: %s", root, err)
+		}
+	}
+	return options, nil
+}
+
+// reachableChunks returns the addresses of all chunks of the manifest with the given
+// reference and the files in it, or of the file if the reference is not a manifest
+func reachableChunks(store *localstore.DB, ref storage.Reference) ([]chunk.Address, error) {
+	seen := make(map[string]bool)
+	var addrs []chunk.Address
+	err := api.WalkChunks(context.Background(), store, storage.Address(ref), func(addr storage.Address) error {
+		if !seen[string(addr)] {
+			seen[string(addr)] = true
+			addrs = append(addrs, addr)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return addrs, nil
+}
+
+// logProgress returns a progress function logging the count at most every ten seconds
+func logProgress(msg string) func(count int64) {
+	var last time.Time
+	return func(count int64) {
+		if time.Since(last) < 10*time.Second {
+			return
+		}
+		last = time.Now()
+		log.Info(msg, "count", count)
+	}
+}
+
 func openLDBStore(path string, basekey []byte) (*localstore.DB, error) {
 	if _, err := os.Stat(filepath.Join(path, "CURRENT")); err != nil {
 		return nil, fmt.Errorf("invalid chunkdb path: %s", err)
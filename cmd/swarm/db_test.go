@@ -0,0 +1,84 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"runtime"
+	"testing"
+
+	"github.com/ethersphere/swarm/api"
+	"github.com/ethersphere/swarm/chunk"
+	"github.com/ethersphere/swarm/storage"
+	"github.com/ethersphere/swarm/storage/localstore"
+	"github.com/ethersphere/swarm/testutil"
+)
+
+// TestDBCheckRepair tests that swarm db check reports an inconsistent
+// database and swarm db repair fixes it
+func TestDBCheckRepair(t *testing.T) {
+	if runtime.GOOS == goosWindows {
+		t.Skip()
+	}
+	dir, err := ioutil.TempDir("", "swarm-db-check")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	baseKey := hex.EncodeToString(make([]byte, 32))
+	store, err := localstore.New(dir, make([]byte, 32), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tags := chunk.NewTags()
+	a := api.NewAPI(storage.NewFileStore(store, store, storage.NewFileStoreParams(), tags), nil, nil, nil, nil, tags)
+	addr, wait, err := a.Store(context.Background(), bytes.NewReader(testutil.RandomBytes(1, 10000)), 10000, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	// pinning unsynced chunks breaks the garbage collection
+	if err := store.Set(context.Background(), chunk.ModeSetPin, chunk.Address(addr)); err != nil {
+		t.Fatal(err)
+	}
+	store.Close()
+
+	check := runSwarm(t, "db", "check", dir, baseKey)
+	check.ExpectRegexp(`(?s)chunks: 4\n.*orphan gc exclude entries: 1\n.*gc size: 0 \(want 0\)\n`)
+	check.ExpectExit()
+	if check.ExitStatus() == 0 {
+		t.Fatal("expected check to fail")
+	}
+
+	repair := runSwarm(t, "db", "repair", dir, baseKey)
+	repair.ExpectRegexp(`(?s)chunks: 4\n.*orphan gc exclude entries: 1\n.*gc size: 0 \(want 0\)\n`)
+	repair.ExpectExit()
+
+	check = runSwarm(t, "db", "check", dir, baseKey)
+	check.ExpectRegexp(`(?s)chunks: 4\n.*orphan gc exclude entries: 0\n.*gc size: 0 \(want 0\)\n`)
+	check.ExpectExit()
+	if check.ExitStatus() != 0 {
+		t.Fatalf("expected check to succeed after repair, got exit status %d", check.ExitStatus())
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/cmd/utils"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethersphere/swarm/network/discovery"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var dnsTreeCommand = cli.Command{
+	Action:             dnsTree,
+	CustomHelpTemplate: helpTemplate,
+	Name:               "dnstree",
+	Usage:              "create a signed node record tree to publish in DNS for peer discovery",
+	ArgsUsage:          "<key file> <domain> <enr>...",
+	Flags: []cli.Flag{
+		SwarmDNSTreeSeqFlag,
+		SwarmDNSTreeLinkFlag,
+	},
+	Description: `Creates a tree of the node records, which must contain the bzzkey entry, an ip address and a tcp
+port, signs it with the hex encoded private key from the key file and prints the TXT records to publish
+at the domain, followed by the link to the tree. Nodes discover peers from the tree with the link
+passed to the --discovery.dns flag. Increase the --seq number every time the tree is updated.`,
+}
+
+func dnsTree(ctx *cli.Context) {
+	args := ctx.Args()
+	if len(args) < 3 {
+		utils.Fatalf("Usage: swarm dnstree <key file> <domain> <enr>...")
+	}
+	key, err := crypto.LoadECDSA(args[0])
+	if err != nil {
+		utils.Fatalf("Failed to load the key: %v", err)
+	}
+	domain := args[1]
+
+	nodes := make([]*enode.Node, 0, len(args)-2)
+	for _, r := range args[2:] {
+		n, err := enode.Parse(enode.ValidSchemes, r)
+		if err != nil {
+			utils.Fatalf("Invalid node record %s: %v", r, err)
+		}
+		nodes = append(nodes, n)
+	}
+
+	tree, err := discovery.MakeTree(ctx.Uint(SwarmDNSTreeSeqFlag.Name), nodes, ctx.StringSlice(SwarmDNSTreeLinkFlag.Name))
+	if err != nil {
+		utils.Fatalf("Failed to create the tree: %v", err)
+	}
+	link, err := tree.Sign(key, domain)
+	if err != nil {
+		utils.Fatalf("Failed to sign the tree: %v", err)
+	}
+	printTXT(os.Stdout, tree.ToTXT(domain))
+	fmt.Fprintf(os.Stdout, "\nLink: %s\n", link)
+}
+
+// printTXT writes the TXT records sorted by their domain names,
+// one record per line in the zone file format.
+func printTXT(out io.Writer, records map[string]string) {
+	names := make([]string, 0, len(records))
+	for name := range records {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(out, "%s. IN TXT %q\n", name, records[name])
+	}
+}
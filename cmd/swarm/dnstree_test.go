@@ -0,0 +1,43 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+// TestPrintTXT validates that the TXT records are
+// printed sorted by their domain names.
+func TestPrintTXT(t *testing.T) {
+	var buf bytes.Buffer
+	printTXT(&buf, map[string]string{
+		"nodes.example.org":      "enrtree-root:v1 e=A l=B seq=1 sig=C",
+		"a.nodes.example.org":    "enr:-record",
+		"b.nodes.example.org":    "enrtree-branch:",
+		"0000.nodes.example.org": "enrtree://key@linked.example.org",
+	})
+
+	want := `0000.nodes.example.org. IN TXT "enrtree://key@linked.example.org"
+a.nodes.example.org. IN TXT "enr:-record"
+b.nodes.example.org. IN TXT "enrtree-branch:"
+nodes.example.org. IN TXT "enrtree-root:v1 e=A l=B seq=1 sig=C"
+`
+	if got := buf.String(); got != want {
+		t.Errorf("got output\n%s\nwant\n%s", got, want)
+	}
+}
@@ -59,7 +59,7 @@ func download(ctx *cli.Context) {
 	var (
 		bzzapi      = strings.TrimRight(ctx.GlobalString(SwarmApiFlag.Name), "/")
 		isRecursive = ctx.Bool(SwarmRecursiveFlag.Name)
-		client      = swarm.NewClient(bzzapi)
+		client      = newClient(ctx, bzzapi)
 	)
 
 	if fi, err := os.Stat(dest); err == nil {
@@ -20,6 +20,7 @@ import (
 	"archive/tar"
 	"bytes"
 	"compress/gzip"
+	"context"
 	"crypto/md5"
 	"encoding/base64"
 	"encoding/hex"
@@ -34,7 +35,11 @@ import (
 
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethersphere/swarm"
+	"github.com/ethersphere/swarm/api"
+	"github.com/ethersphere/swarm/chunk"
 	"github.com/ethersphere/swarm/cmd/testdata"
+	"github.com/ethersphere/swarm/storage"
+	"github.com/ethersphere/swarm/storage/localstore"
 	"github.com/ethersphere/swarm/testutil"
 )
 
@@ -285,3 +290,73 @@ func inflateBase64Gzip(t *testing.T, base64File, directory string) {
 		}
 	}
 }
+
+// TestExportRoot tests that exporting with a root manifest exports the chunks
+// of the manifest and its files, so that they can be read after an import
+func TestExportRoot(t *testing.T) {
+	dir, err := ioutil.TempDir("", "swarm-export-root")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	baseKey := make([]byte, 32)
+	store, err := localstore.New(path.Join(dir, "chunks"), baseKey, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	tags := chunk.NewTags()
+	a := api.NewAPI(storage.NewFileStore(store, store, storage.NewFileStoreParams(), tags), nil, nil, nil, nil, tags)
+
+	ctx := context.Background()
+	// a file larger than a chunk, with intermediate chunks, and an unrelated file
+	content := testutil.RandomBytes(1, 3*chunk.DefaultSize*128)
+	if _, _, err := a.Store(ctx, bytes.NewReader(testutil.RandomBytes(2, 10000)), 10000, false); err != nil {
+		t.Fatal(err)
+	}
+	manifest, err := a.NewManifest(ctx, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mw, err := a.NewManifestWriter(ctx, manifest, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := mw.AddEntry(ctx, bytes.NewReader(content), &api.ManifestEntry{Path: "file", Size: int64(len(content))}); err != nil {
+		t.Fatal(err)
+	}
+	manifest, err = mw.Store()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	addrs, err := reachableChunks(store, storage.Reference(manifest))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	count, err := store.ExportWithOptions(&buf, &localstore.ExportOptions{Addresses: addrs})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the manifest and the file chunks, but not the unrelated file
+	if want := int64(len(addrs)); count != want || count < 3*128+2 {
+		t.Fatalf("got export count %d of %d reachable chunks", count, len(addrs))
+	}
+
+	store2, err := localstore.New(path.Join(dir, "chunks2"), baseKey, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store2.Close()
+	if _, err := store2.Import(&buf, false); err != nil {
+		t.Fatal(err)
+	}
+	a2 := api.NewAPI(storage.NewFileStore(store2, store2, storage.NewFileStoreParams(), tags), nil, nil, nil, nil, tags)
+	reader, _, _, _, err := a2.Get(ctx, api.NOOPDecrypt, manifest, "file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mustEqualFiles(t, bytes.NewReader(content), io.NewSectionReader(reader, 0, int64(len(content))))
+}
@@ -124,7 +124,7 @@ func getTopic(ctx *cli.Context) (topic feed.Topic) {
 func feedCreateManifest(ctx *cli.Context) {
 	var (
 		bzzapi = strings.TrimRight(ctx.GlobalString(SwarmApiFlag.Name), "/")
-		client = swarm.NewClient(bzzapi)
+		client = newClient(ctx, bzzapi)
 	)
 
 	newFeedUpdateRequest := feed.NewFirstRequest(getTopic(ctx))
@@ -144,7 +144,7 @@ func feedUpdate(ctx *cli.Context) {
 
 	var (
 		bzzapi                  = strings.TrimRight(ctx.GlobalString(SwarmApiFlag.Name), "/")
-		client                  = swarm.NewClient(bzzapi)
+		client                  = newClient(ctx, bzzapi)
 		manifestAddressOrDomain = ctx.String(SwarmFeedManifestFlag.Name)
 	)
 
@@ -154,16 +154,21 @@ func feedUpdate(ctx *cli.Context) {
 		return
 	}
 
-	signer := NewGenericSigner(ctx)
-
 	data, err := hexutil.Decode(args[0])
 	if err != nil {
 		utils.Fatalf("Error parsing data: %s", err.Error())
 		return
 	}
 
+	updateFeed(ctx, client, NewGenericSigner(ctx), manifestAddressOrDomain, data)
+}
+
+// updateFeed publishes data as an update of the feed with the given manifest address or domain,
+// or of the feed of the signer with the topic from the command line if it is empty
+func updateFeed(ctx *cli.Context, client *swarm.Client, signer feed.Signer, manifestAddressOrDomain string, data []byte) {
 	var updateRequest *feed.Request
 	var query *feed.Query
+	var err error
 
 	if manifestAddressOrDomain == "" {
 		query = new(feed.Query)
@@ -201,7 +206,7 @@ func feedUpdate(ctx *cli.Context) {
 func feedInfo(ctx *cli.Context) {
 	var (
 		bzzapi                  = strings.TrimRight(ctx.GlobalString(SwarmApiFlag.Name), "/")
-		client                  = swarm.NewClient(bzzapi)
+		client                  = newClient(ctx, bzzapi)
 		manifestAddressOrDomain = ctx.String(SwarmFeedManifestFlag.Name)
 	)
 
@@ -111,7 +111,7 @@ var (
 	}
 	SwarmLightNodeEnabled = cli.BoolFlag{
 		Name:   "lightnode",
-		Usage:  "Enable Swarm LightNode (default false)",
+		Usage:  "Enable Swarm LightNode, which keeps a small cache, push syncs uploads to full nodes and does not serve other nodes (default false)",
 		EnvVar: SwarmEnvLightNodeEnable,
 	}
 	EnsAPIFlag = cli.StringSliceFlag{
@@ -149,6 +149,32 @@ var (
 		Name:  "grant-keys",
 		Usage: "grants a given list of public keys in the following file (separated by line breaks) access to an ACT",
 	}
+	SwarmAccessGranteesFlag = cli.StringFlag{
+		Name:  "grantees",
+		Usage: "the full list of public keys in the following file (separated by line breaks) with access to an ACT without a grantee list",
+	}
+	SwarmAccessExpiresFlag = cli.StringFlag{
+		Name:  "expires",
+		Usage: "time after which the grant can not be used, as an RFC3339 time or a duration from now, e.g. 720h",
+	}
+	SwarmAccessFeedFlag = cli.StringFlag{
+		Name:  "feed",
+		Usage: "address of a feed manifest whose latest update replaces the encrypted reference of the grant",
+	}
+	SwarmSyncFeedFlag = cli.StringFlag{
+		Name:  "feed",
+		Usage: "manifest address or ENS name of a feed to update with the hash of the synced manifest",
+	}
+	SwarmMergeStrategyFlag = cli.StringFlag{
+		Name:  "strategy",
+		Usage: "strategy for paths with different entries in both manifests: fail, ours or theirs",
+		Value: "fail",
+	}
+	SwarmManifestFormatFlag = cli.StringFlag{
+		Name:  "format",
+		Usage: "manifest format: binary or json",
+		Value: "binary",
+	}
 	SwarmUpFromStdinFlag = cli.BoolFlag{
 		Name:  "stdin",
 		Usage: "reads data to be uploaded from stdin",
@@ -157,6 +183,30 @@ var (
 		Name:  "mime",
 		Usage: "Manually specify MIME type",
 	}
+	SwarmCompressFlag = cli.StringFlag{
+		Name:  "compress",
+		Usage: "compress uploaded files with the given content encoding (gzip)",
+	}
+	SwarmMetaFlag = cli.StringSliceFlag{
+		Name:  "meta",
+		Usage: "metadata set on uploaded files, can be repeated, format key=value",
+	}
+	SwarmSearchIndexFlag = cli.BoolFlag{
+		Name:  "search-index",
+		Usage: "build a search index of the file paths and text content of the uploaded manifest",
+	}
+	SwarmIndexDocumentFlag = cli.StringFlag{
+		Name:  "index-document",
+		Usage: "document served for directory paths of the uploaded website, e.g. index.html",
+	}
+	SwarmErrorDocumentFlag = cli.StringFlag{
+		Name:  "error-document",
+		Usage: "document served with 404 status for missing paths of the uploaded website, e.g. 404.html",
+	}
+	SwarmSPAFlag = cli.BoolFlag{
+		Name:  "spa",
+		Usage: "serve the index document for all missing paths of the uploaded website (single-page app)",
+	}
 	SwarmEncryptedFlag = cli.BoolFlag{
 		Name:  "encrypt",
 		Usage: "use encrypted upload",
@@ -175,6 +225,21 @@ var (
 		Usage:  "Domain on which to send Access-Control-Allow-Origin header (multiple domains can be supplied separated by a ',')",
 		EnvVar: SwarmEnvCORS,
 	}
+	SwarmGatewayHostsFlag = cli.StringSliceFlag{
+		Name:   "gateway.hosts",
+		Usage:  "Serve sites on their own hosts from the manifest root, can be repeated, format host=<ENS name or hash>",
+		EnvVar: SwarmEnvGatewayHosts,
+	}
+	SwarmGatewayDomainFlag = cli.StringFlag{
+		Name:   "gateway.domain",
+		Usage:  "Serve ENS names on subdomains of this domain from the manifest root (e.g. mysite.eth.<domain>)",
+		EnvVar: SwarmEnvGatewayDomain,
+	}
+	SwarmGatewayPublicFlag = cli.BoolFlag{
+		Name:   "gateway.public",
+		Usage:  "Serve sites on gateway hosts without requiring an API key when HTTP authorization is enabled",
+		EnvVar: SwarmEnvGatewayPublic,
+	}
 	SwarmStorePath = cli.StringFlag{
 		Name:   "store.path",
 		Usage:  "Path to leveldb chunk DB (default <$GETH_ENV_DIR>/swarm/bzz-<$BZZ_KEY>/chunks)",
@@ -185,6 +250,11 @@ var (
 		Usage:  "Number of chunks (5M is roughly 20-25GB) (default 5000000)",
 		EnvVar: SwarmEnvStoreCapacity,
 	}
+	SwarmStoreLightCapacity = cli.Uint64Flag{
+		Name:   "store.light-size",
+		Usage:  "Number of chunks stored by a light node, overrides store.size in light mode (default 50000)",
+		EnvVar: SwarmEnvStoreLightCapacity,
+	}
 	SwarmStoreCacheCapacity = cli.UintFlag{
 		Name:   "store.cache.size",
 		Usage:  "Number of recent chunks cached in memory",
@@ -224,10 +294,87 @@ var (
 		Usage:  "URL of the Global Store API provider (only for testing)",
 		EnvVar: SwarmGlobalstoreAPI,
 	}
+	SwarmRetrievalStrategyFlag = cli.StringFlag{
+		Name:   "retrieval-strategy",
+		Usage:  "Strategy of chunk retrieval from peers: sequential, hedged or fanout (default sequential)",
+		EnvVar: SwarmEnvRetrievalStrategy,
+	}
+	SwarmRetrievalPublicFlag = cli.StringSliceFlag{
+		Name:   "retrieval-strategy.public",
+		Usage:  "Retrieval strategy that all HTTP clients can request with the x-swarm-retrieval header, can be repeated (by default hedged and fanout only with an API key granted a scope other than read)",
+		EnvVar: SwarmEnvRetrievalPublic,
+	}
+	SwarmPrefetchFlag = cli.BoolFlag{
+		Name:   "prefetch",
+		Usage:  "Fetch chunks ahead of sequential reads of files, like media streaming",
+		EnvVar: SwarmEnvPrefetch,
+	}
+	SwarmSyncRateFlag = cli.UintFlag{
+		Name:   "sync.rate",
+		Usage:  "Bandwidth budget of syncing from all peers in kilobytes per second, 0 for unlimited (default 0)",
+		EnvVar: SwarmEnvSyncRate,
+	}
+	SwarmSyncPeerRateFlag = cli.UintFlag{
+		Name:   "sync.peer-rate",
+		Usage:  "Bandwidth budget of syncing from a single peer in kilobytes per second, 0 for unlimited (default 0)",
+		EnvVar: SwarmEnvSyncPeerRate,
+	}
+	SwarmSyncPauseLatencyFlag = cli.DurationFlag{
+		Name:  "sync.pause-latency",
+		Usage: "Retrieval latency above which syncing is paused, 0 to never pause (default 0)",
+	}
+	SwarmReplicationMinFlag = cli.UintFlag{
+		Name:   "replication.min",
+		Usage:  "Minimum number of nodes in the neighbourhood that should store pinned and uploaded chunks, 0 to disable the checks (default 0)",
+		EnvVar: SwarmEnvReplicationMin,
+	}
+	SwarmDiscoveryDNSFlag = cli.StringSliceFlag{
+		Name:   "discovery.dns",
+		Usage:  "Discover peers from a signed node record tree published in DNS, can be repeated, format enrtree://<key>@<domain>",
+		EnvVar: SwarmEnvDiscoveryDNS,
+	}
+	SwarmDiscoveryLANFlag = cli.BoolFlag{
+		Name:   "discovery.lan",
+		Usage:  "Discover peers on the local network from their multicast announcements",
+		EnvVar: SwarmEnvDiscoveryLAN,
+	}
+	SwarmDNSTreeSeqFlag = cli.UintFlag{
+		Name:  "seq",
+		Usage: "Sequence number of the node record tree, must be increased on every update (default 1)",
+		Value: 1,
+	}
+	SwarmDNSTreeLinkFlag = cli.StringSliceFlag{
+		Name:  "link",
+		Usage: "Link to another node record tree, can be repeated, format enrtree://<key>@<domain>",
+	}
 	SwarmLegacyFlag = cli.BoolFlag{
 		Name:  "legacy",
 		Usage: "Use this flag when importing a db export from a legacy local store database dump (for schemas older than 'sanctuary')",
 	}
+	SwarmExportBinsFlag = cli.StringFlag{
+		Name:  "bins",
+		Usage: "export only chunks in the range of proximity order bins to the base key, e.g. 0-3",
+	}
+	SwarmExportPinnedFlag = cli.BoolFlag{
+		Name:  "pinned",
+		Usage: "export only pinned chunks",
+	}
+	SwarmExportSinceFlag = cli.StringFlag{
+		Name:  "since",
+		Usage: "export only chunks stored at or after the time, in RFC3339 format",
+	}
+	SwarmExportUntilFlag = cli.StringFlag{
+		Name:  "until",
+		Usage: "export only chunks stored at or before the time, in RFC3339 format",
+	}
+	SwarmExportRootFlag = cli.StringFlag{
+		Name:  "root",
+		Usage: "export only chunks reachable from the manifest or file with the given hash",
+	}
+	SwarmImportCheckpointFlag = cli.StringFlag{
+		Name:  "checkpoint",
+		Usage: "file to keep the import progress in, to resume an interrupted import of the same archive",
+	}
 	SwarmPinFlag = cli.BoolFlag{
 		Name:  "pin",
 		Usage: "Use this flag to pin the file after upload is complete. This flag is used when uploading a file.",
@@ -236,6 +383,16 @@ var (
 		Name:  "enable-pinning",
 		Usage: "Use this flag to enable the pinning feature",
 	}
+	SwarmEnableHTTPAuthFlag = cli.BoolFlag{
+		Name:   "enable-http-auth",
+		Usage:  "Require API keys with matching scopes on the HTTP server (keys are managed through the auth RPC API)",
+		EnvVar: SwarmEnvEnableHTTPAuth,
+	}
+	SwarmAPIKeyFlag = cli.StringFlag{
+		Name:   "api-key",
+		Usage:  "API key sent to the Swarm HTTP server when it requires authorization",
+		EnvVar: SwarmEnvAPIKey,
+	}
 	SwarmProgressFlag = cli.BoolFlag{
 		Name:  "progress",
 		Usage: "Use this flag to enable tracking of the upload progress through the CLI",
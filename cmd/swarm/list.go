@@ -23,7 +23,6 @@ import (
 	"text/tabwriter"
 
 	"github.com/ethereum/go-ethereum/cmd/utils"
-	swarm "github.com/ethersphere/swarm/api/client"
 	"gopkg.in/urfave/cli.v1"
 )
 
@@ -52,7 +51,7 @@ func list(ctx *cli.Context) {
 	}
 
 	bzzapi := strings.TrimRight(ctx.GlobalString(SwarmApiFlag.Name), "/")
-	client := swarm.NewClient(bzzapi)
+	client := newClient(ctx, bzzapi)
 	list, err := client.List(manifest, prefix, "")
 	if err != nil {
 		utils.Fatalf("Failed to generate file and directory list: %s", err)
@@ -43,6 +43,7 @@ import (
 	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/ethersphere/swarm"
 	bzzapi "github.com/ethersphere/swarm/api"
+	"github.com/ethersphere/swarm/api/client"
 	"github.com/ethersphere/swarm/internal/debug"
 	"github.com/ethersphere/swarm/internal/flags"
 	swarmmetrics "github.com/ethersphere/swarm/metrics"
@@ -94,6 +95,9 @@ var defaultSubcommandHelp = cli.Command{
 
 var defaultNodeConfig = node.DefaultConfig
 
+// lightNodeMaxPeers is the default maximum number of peers of a light node
+const lightNodeMaxPeers = 12
+
 // This init function sets defaults so cmd/swarm can run alongside geth.
 func init() {
 	sv.GitCommit = gitCommit
@@ -130,6 +134,8 @@ func init() {
 		},
 		// See upload.go
 		upCommand,
+		// See sync.go
+		syncCommand,
 		// See access.go
 		accessCommand,
 		// See feeds.go
@@ -146,6 +152,12 @@ func init() {
 		fsCommand,
 		// See db.go
 		dbCommand,
+		// See status.go
+		statusCommand,
+		// See check.go
+		checkCommand,
+		// See dnstree.go
+		dnsTreeCommand,
 		// See config.go
 		DumpConfigCommand,
 		// hashesCommand
@@ -176,6 +188,9 @@ func init() {
 		SwarmNATInterfaceFlag,
 		// bzzd-specific flags
 		CorsStringFlag,
+		SwarmGatewayHostsFlag,
+		SwarmGatewayDomainFlag,
+		SwarmGatewayPublicFlag,
 		EnsAPIFlag,
 		RnsAPIFlag,
 		SwarmTomlConfigPathFlag,
@@ -199,8 +214,10 @@ func init() {
 		SwarmBzzKeyHexFlag,
 		SwarmNetworkIdFlag,
 		SwarmEnablePinningFlag,
+		SwarmEnableHTTPAuthFlag,
 		// upload flags
 		SwarmApiFlag,
+		SwarmAPIKeyFlag,
 		SwarmRecursiveFlag,
 		SwarmWantManifestFlag,
 		SwarmUploadDefaultPath,
@@ -212,8 +229,20 @@ func init() {
 		// storage flags
 		SwarmStorePath,
 		SwarmStoreCapacity,
+		SwarmStoreLightCapacity,
 		SwarmStoreCacheCapacity,
 		SwarmGlobalStoreAPIFlag,
+		SwarmRetrievalStrategyFlag,
+		SwarmRetrievalPublicFlag,
+		SwarmPrefetchFlag,
+		// syncing flags
+		SwarmSyncRateFlag,
+		SwarmSyncPeerRateFlag,
+		SwarmSyncPauseLatencyFlag,
+		SwarmReplicationMinFlag,
+		// discovery flags
+		SwarmDiscoveryDNSFlag,
+		SwarmDiscoveryLANFlag,
 		// debugging
 		SwarmMutexProfileFlag,
 		SwarmBlockProfileFlag,
@@ -337,6 +366,11 @@ func bzzd(ctx *cli.Context) error {
 	//disable dynamic dialing from p2p/discovery
 	cfg.P2P.NoDial = true
 
+	//light nodes keep only a few connections
+	if bzzconfig.LightNodeEnabled && !ctx.GlobalIsSet(utils.MaxPeersFlag.Name) {
+		cfg.P2P.MaxPeers = lightNodeMaxPeers
+	}
+
 	//optionally set the NAT IP from a network interface
 	setSwarmNATFromInterface(ctx, &cfg)
 
@@ -615,3 +649,13 @@ func pprofProfiles(ctx *cli.Context) {
 		runtime.SetBlockProfileRate(1)
 	}
 }
+
+// newClient creates a client for the Swarm HTTP API at bzzapi which
+// authorizes its requests with the API key given by the api-key flag.
+func newClient(ctx *cli.Context, bzzapi string) *client.Client {
+	c := client.NewClient(bzzapi)
+	if apiKey := ctx.GlobalString(SwarmAPIKeyFlag.Name); apiKey != "" {
+		c.SetAPIKey(apiKey)
+	}
+	return c
+}
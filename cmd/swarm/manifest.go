@@ -68,6 +68,40 @@ var manifestCommand = cli.Command{
 			ArgsUsage:   "<MANIFEST> <path>",
 			Description: "Removes a path from the manifest",
 		},
+		{
+			Action:             manifestDiff,
+			CustomHelpTemplate: helpTemplate,
+			Name:               "diff",
+			Usage:              "shows the paths that differ between two manifests",
+			ArgsUsage:          "<MANIFEST> <MANIFEST>",
+			Description:        "Prints the paths added (+), removed (-) and changed (~) in the second manifest compared to the first one, with their hashes and sizes",
+		},
+		{
+			Action:             manifestMerge,
+			CustomHelpTemplate: helpTemplate,
+			Flags: []cli.Flag{
+				SwarmMergeStrategyFlag,
+			},
+			Name:      "merge",
+			Usage:     "merges the entries of a manifest into another manifest",
+			ArgsUsage: "<MANIFEST> <MANIFEST>",
+			Description: `Adds the entries of the second manifest to the first one and prints the hash of the resulting manifest.
+Paths that are in both manifests with different entries fail the merge, unless --strategy is
+ours (the entries of the first manifest are kept) or theirs (the entries of the second manifest are kept).`,
+		},
+		{
+			Action:             manifestMigrate,
+			CustomHelpTemplate: helpTemplate,
+			Flags: []cli.Flag{
+				SwarmManifestFormatFlag,
+				SwarmPinFlag,
+			},
+			Name:      "migrate",
+			Usage:     "converts a manifest and its submanifests to another format",
+			ArgsUsage: "<MANIFEST>",
+			Description: `Re-encodes the manifest and all of its submanifests in the format given by --format, binary or json,
+and prints the hash of the converted manifest. The compact binary format has a higher size limit than json.`,
+		},
 	},
 }
 
@@ -90,7 +124,7 @@ func manifestAdd(ctx *cli.Context) {
 	)
 
 	bzzapi := strings.TrimRight(ctx.GlobalString(SwarmApiFlag.Name), "/")
-	client := swarm.NewClient(bzzapi)
+	client := newClient(ctx, bzzapi)
 
 	m, _, err := client.DownloadManifest(hash)
 	if err != nil {
@@ -126,7 +160,7 @@ func manifestUpdate(ctx *cli.Context) {
 	)
 
 	bzzapi := strings.TrimRight(ctx.GlobalString(SwarmApiFlag.Name), "/")
-	client := swarm.NewClient(bzzapi)
+	client := newClient(ctx, bzzapi)
 
 	m, _, err := client.DownloadManifest(hash)
 	if err != nil {
@@ -166,12 +200,112 @@ func manifestRemove(ctx *cli.Context) {
 	)
 
 	bzzapi := strings.TrimRight(ctx.GlobalString(SwarmApiFlag.Name), "/")
-	client := swarm.NewClient(bzzapi)
+	client := newClient(ctx, bzzapi)
 
 	newManifest := removeEntryFromManifest(client, mhash, path, toPin)
 	fmt.Println(newManifest)
 }
 
+// manifestDiff prints the paths that differ between two manifests.
+func manifestDiff(ctx *cli.Context) {
+	args := ctx.Args()
+	if len(args) != 2 {
+		utils.Fatalf("Need exactly two arguments <MHASH> <MHASH>")
+	}
+
+	bzzapi := strings.TrimRight(ctx.GlobalString(SwarmApiFlag.Name), "/")
+	client := newClient(ctx, bzzapi)
+
+	diff, err := client.DiffManifests(args[0], args[1], "")
+	if err != nil {
+		utils.Fatalf("Manifest diff failed: %v", err)
+	}
+	for _, e := range diff.Added {
+		fmt.Printf("+ %s %s %d\n", e.Path, e.Hash, e.Size)
+	}
+	for _, e := range diff.Removed {
+		fmt.Printf("- %s %s %d\n", e.Path, e.Hash, e.Size)
+	}
+	for _, e := range diff.Changed {
+		fmt.Printf("~ %s %s -> %s %d -> %d\n", e.Path, e.OldHash, e.Hash, e.OldSize, e.Size)
+	}
+}
+
+// manifestMerge merges the entries of the second manifest into the first one.
+// On success, this function will print the hash of the merged manifest.
+func manifestMerge(ctx *cli.Context) {
+	args := ctx.Args()
+	if len(args) != 2 {
+		utils.Fatalf("Need exactly two arguments <MHASH> <MHASH>")
+	}
+	strategy := ctx.String(SwarmMergeStrategyFlag.Name)
+	if _, err := api.ParseMergeStrategy(strategy); err != nil {
+		utils.Fatalf("%v", err)
+	}
+
+	bzzapi := strings.TrimRight(ctx.GlobalString(SwarmApiFlag.Name), "/")
+	client := newClient(ctx, bzzapi)
+
+	newManifest, err := client.MergeManifests(args[0], args[1], strategy)
+	if err != nil {
+		utils.Fatalf("Manifest merge failed: %v", err)
+	}
+	fmt.Println(newManifest)
+}
+
+// manifestMigrate converts the manifest and its submanifests to another format.
+// On success, this function will print the hash of the converted manifest.
+func manifestMigrate(ctx *cli.Context) {
+	toPin := ctx.Bool(SwarmPinFlag.Name)
+
+	args := ctx.Args()
+	if len(args) != 1 {
+		utils.Fatalf("Need exactly one argument <MHASH>")
+	}
+	format, err := api.ParseManifestFormat(ctx.String(SwarmManifestFormatFlag.Name))
+	if err != nil {
+		utils.Fatalf("%v", err)
+	}
+
+	bzzapi := strings.TrimRight(ctx.GlobalString(SwarmApiFlag.Name), "/")
+	client := newClient(ctx, bzzapi)
+
+	newManifest := migrateManifest(client, args[0], format, toPin)
+	fmt.Println(newManifest)
+}
+
+// migrateManifest uploads the manifest with the given mhash and its submanifests
+// in the format, returning the mhash unchanged if they are all in the format already.
+// Submanifests of access controlled entries can not be read and are left as they are.
+func migrateManifest(client *swarm.Client, mhash string, format api.ManifestFormat, toPin bool) string {
+	mroot, isEncrypted, err := client.DownloadManifest(mhash)
+	if err != nil {
+		utils.Fatalf("Manifest download failed: %v", err)
+	}
+
+	changed := mroot.Format != format
+	for i, e := range mroot.Entries {
+		if e.ContentType != api.ManifestType || e.Access != nil {
+			continue
+		}
+		newHash := migrateManifest(client, e.Hash, format, toPin)
+		if newHash != e.Hash {
+			mroot.Entries[i].Hash = newHash
+			changed = true
+		}
+	}
+	if !changed {
+		return mhash
+	}
+
+	mroot.Format = format
+	newManifestHash, err := client.UploadManifest(mroot, isEncrypted, toPin, true)
+	if err != nil {
+		utils.Fatalf("Manifest upload failed: %v", err)
+	}
+	return newManifestHash
+}
+
 func addEntryToManifest(client *swarm.Client, mhash, path string, entry api.ManifestEntry, toPin bool) string {
 	var longestPathEntry = api.ManifestEntry{}
 
@@ -200,7 +334,7 @@ func addEntryToManifest(client *swarm.Client, mhash, path string, entry api.Mani
 		newHash := addEntryToManifest(client, longestPathEntry.Hash, newPath, entry, toPin)
 
 		// Replace the hash for parent Manifests
-		newMRoot := &api.Manifest{}
+		newMRoot := &api.Manifest{Format: mroot.Format}
 		for _, e := range mroot.Entries {
 			if longestPathEntry.Path == e.Path {
 				e.Hash = newHash
@@ -266,7 +400,7 @@ func updateEntryInManifest(client *swarm.Client, mhash, path string, entry api.M
 		newHash, oldHash, _ = updateEntryInManifest(client, longestPathEntry.Hash, newPath, entry, false, toPin)
 
 		// Replace the hash for parent Manifests
-		newMRoot := &api.Manifest{}
+		newMRoot := &api.Manifest{Format: mroot.Format}
 		for _, e := range mroot.Entries {
 			if longestPathEntry.Path == e.Path {
 				e.Hash = newHash
@@ -281,7 +415,7 @@ func updateEntryInManifest(client *swarm.Client, mhash, path string, entry api.M
 	// check if default entry should be updated
 	if newEntry.Path != "" || isRoot {
 		// Replace the hash for leaf Manifest
-		newMRoot := &api.Manifest{}
+		newMRoot := &api.Manifest{Format: mroot.Format}
 		for _, e := range mroot.Entries {
 			if newEntry.Path == e.Path {
 				entry.Path = e.Path
@@ -339,7 +473,7 @@ func removeEntryFromManifest(client *swarm.Client, mhash, path string, toPin boo
 		newHash := removeEntryFromManifest(client, longestPathEntry.Hash, newPath, toPin)
 
 		// Replace the hash for parent Manifests
-		newMRoot := &api.Manifest{}
+		newMRoot := &api.Manifest{Format: mroot.Format}
 		for _, entry := range mroot.Entries {
 			if longestPathEntry.Path == entry.Path {
 				entry.Hash = newHash
@@ -351,7 +485,7 @@ func removeEntryFromManifest(client *swarm.Client, mhash, path string, toPin boo
 
 	if entryToRemove.Path != "" {
 		// remove the entry in this Manifest
-		newMRoot := &api.Manifest{}
+		newMRoot := &api.Manifest{Format: mroot.Format}
 		for _, entry := range mroot.Entries {
 			if entryToRemove.Path != entry.Path {
 				newMRoot.Entries = append(newMRoot.Entries, entry)
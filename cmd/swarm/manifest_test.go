@@ -594,3 +594,100 @@ func checkFile(t *testing.T, client *swarm.Client, hash, path string, expected [
 		t.Errorf("expected file content %q, got %q", expected, got)
 	}
 }
+
+// TestManifestMigrate tests that the manifest migrate command converts
+// a manifest and its submanifests to the binary format and back.
+func TestManifestMigrate(t *testing.T) {
+	if runtime.GOOS == goosWindows {
+		t.Skip()
+	}
+	t.Parallel()
+
+	srv := swarmhttp.NewTestSwarmServer(t, serverFunc, nil, nil)
+	defer srv.Close()
+
+	tmp, err := ioutil.TempDir("", "swarm-manifest-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	files := map[string][]byte{
+		"index.html":       []byte("<h1>Index</h1>"),
+		"robots.txt":       []byte("User-agent: *"),
+		"robots.html":      []byte("<strong>Robots</strong>"),
+		"data/doc1.txt":    []byte("doc1"),
+		"data/doc2.txt":    []byte("doc2"),
+		"data/nested/a.md": []byte("# a"),
+	}
+	for name, data := range files {
+		p := filepath.Join(tmp, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0777); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, data, 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	origManifestHash := runSwarmExpectHash(t,
+		"--bzzapi",
+		srv.URL,
+		"--recursive",
+		"up",
+		tmp,
+	)
+
+	client := swarm.NewClient(srv.URL)
+
+	binaryManifestHash := runSwarmExpectHash(t,
+		"--bzzapi",
+		srv.URL,
+		"manifest",
+		"migrate",
+		origManifestHash,
+	)
+	if binaryManifestHash == origManifestHash {
+		t.Fatal("expected the migrated manifest to have a different hash")
+	}
+	checkManifestFormat(t, client, binaryManifestHash, api.ManifestFormatBinary)
+	for name, data := range files {
+		checkFile(t, client, binaryManifestHash, name, data)
+	}
+
+	// migrating a binary manifest to binary does not change it
+	if hash := runSwarmExpectHash(t, "--bzzapi", srv.URL, "manifest", "migrate", binaryManifestHash); hash != binaryManifestHash {
+		t.Fatalf("expected unchanged manifest %s, got %s", binaryManifestHash, hash)
+	}
+
+	jsonManifestHash := runSwarmExpectHash(t,
+		"--bzzapi",
+		srv.URL,
+		"manifest",
+		"migrate",
+		"--format",
+		"json",
+		binaryManifestHash,
+	)
+	checkManifestFormat(t, client, jsonManifestHash, api.ManifestFormatJSON)
+	for name, data := range files {
+		checkFile(t, client, jsonManifestHash, name, data)
+	}
+}
+
+// checkManifestFormat checks that the manifest and all of its submanifests are in the format
+func checkManifestFormat(t *testing.T, client *swarm.Client, hash string, format api.ManifestFormat) {
+	t.Helper()
+	m, _, err := client.DownloadManifest(hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Format != format {
+		t.Fatalf("expected manifest %s in format %s, got %s", hash, format, m.Format)
+	}
+	for _, e := range m.Entries {
+		if e.ContentType == api.ManifestType {
+			checkManifestFormat(t, client, e.Hash, format)
+		}
+	}
+}
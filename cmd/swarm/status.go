@@ -0,0 +1,98 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ethereum/go-ethereum/cmd/utils"
+	"github.com/ethersphere/swarm/network/stream"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var statusCommand = cli.Command{
+	Action:             status,
+	CustomHelpTemplate: helpTemplate,
+	Name:               "status",
+	Usage:              "print the syncing progress of a running node",
+	ArgsUsage:          " ",
+	Description: `Prints the progress of historical syncing of a locally running node from every connected peer
+and proximity order bin, with the estimated number of remaining chunks and the time to sync them.
+The number of remaining chunks is an upper bound, as the same chunks are offered by many peers.
+This assumes you already have a Swarm node running locally. You must reference the correct path
+to your bzzd.ipc file.`,
+}
+
+func status(ctx *cli.Context) {
+	client, err := dialRPC(ctx)
+	if err != nil {
+		utils.Fatalf("Failed to dial the RPC endpoint: %v", err)
+	}
+	defer client.Close()
+
+	rctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var progress stream.SyncProgress
+	if err := client.CallContext(rctx, &progress, "bzz_syncProgress"); err != nil {
+		utils.Fatalf("Failed to get the syncing progress: %v", err)
+	}
+	printSyncProgress(os.Stdout, &progress)
+}
+
+// printSyncProgress writes the syncing progress as a table
+// of bins with chunks to sync for every peer, followed by totals.
+func printSyncProgress(out io.Writer, progress *stream.SyncProgress) {
+	w := tabwriter.NewWriter(out, 1, 2, 2, ' ', 0)
+	fmt.Fprintln(w, "PEER\tBIN\tCURSOR\tSYNCED\tREMAINING\tPROGRESS")
+	for _, p := range progress.Peers {
+		for _, b := range p.Bins {
+			if b.Cursor == 0 {
+				continue
+			}
+			fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\t%s\n", p.Peer, b.Bin, b.Cursor, b.Synced, b.Remaining, progressPercent(b.Synced, b.Cursor))
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%d\t%s\n", p.Peer, "all", p.Synced+p.Remaining, p.Synced, p.Remaining, progressPercent(p.Synced, p.Synced+p.Remaining))
+	}
+	w.Flush()
+
+	fmt.Fprintln(out)
+	fmt.Fprintf(out, "Peers:     %d\n", len(progress.Peers))
+	fmt.Fprintf(out, "Synced:    %d chunks (%s)\n", progress.Synced, progressPercent(progress.Synced, progress.Synced+progress.Remaining))
+	fmt.Fprintf(out, "Remaining: %d chunks at most\n", progress.Remaining)
+	fmt.Fprintf(out, "Rate:      %.1f chunks/s\n", progress.Rate)
+	switch {
+	case progress.Done:
+		fmt.Fprintln(out, "ETA:       done")
+	case progress.ETA > 0:
+		fmt.Fprintf(out, "ETA:       %s\n", progress.ETA.Round(time.Second))
+	default:
+		fmt.Fprintln(out, "ETA:       unknown")
+	}
+}
+
+func progressPercent(n, total uint64) string {
+	if total == 0 {
+		return "100.0%"
+	}
+	return fmt.Sprintf("%.1f%%", float64(n)*100/float64(total))
+}
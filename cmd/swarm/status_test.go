@@ -0,0 +1,64 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethersphere/swarm/network/stream"
+)
+
+// TestPrintSyncProgress validates that bins with chunks
+// to sync and the estimated time are printed.
+func TestPrintSyncProgress(t *testing.T) {
+	var buf bytes.Buffer
+	printSyncProgress(&buf, &stream.SyncProgress{
+		Peers: []stream.PeerSyncProgress{
+			{
+				Peer: "77b51860f5ac85bf",
+				Bins: []stream.BinSyncProgress{
+					{Bin: 0, Cursor: 100, Synced: 25, Remaining: 75},
+					{Bin: 1},
+				},
+				Synced:    25,
+				Remaining: 75,
+			},
+		},
+		Synced:    25,
+		Remaining: 75,
+		Rate:      0.5,
+		ETA:       150 * time.Second,
+	})
+	out := buf.String()
+
+	for _, want := range []string{
+		"77b51860f5ac85bf  0    100     25      75         25.0%",
+		"77b51860f5ac85bf  all  100     25      75         25.0%",
+		"Remaining: 75 chunks at most",
+		"ETA:       2m30s",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output does not contain %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "77b51860f5ac85bf  1 ") {
+		t.Errorf("output contains an empty bin:\n%s", out)
+	}
+}
@@ -0,0 +1,262 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+// Command sync uploads the changes of a local directory to a manifest.
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/cmd/utils"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethersphere/swarm/api"
+	swarm "github.com/ethersphere/swarm/api/client"
+	"github.com/ethersphere/swarm/chunk"
+	"github.com/ethersphere/swarm/storage"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var syncCommand = cli.Command{
+	Action:             syncDir,
+	CustomHelpTemplate: helpTemplate,
+	Name:               "sync",
+	Usage:              "uploads the changes of a local directory to a manifest",
+	ArgsUsage:          "<localdir> <manifest>",
+	Flags:              []cli.Flag{SwarmDryRunFlag, SwarmPinFlag, SwarmCompressFlag, SwarmMetaFlag, SwarmSyncFeedFlag},
+	Description: `Compares the files of a local directory with the entries of a manifest, given as a hash or an ENS name,
+uploads only the files that were added or changed, removes the entries of deleted files and prints the hash
+of the resulting manifest. With --feed, the feed with the given manifest address or ENS name is updated
+with the resulting manifest hash. Files in encrypted manifests are always uploaded, as their hashes
+can not be computed locally.`,
+}
+
+func syncDir(ctx *cli.Context) {
+	args := ctx.Args()
+	if len(args) != 2 {
+		utils.Fatalf("Need exactly two arguments <localdir> <manifest>")
+	}
+	var (
+		dir      = expandPath(args[0])
+		manifest = args[1]
+		bzzapi   = strings.TrimRight(ctx.GlobalString(SwarmApiFlag.Name), "/")
+		client   = newClient(ctx, bzzapi)
+		dryRun   = ctx.Bool(SwarmDryRunFlag.Name)
+		toPin    = ctx.Bool(SwarmPinFlag.Name)
+		anon     = ctx.Bool(SwarmAnonymousUploadFlag.Name)
+		feedAddr = ctx.String(SwarmSyncFeedFlag.Name)
+	)
+	if err := client.SetContentEncoding(ctx.String(SwarmCompressFlag.Name)); err != nil {
+		utils.Fatalf("invalid --%s: %v", SwarmCompressFlag.Name, err)
+	}
+	setMetadata(ctx, client)
+	if stat, err := os.Stat(dir); err != nil || !stat.IsDir() {
+		utils.Fatalf("%s is not a directory", dir)
+	}
+
+	// names are resolved once so that all changes are made to the same manifest
+	hash, err := client.ResolveHash(manifest)
+	if err != nil {
+		utils.Fatalf("Error resolving %s: %v", manifest, err)
+	}
+	remote, isEncrypted, err := manifestFileEntries(client, hash, "")
+	if err != nil {
+		utils.Fatalf("Error downloading manifest %s: %v", manifest, err)
+	}
+	changes, err := syncChanges(dir, remote, isEncrypted)
+	if err != nil {
+		utils.Fatalf("Error comparing %s with manifest %s: %v", dir, manifest, err)
+	}
+
+	for _, p := range changes.added {
+		fmt.Fprintln(os.Stderr, "+", p)
+	}
+	for _, p := range changes.changed {
+		fmt.Fprintln(os.Stderr, "~", p)
+	}
+	for _, p := range changes.removed {
+		fmt.Fprintln(os.Stderr, "-", p)
+	}
+	if dryRun {
+		return
+	}
+
+	if uploads := append(changes.added, changes.changed...); len(uploads) > 0 {
+		uploader := &fileListUploader{dir: dir, paths: uploads}
+		hash, err = client.TarUpload(hash, uploader, changes.defaultPath, nil, isEncrypted, toPin, anon)
+		if err != nil {
+			utils.Fatalf("Upload failed: %v", err)
+		}
+	}
+	for _, p := range changes.removed {
+		hash, err = client.DeleteEntry(hash, p)
+		if err != nil {
+			utils.Fatalf("Error removing %s: %v", p, err)
+		}
+	}
+	if feedAddr != "" {
+		updateFeed(ctx, client, NewGenericSigner(ctx), feedAddr, common.FromHex(hash))
+	}
+	fmt.Println(hash)
+}
+
+// syncChangeSet lists the paths of the local directory that differ from the manifest
+type syncChangeSet struct {
+	added, changed, removed []string
+	defaultPath             string // path of a changed file the default entry of the manifest refers to
+}
+
+// syncChanges compares the files of the directory with the file entries of a manifest
+func syncChanges(dir string, remote map[string]api.ManifestEntry, isEncrypted bool) (*syncChangeSet, error) {
+	changes := &syncChangeSet{}
+	local := make(map[string]bool)
+	err := filepath.Walk(dir, func(path string, f os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if f.IsDir() {
+			return nil
+		}
+		relPath, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		p := filepath.ToSlash(relPath)
+		local[p] = true
+
+		entry, ok := remote[p]
+		if !ok {
+			changes.added = append(changes.added, p)
+			return nil
+		}
+		if !isEncrypted {
+			hash, err := localFileHash(path, entry.ContentEncoding)
+			if err != nil {
+				return err
+			}
+			if hash == entry.Hash {
+				return nil
+			}
+		}
+		changes.changed = append(changes.changed, p)
+		if def, ok := remote[""]; ok && def.Hash == entry.Hash {
+			changes.defaultPath = p
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for p := range remote {
+		if p != "" && !local[p] {
+			changes.removed = append(changes.removed, p)
+		}
+	}
+	sort.Strings(changes.removed)
+	return changes, nil
+}
+
+// localFileHash returns the swarm hash of a file as it is stored in a manifest entry
+// with the given content encoding, without uploading it
+func localFileHash(path, encoding string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	var (
+		data io.Reader = f
+		size int64
+	)
+	if encoding != "" {
+		compressed, _, compressedSize, err := api.Compress(f, encoding)
+		if err != nil {
+			return "", err
+		}
+		defer os.Remove(compressed.Name())
+		defer compressed.Close()
+		data, size = compressed, compressedSize
+	} else {
+		stat, err := f.Stat()
+		if err != nil {
+			return "", err
+		}
+		size = stat.Size()
+	}
+
+	fileStore := storage.NewFileStore(&storage.FakeChunkStore{}, &storage.FakeChunkStore{}, storage.NewFileStoreParams(), chunk.NewTags())
+	addr, _, err := fileStore.Store(context.TODO(), data, size, false)
+	if err != nil {
+		return "", err
+	}
+	return addr.Hex(), nil
+}
+
+// manifestFileEntries returns the entries of a manifest and its submanifests
+// other than the submanifests themselves by their full path
+func manifestFileEntries(client *swarm.Client, hash, prefix string) (entries map[string]api.ManifestEntry, isEncrypted bool, err error) {
+	m, isEncrypted, err := client.DownloadManifest(hash)
+	if err != nil {
+		return nil, false, err
+	}
+	entries = make(map[string]api.ManifestEntry)
+	for _, e := range m.Entries {
+		if e.ContentType != api.ManifestType {
+			entries[prefix+e.Path] = e
+			continue
+		}
+		sub, _, err := manifestFileEntries(client, e.Hash, prefix+e.Path)
+		if err != nil {
+			return nil, false, err
+		}
+		for p, e := range sub {
+			entries[p] = e
+		}
+	}
+	return entries, isEncrypted, nil
+}
+
+// fileListUploader uploads the files of a directory with the given paths
+type fileListUploader struct {
+	dir   string
+	paths []string
+}
+
+func (u *fileListUploader) Tag() string {
+	return filepath.Base(u.dir)
+}
+
+func (u *fileListUploader) Upload(upload swarm.UploadFn) error {
+	for _, p := range u.paths {
+		file, err := swarm.Open(filepath.Join(u.dir, filepath.FromSlash(p)))
+		if err != nil {
+			return err
+		}
+		file.Path = p
+		err = upload(file)
+		file.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	swarm "github.com/ethersphere/swarm/api/client"
+	swarmhttp "github.com/ethersphere/swarm/api/http"
+)
+
+// TestSync tests that the sync command uploads added and changed files,
+// removes deleted files and keeps the entries of unchanged files.
+func TestSync(t *testing.T) {
+	if runtime.GOOS == goosWindows {
+		t.Skip()
+	}
+	t.Parallel()
+
+	srv := swarmhttp.NewTestSwarmServer(t, serverFunc, nil, nil)
+	defer srv.Close()
+
+	tmp, err := ioutil.TempDir("", "swarm-sync-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	files := map[string]string{
+		"index.html":      "index",
+		"robots.txt":      "robots",
+		"css/style.css":   "body {}",
+		"docs/readme.txt": "readme",
+	}
+	for p, content := range files {
+		writeSyncFile(t, tmp, p, content)
+	}
+
+	origHash := runSwarmExpectHash(t,
+		"--bzzapi",
+		srv.URL,
+		"--recursive",
+		"up",
+		tmp,
+	)
+
+	client := swarm.NewClient(srv.URL)
+	origEntries, _, err := manifestFileEntries(client, origHash, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// an unchanged directory gives the same manifest
+	if hash := runSwarmExpectHash(t, "--bzzapi", srv.URL, "sync", tmp, origHash); hash != origHash {
+		t.Fatalf("expected unchanged manifest %s, got %s", origHash, hash)
+	}
+
+	writeSyncFile(t, tmp, "index.html", "new index")
+	writeSyncFile(t, tmp, "docs/api.txt", "api")
+	if err := os.Remove(filepath.Join(tmp, "robots.txt")); err != nil {
+		t.Fatal(err)
+	}
+
+	hash := runSwarmExpectHash(t,
+		"--bzzapi",
+		srv.URL,
+		"sync",
+		tmp,
+		origHash,
+	)
+	if hash == origHash {
+		t.Fatal("expected the manifest to change")
+	}
+
+	entries, _, err := manifestFileEntries(client, hash, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := entries["robots.txt"]; ok {
+		t.Error("expected robots.txt to be removed")
+	}
+	for _, p := range []string{"css/style.css", "docs/readme.txt"} {
+		if entries[p].Hash != origEntries[p].Hash {
+			t.Errorf("expected %s to keep hash %s, got %s", p, origEntries[p].Hash, entries[p].Hash)
+		}
+	}
+	if entries["index.html"].Hash == origEntries["index.html"].Hash {
+		t.Error("expected index.html to change")
+	}
+	checkFile(t, client, hash, "index.html", []byte("new index"))
+	checkFile(t, client, hash, "docs/api.txt", []byte("api"))
+	checkFile(t, client, hash, "css/style.css", []byte("body {}"))
+}
+
+func writeSyncFile(t *testing.T, dir, path, content string) {
+	t.Helper()
+	p := filepath.Join(dir, filepath.FromSlash(path))
+	if err := os.MkdirAll(filepath.Dir(p), 0777); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(p, []byte(content), 0666); err != nil {
+		t.Fatal(err)
+	}
+}
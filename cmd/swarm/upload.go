@@ -31,6 +31,7 @@ import (
 	"time"
 
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethersphere/swarm/api"
 	"github.com/ethersphere/swarm/api/client"
 	swarm "github.com/ethersphere/swarm/api/client"
 	"github.com/ethersphere/swarm/chunk"
@@ -48,7 +49,7 @@ var (
 		Name:               "up",
 		Usage:              "uploads a file or directory to swarm using the HTTP API",
 		ArgsUsage:          "<file>",
-		Flags:              []cli.Flag{SwarmEncryptedFlag, SwarmPinFlag, SwarmProgressFlag, SwarmVerboseFlag},
+		Flags:              []cli.Flag{SwarmEncryptedFlag, SwarmPinFlag, SwarmProgressFlag, SwarmVerboseFlag, SwarmCompressFlag, SwarmMetaFlag, SwarmSearchIndexFlag, SwarmIndexDocumentFlag, SwarmErrorDocumentFlag, SwarmSPAFlag},
 		Description:        "uploads a file or directory to swarm using the HTTP API and prints the root hash",
 	}
 
@@ -74,11 +75,12 @@ func upload(ctx *cli.Context) {
 		fromStdin       = ctx.GlobalBool(SwarmUpFromStdinFlag.Name)
 		mimeType        = ctx.GlobalString(SwarmUploadMimeType.Name)
 		verbose         = ctx.Bool(SwarmVerboseFlag.Name)
-		client          = swarm.NewClient(bzzapi)
+		client          = newClient(ctx, bzzapi)
 		toEncrypt       = ctx.Bool(SwarmEncryptedFlag.Name)
 		toPin           = ctx.Bool(SwarmPinFlag.Name)
 		progress        = ctx.Bool(SwarmProgressFlag.Name)
 		anon            = ctx.Bool(SwarmAnonymousUploadFlag.Name)
+		compress        = ctx.String(SwarmCompressFlag.Name)
 		autoDefaultPath = false
 		file            string
 	)
@@ -92,6 +94,19 @@ func upload(ctx *cli.Context) {
 		}
 		autoDefaultPath = b
 	}
+	if err := client.SetContentEncoding(compress); err != nil {
+		utils.Fatalf("invalid --%s: %v", SwarmCompressFlag.Name, err)
+	}
+	setMetadata(ctx, client)
+	client.SetSearchIndex(ctx.Bool(SwarmSearchIndexFlag.Name))
+	var website *api.WebsiteConfig
+	if indexDocument, errorDocument, spa := ctx.String(SwarmIndexDocumentFlag.Name), ctx.String(SwarmErrorDocumentFlag.Name), ctx.Bool(SwarmSPAFlag.Name); indexDocument != "" || errorDocument != "" || spa {
+		website = &api.WebsiteConfig{
+			IndexDocument: indexDocument,
+			ErrorDocument: errorDocument,
+			SPA:           spa,
+		}
+	}
 	if len(args) != 1 {
 		if fromStdin {
 			tmp, err := ioutil.TempFile("", "swarm-stdin")
@@ -160,7 +175,7 @@ func upload(ctx *cli.Context) {
 					defaultPath = strings.TrimPrefix(absDefaultPath, absFile)
 				}
 			}
-			return client.UploadDirectory(file, defaultPath, "", toEncrypt, toPin, anon)
+			return client.UploadDirectory(file, defaultPath, "", website, toEncrypt, toPin, anon)
 		}
 	} else {
 		doUpload = func() (string, error) {
@@ -211,6 +226,25 @@ func upload(ctx *cli.Context) {
 	fmt.Println("Your Swarm hash should now be retrievable from other nodes!")
 }
 
+// setMetadata sets the metadata given with --meta key=value flags on the client
+func setMetadata(ctx *cli.Context, client *swarm.Client) {
+	values := ctx.StringSlice(SwarmMetaFlag.Name)
+	if len(values) == 0 {
+		return
+	}
+	meta := make(map[string]string, len(values))
+	for _, v := range values {
+		kv := strings.SplitN(v, "=", 2)
+		if len(kv) != 2 {
+			utils.Fatalf("invalid --%s %q, expected key=value", SwarmMetaFlag.Name, v)
+		}
+		meta[strings.ToLower(kv[0])] = kv[1]
+	}
+	if err := client.SetMetadata(meta); err != nil {
+		utils.Fatalf("invalid --%s: %v", SwarmMetaFlag.Name, err)
+	}
+}
+
 func pollTag(client *client.Client, hash string, tag *chunk.Tag, bars map[string]*mpb.Bar) {
 	oldTag := tag
 	lastTime := time.Now()
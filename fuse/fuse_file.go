@@ -26,6 +26,7 @@ import (
 
 	"bazil.org/fuse"
 	"bazil.org/fuse/fs"
+	"github.com/ethersphere/swarm/api"
 	"github.com/ethersphere/swarm/log"
 	"github.com/ethersphere/swarm/storage"
 	"golang.org/x/net/context"
@@ -38,6 +39,7 @@ const (
 var (
 	errInvalidOffset           = errors.New("Invalid offset during write")
 	errFileSizeMaxLimixReached = errors.New("File size exceeded max limit")
+	errCompressedFileWrite     = errors.New("Writing to compressed files is not supported")
 )
 
 var (
@@ -54,6 +56,13 @@ type SwarmFile struct {
 	fileSize int64
 	reader   storage.LazySectionReader
 
+	// content encoding of compressed files, whose fileSize is the size
+	// of the decompressed content, and the reader of the decompressed content
+	// kept between reads so that sequential reads do not decompress from the start
+	encoding     string
+	decompressed *api.DecompressReader
+	decompressMu sync.Mutex
+
 	mountInfo *MountInfo
 	lock      *sync.RWMutex
 }
@@ -103,6 +112,9 @@ func (sf *SwarmFile) Read(ctx context.Context, req *fuse.ReadRequest, resp *fuse
 	log.Debug("swarmfs Read", "path", sf.path, "req.String", req.String())
 	sf.lock.RLock()
 	defer sf.lock.RUnlock()
+	if sf.encoding != "" {
+		return sf.readDecompressed(req, resp)
+	}
 	if sf.reader == nil {
 		sf.reader, _ = sf.mountInfo.swarmApi.Retrieve(ctx, sf.addr)
 	}
@@ -117,8 +129,39 @@ func (sf *SwarmFile) Read(ctx context.Context, req *fuse.ReadRequest, resp *fuse
 	return err
 }
 
+// readDecompressed reads the decompressed content of a compressed file
+func (sf *SwarmFile) readDecompressed(req *fuse.ReadRequest, resp *fuse.ReadResponse) error {
+	sf.decompressMu.Lock()
+	defer sf.decompressMu.Unlock()
+	if sf.decompressed == nil {
+		// the reader outlives the request, so it does not use its context
+		reader, _ := sf.mountInfo.swarmApi.Retrieve(context.Background(), sf.addr)
+		size, err := reader.Size(context.Background(), nil)
+		if err != nil {
+			return err
+		}
+		sf.decompressed, err = api.NewDecompressReader(reader, size, sf.fileSize, sf.encoding)
+		if err != nil {
+			return err
+		}
+	}
+	if _, err := sf.decompressed.Seek(req.Offset, io.SeekStart); err != nil {
+		return err
+	}
+	buf := make([]byte, req.Size)
+	n, err := io.ReadFull(sf.decompressed, buf)
+	if err == io.ErrUnexpectedEOF || err == io.EOF {
+		err = nil
+	}
+	resp.Data = buf[:n]
+	return err
+}
+
 func (sf *SwarmFile) Write(ctx context.Context, req *fuse.WriteRequest, resp *fuse.WriteResponse) error {
 	log.Debug("swarmfs Write", "path", sf.path, "req.String", req.String())
+	if sf.encoding != "" {
+		return errCompressedFileWrite
+	}
 	if sf.fileSize == 0 && req.Offset == 0 {
 		// A new file is created
 		err := addFileToSwarm(sf, req.Data, len(req.Data))
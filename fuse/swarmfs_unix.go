@@ -147,6 +147,12 @@ func (swarmfs *SwarmFS) Mount(mhash, mountpoint string) (*MountInfo, error) {
 		}
 		thisFile := NewSwarmFile(basepath, filepath.Base(fullpath), mi)
 		thisFile.addr = addr
+		if entry.ContentEncoding != "" {
+			// compressed files are read decompressed, with the size of the
+			// decompressed content recorded in the manifest
+			thisFile.encoding = entry.ContentEncoding
+			thisFile.fileSize = entry.Size
+		}
 
 		parentDir.files = append(parentDir.files, thisFile)
 	}
@@ -0,0 +1,70 @@
+// Copyright 2019 The Swarm Authors
+// This file is part of the Swarm library.
+//
+// The Swarm library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Swarm library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Swarm library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package discovery finds peers to bootstrap the connectivity of a node
+// without bootnodes, from signed node record trees published in DNS and
+// from the announcements of other nodes on the local network. The found
+// nodes are registered in the kademlia table, from which hive connects
+// to them.
+package discovery
+
+import (
+	"bytes"
+	"errors"
+	"net"
+
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethersphere/swarm/network"
+)
+
+var errNoEndpoint = errors.New("node record has no ip address or tcp port")
+
+// bzzAddr returns the bzz address of the node from the bzzkey entry of its
+// record. The underlay address is the node endpoint at the ip, or at the ip
+// in the record if it is nil.
+func bzzAddr(n *enode.Node, ip net.IP) (*network.BzzAddr, error) {
+	var key network.ENRAddrEntry
+	if err := n.Load(&key); err != nil {
+		return nil, err
+	}
+	if ip == nil {
+		ip = n.IP()
+	}
+	if ip == nil || n.TCP() == 0 {
+		return nil, errNoEndpoint
+	}
+	under := enode.NewV4(n.Pubkey(), ip, n.TCP(), n.UDP())
+	return network.NewBzzAddr(key.Address(), []byte(under.URLv4())), nil
+}
+
+// register registers the addresses in the kademlia table, skipping the
+// address of the node itself, and returns the number of registered addresses.
+func register(kad *network.Kademlia, addrs []*network.BzzAddr) (int, error) {
+	peers := make([]*network.BzzAddr, 0, len(addrs))
+	for _, a := range addrs {
+		if bytes.Equal(a.Address(), kad.BaseAddr()) {
+			continue
+		}
+		peers = append(peers, a)
+	}
+	if len(peers) == 0 {
+		return 0, nil
+	}
+	if err := kad.Register(peers...); err != nil {
+		return 0, err
+	}
+	return len(peers), nil
+}
@@ -0,0 +1,282 @@
+// Copyright 2019 The Swarm Authors
+// This file is part of the Swarm library.
+//
+// The Swarm library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Swarm library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Swarm library. If not, see <http://www.gnu.org/licenses/>.
+
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethersphere/swarm/log"
+	"github.com/ethersphere/swarm/network"
+)
+
+const (
+	// maxTreeEntries is the maximum number of entries resolved from a tree
+	maxTreeEntries = 10000
+
+	// dnsSyncTimeout is the maximum duration of resolving all trees
+	dnsSyncTimeout = 5 * time.Minute
+)
+
+var (
+	dnsRegisteredCount = metrics.NewRegisteredCounter("network/discovery/dns/registered", nil)
+	dnsFailCount       = metrics.NewRegisteredCounter("network/discovery/dns/fail", nil)
+)
+
+// Resolver looks up the TXT records of a domain. It is implemented by
+// net.Resolver.
+type Resolver interface {
+	LookupTXT(ctx context.Context, domain string) ([]string, error)
+}
+
+// DNSParams configure the discovery of nodes from
+// the node record trees published in DNS.
+type DNSParams struct {
+	// Trees are the links to the trees in the enrtree://<key>@<domain>
+	// format, where key is the public key that signs the tree. No trees
+	// disable the discovery.
+	Trees []string
+	// Interval is the time between the resolutions of all trees.
+	Interval time.Duration
+}
+
+// NewDNSParams returns the default DNS discovery parameters.
+func NewDNSParams() *DNSParams {
+	return &DNSParams{
+		Interval: 30 * time.Minute,
+	}
+}
+
+// DNS periodically resolves the node record trees from DNS
+// and registers the nodes in the kademlia table.
+type DNS struct {
+	kad      *network.Kademlia
+	resolver Resolver
+	params   *DNSParams
+	links    []*linkEntry
+	quit     chan struct{}
+	done     chan struct{}
+}
+
+// NewDNS returns a new DNS discovery of the trees in params that looks up
+// the TXT records with the resolver.
+func NewDNS(kad *network.Kademlia, resolver Resolver, params *DNSParams) (*DNS, error) {
+	d := &DNS{
+		kad:      kad,
+		resolver: resolver,
+		params:   params,
+		quit:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	for _, t := range params.Trees {
+		link, err := parseLink(t)
+		if err != nil {
+			return nil, err
+		}
+		d.links = append(d.links, link)
+	}
+	return d, nil
+}
+
+// Start resolves the trees immediately and then every Interval.
+func (d *DNS) Start() {
+	go d.run()
+}
+
+// Stop terminates the periodic resolution of the trees.
+func (d *DNS) Stop() {
+	close(d.quit)
+	<-d.done
+}
+
+func (d *DNS) run() {
+	defer close(d.done)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-d.quit:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	ticker := time.NewTicker(d.params.Interval)
+	defer ticker.Stop()
+	for {
+		sctx, scancel := context.WithTimeout(ctx, dnsSyncTimeout)
+		n, err := d.Sync(sctx)
+		scancel()
+		if err != nil && ctx.Err() == nil {
+			log.Warn("dns discovery", "registered", n, "err", err)
+		} else {
+			log.Debug("dns discovery", "registered", n)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-d.quit:
+			return
+		}
+	}
+}
+
+// Sync resolves all trees, including the ones that they link to, registers
+// their nodes in the kademlia table and returns the number of registered
+// nodes. Trees that can not be resolved are skipped and the last error is
+// returned.
+func (d *DNS) Sync(ctx context.Context) (registered int, err error) {
+	visited := make(map[string]bool)
+	for _, link := range d.links {
+		nodes, terr := d.resolveTree(ctx, link, visited)
+		if terr != nil {
+			dnsFailCount.Inc(1)
+			err = fmt.Errorf("tree %s: %v", link.domain, terr)
+		}
+		addrs := make([]*network.BzzAddr, 0, len(nodes))
+		for _, n := range nodes {
+			a, aerr := bzzAddr(n, nil)
+			if aerr != nil {
+				log.Trace("dns discovery: skipping node", "tree", link.domain, "node", n.ID(), "err", aerr)
+				continue
+			}
+			addrs = append(addrs, a)
+		}
+		n, rerr := register(d.kad, addrs)
+		if rerr != nil {
+			return registered, rerr
+		}
+		registered += n
+		dnsRegisteredCount.Inc(int64(n))
+	}
+	return registered, err
+}
+
+// resolveTree returns the nodes of the tree and of the trees that it links
+// to, which are not yet visited. Nodes that are resolved before an error
+// are returned with it.
+func (d *DNS) resolveTree(ctx context.Context, link *linkEntry, visited map[string]bool) (nodes []*enode.Node, err error) {
+	if visited[link.domain] {
+		return nil, nil
+	}
+	visited[link.domain] = true
+
+	root, err := d.resolveRoot(ctx, link)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := d.resolveEntries(ctx, link.domain, root.eroot, false)
+	for _, e := range entries {
+		nodes = append(nodes, e.(*enrEntry).node)
+	}
+	if err != nil {
+		return nodes, err
+	}
+	links, err := d.resolveEntries(ctx, link.domain, root.lroot, true)
+	if err != nil {
+		return nodes, err
+	}
+	for _, l := range links {
+		n, err := d.resolveTree(ctx, l.(*linkEntry), visited)
+		nodes = append(nodes, n...)
+		if err != nil {
+			return nodes, fmt.Errorf("linked tree %s: %v", l.(*linkEntry).domain, err)
+		}
+	}
+	return nodes, nil
+}
+
+// resolveRoot looks up the root of the tree and verifies its signature.
+func (d *DNS) resolveRoot(ctx context.Context, link *linkEntry) (*rootEntry, error) {
+	txts, err := d.resolver.LookupTXT(ctx, link.domain)
+	if err != nil {
+		return nil, err
+	}
+	for _, txt := range txts {
+		if !strings.HasPrefix(txt, rootPrefix) {
+			continue
+		}
+		root, err := parseRoot(txt)
+		if err != nil {
+			return nil, err
+		}
+		if !root.verifySignature(link.pubkey) {
+			return nil, errInvalidSig
+		}
+		return root, nil
+	}
+	return nil, fmt.Errorf("no tree root found at %s", link.domain)
+}
+
+// resolveEntries returns the leaf entries of the subtree with the hash,
+// which are links if links is true, and node records otherwise.
+func (d *DNS) resolveEntries(ctx context.Context, domain, hash string, links bool) (leaves []entry, err error) {
+	queue := []string{hash}
+	for resolved := 0; len(queue) > 0; resolved++ {
+		if resolved >= maxTreeEntries {
+			return leaves, fmt.Errorf("more than %d entries", maxTreeEntries)
+		}
+		e, err := d.resolveEntry(ctx, domain, queue[0])
+		if err != nil {
+			return leaves, err
+		}
+		queue = queue[1:]
+
+		switch e := e.(type) {
+		case *branchEntry:
+			queue = append(queue, e.children...)
+		case *linkEntry:
+			if !links {
+				return leaves, fmt.Errorf("link %s in node records", e)
+			}
+			leaves = append(leaves, e)
+		case *enrEntry:
+			if links {
+				return leaves, fmt.Errorf("node record %s in links", e.node.ID())
+			}
+			leaves = append(leaves, e)
+		}
+	}
+	return leaves, nil
+}
+
+// resolveEntry looks up the entry with the hash and
+// verifies that the hash matches its content.
+func (d *DNS) resolveEntry(ctx context.Context, domain, hash string) (entry, error) {
+	name := hash + "." + domain
+	txts, err := d.resolver.LookupTXT(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	for _, txt := range txts {
+		if hashTXT(txt) != hash {
+			log.Trace("dns discovery: entry hash mismatch", "name", name)
+			continue
+		}
+		e, err := parseEntry(txt)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+		return e, nil
+	}
+	return nil, fmt.Errorf("no valid entry found at %s", name)
+}
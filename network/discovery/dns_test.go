@@ -0,0 +1,302 @@
+// Copyright 2019 The Swarm Authors
+// This file is part of the Swarm library.
+//
+// The Swarm library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Swarm library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Swarm library. If not, see <http://www.gnu.org/licenses/>.
+
+package discovery
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/p2p/enr"
+	"github.com/ethersphere/swarm/network"
+)
+
+// mapResolver is a fake DNS resolver with TXT records by domain name.
+type mapResolver map[string]string
+
+func (r mapResolver) LookupTXT(ctx context.Context, domain string) ([]string, error) {
+	txt, ok := r[domain]
+	if !ok {
+		return nil, fmt.Errorf("no such host %s", domain)
+	}
+	return []string{txt}, nil
+}
+
+// add adds the TXT records of the tree signed with the key
+// at the domain and returns the link to the tree.
+func (r mapResolver) add(t *testing.T, tree *Tree, key *ecdsa.PrivateKey, domain string) string {
+	t.Helper()
+
+	link, err := tree.Sign(key, domain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, txt := range tree.ToTXT(domain) {
+		r[name] = txt
+	}
+	return link
+}
+
+// newTestNode returns a signed node record with a random bzz key
+// and the endpoint at the ip and the tcp port.
+func newTestNode(t *testing.T, ip net.IP, tcp int) *enode.Node {
+	t.Helper()
+
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var r enr.Record
+	r.Set(network.NewENRAddrEntry(network.RandomBzzAddr().Over()))
+	r.Set(enr.IP(ip))
+	r.Set(enr.TCP(tcp))
+	if err := enode.SignV4(&r, key); err != nil {
+		t.Fatal(err)
+	}
+	n, err := enode.New(enode.ValidSchemes, &r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func newTestNodes(t *testing.T, count int) []*enode.Node {
+	t.Helper()
+
+	nodes := make([]*enode.Node, count)
+	for i := range nodes {
+		nodes[i] = newTestNode(t, net.IPv4(10, 0, 0, byte(i+1)), 30399)
+	}
+	return nodes
+}
+
+func newTestKey(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+// kadAddrs returns the addresses registered in the kademlia table.
+func kadAddrs(kad *network.Kademlia) map[string]*network.BzzAddr {
+	addrs := make(map[string]*network.BzzAddr)
+	kad.EachAddr(nil, 255, func(a *network.BzzAddr, _ int) bool {
+		addrs[string(a.Address())] = a
+		return true
+	})
+	return addrs
+}
+
+// checkRegistered fails the test if any of the nodes
+// is not registered in the kademlia table.
+func checkRegistered(t *testing.T, kad *network.Kademlia, nodes []*enode.Node) {
+	t.Helper()
+
+	addrs := kadAddrs(kad)
+	if len(addrs) != len(nodes) {
+		t.Fatalf("got %v registered addresses, want %v", len(addrs), len(nodes))
+	}
+	for _, n := range nodes {
+		var key network.ENRAddrEntry
+		if err := n.Load(&key); err != nil {
+			t.Fatal(err)
+		}
+		a, ok := addrs[string(key.Address())]
+		if !ok {
+			t.Fatalf("node %s not registered", n.ID())
+		}
+		u, err := enode.ParseV4(string(a.Under()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if u.ID() != n.ID() || !u.IP().Equal(n.IP()) || u.TCP() != n.TCP() {
+			t.Fatalf("got underlay address %s, want %s", u.URLv4(), n.URLv4())
+		}
+	}
+}
+
+// TestTreeRoundTrip validates that all entries of a tree can be parsed
+// and that they are stored under the hashes of their content.
+func TestTreeRoundTrip(t *testing.T) {
+	key := newTestKey(t)
+	// more nodes than fit in a single branch
+	nodes := newTestNodes(t, 3*maxChildren)
+	tree, err := MakeTree(1, nodes, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	link, err := tree.Sign(key, "nodes.example.org")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l, err := parseLink(link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.domain != "nodes.example.org" {
+		t.Fatalf("got link domain %s, want nodes.example.org", l.domain)
+	}
+	if !bytes.Equal(crypto.FromECDSAPub(l.pubkey), crypto.FromECDSAPub(&key.PublicKey)) {
+		t.Fatal("link public key does not match")
+	}
+
+	records := tree.ToTXT("nodes.example.org")
+	root, err := parseRoot(records["nodes.example.org"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !root.verifySignature(&key.PublicKey) {
+		t.Fatal("invalid root signature")
+	}
+	if root.seq != 1 {
+		t.Fatalf("got root seq %v, want 1", root.seq)
+	}
+	var enrs int
+	for name, txt := range records {
+		if name == "nodes.example.org" {
+			continue
+		}
+		if want := hashTXT(txt) + ".nodes.example.org"; name != want {
+			t.Fatalf("got entry name %s, want %s", name, want)
+		}
+		e, err := parseEntry(txt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := e.(*enrEntry); ok {
+			enrs++
+		}
+	}
+	if enrs != len(nodes) {
+		t.Fatalf("got %v node records, want %v", enrs, len(nodes))
+	}
+}
+
+// TestDNSSync validates that the nodes of a tree and of the trees that it
+// links to are registered in the kademlia table, excluding the node itself.
+func TestDNSSync(t *testing.T) {
+	resolver := make(mapResolver)
+
+	linkedNodes := newTestNodes(t, 5)
+	linkedTree, err := MakeTree(1, linkedNodes, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	linkedLink := resolver.add(t, linkedTree, newTestKey(t), "linked.example.org")
+
+	nodes := newTestNodes(t, 2*maxChildren)
+	tree, err := MakeTree(1, nodes, []string{linkedLink})
+	if err != nil {
+		t.Fatal(err)
+	}
+	link := resolver.add(t, tree, newTestKey(t), "nodes.example.org")
+
+	// the node itself is in the tree
+	var key network.ENRAddrEntry
+	if err := nodes[0].Load(&key); err != nil {
+		t.Fatal(err)
+	}
+	kad := network.NewKademlia(key.Address(), network.NewKadParams())
+
+	params := NewDNSParams()
+	params.Trees = []string{link}
+	d, err := NewDNS(kad, resolver, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	registered, err := d.Sync(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := append(append([]*enode.Node{}, nodes[1:]...), linkedNodes...)
+	if registered != len(want) {
+		t.Fatalf("got %v registered nodes, want %v", registered, len(want))
+	}
+	checkRegistered(t, kad, want)
+}
+
+// TestDNSSyncInvalid validates that trees with an invalid
+// signature or modified entries are rejected.
+func TestDNSSyncInvalid(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		modify func(t *testing.T, resolver mapResolver, link string) string
+	}{
+		{
+			name: "signature",
+			modify: func(t *testing.T, resolver mapResolver, link string) string {
+				// the link to the tree with another key
+				l, err := parseLink(link)
+				if err != nil {
+					t.Fatal(err)
+				}
+				l.pubkey = &newTestKey(t).PublicKey
+				return l.String()
+			},
+		},
+		{
+			name: "entry",
+			modify: func(t *testing.T, resolver mapResolver, link string) string {
+				// replace a node record with another one
+				for name, txt := range resolver {
+					if bytes.HasPrefix([]byte(txt), []byte(enrPrefix)) {
+						resolver[name] = newTestNode(t, net.IPv4(10, 0, 1, 1), 30399).String()
+						break
+					}
+				}
+				return link
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			resolver := make(mapResolver)
+			tree, err := MakeTree(1, newTestNodes(t, 1), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			link := resolver.add(t, tree, newTestKey(t), "nodes.example.org")
+			link = tc.modify(t, resolver, link)
+
+			kad := network.NewKademlia(network.RandomBzzAddr().Over(), network.NewKadParams())
+			params := NewDNSParams()
+			params.Trees = []string{link}
+			d, err := NewDNS(kad, resolver, params)
+			if err != nil {
+				t.Fatal(err)
+			}
+			registered, err := d.Sync(context.Background())
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if registered != 0 {
+				t.Fatalf("got %v registered nodes, want 0", registered)
+			}
+			if addrs := kadAddrs(kad); len(addrs) != 0 {
+				t.Fatalf("got %v registered addresses, want 0", len(addrs))
+			}
+		})
+	}
+}
@@ -0,0 +1,198 @@
+// Copyright 2019 The Swarm Authors
+// This file is part of the Swarm library.
+//
+// The Swarm library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Swarm library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Swarm library. If not, see <http://www.gnu.org/licenses/>.
+
+package discovery
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethersphere/swarm/log"
+	"github.com/ethersphere/swarm/network"
+)
+
+const (
+	// lanPrefix starts every announcement on the local network
+	lanPrefix = "swarm-lan:v1 "
+
+	// maxAnnouncementSize is the maximum size of an announcement,
+	// the maximum size of a node record with the prefix
+	maxAnnouncementSize = 512
+)
+
+var (
+	lanRegisteredCount = metrics.NewRegisteredCounter("network/discovery/lan/registered", nil)
+
+	errInvalidAnnouncement = errors.New("invalid announcement")
+)
+
+// LANParams configure the discovery of nodes on the local network.
+type LANParams struct {
+	// Enabled enables the announcements and the discovery.
+	Enabled bool
+	// Group is the UDP multicast address of announcements.
+	Group string
+	// Interval is the time between announcements of this node.
+	Interval time.Duration
+}
+
+// NewLANParams returns the default local network discovery parameters.
+func NewLANParams() *LANParams {
+	return &LANParams{
+		Group:    "239.255.66.90:30398",
+		Interval: 10 * time.Second,
+	}
+}
+
+// LAN periodically announces the node record of this node to a multicast
+// group on the local network and registers the nodes from the received
+// announcements in the kademlia table, similarly to multicast DNS.
+type LAN struct {
+	kad    *network.Kademlia
+	params *LANParams
+	conn   *net.UDPConn
+	mu     sync.Mutex
+	seen   map[string]bool // addresses of registered nodes
+	quit   chan struct{}
+	wg     sync.WaitGroup
+}
+
+// NewLAN returns a new local network discovery.
+func NewLAN(kad *network.Kademlia, params *LANParams) *LAN {
+	return &LAN{
+		kad:    kad,
+		params: params,
+		seen:   make(map[string]bool),
+		quit:   make(chan struct{}),
+	}
+}
+
+// Start joins the multicast group and announces the node record returned by
+// the self function every Interval. If self is nil, the node is not announced
+// and only the announcements of other nodes are received.
+func (l *LAN) Start(self func() *enode.Node) error {
+	group, err := net.ResolveUDPAddr("udp4", l.params.Group)
+	if err != nil {
+		return err
+	}
+	l.conn, err = net.ListenMulticastUDP("udp4", nil, group)
+	if err != nil {
+		return err
+	}
+	l.wg.Add(1)
+	go l.listen()
+	if self != nil {
+		l.wg.Add(1)
+		go l.announce(group, self)
+	}
+	return nil
+}
+
+// Stop leaves the multicast group and terminates the announcements.
+func (l *LAN) Stop() {
+	close(l.quit)
+	if l.conn != nil {
+		l.conn.Close()
+	}
+	l.wg.Wait()
+}
+
+func (l *LAN) announce(group *net.UDPAddr, self func() *enode.Node) {
+	defer l.wg.Done()
+
+	conn, err := net.DialUDP("udp4", nil, group)
+	if err != nil {
+		log.Error("lan discovery: announce", "err", err)
+		return
+	}
+	defer conn.Close()
+
+	ticker := time.NewTicker(l.params.Interval)
+	defer ticker.Stop()
+	for {
+		if _, err := conn.Write(announcement(self())); err != nil {
+			log.Debug("lan discovery: announce", "err", err)
+		}
+		select {
+		case <-ticker.C:
+		case <-l.quit:
+			return
+		}
+	}
+}
+
+func (l *LAN) listen() {
+	defer l.wg.Done()
+
+	buf := make([]byte, maxAnnouncementSize)
+	for {
+		n, from, err := l.conn.ReadFromUDP(buf)
+		if err != nil {
+			select {
+			case <-l.quit:
+			default:
+				log.Error("lan discovery: receive", "err", err)
+			}
+			return
+		}
+		if err := l.handleAnnouncement(buf[:n], from.IP); err != nil {
+			log.Trace("lan discovery: invalid announcement", "from", from, "err", err)
+		}
+	}
+}
+
+// handleAnnouncement registers the node from the announcement received from
+// the ip, at which the node is reachable on the local network.
+func (l *LAN) handleAnnouncement(data []byte, ip net.IP) error {
+	if !bytes.HasPrefix(data, []byte(lanPrefix)) {
+		return errInvalidAnnouncement
+	}
+	node, err := enode.Parse(enode.ValidSchemes, string(data[len(lanPrefix):]))
+	if err != nil {
+		return err
+	}
+	a, err := bzzAddr(node, ip)
+	if err != nil {
+		return err
+	}
+
+	key := string(a.Address()) + string(a.Under())
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.seen[key] {
+		return nil
+	}
+	n, err := register(l.kad, []*network.BzzAddr{a})
+	if err != nil {
+		return err
+	}
+	l.seen[key] = true
+	if n > 0 {
+		lanRegisteredCount.Inc(1)
+		log.Debug("lan discovery: registered node", "addr", a)
+	}
+	return nil
+}
+
+// announcement returns the announcement of the node record.
+func announcement(n *enode.Node) []byte {
+	return []byte(lanPrefix + n.String())
+}
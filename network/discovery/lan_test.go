@@ -0,0 +1,109 @@
+// Copyright 2019 The Swarm Authors
+// This file is part of the Swarm library.
+//
+// The Swarm library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Swarm library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Swarm library. If not, see <http://www.gnu.org/licenses/>.
+
+package discovery
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethersphere/swarm/network"
+)
+
+// TestLANHandleAnnouncement validates that announced nodes are registered
+// at the address from which the announcement is received.
+func TestLANHandleAnnouncement(t *testing.T) {
+	kad := network.NewKademlia(network.RandomBzzAddr().Over(), network.NewKadParams())
+	l := NewLAN(kad, NewLANParams())
+
+	// the node record has a public ip, which is not
+	// reachable from the local network
+	n := newTestNode(t, net.IPv4(203, 0, 113, 1), 30399)
+	from := net.IPv4(192, 168, 1, 10)
+	if err := l.handleAnnouncement(announcement(n), from); err != nil {
+		t.Fatal(err)
+	}
+	addrs := kadAddrs(kad)
+	if len(addrs) != 1 {
+		t.Fatalf("got %v registered addresses, want 1", len(addrs))
+	}
+	for _, a := range addrs {
+		u, err := enode.ParseV4(string(a.Under()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !u.IP().Equal(from) || u.TCP() != n.TCP() {
+			t.Fatalf("got underlay address %s, want ip %s and port %v", u.URLv4(), from, n.TCP())
+		}
+	}
+
+	if err := l.handleAnnouncement([]byte("invalid"), from); err != errInvalidAnnouncement {
+		t.Fatalf("got error %v, want %v", err, errInvalidAnnouncement)
+	}
+	if err := l.handleAnnouncement([]byte(lanPrefix+"enr:invalid"), from); err == nil {
+		t.Fatal("expected error for invalid node record")
+	}
+}
+
+// TestLAN validates that nodes discover each other
+// from the announcements on the local network.
+func TestLAN(t *testing.T) {
+	params := NewLANParams()
+	params.Group = "239.255.66.90:30399"
+	params.Interval = 100 * time.Millisecond
+
+	nodes := make([]*enode.Node, 2)
+	kads := make([]*network.Kademlia, 2)
+	for i := range nodes {
+		nodes[i] = newTestNode(t, net.IPv4(127, 0, 0, 1), 30399+i)
+		var key network.ENRAddrEntry
+		if err := nodes[i].Load(&key); err != nil {
+			t.Fatal(err)
+		}
+		kads[i] = network.NewKademlia(key.Address(), network.NewKadParams())
+
+		l := NewLAN(kads[i], params)
+		n := nodes[i]
+		if err := l.Start(func() *enode.Node { return n }); err != nil {
+			t.Skipf("multicast is not available: %v", err)
+		}
+		defer l.Stop()
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if len(kadAddrs(kads[0])) == 1 && len(kadAddrs(kads[1])) == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Skip("no announcements received, multicast is probably not routed")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	for i, kad := range kads {
+		for _, a := range kadAddrs(kad) {
+			var key network.ENRAddrEntry
+			if err := nodes[1-i].Load(&key); err != nil {
+				t.Fatal(err)
+			}
+			if string(a.Address()) != string(key.Address()) {
+				t.Fatalf("node %v registered %x, want %x", i, a.Address(), key.Address())
+			}
+		}
+	}
+}
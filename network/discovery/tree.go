@@ -0,0 +1,287 @@
+// Copyright 2019 The Swarm Authors
+// This file is part of the Swarm library.
+//
+// The Swarm library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Swarm library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Swarm library. If not, see <http://www.gnu.org/licenses/>.
+
+package discovery
+
+import (
+	"crypto/ecdsa"
+	"encoding/base32"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+)
+
+// Entries of the node record tree, as TXT records
+// in the format described in EIP-1459:
+//
+//	enrtree-root:v1 e=<enr-root> l=<link-root> seq=<sequence-number> sig=<signature>
+//	enrtree-branch:<h1>,<h2>,...,<hN>
+//	enr:<node-record>
+//	enrtree://<key>@<fqdn>
+//
+// The root record is stored at the domain of the tree and all other
+// entries at subdomains named by the hashes of their content.
+const (
+	rootPrefix   = "enrtree-root:v1"
+	branchPrefix = "enrtree-branch:"
+	linkPrefix   = "enrtree://"
+	enrPrefix    = "enr:"
+)
+
+const (
+	// maxChildren is the maximum number of hashes in a branch entry,
+	// so that the entry fits in a single TXT record string
+	maxChildren = 370 / 27
+
+	// sigLength is the length of a signature with the recovery id
+	sigLength = 65
+)
+
+var (
+	b32format = base32.StdEncoding.WithPadding(base32.NoPadding)
+	b64format = base64.RawURLEncoding
+
+	errInvalidSig = errors.New("invalid tree root signature")
+)
+
+type entry interface {
+	fmt.Stringer
+}
+
+type (
+	rootEntry struct {
+		eroot string
+		lroot string
+		seq   uint
+		sig   []byte
+	}
+	branchEntry struct {
+		children []string
+	}
+	linkEntry struct {
+		domain string
+		pubkey *ecdsa.PublicKey
+	}
+	enrEntry struct {
+		node *enode.Node
+	}
+)
+
+func (e *rootEntry) sigHash() []byte {
+	return crypto.Keccak256([]byte(fmt.Sprintf("%s e=%s l=%s seq=%d", rootPrefix, e.eroot, e.lroot, e.seq)))
+}
+
+func (e *rootEntry) String() string {
+	return fmt.Sprintf("%s e=%s l=%s seq=%d sig=%s", rootPrefix, e.eroot, e.lroot, e.seq, b64format.EncodeToString(e.sig))
+}
+
+// verifySignature returns true if the root is signed by the key.
+func (e *rootEntry) verifySignature(pubkey *ecdsa.PublicKey) bool {
+	if len(e.sig) != sigLength {
+		return false
+	}
+	// the recovery id is not used for verification
+	return crypto.VerifySignature(crypto.FromECDSAPub(pubkey), e.sigHash(), e.sig[:sigLength-1])
+}
+
+func (e *branchEntry) String() string {
+	return branchPrefix + strings.Join(e.children, ",")
+}
+
+func (e *linkEntry) String() string {
+	return linkPrefix + b32format.EncodeToString(crypto.CompressPubkey(e.pubkey)) + "@" + e.domain
+}
+
+func (e *enrEntry) String() string {
+	return e.node.String()
+}
+
+// subdomain returns the name of the subdomain at which the entry is stored.
+func subdomain(e entry) string {
+	return hashTXT(e.String())
+}
+
+// hashTXT returns the base32 encoded first 16 bytes of the hash of the text.
+func hashTXT(s string) string {
+	return b32format.EncodeToString(crypto.Keccak256([]byte(s))[:16])
+}
+
+// parseEntry parses a TXT record of a tree entry other than the root.
+func parseEntry(s string) (entry, error) {
+	switch {
+	case strings.HasPrefix(s, linkPrefix):
+		return parseLink(s)
+	case strings.HasPrefix(s, branchPrefix):
+		return parseBranch(s)
+	case strings.HasPrefix(s, enrPrefix):
+		n, err := enode.Parse(enode.ValidSchemes, s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid node record: %v", err)
+		}
+		return &enrEntry{node: n}, nil
+	}
+	return nil, fmt.Errorf("unknown tree entry %q", s)
+}
+
+func parseRoot(s string) (*rootEntry, error) {
+	var e rootEntry
+	var sig string
+	if _, err := fmt.Sscanf(s, rootPrefix+" e=%s l=%s seq=%d sig=%s", &e.eroot, &e.lroot, &e.seq, &sig); err != nil {
+		return nil, fmt.Errorf("invalid tree root %q: %v", s, err)
+	}
+	if !isHash(e.eroot) || !isHash(e.lroot) {
+		return nil, fmt.Errorf("invalid tree root %q: invalid hash", s)
+	}
+	var err error
+	e.sig, err = b64format.DecodeString(sig)
+	if err != nil || len(e.sig) != sigLength {
+		return nil, errInvalidSig
+	}
+	return &e, nil
+}
+
+func parseBranch(s string) (*branchEntry, error) {
+	s = strings.TrimPrefix(s, branchPrefix)
+	if s == "" {
+		return &branchEntry{}, nil
+	}
+	children := strings.Split(s, ",")
+	for _, c := range children {
+		if !isHash(c) {
+			return nil, fmt.Errorf("invalid tree branch child %q", c)
+		}
+	}
+	return &branchEntry{children: children}, nil
+}
+
+// parseLink parses a link to a tree in the enrtree://<key>@<domain> format.
+func parseLink(s string) (*linkEntry, error) {
+	if !strings.HasPrefix(s, linkPrefix) {
+		return nil, fmt.Errorf("invalid tree link %q: missing %s prefix", s, linkPrefix)
+	}
+	parts := strings.SplitN(strings.TrimPrefix(s, linkPrefix), "@", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return nil, fmt.Errorf("invalid tree link %q: missing domain", s)
+	}
+	keybytes, err := b32format.DecodeString(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("invalid tree link %q: invalid public key", s)
+	}
+	pubkey, err := crypto.DecompressPubkey(keybytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tree link %q: invalid public key", s)
+	}
+	return &linkEntry{domain: parts[1], pubkey: pubkey}, nil
+}
+
+func isHash(s string) bool {
+	b, err := b32format.DecodeString(s)
+	return err == nil && len(b) >= 12 && len(b) <= 32
+}
+
+// Tree is a node record tree that can be published in DNS.
+type Tree struct {
+	root    *rootEntry
+	entries map[string]entry
+}
+
+// MakeTree creates a tree with the sequence number that contains the node
+// records and the links to other trees in the enrtree://<key>@<domain> format.
+// The tree must be signed before it is published.
+func MakeTree(seq uint, nodes []*enode.Node, links []string) (*Tree, error) {
+	t := &Tree{entries: make(map[string]entry)}
+
+	records := make([]entry, 0, len(nodes))
+	for _, n := range nodes {
+		e := &enrEntry{node: n}
+		if !strings.HasPrefix(e.String(), enrPrefix) {
+			return nil, fmt.Errorf("node %s has no signed record", n.ID())
+		}
+		records = append(records, e)
+	}
+	// sort the records so that the same nodes make the same tree
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].String() < records[j].String()
+	})
+
+	linkEntries := make([]entry, 0, len(links))
+	for _, l := range links {
+		e, err := parseLink(l)
+		if err != nil {
+			return nil, err
+		}
+		linkEntries = append(linkEntries, e)
+	}
+
+	t.root = &rootEntry{
+		eroot: subdomain(t.addSubtree(records)),
+		lroot: subdomain(t.addSubtree(linkEntries)),
+		seq:   seq,
+	}
+	return t, nil
+}
+
+// addSubtree adds the entries to the tree under branches
+// with at most maxChildren children and returns the subtree root.
+func (t *Tree) addSubtree(entries []entry) entry {
+	if len(entries) == 1 {
+		t.entries[subdomain(entries[0])] = entries[0]
+		return entries[0]
+	}
+	if len(entries) <= maxChildren {
+		b := &branchEntry{children: make([]string, len(entries))}
+		for i, e := range entries {
+			b.children[i] = subdomain(e)
+			t.entries[b.children[i]] = e
+		}
+		t.entries[subdomain(b)] = b
+		return b
+	}
+	var roots []entry
+	for len(entries) > 0 {
+		n := maxChildren
+		if len(entries) < n {
+			n = len(entries)
+		}
+		roots = append(roots, t.addSubtree(entries[:n]))
+		entries = entries[n:]
+	}
+	return t.addSubtree(roots)
+}
+
+// Sign signs the tree root with the key and returns the link to the tree
+// that is published at the domain.
+func (t *Tree) Sign(key *ecdsa.PrivateKey, domain string) (link string, err error) {
+	t.root.sig, err = crypto.Sign(t.root.sigHash(), key)
+	if err != nil {
+		return "", err
+	}
+	return (&linkEntry{domain: domain, pubkey: &key.PublicKey}).String(), nil
+}
+
+// ToTXT returns the TXT records of the tree by their domain names.
+func (t *Tree) ToTXT(domain string) map[string]string {
+	records := map[string]string{domain: t.root.String()}
+	for name, e := range t.entries {
+		records[name+"."+domain] = e.String()
+	}
+	return records
+}
@@ -200,7 +200,10 @@ func (h *Hive) Run(p *BzzPeer) error {
 			// otherwise just send depth to new peer
 			dp.NotifyDepth(depth)
 		}
-		h.NotifyPeer(p.BzzAddr)
+		// light nodes are not advertised, as they do not serve other nodes
+		if !p.IsLightNode() {
+			h.NotifyPeer(p.BzzAddr)
+		}
 	}
 	defer h.Off(dp)
 	return dp.Run(h.handleMsg(dp))
@@ -373,11 +376,17 @@ func (h *Hive) handlePeersMsg(d *Peer, msg *peersMsg) error {
 	if len(msg.Peers) == 0 {
 		return nil
 	}
+	peers := make([]*BzzAddr, 0, len(msg.Peers))
 	for _, a := range msg.Peers {
 		d.seen(a)
+		// light nodes are not dialed
+		if a.IsLightNode() {
+			continue
+		}
 		h.NotifyPeer(a)
+		peers = append(peers, a)
 	}
-	return h.Register(msg.Peers...)
+	return h.Register(peers...)
 }
 
 // handleSubPeersMsg handles incoming subPeersMsg
@@ -406,6 +415,9 @@ func (h *Hive) handleSubPeersMsg(ctx context.Context, d *Peer, msg *subPeersMsg)
 		if uint8(po) < msg.Depth {
 			return false
 		}
+		if p.IsLightNode() {
+			return true
+		}
 		if !d.seen(p.BzzAddr) { // here just records the peer sent
 			peers = append(peers, p.BzzAddr)
 		}
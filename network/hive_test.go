@@ -28,6 +28,7 @@ import (
 	"github.com/ethereum/go-ethereum/p2p"
 	"github.com/ethereum/go-ethereum/p2p/enode"
 	"github.com/ethersphere/swarm/log"
+	"github.com/ethersphere/swarm/network/capability"
 	p2ptest "github.com/ethersphere/swarm/p2p/testing"
 	"github.com/ethersphere/swarm/pot"
 	"github.com/ethersphere/swarm/state"
@@ -319,3 +320,29 @@ func newConnPeerLocal(addr []byte, kademlia *Kademlia) *Peer {
 	peer.kad = kademlia
 	return peer
 }
+
+// TestHiveLightNodePeers verifies that the received addresses of
+// light nodes are not registered, as light nodes are not dialed
+func TestHiveLightNodePeers(t *testing.T) {
+	to := NewKademlia(RandomBzzAddr().Over(), NewKadParams())
+	h := NewHive(NewHiveParams(), to, nil)
+
+	fullCaps := capability.NewCapabilities()
+	fullCaps.Add(newFullCapability())
+	full := RandomBzzAddr().WithCapabilities(fullCaps)
+	light := RandomBzzAddr().WithCapabilities(NewLightCapabilities())
+
+	d := NewPeer(&BzzPeer{BzzAddr: RandomBzzAddr()}, to)
+	if err := h.handlePeersMsg(d, &peersMsg{Peers: []*BzzAddr{full, light}}); err != nil {
+		t.Fatal(err)
+	}
+
+	var registered []*BzzAddr
+	to.EachAddr(nil, 255, func(a *BzzAddr, _ int) bool {
+		registered = append(registered, a)
+		return true
+	})
+	if len(registered) != 1 || !registered[0].Match(full) {
+		t.Fatalf("got registered addresses %v, want only %v", registered, full)
+	}
+}
@@ -40,3 +40,25 @@ func (b *BzzAddr) Match(bcmp *BzzAddr) bool {
 	}
 	return true
 }
+
+// TestBzzAddrIsLightNode verifies that only addresses with
+// the light node capabilities are reported as light nodes
+func TestBzzAddrIsLightNode(t *testing.T) {
+	fullCaps := capability.NewCapabilities()
+	fullCaps.Add(fullCapability)
+	for _, tc := range []struct {
+		name string
+		caps *capability.Capabilities
+		want bool
+	}{
+		{name: "light", caps: NewLightCapabilities(), want: true},
+		{name: "full", caps: fullCaps, want: false},
+		{name: "empty", caps: capability.NewCapabilities(), want: false},
+		{name: "nil", caps: nil, want: false},
+	} {
+		addr := RandomBzzAddr().WithCapabilities(tc.caps)
+		if got := addr.IsLightNode(); got != tc.want {
+			t.Errorf("%s: got light node %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
@@ -92,6 +92,27 @@ func isLightCapability(c *capability.Capability) bool {
 	return lightCapability.IsSameAs(c)
 }
 
+// NewLightCapabilities returns the capabilities advertised by a light node
+func NewLightCapabilities() *capability.Capabilities {
+	c := capability.NewCapabilities()
+	c.Add(newLightCapability())
+	return c
+}
+
+// IsLightNode returns true if the capabilities are the ones of a light node,
+// which neither stores nor serves chunks for other nodes.
+func IsLightNode(c *capability.Capabilities) bool {
+	if c == nil {
+		return false
+	}
+	return isLightCapability(c.Get(CapabilityID))
+}
+
+// IsLightNode returns true if the address advertises the light node capabilities
+func (a *BzzAddr) IsLightNode() bool {
+	return IsLightNode(a.Capabilities)
+}
+
 // temporary convenience functions for legacy "full node"
 func newFullCapability() *capability.Capability {
 	c := capability.NewCapability(CapabilityID, 16)
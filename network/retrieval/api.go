@@ -0,0 +1,47 @@
+// Copyright 2019 The Swarm Authors
+// This file is part of the Swarm library.
+//
+// The Swarm library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Swarm library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Swarm library. If not, see <http://www.gnu.org/licenses/>.
+
+package retrieval
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ethersphere/swarm/chunk"
+)
+
+// API is the RPC API of the retrieval protocol.
+type API struct {
+	retrieval *Retrieval
+}
+
+// NewAPI creates a new API instance.
+func NewAPI(r *Retrieval) *API {
+	return &API{
+		retrieval: r,
+	}
+}
+
+// Replication returns the number of nodes in the neighbourhood
+// of every chunk that store it.
+func (a *API) Replication(ctx context.Context, addrs []chunk.Address) ([]ChunkReplication, error) {
+	for _, addr := range addrs {
+		if len(addr) != chunk.AddressLength {
+			return nil, fmt.Errorf("invalid chunk address length %v", len(addr))
+		}
+	}
+	return a.retrieval.Replication(ctx, addrs...)
+}
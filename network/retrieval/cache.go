@@ -0,0 +1,225 @@
+// Copyright 2019 The Swarm Authors
+// This file is part of the Swarm library.
+//
+// The Swarm library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Swarm library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Swarm library. If not, see <http://www.gnu.org/licenses/>.
+
+package retrieval
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethersphere/swarm/chunk"
+	"github.com/ethersphere/swarm/log"
+	"github.com/hashicorp/golang-lru/simplelru"
+)
+
+var (
+	cacheAdmittedCount = metrics.NewRegisteredCounter("network/retrieve/cache/admitted", nil)
+	cacheRejectedCount = metrics.NewRegisteredCounter("network/retrieve/cache/rejected", nil)
+	cacheEvictedCount  = metrics.NewRegisteredCounter("network/retrieve/cache/evicted", nil)
+)
+
+// CacheParams configure the admission of chunks that are retrieved for
+// requests and are outside of the neighbourhood depth to the local store.
+type CacheParams struct {
+	// Capacity is the maximum number of cached chunks. It is accounted
+	// separately from the chunks within the neighbourhood depth, so that
+	// cached chunks do not evict them.
+	Capacity int
+	// MinRequests is the number of requests for a chunk that must be seen
+	// before it can replace another chunk in a full cache.
+	MinRequests int
+	// TrackedRequests is the number of chunk addresses
+	// for which the request counts are kept.
+	TrackedRequests int
+}
+
+// NewCacheParams returns the default cache parameters.
+func NewCacheParams() *CacheParams {
+	return &CacheParams{
+		Capacity:        100000,
+		MinRequests:     2,
+		TrackedRequests: 100000,
+	}
+}
+
+// IterateCachedFunc calls fn with the addresses of cached chunks and the
+// time they were cached at until fn returns true or an error. It is
+// implemented by localstore.DB IterateCached method.
+type IterateCachedFunc func(fn func(addr chunk.Address, cachedAt int64) (stop bool, err error)) error
+
+// cache decides which chunks retrieved for requests are stored, based on
+// their popularity, proximity and the available cache capacity. Cached
+// chunks are stored with chunk.ModePutCache, so that the store keeps them
+// out of the garbage collection and persists the cache membership, which
+// is loaded on start.
+type cache struct {
+	params      *CacheParams
+	baseAddr    []byte
+	store       chunk.Store
+	withinDepth func(addr chunk.Address) bool
+
+	mu       sync.Mutex
+	requests *simplelru.LRU // request counts by chunk address
+	cached   *simplelru.LRU // addresses of cached chunks, least recently requested first
+}
+
+// newCache returns a new cache that evicts chunks from the store, unless
+// they are within the neighbourhood depth as reported by withinDepth.
+// It returns nil if the params do not allow any chunks to be cached.
+func newCache(params *CacheParams, baseAddr []byte, store chunk.Store, withinDepth func(addr chunk.Address) bool) *cache {
+	if params == nil || params.Capacity <= 0 || params.TrackedRequests <= 0 {
+		return nil
+	}
+	requests, _ := simplelru.NewLRU(params.TrackedRequests, nil)
+	cached, _ := simplelru.NewLRU(params.Capacity, nil)
+	return &cache{
+		params:      params,
+		baseAddr:    baseAddr,
+		store:       store,
+		withinDepth: withinDepth,
+		requests:    requests,
+		cached:      cached,
+	}
+}
+
+// load adds the chunks persisted in the store to the cache, the least
+// recently cached first, and evicts the ones that exceed the capacity.
+func (c *cache) load(iterate IterateCachedFunc) error {
+	type entry struct {
+		addr     chunk.Address
+		cachedAt int64
+	}
+	var entries []entry
+	err := iterate(func(addr chunk.Address, cachedAt int64) (stop bool, err error) {
+		entries = append(entries, entry{addr: addr, cachedAt: cachedAt})
+		return false, nil
+	})
+	if err != nil {
+		return err
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].cachedAt < entries[j].cachedAt
+	})
+	var excess []chunk.Address
+	c.mu.Lock()
+	for _, e := range entries {
+		if c.cached.Len() >= c.params.Capacity {
+			oldest, _, _ := c.cached.RemoveOldest()
+			excess = append(excess, chunk.Address(oldest.(string)))
+		}
+		c.cached.Add(string(e.addr), nil)
+	}
+	c.mu.Unlock()
+
+	for _, addr := range excess {
+		c.evict(addr)
+	}
+	return nil
+}
+
+// uncacheAll removes all chunks persisted in the store from the cache,
+// leaving them to the garbage collection. It is used when the cache is
+// disabled.
+func uncacheAll(store chunk.Store, iterate IterateCachedFunc) error {
+	var addrs []chunk.Address
+	err := iterate(func(addr chunk.Address, _ int64) (stop bool, err error) {
+		addrs = append(addrs, addr)
+		return false, nil
+	})
+	if err != nil || len(addrs) == 0 {
+		return err
+	}
+	return store.Set(context.Background(), chunk.ModeSetUncache, addrs...)
+}
+
+// requested counts a request for the chunk.
+func (c *cache) requested(addr chunk.Address) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := string(addr)
+	c.requests.Add(key, c.count(key)+1)
+	// update the recency of a cached chunk
+	c.cached.Get(key)
+}
+
+// count returns the number of requests seen for the chunk.
+// It must be called with the mutex locked.
+func (c *cache) count(key string) int {
+	if v, ok := c.requests.Peek(key); ok {
+		return v.(int)
+	}
+	return 0
+}
+
+// score returns the value of caching the chunk. Chunks closer to the base
+// address are worth more, as requests for them are more likely to be
+// routed through this node. It must be called with the mutex locked.
+func (c *cache) score(key string) int {
+	return c.count(key) * (chunk.Proximity(c.baseAddr, []byte(key)) + 1)
+}
+
+// admit returns true if the chunk should be stored. Chunks are admitted
+// while the cache is not full. When it is full, a chunk is admitted only if
+// it is requested at least MinRequests times and it has a higher score than
+// the least recently requested cached chunk, which is evicted.
+func (c *cache) admit(addr chunk.Address) bool {
+	c.mu.Lock()
+	key := string(addr)
+	if c.cached.Contains(key) {
+		c.mu.Unlock()
+		return true
+	}
+	if c.cached.Len() < c.params.Capacity {
+		c.cached.Add(key, nil)
+		c.mu.Unlock()
+		cacheAdmittedCount.Inc(1)
+		return true
+	}
+	oldest, _, _ := c.cached.GetOldest()
+	victim := oldest.(string)
+	if c.count(key) < c.params.MinRequests || c.score(key) <= c.score(victim) {
+		c.mu.Unlock()
+		cacheRejectedCount.Inc(1)
+		return false
+	}
+	c.cached.RemoveOldest()
+	c.cached.Add(key, nil)
+	c.mu.Unlock()
+
+	cacheAdmittedCount.Inc(1)
+	c.evict(chunk.Address(victim))
+	return true
+}
+
+// evict removes the chunk from the store, unless it is within the
+// neighbourhood depth, as it is in the reserve and is only removed
+// from the cache, leaving it to the garbage collection.
+func (c *cache) evict(addr chunk.Address) {
+	if c.withinDepth(addr) {
+		if err := c.store.Set(context.Background(), chunk.ModeSetUncache, addr); err != nil {
+			log.Error("retrieval cache uncache", "addr", addr, "err", err)
+		}
+		return
+	}
+	if err := c.store.Set(context.Background(), chunk.ModeSetEvict, addr); err != nil {
+		log.Error("retrieval cache evict", "addr", addr, "err", err)
+		return
+	}
+	cacheEvictedCount.Inc(1)
+}
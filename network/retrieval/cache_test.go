@@ -0,0 +1,179 @@
+// Copyright 2019 The Swarm Authors
+// This file is part of the Swarm library.
+//
+// The Swarm library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Swarm library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Swarm library. If not, see <http://www.gnu.org/licenses/>.
+
+package retrieval
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/ethersphere/swarm/chunk"
+)
+
+// evictRecordingStore records addresses of chunks
+// set with ModeSetEvict and ModeSetUncache.
+type evictRecordingStore struct {
+	chunk.Store
+	evicted  []chunk.Address
+	uncached []chunk.Address
+}
+
+func (s *evictRecordingStore) Set(_ context.Context, mode chunk.ModeSet, addrs ...chunk.Address) error {
+	switch mode {
+	case chunk.ModeSetEvict:
+		s.evicted = append(s.evicted, addrs...)
+	case chunk.ModeSetUncache:
+		s.uncached = append(s.uncached, addrs...)
+	}
+	return nil
+}
+
+// testCacheAddr returns an address with the given proximity to the zero address.
+func testCacheAddr(po int, suffix byte) chunk.Address {
+	addr := make(chunk.Address, chunk.AddressLength)
+	addr[po/8] = 0x80 >> uint(po%8)
+	addr[chunk.AddressLength-1] = suffix
+	return addr
+}
+
+// TestCacheAdmit validates the admission of chunks to a full cache
+// and that evicted chunks within depth are kept in the store.
+func TestCacheAdmit(t *testing.T) {
+	baseAddr := make([]byte, chunk.AddressLength)
+	store := new(evictRecordingStore)
+	withinDepth := func(addr chunk.Address) bool {
+		return chunk.Proximity(baseAddr, addr) >= 8
+	}
+	c := newCache(&CacheParams{
+		Capacity:        2,
+		MinRequests:     2,
+		TrackedRequests: 10,
+	}, baseAddr, store, withinDepth)
+
+	far1 := testCacheAddr(0, 1)
+	far2 := testCacheAddr(0, 2)
+	near := testCacheAddr(8, 3)
+	closer := testCacheAddr(4, 4)
+
+	for _, addr := range []chunk.Address{far1, far2} {
+		if !c.admit(addr) {
+			t.Fatalf("chunk %v not admitted to a cache that is not full", addr)
+		}
+	}
+
+	// not enough requests
+	c.requested(near)
+	if c.admit(near) {
+		t.Fatal("chunk admitted with less than min requests")
+	}
+
+	// far1 is the least recently requested and is evicted
+	c.requested(far2)
+	c.requested(near)
+	if !c.admit(near) {
+		t.Fatal("chunk with a higher score not admitted")
+	}
+	if len(store.evicted) != 1 || !bytes.Equal(store.evicted[0], far1) {
+		t.Fatalf("got evicted chunks %v, want %v", store.evicted, far1)
+	}
+
+	// far2 has a score of 1 and closer a score of 2 * 5
+	c.requested(closer)
+	c.requested(closer)
+	if !c.admit(closer) {
+		t.Fatal("closer chunk not admitted")
+	}
+	if len(store.evicted) != 2 || !bytes.Equal(store.evicted[1], far2) {
+		t.Fatalf("got evicted chunks %v, want %v", store.evicted, far2)
+	}
+
+	// near is evicted from the cache, but not from the store
+	// as it is within depth
+	farther := testCacheAddr(1, 5)
+	for i := 0; i < 10; i++ {
+		c.requested(farther)
+	}
+	c.requested(closer)
+	if !c.admit(farther) {
+		t.Fatal("popular chunk not admitted")
+	}
+	if len(store.evicted) != 2 {
+		t.Fatalf("got evicted chunks %v, want none within depth", store.evicted[2:])
+	}
+	if len(store.uncached) != 1 || !bytes.Equal(store.uncached[0], near) {
+		t.Fatalf("got uncached chunks %v, want %v", store.uncached, near)
+	}
+}
+
+// TestCacheLoad validates that the chunks cached before are loaded
+// in the order they were cached at and that the excess is evicted.
+func TestCacheLoad(t *testing.T) {
+	baseAddr := make([]byte, chunk.AddressLength)
+	store := new(evictRecordingStore)
+	c := newCache(&CacheParams{
+		Capacity:        2,
+		MinRequests:     2,
+		TrackedRequests: 10,
+	}, baseAddr, store, func(chunk.Address) bool { return false })
+
+	oldest := testCacheAddr(0, 1)
+	older := testCacheAddr(0, 2)
+	newest := testCacheAddr(0, 3)
+	err := c.load(func(fn func(addr chunk.Address, cachedAt int64) (stop bool, err error)) error {
+		for _, e := range []struct {
+			addr     chunk.Address
+			cachedAt int64
+		}{
+			{newest, 3},
+			{oldest, 1},
+			{older, 2},
+		} {
+			if stop, err := fn(e.addr, e.cachedAt); stop || err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(store.evicted) != 1 || !bytes.Equal(store.evicted[0], oldest) {
+		t.Fatalf("got evicted chunks %v, want %v", store.evicted, oldest)
+	}
+	for _, addr := range []chunk.Address{older, newest} {
+		if !c.admit(addr) {
+			t.Errorf("loaded chunk %v not in the cache", addr)
+		}
+	}
+	if c.admit(testCacheAddr(0, 4)) {
+		t.Error("chunk admitted to a full loaded cache")
+	}
+}
+
+// TestCacheDisabled validates that no cache
+// is created with params that do not allow it.
+func TestCacheDisabled(t *testing.T) {
+	for _, params := range []*CacheParams{
+		nil,
+		{Capacity: 0, TrackedRequests: 10},
+		{Capacity: 10, TrackedRequests: 0},
+	} {
+		if c := newCache(params, nil, nil, nil); c != nil {
+			t.Errorf("got cache for params %+v", params)
+		}
+	}
+}
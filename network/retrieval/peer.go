@@ -20,6 +20,7 @@ import (
 	"bytes"
 	"errors"
 	"sync"
+	"time"
 
 	"github.com/ethersphere/swarm/chunk"
 	"github.com/ethersphere/swarm/log"
@@ -27,6 +28,18 @@ import (
 	"github.com/ethersphere/swarm/storage"
 )
 
+// cancelledRetrievalTTL is the time for which late deliveries
+// of cancelled retrievals are not treated as unsolicited
+const cancelledRetrievalTTL = 30 * time.Second
+
+// errCancelledRetrieval is returned by checkRequest
+// for a delivery of a cancelled retrieval
+var errCancelledRetrieval = errors.New("retrieval cancelled")
+
+// errExpiredProbe is returned by checkRequest
+// for a delivery of an expired probe
+var errExpiredProbe = errors.New("probe expired")
+
 // Peer wraps BzzPeer with a contextual logger and tracks open
 // retrievals for that peer
 type Peer struct {
@@ -34,6 +47,22 @@ type Peer struct {
 	logger     log.Logger             // logger with base and peer address
 	mtx        sync.Mutex             // synchronize retrievals
 	retrievals map[uint]chunk.Address // current ongoing retrievals
+	cancelled  map[uint]cancelled     // cancelled retrievals that may still be delivered
+	has        map[uint]chan []byte   // current ongoing has requests
+	probes     map[uint]*probe        // current ongoing probes
+}
+
+// cancelled is a retrieval or a probe that was cancelled at the given time
+type cancelled struct {
+	addr  chunk.Address
+	at    time.Time
+	probe bool
+}
+
+// probe is a retrieval of a chunk that is not stored when it is delivered
+type probe struct {
+	addr      chunk.Address
+	delivered chan []byte // receives the chunk data
 }
 
 // NewPeer is the constructor for Peer
@@ -42,6 +71,9 @@ func NewPeer(peer *network.BzzPeer, baseKey *network.BzzAddr) *Peer {
 		BzzPeer:    peer,
 		logger:     log.NewBaseAddressLogger(baseKey.ShortString(), "peer", peer.BzzAddr.ShortString()),
 		retrievals: make(map[uint]chunk.Address),
+		cancelled:  make(map[uint]cancelled),
+		has:        make(map[uint]chan []byte),
+		probes:     make(map[uint]*probe),
 	}
 }
 
@@ -60,6 +92,34 @@ func (p *Peer) expireRetrieval(ruid uint) {
 	delete(p.retrievals, ruid)
 }
 
+// cancelRetrieval expires a retrieval that may still be delivered,
+// as the chunk was requested from multiple peers or the request timed out,
+// so that its late delivery is not treated as unsolicited
+func (p *Peer) cancelRetrieval(ruid uint) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	addr, ok := p.retrievals[ruid]
+	if !ok {
+		return
+	}
+	delete(p.retrievals, ruid)
+	p.addCancelled(ruid, addr, false)
+}
+
+// addCancelled marks the retrieval as cancelled and removes
+// the ones that were cancelled before cancelledRetrievalTTL.
+// It must be called with the lock held.
+func (p *Peer) addCancelled(ruid uint, addr chunk.Address, probe bool) {
+	now := time.Now()
+	for r, c := range p.cancelled {
+		if now.Sub(c.at) > cancelledRetrievalTTL {
+			delete(p.cancelled, r)
+		}
+	}
+	p.cancelled[ruid] = cancelled{addr: addr, at: now, probe: probe}
+}
+
 // chunkReceived is called upon ChunkDelivery message reception
 // it is meant to idenfify unsolicited chunk deliveries
 func (p *Peer) checkRequest(ruid uint, addr storage.Address) error {
@@ -67,6 +127,16 @@ func (p *Peer) checkRequest(ruid uint, addr storage.Address) error {
 	defer p.mtx.Unlock()
 	v, ok := p.retrievals[ruid]
 	if !ok {
+		if c, ok := p.cancelled[ruid]; ok {
+			delete(p.cancelled, ruid)
+			if !bytes.Equal(c.addr, addr) {
+				return errors.New("cancelled retrieve request found but address does not match")
+			}
+			if c.probe {
+				return errExpiredProbe
+			}
+			return errCancelledRetrieval
+		}
 		return errors.New("cannot find ruid")
 	}
 	delete(p.retrievals, ruid) // since we got the delivery we wanted - it is safe to delete the retrieve request
@@ -76,3 +146,79 @@ func (p *Peer) checkRequest(ruid uint, addr storage.Address) error {
 
 	return nil
 }
+
+// addHasRequest adds a new has request and returns
+// the channel on which the response bit vector is received
+func (p *Peer) addHasRequest(ruid uint) <-chan []byte {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	c := make(chan []byte, 1)
+	p.has[ruid] = c
+	return c
+}
+
+func (p *Peer) expireHasRequest(ruid uint) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	delete(p.has, ruid)
+}
+
+// deliverHasResponse passes the bit vector to the has request with
+// the ruid and returns false if the request is not found
+func (p *Peer) deliverHasResponse(ruid uint, bitVector []byte) bool {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	c, ok := p.has[ruid]
+	if !ok {
+		return false
+	}
+	delete(p.has, ruid)
+	c <- bitVector
+	return true
+}
+
+// addProbe adds a new probe and returns the channel
+// on which the delivered chunk data is received
+func (p *Peer) addProbe(ruid uint, addr chunk.Address) <-chan []byte {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	c := make(chan []byte, 1)
+	p.probes[ruid] = &probe{
+		addr:      addr,
+		delivered: c,
+	}
+	return c
+}
+
+// expireProbe removes the probe, so that its late
+// delivery is treated as a cancelled retrieval
+func (p *Peer) expireProbe(ruid uint) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	pr, ok := p.probes[ruid]
+	if !ok {
+		return
+	}
+	delete(p.probes, ruid)
+	p.addCancelled(ruid, pr.addr, true)
+}
+
+// deliverProbe passes the chunk data to the probe with the ruid
+// and returns false if the delivery is not for a probe
+func (p *Peer) deliverProbe(ruid uint, addr chunk.Address, data []byte) bool {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	pr, ok := p.probes[ruid]
+	if !ok || !bytes.Equal(pr.addr, addr) {
+		return false
+	}
+	delete(p.probes, ruid)
+	pr.delivered <- data
+	return true
+}
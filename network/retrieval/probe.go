@@ -0,0 +1,105 @@
+// Copyright 2019 The Swarm Authors
+// This file is part of the Swarm library.
+//
+// The Swarm library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Swarm library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Swarm library. If not, see <http://www.gnu.org/licenses/>.
+
+package retrieval
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"time"
+
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethersphere/swarm/chunk"
+	"github.com/ethersphere/swarm/storage"
+)
+
+// probePeers is the maximum number of peers that a chunk is requested from by Probe
+const probePeers = 3
+
+var (
+	// probeTimeout is the time to wait for the chunk delivery from a single peer
+	probeTimeout = 3 * time.Second
+
+	probeCount     = metrics.NewRegisteredCounter("network/retrieve/probe", nil)
+	probeFailCount = metrics.NewRegisteredCounter("network/retrieve/probe/fail", nil)
+)
+
+// Probe retrieves the chunk from the peers, without looking it up in
+// or storing it to the local store, in order to check if the chunk is
+// retrievable from the network. The chunk is requested from up to
+// probePeers peers, one after another, with a short timeout.
+func (r *Retrieval) Probe(ctx context.Context, addr chunk.Address) (chunk.Chunk, error) {
+	probeCount.Inc(1)
+
+	req := storage.NewRequest(addr)
+	for i := 0; i < probePeers; i++ {
+		sp, err := r.findPeerLB(ctx, req)
+		if err != nil {
+			r.logger.Trace("retrieval.Probe - find peer", "ref", addr, "err", err)
+			break
+		}
+		req.PeersToSkip.Store(sp.ID().String(), time.Now())
+
+		p := r.getPeer(sp.ID())
+		if p == nil {
+			continue
+		}
+		ch, err := r.probePeer(ctx, p, addr)
+		if err == nil {
+			return ch, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		p.logger.Trace("retrieval.Probe", "ref", addr, "err", err)
+	}
+	probeFailCount.Inc(1)
+	return nil, chunk.ErrChunkNotFound
+}
+
+// probePeer requests the chunk from the peer and
+// waits for its delivery for at most probeTimeout.
+func (r *Retrieval) probePeer(ctx context.Context, p *Peer, addr chunk.Address) (chunk.Chunk, error) {
+	msg := &RetrieveRequest{
+		Ruid: uint(rand.Uint32()),
+		Addr: addr,
+	}
+	c := p.addProbe(msg.Ruid, addr)
+	defer p.expireProbe(msg.Ruid)
+
+	if err := p.Send(ctx, msg); err != nil {
+		return nil, err
+	}
+
+	timer := time.NewTimer(probeTimeout)
+	defer timer.Stop()
+
+	select {
+	case data := <-c:
+		ch := storage.NewChunk(addr, data)
+		if v, ok := r.netStore.Store.(chunk.Validator); ok && !v.Validate(ch) {
+			return nil, chunk.ErrChunkInvalid
+		}
+		return ch, nil
+	case <-timer.C:
+		return nil, errors.New("probe timed out")
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-r.quit:
+		return nil, errors.New("shutting down")
+	}
+}
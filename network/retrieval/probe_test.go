@@ -0,0 +1,89 @@
+// Copyright 2019 The Swarm Authors
+// This file is part of the Swarm library.
+//
+// The Swarm library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Swarm library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Swarm library. If not, see <http://www.gnu.org/licenses/>.
+
+package retrieval
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ethersphere/swarm/chunk"
+	"github.com/ethersphere/swarm/network/simulation"
+	"github.com/ethersphere/swarm/storage"
+)
+
+// TestProbe validates that a chunk is retrieved from a peer by Probe
+// without being stored locally, and that a chunk that no peer has is
+// reported as not found.
+func TestProbe(t *testing.T) {
+	defer func(t time.Duration) { probeTimeout = t }(probeTimeout)
+	probeTimeout = 500 * time.Millisecond
+
+	sim := simulation.NewBzzInProc(map[string]simulation.ServiceFunc{
+		"bzz-retrieve": newBzzRetrieveWithLocalstore,
+	}, true)
+	defer sim.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	ids, err := sim.AddNodesAndConnectFull(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := sim.Service("bzz-retrieve", ids[0]).(*Retrieval)
+	for {
+		r.mtx.RLock()
+		n := len(r.peers)
+		r.mtx.RUnlock()
+		if n == 1 {
+			break
+		}
+		select {
+		case <-time.After(10 * time.Millisecond):
+		case <-ctx.Done():
+			t.Fatal("peer not connected")
+		}
+	}
+
+	stored := storage.GenerateRandomChunk(chunk.DefaultSize)
+	missing := storage.GenerateRandomChunk(chunk.DefaultSize)
+	ns := sim.MustNodeItem(ids[1], bucketKeyNetstore).(*storage.NetStore)
+	if _, err := ns.Store.Put(ctx, chunk.ModePutUpload, stored); err != nil {
+		t.Fatal(err)
+	}
+
+	ch, err := r.Probe(ctx, stored.Address())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(ch.Data(), stored.Data()) {
+		t.Error("got probed chunk with different data")
+	}
+	has, err := r.netStore.Store.Has(ctx, stored.Address())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if has {
+		t.Error("probed chunk is stored locally")
+	}
+
+	if _, err := r.Probe(ctx, missing.Address()); err != chunk.ErrChunkNotFound {
+		t.Errorf("got error %v, want %v", err, chunk.ErrChunkNotFound)
+	}
+}
@@ -0,0 +1,237 @@
+// Copyright 2019 The Swarm Authors
+// This file is part of the Swarm library.
+//
+// The Swarm library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Swarm library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Swarm library. If not, see <http://www.gnu.org/licenses/>.
+
+package retrieval
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethersphere/swarm/chunk"
+	"github.com/ethersphere/swarm/network"
+	bv "github.com/ethersphere/swarm/network/bitvector"
+	"github.com/ethersphere/swarm/p2p/protocols"
+)
+
+// maxHasAddrs is the maximum number of chunk addresses in a single HasRequest
+const maxHasAddrs = 128
+
+var (
+	// hasRequestTimeout is the time to wait for the response to a HasRequest
+	hasRequestTimeout = 5 * time.Second
+
+	handleHasRequestMsgCount = metrics.NewRegisteredCounter("network/retrieve/handle_has_request_msg", nil)
+	hasRequestFail           = metrics.NewRegisteredCounter("network/retrieve/has_request_fail", nil)
+)
+
+// ChunkReplication holds the number of nodes in the
+// neighbourhood of a chunk that store it.
+type ChunkReplication struct {
+	Address    chunk.Address `json:"address"`
+	Replicas   int           `json:"replicas"`   // number of nodes that store the chunk, including this node
+	Neighbours int           `json:"neighbours"` // number of nodes that responded, including this node if it is in the neighbourhood
+}
+
+// Replication queries the nodes in the neighbourhood of every chunk, as
+// known from the kademlia table of this node, which of the chunks they
+// store. If the chunk is not within the depth of this node, its neighbourhood
+// is approximated by the connected peers that are the closest to the chunk.
+// Peers that do not respond are not counted as neighbours.
+func (r *Retrieval) Replication(ctx context.Context, addrs ...chunk.Address) ([]ChunkReplication, error) {
+	has, err := r.netStore.Store.HasMulti(ctx, addrs...)
+	if err != nil {
+		return nil, err
+	}
+
+	type query struct {
+		peer    *Peer
+		indexes []int // indexes of addrs
+	}
+	var queries []*query
+	peerQueries := make(map[enode.ID]*query)
+
+	result := make([]ChunkReplication, len(addrs))
+	for i, addr := range addrs {
+		result[i].Address = addr
+		peers, self := r.neighbourhood(addr)
+		if self {
+			result[i].Neighbours++
+			if has[i] {
+				result[i].Replicas++
+			}
+		}
+		for _, p := range peers {
+			q, ok := peerQueries[p.ID()]
+			if !ok || len(q.indexes) == maxHasAddrs {
+				q = &query{peer: p}
+				peerQueries[p.ID()] = q
+				queries = append(queries, q)
+			}
+			q.indexes = append(q.indexes, i)
+		}
+	}
+
+	var (
+		wg sync.WaitGroup
+		mu sync.Mutex // protects result
+	)
+	for _, q := range queries {
+		wg.Add(1)
+		go func(q *query) {
+			defer wg.Done()
+
+			qaddrs := make([]chunk.Address, len(q.indexes))
+			for j, i := range q.indexes {
+				qaddrs[j] = addrs[i]
+			}
+			stored, err := r.requestHas(ctx, q.peer, qaddrs)
+			if err != nil {
+				hasRequestFail.Inc(1)
+				q.peer.logger.Debug("retrieval.Replication - has request", "err", err)
+				return
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			for j, i := range q.indexes {
+				result[i].Neighbours++
+				if stored.Get(j) {
+					result[i].Replicas++
+				}
+			}
+		}(q)
+	}
+	wg.Wait()
+
+	return result, nil
+}
+
+// neighbourhood returns the connected peers in the neighbourhood of the chunk,
+// and whether this node is within it. If the chunk is not within the depth of
+// this node, the peers in the closest bin to the chunk are returned. Light
+// nodes, including this one, are not in any neighbourhood.
+func (r *Retrieval) neighbourhood(addr chunk.Address) (peers []*Peer, self bool) {
+	depth := r.kad.NeighbourhoodDepth()
+	self = !r.isLightNode() && chunk.Proximity(r.kad.BaseAddr(), addr) >= depth
+
+	minPo := -1
+	if self {
+		minPo = depth
+	}
+	r.kad.EachConn(addr, 255, func(p *network.Peer, po int) bool {
+		// light nodes do not store chunks for the network
+		if p.IsLightNode() {
+			return true
+		}
+		if minPo < 0 {
+			minPo = po
+		}
+		if po < minPo {
+			return false
+		}
+		if rp := r.getPeer(p.ID()); rp != nil {
+			peers = append(peers, rp)
+		}
+		return true
+	})
+	return peers, self
+}
+
+// requestHas sends a HasRequest to the peer and returns the
+// bit vector in which the bits of the stored chunks are set.
+func (r *Retrieval) requestHas(ctx context.Context, p *Peer, addrs []chunk.Address) (*bv.BitVector, error) {
+	msg := &HasRequest{
+		Ruid:  uint(rand.Uint32()),
+		Addrs: make([]byte, 0, len(addrs)*chunk.AddressLength),
+	}
+	for _, addr := range addrs {
+		msg.Addrs = append(msg.Addrs, addr...)
+	}
+
+	c := p.addHasRequest(msg.Ruid)
+	defer p.expireHasRequest(msg.Ruid)
+
+	if err := p.Send(ctx, msg); err != nil {
+		return nil, err
+	}
+
+	timer := time.NewTimer(hasRequestTimeout)
+	defer timer.Stop()
+
+	select {
+	case b := <-c:
+		return bv.NewFromBytes(b, len(addrs))
+	case <-timer.C:
+		return nil, errors.New("has request timed out")
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-r.quit:
+		return nil, errors.New("shutting down")
+	}
+}
+
+// handleHasRequest responds to the HasRequest
+// with the chunks that are in the local store
+func (r *Retrieval) handleHasRequest(ctx context.Context, p *Peer, msg *HasRequest) error {
+	p.logger.Trace("retrieval.handleHasRequest", "ruid", msg.Ruid)
+	handleHasRequestMsgCount.Inc(1)
+
+	if len(msg.Addrs) == 0 || len(msg.Addrs)%chunk.AddressLength != 0 {
+		return protocols.Break(fmt.Errorf("invalid has request addresses length %d, ruid %d", len(msg.Addrs), msg.Ruid))
+	}
+	count := len(msg.Addrs) / chunk.AddressLength
+	if count > maxHasAddrs {
+		return protocols.Break(fmt.Errorf("too many has request addresses %d, ruid %d", count, msg.Ruid))
+	}
+	addrs := make([]chunk.Address, count)
+	for i := range addrs {
+		addrs[i] = msg.Addrs[i*chunk.AddressLength : (i+1)*chunk.AddressLength]
+	}
+
+	has, err := r.netStore.Store.HasMulti(ctx, addrs...)
+	if err != nil {
+		return fmt.Errorf("has request ruid %d: %w", msg.Ruid, err)
+	}
+	stored, err := bv.New(count)
+	if err != nil {
+		return err
+	}
+	for i, h := range has {
+		if h {
+			stored.Set(i)
+		}
+	}
+
+	return p.Send(ctx, &HasResponse{
+		Ruid:      msg.Ruid,
+		BitVector: stored.Bytes(),
+	})
+}
+
+// handleHasResponse passes the response to the pending has request,
+// ignoring responses to requests that have timed out
+func (r *Retrieval) handleHasResponse(ctx context.Context, p *Peer, msg *HasResponse) error {
+	if !p.deliverHasResponse(msg.Ruid, msg.BitVector) {
+		p.logger.Trace("retrieval.handleHasResponse - has request not found", "ruid", msg.Ruid)
+	}
+	return nil
+}
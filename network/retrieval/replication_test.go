@@ -0,0 +1,121 @@
+// Copyright 2019 The Swarm Authors
+// This file is part of the Swarm library.
+//
+// The Swarm library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Swarm library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Swarm library. If not, see <http://www.gnu.org/licenses/>.
+
+package retrieval
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethersphere/swarm/chunk"
+	"github.com/ethersphere/swarm/network/simulation"
+	"github.com/ethersphere/swarm/storage"
+	"github.com/ethersphere/swarm/storage/localstore"
+)
+
+// TestReplication validates that the replication of chunks is counted
+// from the responses of the peers in the neighbourhood, and that the
+// chunks with too few replicas are push synced again.
+func TestReplication(t *testing.T) {
+	sim := simulation.NewBzzInProc(map[string]simulation.ServiceFunc{
+		"bzz-retrieve": newBzzRetrieveWithLocalstore,
+	}, true)
+	defer sim.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	ids, err := sim.AddNodesAndConnectFull(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range ids {
+		r := sim.Service("bzz-retrieve", id).(*Retrieval)
+		for {
+			r.mtx.RLock()
+			n := len(r.peers)
+			r.mtx.RUnlock()
+			if n == len(ids)-1 {
+				break
+			}
+			select {
+			case <-time.After(10 * time.Millisecond):
+			case <-ctx.Done():
+				t.Fatalf("node %s: got %v peers, want %v", id, n, len(ids)-1)
+			}
+		}
+	}
+
+	replicated := storage.GenerateRandomChunk(chunk.DefaultSize)
+	single := storage.GenerateRandomChunk(chunk.DefaultSize)
+	missing := storage.GenerateRandomChunk(chunk.DefaultSize)
+	putChunks := func(id enode.ID, chunks ...chunk.Chunk) {
+		t.Helper()
+		ns := sim.MustNodeItem(id, bucketKeyNetstore).(*storage.NetStore)
+		if _, err := ns.Store.Put(ctx, chunk.ModePutSync, chunks...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	putChunks(ids[0], replicated, single)
+	putChunks(ids[1], replicated)
+	putChunks(ids[2], replicated)
+
+	r := sim.Service("bzz-retrieve", ids[0]).(*Retrieval)
+	got, err := r.Replication(ctx, replicated.Address(), single.Address(), missing.Address())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range []ChunkReplication{
+		{Address: replicated.Address(), Replicas: 3, Neighbours: 3},
+		{Address: single.Address(), Replicas: 1, Neighbours: 3},
+		{Address: missing.Address(), Replicas: 0, Neighbours: 3},
+	} {
+		if !bytes.Equal(got[i].Address, want.Address) || got[i].Replicas != want.Replicas || got[i].Neighbours != want.Neighbours {
+			t.Errorf("chunk %v: got %+v, want %+v", i, got[i], want)
+		}
+	}
+
+	r.SetReplicationParams(&ReplicationParams{MinReplicas: 2, Interval: time.Hour}, func(start chunk.Address, fn func(chunk.Address) (bool, error)) error {
+		for _, ch := range []chunk.Chunk{replicated, single} {
+			if stop, err := fn(ch.Address()); stop || err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	db := r.netStore.Store.(*localstore.DB)
+	chunks, stop := db.SubscribePush(ctx)
+	defer stop()
+
+	checked, reuploaded, err := r.replicator.check(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if checked != 2 || reuploaded != 1 {
+		t.Errorf("got %v checked and %v reuploaded chunks, want 2 and 1", checked, reuploaded)
+	}
+	select {
+	case ch := <-chunks:
+		if !bytes.Equal(ch.Address(), single.Address()) {
+			t.Errorf("got reuploaded chunk %s, want %s", ch.Address(), single.Address())
+		}
+	case <-ctx.Done():
+		t.Fatal("chunk not reuploaded")
+	}
+}
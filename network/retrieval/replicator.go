@@ -0,0 +1,168 @@
+// Copyright 2019 The Swarm Authors
+// This file is part of the Swarm library.
+//
+// The Swarm library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Swarm library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Swarm library. If not, see <http://www.gnu.org/licenses/>.
+
+package retrieval
+
+import (
+	"context"
+	"time"
+
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethersphere/swarm/chunk"
+)
+
+var (
+	replicationCheckedCount    = metrics.NewRegisteredCounter("network/retrieve/replication/checked", nil)
+	replicationReuploadedCount = metrics.NewRegisteredCounter("network/retrieve/replication/reuploaded", nil)
+)
+
+// ReplicationParams configure the periodic checks of the replication
+// of pinned and uploaded chunks in their neighbourhoods.
+type ReplicationParams struct {
+	// MinReplicas is the minimum number of nodes in the neighbourhood of
+	// a chunk that should store it. Chunks with fewer replicas are push
+	// synced again, unless there are not enough nodes in the neighbourhood.
+	// Zero disables the checks, which is the default.
+	MinReplicas int
+	// Interval is the time between checks of all pinned and uploaded chunks.
+	Interval time.Duration
+}
+
+// NewReplicationParams returns the default replication check parameters,
+// with the checks disabled.
+func NewReplicationParams() *ReplicationParams {
+	return &ReplicationParams{
+		Interval: time.Hour,
+	}
+}
+
+// IterateUploadedFunc calls fn with the addresses of pinned and uploaded
+// chunks until fn returns true or an error, after the chunk with the start
+// address if it is not nil. It is implemented by localstore.DB
+// IterateUploaded method.
+type IterateUploadedFunc func(start chunk.Address, fn func(addr chunk.Address) (stop bool, err error)) error
+
+// replicator periodically checks the replication of pinned and uploaded
+// chunks, and push syncs again the chunks with too few replicas.
+type replicator struct {
+	retrieval *Retrieval
+	params    *ReplicationParams
+	iterate   IterateUploadedFunc
+	done      chan struct{} // closed when the run loop returns, nil if not started
+}
+
+// SetReplicationParams sets the parameters of the periodic replication checks
+// of the chunks iterated by the iterate function. With nil params or zero
+// MinReplicas, the checks are disabled. It must be called before the protocol
+// is started.
+func (r *Retrieval) SetReplicationParams(p *ReplicationParams, iterate IterateUploadedFunc) {
+	r.replicator = nil
+	if p == nil || p.MinReplicas <= 0 || p.Interval <= 0 || iterate == nil || r.netStore == nil {
+		return
+	}
+	r.replicator = &replicator{
+		retrieval: r,
+		params:    p,
+		iterate:   iterate,
+	}
+}
+
+// run checks the replication of chunks every Interval until quit is closed.
+func (s *replicator) run(quit chan struct{}) {
+	defer close(s.done)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-quit:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	ticker := time.NewTicker(s.params.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			checked, reuploaded, err := s.check(ctx)
+			if err != nil && ctx.Err() == nil {
+				s.retrieval.logger.Error("replication check", "checked", checked, "reuploaded", reuploaded, "err", err)
+				continue
+			}
+			s.retrieval.logger.Debug("replication check", "checked", checked, "reuploaded", reuploaded)
+		case <-quit:
+			return
+		}
+	}
+}
+
+// check queries the replication of all iterated chunks in batches and
+// sets the chunks with too few replicas to be push synced again. Every
+// batch is collected by a separate iteration, which continues after the
+// last chunk of the previous batch, so that no iterator is kept open
+// while peers are queried.
+func (s *replicator) check(ctx context.Context) (checked, reuploaded int, err error) {
+	var start chunk.Address
+	for {
+		batch := make([]chunk.Address, 0, maxHasAddrs)
+		err = s.iterate(start, func(addr chunk.Address) (stop bool, err error) {
+			batch = append(batch, addr)
+			return len(batch) == maxHasAddrs, nil
+		})
+		if err != nil || len(batch) == 0 {
+			return checked, reuploaded, err
+		}
+		n, err := s.checkBatch(ctx, batch)
+		checked += len(batch)
+		reuploaded += n
+		if err != nil || len(batch) < maxHasAddrs {
+			return checked, reuploaded, err
+		}
+		start = batch[len(batch)-1]
+	}
+}
+
+// checkBatch sets the chunks with fewer replicas than MinReplicas, or than
+// the number of nodes in their neighbourhoods if there are fewer of them,
+// to be push synced again and returns their number.
+func (s *replicator) checkBatch(ctx context.Context, addrs []chunk.Address) (reuploaded int, err error) {
+	replication, err := s.retrieval.Replication(ctx, addrs...)
+	if err != nil {
+		return 0, err
+	}
+	replicationCheckedCount.Inc(int64(len(addrs)))
+
+	var reupload []chunk.Address
+	for _, c := range replication {
+		min := s.params.MinReplicas
+		if c.Neighbours < min {
+			min = c.Neighbours
+		}
+		if c.Replicas < min {
+			reupload = append(reupload, c.Address)
+		}
+	}
+	if len(reupload) == 0 {
+		return 0, nil
+	}
+	if err := s.retrieval.netStore.Store.Set(ctx, chunk.ModeSetReupload, reupload...); err != nil {
+		return 0, err
+	}
+	replicationReuploadedCount.Inc(int64(len(reupload)))
+	return len(reupload), nil
+}
@@ -53,22 +53,41 @@ var (
 	handleRetrieveRequestMsgCount = metrics.NewRegisteredCounter("network/retrieve/handle_retrieve_request_msg", nil)
 	retrieveChunkFail             = metrics.NewRegisteredCounter("network/retrieve/retrieve_chunks_fail", nil)
 	unsolicitedChunkDelivery      = metrics.NewRegisteredCounter("network/retrieve/unsolicited_delivery", nil)
+	cancelledChunkDelivery        = metrics.NewRegisteredCounter("network/retrieve/cancelled_delivery", nil)
+	lateChunkDelivery             = metrics.NewRegisteredCounter("network/retrieve/late_delivery", nil)
+	ignoredRetrieveRequest        = metrics.NewRegisteredCounter("network/retrieve/ignored_request", nil)
 
 	retrievalPeers = metrics.GetOrRegisterGauge("network/retrieve/peers", nil)
 
 	spec = &protocols.Spec{
 		Name:       "bzz-retrieve",
-		Version:    2,
+		Version:    3,
 		MaxMsgSize: 10 * 1024 * 1024,
 		Messages: []interface{}{
 			ChunkDelivery{},
 			RetrieveRequest{},
+			HasRequest{},
+			HasResponse{},
 		},
 	}
 
 	ErrNoPeerFound = errors.New("no peer found")
+
+	// ErrExtraRequestNotFree is returned for extra requests of hedged and
+	// fan-out retrieval that the node would have to pay the peer for.
+	ErrExtraRequestNotFree = errors.New("extra request would be paid for")
 )
 
+// extraRequestCost is the cost to the local node of a retrieve request
+// and the delivery of a chunk.
+var extraRequestCost = -(&RetrieveRequest{}).Price().For(protocols.Sender, 0) - (&ChunkDelivery{}).Price().For(protocols.Receiver, chunk.DefaultSize)
+
+// payer is implemented by balances that report whether a cost to the
+// local node can be accounted without paying the peer, like swap.
+type payer interface {
+	CanPay(amount int64, peer *protocols.Peer) bool
+}
+
 // Price is the method through which a message type marks itself
 // as implementing the protocols.Price protocol and thus
 // as swap-enabled message
@@ -102,6 +121,9 @@ type Retrieval struct {
 	spec        *protocols.Spec    // protocol spec
 	logger      log.Logger         // custom logger to append a basekey
 	quit        chan struct{}      // shutdown channel
+	cache       *cache             // admission of retrieved chunks outside of depth, nil stores all
+	replicator  *replicator        // periodic replication checks of uploaded chunks, nil if disabled
+	payer       payer              // checks the cost of extra requests, nil if swap is disabled
 }
 
 // New returns a new instance of the retrieval protocol handler
@@ -119,10 +141,45 @@ func New(kad *network.Kademlia, ns *storage.NetStore, baseKey *network.BzzAddr,
 	if balance != nil && !reflect.ValueOf(balance).IsNil() {
 		// swap is enabled, so setup the hook
 		r.spec.Hook = protocols.NewAccounting(balance)
+		r.payer, _ = balance.(payer)
 	}
+	r.SetCacheParams(NewCacheParams(), nil)
 	return r
 }
 
+// SetCacheParams sets the parameters of the admission of retrieved chunks
+// outside of the neighbourhood depth to the local store. With nil params,
+// all retrieved chunks are stored. Chunks cached before, as iterated by the
+// iterate function, are loaded to the cache, or are left to the garbage
+// collection if the cache is disabled. It must be called before the
+// protocol is started.
+func (r *Retrieval) SetCacheParams(p *CacheParams, iterate IterateCachedFunc) {
+	r.cache = nil
+	if r.netStore == nil {
+		return
+	}
+	r.cache = newCache(p, r.kad.BaseAddr(), r.netStore.Store, func(addr chunk.Address) bool {
+		return chunk.Proximity(r.kad.BaseAddr(), addr) >= r.kad.NeighbourhoodDepth()
+	})
+	if iterate == nil {
+		return
+	}
+	var err error
+	if r.cache != nil {
+		err = r.cache.load(iterate)
+	} else {
+		err = uncacheAll(r.netStore.Store, iterate)
+	}
+	if err != nil {
+		r.logger.Error("retrieval cache load", "err", err)
+	}
+}
+
+// isLightNode returns true if this node advertises the light node capabilities
+func (r *Retrieval) isLightNode() bool {
+	return network.IsLightNode(r.kad.Capabilities)
+}
+
 func (r *Retrieval) addPeer(p *Peer) {
 	r.mtx.Lock()
 	defer r.mtx.Unlock()
@@ -160,6 +217,10 @@ func (r *Retrieval) handleMsg(p *Peer) func(context.Context, interface{}) error
 			return r.handleRetrieveRequest(ctx, p, msg)
 		case *ChunkDelivery:
 			return r.handleChunkDelivery(ctx, p, msg)
+		case *HasRequest:
+			return r.handleHasRequest(ctx, p, msg)
+		case *HasResponse:
+			return r.handleHasResponse(ctx, p, msg)
 		}
 		return nil
 	}
@@ -224,6 +285,11 @@ func (r *Retrieval) findPeerLB(ctx context.Context, req *storage.Request) (retPe
 				continue
 			}
 
+			// skip light nodes, as they do not serve retrieve requests
+			if lbPeer.Peer.IsLightNode() {
+				continue
+			}
+
 			// do not send request back to peer who asked us. maybe merge with SkipPeer at some point
 			if bytes.Equal(req.Origin.Bytes(), id.Bytes()) {
 				continue
@@ -294,10 +360,16 @@ func (r *Retrieval) findPeerLB(ctx context.Context, req *storage.Request) (retPe
 // handleRetrieveRequest handles an incoming retrieve request from a certain Peer
 // if the chunk is found in the localstore it is served immediately, otherwise
 // it results in a new retrieve request to candidate peers in our kademlia
+// light nodes ignore retrieve requests, as they do not serve other nodes
 func (r *Retrieval) handleRetrieveRequest(ctx context.Context, p *Peer, msg *RetrieveRequest) error {
 	p.logger.Debug("retrieval.handleRetrieveRequest", "ref", msg.Addr)
 	handleRetrieveRequestMsgCount.Inc(1)
 
+	if r.isLightNode() {
+		ignoredRetrieveRequest.Inc(1)
+		return nil
+	}
+
 	ctx, osp := spancontext.StartSpan(
 		ctx,
 		"handle.retrieve.request")
@@ -309,6 +381,10 @@ func (r *Retrieval) handleRetrieveRequest(ctx context.Context, p *Peer, msg *Ret
 	ctx, cancel := context.WithTimeout(ctx, timeouts.FetcherGlobalTimeout)
 	defer cancel()
 
+	if r.cache != nil {
+		r.cache.requested(msg.Addr)
+	}
+
 	req := &storage.Request{
 		Addr:   msg.Addr,
 		Origin: p.ID(),
@@ -341,8 +417,31 @@ func (r *Retrieval) handleRetrieveRequest(ctx context.Context, p *Peer, msg *Ret
 // we treat the chunk as a chunk received in syncing
 func (r *Retrieval) handleChunkDelivery(ctx context.Context, p *Peer, msg *ChunkDelivery) error {
 	p.logger.Debug("retrieval.handleChunkDelivery", "ref", msg.Addr)
-	err := p.checkRequest(msg.Ruid, msg.Addr)
-	if err != nil {
+	if p.deliverProbe(msg.Ruid, msg.Addr, msg.SData) {
+		// the chunk is not stored
+		return nil
+	}
+	switch err := p.checkRequest(msg.Ruid, msg.Addr); err {
+	case nil:
+	case errExpiredProbe:
+		p.logger.Trace("retrieval.handleChunkDelivery - delivery of expired probe", "ref", msg.Addr, "ruid", msg.Ruid)
+		cancelledChunkDelivery.Inc(1)
+		return nil
+	case errCancelledRetrieval:
+		// the chunk was delivered by another peer or the request timed out,
+		// it is stored if it is not yet, as it is paid for and can be
+		// requested again if the retrieval failed
+		p.logger.Debug("retrieval.handleChunkDelivery - delivery of cancelled retrieval", "ref", msg.Addr, "ruid", msg.Ruid)
+		cancelledChunkDelivery.Inc(1)
+		has, err := r.netStore.Has(ctx, msg.Addr)
+		if err != nil {
+			return fmt.Errorf("netstore checking chunk of cancelled retrieval: %w", err)
+		}
+		if has {
+			return nil
+		}
+		lateChunkDelivery.Inc(1)
+	default:
 		unsolicitedChunkDelivery.Inc(1)
 		return protocols.Break(fmt.Errorf("unsolicited chunk delivery from peer, ruid %d, addr %s: %w", msg.Ruid, msg.Addr, err))
 	}
@@ -363,7 +462,8 @@ func (r *Retrieval) handleChunkDelivery(ctx context.Context, p *Peer, msg *Chunk
 	var mode chunk.ModePut
 	// chunks within the area of responsibility should always sync
 	// https://github.com/ethersphere/go-ethereum/pull/1282#discussion_r269406125
-	if po >= depth || peerPO < po {
+	// light nodes have no area of responsibility and only cache chunks
+	if !r.isLightNode() && (po >= depth || peerPO < po) {
 		mode = chunk.ModePutSync
 	} else {
 		// do not sync if peer that is sending us a chunk is closer to the chunk then we are
@@ -371,7 +471,19 @@ func (r *Retrieval) handleChunkDelivery(ctx context.Context, p *Peer, msg *Chunk
 	}
 	defer osp.Finish()
 
-	_, err = r.netStore.Put(ctx, mode, storage.NewChunk(msg.Addr, msg.SData))
+	if mode == chunk.ModePutRequest && r.cache != nil {
+		if !r.cache.admit(msg.Addr) {
+			// deliver the chunk to the requests without storing it
+			if err := r.netStore.Deliver(storage.NewChunk(msg.Addr, msg.SData)); err != nil {
+				return protocols.Break(fmt.Errorf("netstore delivering chunk: %w", err))
+			}
+			return nil
+		}
+		// keep the admitted chunk out of the garbage collection
+		mode = chunk.ModePutCache
+	}
+
+	_, err := r.netStore.Put(ctx, mode, storage.NewChunk(msg.Addr, msg.SData))
 	if err != nil {
 		if err == storage.ErrChunkInvalid {
 			return protocols.Break(fmt.Errorf("netstore putting chunk to localstore: %w", err))
@@ -384,11 +496,17 @@ func (r *Retrieval) handleChunkDelivery(ctx context.Context, p *Peer, msg *Chunk
 }
 
 // RequestFromPeers sends a chunk retrieve request to the next found peer.
-// returns the next peer to try, a cleanup function to expire retrievals that were never delivered
+// returns the next peer to try, a cleanup function to cancel retrievals that were not delivered
 func (r *Retrieval) RequestFromPeers(ctx context.Context, req *storage.Request, localID enode.ID) (*enode.ID, func(), error) {
 	r.logger.Debug("retrieval.requestFromPeers", "req.Addr", req.Addr, "localID", localID)
 	metrics.GetOrRegisterCounter("network/retrieve/request_from_peers", nil).Inc(1)
 
+	// count local requests for the cache, only once per request, before any
+	// peer is skipped, as requests from peers are counted when they are received
+	if r.cache != nil && req.Origin == (enode.ID{}) && !hasPeersToSkip(req) {
+		r.cache.requested(req.Addr)
+	}
+
 	const maxFindPeerRetries = 5
 	retries := 0
 
@@ -412,6 +530,11 @@ FINDPEER:
 		goto FINDPEER
 	}
 
+	if storage.IsExtraRequest(ctx) && r.payer != nil && !r.payer.CanPay(extraRequestCost, protoPeer.Peer) {
+		r.logger.Trace("extra request not sent", "peer", sp.String(), "ref", req.Addr)
+		return nil, func() {}, ErrExtraRequestNotFree
+	}
+
 	ret := &RetrieveRequest{
 		Ruid: uint(rand.Uint32()),
 		Addr: req.Addr,
@@ -419,12 +542,12 @@ FINDPEER:
 	protoPeer.logger.Trace("sending retrieve request", "ref", ret.Addr, "origin", localID, "ruid", ret.Ruid)
 	protoPeer.addRetrieval(ret.Ruid, ret.Addr)
 	cleanup := func() {
-		protoPeer.expireRetrieval(ret.Ruid)
+		protoPeer.cancelRetrieval(ret.Ruid)
 	}
 	err = protoPeer.Send(ctx, ret)
 	if err != nil {
 		protoPeer.logger.Trace("error sending retrieve request to peer", "ruid", ret.Ruid, "err", err)
-		cleanup()
+		protoPeer.expireRetrieval(ret.Ruid)
 		return nil, func() {}, err
 	}
 
@@ -432,14 +555,31 @@ FINDPEER:
 	return &spID, cleanup, nil
 }
 
+// hasPeersToSkip returns true if any peer is skipped for the request.
+func hasPeersToSkip(req *storage.Request) bool {
+	var has bool
+	req.PeersToSkip.Range(func(_, _ interface{}) bool {
+		has = true
+		return false
+	})
+	return has
+}
+
 func (r *Retrieval) Start(server *p2p.Server) error {
 	r.logger.Info("starting bzz-retrieve")
+	if r.replicator != nil {
+		r.replicator.done = make(chan struct{})
+		go r.replicator.run(r.quit)
+	}
 	return nil
 }
 
 func (r *Retrieval) Stop() error {
 	r.logger.Info("shutting down bzz-retrieve")
 	close(r.quit)
+	if r.replicator != nil && r.replicator.done != nil {
+		<-r.replicator.done
+	}
 	r.kademliaLB.Stop()
 	return nil
 }
@@ -463,7 +603,14 @@ func (r *Retrieval) runProtocol(p *p2p.Peer, rw p2p.MsgReadWriter) error {
 }
 
 func (r *Retrieval) APIs() []rpc.API {
-	return nil
+	return []rpc.API{
+		{
+			Namespace: "retrieval",
+			Version:   "1.0",
+			Service:   NewAPI(r),
+			Public:    false,
+		},
+	}
 }
 
 func (r *Retrieval) Spec() *protocols.Spec {
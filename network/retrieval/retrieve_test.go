@@ -59,6 +59,7 @@ var (
 	bucketKeyNetstore  = simulation.BucketKey("netstore")
 
 	hash0 = sha3.Sum256([]byte{0})
+	hash1 = sha3.Sum256([]byte{1})
 )
 
 func init() {
@@ -398,6 +399,87 @@ func TestUnsolicitedChunkDeliveryDouble(t *testing.T) {
 	}
 }
 
+// TestCancelledRetrievalDelivery tests that a late delivery of a cancelled retrieval
+// is not treated as unsolicited, but only once and only for the cancelled Ruid
+func TestCancelledRetrievalDelivery(t *testing.T) {
+	p := NewPeer(&network.BzzPeer{BzzAddr: network.RandomBzzAddr()}, network.RandomBzzAddr())
+	addr := storage.Address(hash0[:])
+
+	p.addRetrieval(1234, addr)
+	p.cancelRetrieval(1234)
+	// cancelling an unknown or delivered retrieval has no effect
+	p.cancelRetrieval(5678)
+
+	if err := p.checkRequest(1234, addr); err != errCancelledRetrieval {
+		t.Fatalf("got error %v for cancelled retrieval delivery, want %v", err, errCancelledRetrieval)
+	}
+	if err := p.checkRequest(1234, addr); err == nil || err == errCancelledRetrieval {
+		t.Fatalf("got error %v for second cancelled retrieval delivery, want unsolicited delivery error", err)
+	}
+	if err := p.checkRequest(5678, addr); err == nil || err == errCancelledRetrieval {
+		t.Fatalf("got error %v for unknown retrieval delivery, want unsolicited delivery error", err)
+	}
+
+	p.addRetrieval(1234, addr)
+	p.cancelRetrieval(1234)
+	if err := p.checkRequest(1234, storage.Address(hash1[:])); err == nil || err == errCancelledRetrieval {
+		t.Fatalf("got error %v for cancelled retrieval delivery of another chunk, want unsolicited delivery error", err)
+	}
+}
+
+// TestCancelledRetrievalDeliveryStored tests that a late delivery of a cancelled
+// retrieval is stored, and that a late delivery of an expired probe is not
+func TestCancelledRetrievalDeliveryStored(t *testing.T) {
+	pk, ns, cleanup := newTestNetstore(t)
+	defer cleanup()
+
+	kad := network.NewKademlia(network.PrivateKeyToBzzKey(pk), network.NewKadParams())
+	_, r, teardown, err := newRetrievalTester(t, pk, ns, kad)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer teardown()
+
+	ctx := context.Background()
+	p := NewPeer(&network.BzzPeer{BzzAddr: network.RandomBzzAddr()}, network.RandomBzzAddr())
+	for _, tc := range []struct {
+		name   string
+		cancel func(ruid uint, addr chunk.Address)
+		stored bool
+	}{
+		{
+			name: "retrieval",
+			cancel: func(ruid uint, addr chunk.Address) {
+				p.addRetrieval(ruid, addr)
+				p.cancelRetrieval(ruid)
+			},
+			stored: true,
+		},
+		{
+			name: "probe",
+			cancel: func(ruid uint, addr chunk.Address) {
+				p.addProbe(ruid, addr)
+				p.expireProbe(ruid)
+			},
+		},
+	} {
+		ch := storage.GenerateRandomChunk(chunk.DefaultSize)
+		tc.cancel(1234, ch.Address())
+
+		err := r.handleChunkDelivery(ctx, p, &ChunkDelivery{Ruid: 1234, Addr: ch.Address(), SData: ch.Data()})
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		has, err := ns.Has(ctx, ch.Address())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if has != tc.stored {
+			t.Errorf("%s: got stored %v, want %v", tc.name, has, tc.stored)
+		}
+	}
+}
+
 // TestDeliveryForwarding tests that chunk delivery forwarding requests happen. It creates three nodes (fetching, forwarding and uploading)
 // where po(fetching,forwarding) = 1 and po(forwarding,uploading) = 1, then uploads chunks to the uploading node, afterwards
 // tries to retrieve the relevant chunks (ones with po = 0 to fetching i.e. no bits in common with fetching and with
@@ -519,6 +601,53 @@ func TestRequestFromPeers(t *testing.T) {
 	}
 }
 
+// light nodes in the Kademlia should not be asked for chunks
+func TestRequestFromPeersSkipsLightNodes(t *testing.T) {
+	dummyPeerID := enode.HexID("3431c3939e1ee2a6345e976a8234f9870152d64879f30bc272a074f6859e75e8")
+
+	addr := network.RandomBzzAddr()
+	to := network.NewKademlia(addr.OAddr, network.NewKadParams())
+	protocolsPeer := protocols.NewPeer(p2p.NewPeer(dummyPeerID, "dummy", []p2p.Cap{{Name: "bzz-retrieve", Version: 1}}), nil, nil)
+	peer := network.NewPeer(&network.BzzPeer{
+		BzzAddr: network.RandomBzzAddr().WithCapabilities(network.NewLightCapabilities()),
+		Peer:    protocolsPeer,
+	}, to)
+
+	to.On(peer)
+
+	s := New(to, nil, addr, nil)
+
+	req := storage.NewRequest(storage.Address(hash0[:]))
+	_, err := s.findPeerLB(context.Background(), req)
+	if err != ErrNoPeerFound {
+		t.Fatalf("got error %v, want %v", err, ErrNoPeerFound)
+	}
+}
+
+// light nodes should ignore retrieve requests without looking up the chunk
+func TestLightNodeIgnoresRetrieveRequest(t *testing.T) {
+	addr := network.RandomBzzAddr()
+	params := network.NewKadParams()
+	params.Capabilities = network.NewLightCapabilities()
+	to := network.NewKademlia(addr.OAddr, params)
+
+	s := New(to, nil, addr, nil)
+
+	protocolsPeer := protocols.NewPeer(p2p.NewPeer(enode.ID{}, "dummy", nil), nil, nil)
+	p := NewPeer(&network.BzzPeer{
+		BzzAddr: network.RandomBzzAddr(),
+		Peer:    protocolsPeer,
+	}, addr)
+
+	err := s.handleRetrieveRequest(context.Background(), p, &RetrieveRequest{
+		Ruid: 1,
+		Addr: storage.Address(hash0[:]),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
 //TestHasPriceImplementation is to check that Retrieval provides priced messages
 func TestHasPriceImplementation(t *testing.T) {
 	price := (&ChunkDelivery{}).Price()
@@ -30,3 +30,16 @@ type ChunkDelivery struct {
 	Addr  storage.Address
 	SData []byte
 }
+
+// HasRequest is the protocol msg for asking a peer which of the chunks it stores
+type HasRequest struct {
+	Ruid  uint
+	Addrs []byte // concatenated chunk addresses
+}
+
+// HasResponse is the protocol msg for responding to a HasRequest with
+// a bit vector in which the bits of the stored chunks are set
+type HasResponse struct {
+	Ruid      uint
+	BitVector []byte
+}
@@ -0,0 +1,42 @@
+// Copyright 2019 The Swarm Authors
+// This file is part of the Swarm library.
+//
+// The Swarm library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Swarm library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Swarm library. If not, see <http://www.gnu.org/licenses/>.
+
+package stream
+
+import (
+	"context"
+
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethersphere/swarm/chunk"
+)
+
+// API is the RPC API of the stream protocol.
+type API struct {
+	registry *Registry
+}
+
+// NewAPI creates a new API instance.
+func NewAPI(r *Registry) *API {
+	return &API{
+		registry: r,
+	}
+}
+
+// RequestTree syncs all chunks of the content with the root
+// address from the peer, that are not in the local store.
+func (a *API) RequestTree(ctx context.Context, peer enode.ID, root chunk.Address) error {
+	return a.registry.RequestTree(ctx, peer, root)
+}
@@ -0,0 +1,267 @@
+// Copyright 2019 The Swarm Authors
+// This file is part of the Swarm library.
+//
+// The Swarm library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Swarm library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Swarm library. If not, see <http://www.gnu.org/licenses/>.
+
+package stream
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethersphere/swarm/chunk"
+	"golang.org/x/time/rate"
+)
+
+var (
+	budgetPausedGauge = metrics.GetOrRegisterGauge("network/stream/budget/paused", nil)
+	budgetWaitTimer   = metrics.GetOrRegisterResettingTimer("network/stream/budget/wait/total-time", nil)
+)
+
+const (
+	// maxBatchBytes is the maximum size of chunks requested in a single
+	// batch, which is the minimal burst of budget rate limiters
+	maxBatchBytes = BatchSize * chunk.DefaultSize
+	// pauseCheckInterval is the time between checks whether
+	// paused syncing can be resumed
+	pauseCheckInterval = time.Second
+	// pauseLatencyMaxAge is the time after the last retrieval after which
+	// retrieval latencies are not considered for pausing syncing
+	pauseLatencyMaxAge = 10 * time.Second
+)
+
+// BudgetParams configure the bandwidth budget of syncing, so that it does not
+// compete with retrieval requests for the bandwidth of the node.
+type BudgetParams struct {
+	// Rate is the maximum number of bytes per second of chunks requested
+	// by syncing from all peers. Zero means unlimited.
+	Rate int
+	// PeerRate is the maximum number of bytes per second of chunks
+	// requested by syncing from a single peer. Zero means unlimited.
+	PeerRate int
+	// PauseLatency is the retrieval latency above which syncing is paused,
+	// except for live syncing of bins within the neighbourhood depth.
+	// Zero means that syncing is never paused.
+	PauseLatency time.Duration
+	// PauseLatencyPercentile is the percentile of recent retrieval
+	// latencies that is compared to PauseLatency.
+	PauseLatencyPercentile float64
+}
+
+// NewBudgetParams returns the default syncing budget parameters, with
+// unlimited rates and pausing disabled, so that the syncing is not limited
+// unless configured.
+func NewBudgetParams() *BudgetParams {
+	return &BudgetParams{
+		PauseLatencyPercentile: 0.9,
+	}
+}
+
+// RetrievalLatencyFunc returns the p-th percentile of recent retrieval
+// latencies or false if no chunks were retrieved within maxAge.
+// It is implemented by storage.NetStore RetrievalLatency method.
+type RetrievalLatencyFunc func(p float64, maxAge time.Duration) (time.Duration, bool)
+
+// BudgetInfo holds the state of the syncing budget.
+type BudgetInfo struct {
+	Rate              int    `json:"rate"`
+	PeerRate          int    `json:"peerRate"`
+	Paused            bool   `json:"paused"`
+	RetrievalLatency  string `json:"retrievalLatency,omitempty"`
+	Waiting           int    `json:"waiting"`           // batches of bins within depth waiting for the budget
+	WaitingOutOfDepth int    `json:"waitingOutOfDepth"` // batches of bins out of depth waiting for the budget
+}
+
+// syncBudget limits the rate of chunks requested by syncing. Batches of
+// bins within the neighbourhood depth have priority over the ones out of
+// depth, as the node is responsible for storing them.
+type syncBudget struct {
+	params  *BudgetParams
+	limiter *rate.Limiter // nil if the rate is unlimited
+	latency RetrievalLatencyFunc
+
+	mu                sync.Mutex
+	waiting           int           // number of waiting batches within depth
+	waitingOutOfDepth int           // number of waiting batches out of depth
+	idle              chan struct{} // closed when no batches within depth are waiting
+}
+
+// newSyncBudget returns a new syncBudget. Argument latency
+// can be nil, in which case syncing is never paused.
+func newSyncBudget(params *BudgetParams, latency RetrievalLatencyFunc) *syncBudget {
+	idle := make(chan struct{})
+	close(idle)
+	return &syncBudget{
+		params:  params,
+		limiter: newBudgetLimiter(params.Rate),
+		latency: latency,
+		idle:    idle,
+	}
+}
+
+// newBudgetLimiter returns a rate limiter of bytes per second,
+// or nil if the rate is not positive.
+func newBudgetLimiter(bytesPerSecond int) *rate.Limiter {
+	if bytesPerSecond <= 0 {
+		return nil
+	}
+	burst := bytesPerSecond
+	if burst < maxBatchBytes {
+		burst = maxBatchBytes
+	}
+	return rate.NewLimiter(rate.Limit(bytesPerSecond), burst)
+}
+
+// newPeerLimiter returns the rate limiter for a single peer.
+func (b *syncBudget) newPeerLimiter() *rate.Limiter {
+	return newBudgetLimiter(b.params.PeerRate)
+}
+
+// wait blocks until the size of chunks can be requested from the peer
+// with the peerLimiter within the budget. Live syncing of bins within depth
+// is never paused, while bins out of depth wait for the ones within depth.
+func (b *syncBudget) wait(ctx context.Context, peerLimiter *rate.Limiter, size int, withinDepth, live bool) error {
+	defer func(start time.Time) {
+		budgetWaitTimer.UpdateSince(start)
+	}(time.Now())
+
+	if !withinDepth || !live {
+		if err := b.waitResume(ctx); err != nil {
+			return err
+		}
+	}
+	defer b.track(withinDepth)()
+	if !withinDepth {
+		if err := b.waitIdle(ctx); err != nil {
+			return err
+		}
+	}
+	if b.limiter != nil {
+		if err := b.limiter.WaitN(ctx, size); err != nil {
+			return err
+		}
+	}
+	if peerLimiter != nil {
+		if err := peerLimiter.WaitN(ctx, size); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// track counts a waiting batch and returns the function
+// that marks it as done.
+func (b *syncBudget) track(withinDepth bool) (done func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !withinDepth {
+		b.waitingOutOfDepth++
+		return func() {
+			b.mu.Lock()
+			b.waitingOutOfDepth--
+			b.mu.Unlock()
+		}
+	}
+	if b.waiting == 0 {
+		b.idle = make(chan struct{})
+	}
+	b.waiting++
+	return func() {
+		b.mu.Lock()
+		b.waiting--
+		if b.waiting == 0 {
+			close(b.idle)
+		}
+		b.mu.Unlock()
+	}
+}
+
+// waitIdle blocks until no batches within depth are waiting.
+func (b *syncBudget) waitIdle(ctx context.Context) error {
+	for {
+		b.mu.Lock()
+		if b.waiting == 0 {
+			b.mu.Unlock()
+			return nil
+		}
+		idle := b.idle
+		b.mu.Unlock()
+
+		select {
+		case <-idle:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// waitResume blocks while syncing is paused.
+func (b *syncBudget) waitResume(ctx context.Context) error {
+	if !b.paused() {
+		return nil
+	}
+	ticker := time.NewTicker(pauseCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if !b.paused() {
+				return nil
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// paused returns true if recent retrieval latencies are above PauseLatency.
+func (b *syncBudget) paused() bool {
+	d, ok := b.retrievalLatency()
+	paused := ok && d > b.params.PauseLatency
+	if paused {
+		budgetPausedGauge.Update(1)
+	} else {
+		budgetPausedGauge.Update(0)
+	}
+	return paused
+}
+
+// retrievalLatency returns the recent retrieval latency at PauseLatencyPercentile
+// or false if it is not known or pausing is disabled.
+func (b *syncBudget) retrievalLatency() (time.Duration, bool) {
+	if b.latency == nil || b.params.PauseLatency <= 0 {
+		return 0, false
+	}
+	return b.latency(b.params.PauseLatencyPercentile, pauseLatencyMaxAge)
+}
+
+// info returns the current state of the budget.
+func (b *syncBudget) info() *BudgetInfo {
+	info := &BudgetInfo{
+		Rate:     b.params.Rate,
+		PeerRate: b.params.PeerRate,
+		Paused:   b.paused(),
+	}
+	if d, ok := b.retrievalLatency(); ok {
+		info.RetrievalLatency = d.String()
+	}
+	b.mu.Lock()
+	info.Waiting = b.waiting
+	info.WaitingOutOfDepth = b.waitingOutOfDepth
+	b.mu.Unlock()
+	return info
+}
@@ -0,0 +1,166 @@
+// Copyright 2019 The Swarm Authors
+// This file is part of the Swarm library.
+//
+// The Swarm library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Swarm library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Swarm library. If not, see <http://www.gnu.org/licenses/>.
+
+package stream
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestSyncBudgetPause validates that syncing is paused when retrieval
+// latency is high, except for live syncing of bins within depth.
+func TestSyncBudgetPause(t *testing.T) {
+	var latency int64 = int64(3 * time.Second)
+	b := newSyncBudget(&BudgetParams{
+		PauseLatency:           time.Second,
+		PauseLatencyPercentile: 0.9,
+	}, func(p float64, maxAge time.Duration) (time.Duration, bool) {
+		return time.Duration(atomic.LoadInt64(&latency)), true
+	})
+
+	if !b.info().Paused {
+		t.Fatal("budget not paused")
+	}
+	if err := b.wait(context.Background(), nil, maxBatchBytes, true, true); err != nil {
+		t.Fatalf("live syncing within depth: %v", err)
+	}
+	for _, tc := range []struct {
+		name        string
+		withinDepth bool
+		live        bool
+	}{
+		{name: "historical within depth", withinDepth: true},
+		{name: "live out of depth", live: true},
+		{name: "historical out of depth"},
+	} {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		err := b.wait(ctx, nil, maxBatchBytes, tc.withinDepth, tc.live)
+		cancel()
+		if err != context.DeadlineExceeded {
+			t.Errorf("%s: got error %v, want %v", tc.name, err, context.DeadlineExceeded)
+		}
+	}
+
+	// syncing is resumed when the latency drops
+	done := make(chan error)
+	go func() {
+		done <- b.wait(context.Background(), nil, maxBatchBytes, false, false)
+	}()
+	atomic.StoreInt64(&latency, int64(100*time.Millisecond))
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * pauseCheckInterval):
+		t.Fatal("syncing not resumed")
+	}
+}
+
+// TestSyncBudgetPriority validates that batches of bins out of depth
+// wait for the budget until batches within depth are requested.
+func TestSyncBudgetPriority(t *testing.T) {
+	b := newSyncBudget(&BudgetParams{
+		Rate: 4 * maxBatchBytes,
+	}, nil)
+
+	// use all of the burst
+	for i := 0; i < 4; i++ {
+		if err := b.wait(context.Background(), nil, maxBatchBytes, true, true); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var order []string
+	done := make(chan string)
+	go func() {
+		if err := b.wait(context.Background(), nil, maxBatchBytes, true, false); err != nil {
+			t.Error(err)
+		}
+		done <- "within depth"
+	}()
+	for start := time.Now(); b.info().Waiting != 1; time.Sleep(time.Millisecond) {
+		if time.Since(start) > 5*time.Second {
+			t.Fatal("batch within depth not waiting")
+		}
+	}
+	go func() {
+		if err := b.wait(context.Background(), nil, 1, false, true); err != nil {
+			t.Error(err)
+		}
+		done <- "out of depth"
+	}()
+	for i := 0; i < 2; i++ {
+		select {
+		case name := <-done:
+			order = append(order, name)
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout waiting for the budget")
+		}
+	}
+	if order[0] != "within depth" {
+		t.Errorf("got order %v, want batch within depth first", order)
+	}
+	if info := b.info(); info.Waiting != 0 || info.WaitingOutOfDepth != 0 {
+		t.Errorf("got %v and %v waiting batches, want none", info.Waiting, info.WaitingOutOfDepth)
+	}
+}
+
+// TestSyncBudgetPeerRate validates that a peer limiter
+// limits the rate of requested chunks from the peer.
+func TestSyncBudgetPeerRate(t *testing.T) {
+	b := newSyncBudget(&BudgetParams{
+		PeerRate: 4 * maxBatchBytes,
+	}, nil)
+	peer := b.newPeerLimiter()
+	other := b.newPeerLimiter()
+
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		if err := b.wait(context.Background(), peer, maxBatchBytes, true, true); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if d := time.Since(start); d < 200*time.Millisecond {
+		t.Errorf("got chunks requested from the peer in %v, want at least 200ms", d)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := b.wait(ctx, other, maxBatchBytes, true, true); err != nil {
+		t.Errorf("other peer: %v", err)
+	}
+}
+
+// TestSyncBudgetDefault validates that the default budget
+// does not limit nor pause syncing.
+func TestSyncBudgetDefault(t *testing.T) {
+	b := newSyncBudget(NewBudgetParams(), func(p float64, maxAge time.Duration) (time.Duration, bool) {
+		return time.Hour, true
+	})
+	if b.limiter != nil {
+		t.Error("got budget rate limiter")
+	}
+	if b.newPeerLimiter() != nil {
+		t.Error("got peer rate limiter")
+	}
+	if b.info().Paused {
+		t.Error("budget paused")
+	}
+}
@@ -95,6 +95,7 @@ type SyncSimServiceOptions struct {
 	InitialChunkCount     uint64
 	SyncOnlyWithinDepth   bool
 	Autostart             bool
+	TreeProvider          bool
 	StreamConstructorFunc func(state.Store, *network.BzzAddr, ...StreamProvider) node.Service
 }
 
@@ -155,8 +156,11 @@ func newSyncSimServiceFunc(o *SyncSimServiceOptions) func(ctx *adapters.ServiceC
 		if err != nil {
 			return nil, nil, err
 		}
-		sp := NewSyncProvider(netStore, kad, addr, o.Autostart, o.SyncOnlyWithinDepth)
-		ss := o.StreamConstructorFunc(store, addr, sp)
+		providers := []StreamProvider{NewSyncProvider(netStore, kad, addr, o.Autostart, o.SyncOnlyWithinDepth)}
+		if o.TreeProvider {
+			providers = append(providers, NewTreeProvider(netStore, addr, nil))
+		}
+		ss := o.StreamConstructorFunc(store, addr, providers...)
 
 		cleanup = func() {
 			//ss.Stop() // wait for handlers to finish before closing localstore
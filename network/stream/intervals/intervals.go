@@ -167,6 +167,27 @@ func (i *Intervals) Last() (end uint64) {
 	return i.ranges[l-1][1]
 }
 
+// Covered returns the number of values between start and end,
+// both inclusive, that are within the intervals.
+func (i *Intervals) Covered(start, end uint64) (count uint64) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	for _, r := range i.ranges {
+		s, e := r[0], r[1]
+		if s < start {
+			s = start
+		}
+		if e > end {
+			e = end
+		}
+		if s <= e {
+			count += e - s + 1
+		}
+	}
+	return count
+}
+
 // String returns a descriptive representation of range intervals
 // in [] notation, as a list of two element vectors.
 func (i *Intervals) String() string {
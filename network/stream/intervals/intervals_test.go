@@ -471,3 +471,56 @@ func TestMerge(t *testing.T) {
 		}
 	}
 }
+
+func TestCovered(t *testing.T) {
+	for i, tc := range []struct {
+		ranges     [][2]uint64
+		start, end uint64
+		expected   uint64
+	}{
+		{
+			ranges:   nil,
+			start:    1,
+			end:      100,
+			expected: 0,
+		},
+		{
+			ranges:   [][2]uint64{{1, 100}},
+			start:    1,
+			end:      100,
+			expected: 100,
+		},
+		{
+			ranges:   [][2]uint64{{1, 10}, {20, 30}},
+			start:    1,
+			end:      100,
+			expected: 21,
+		},
+		{
+			ranges:   [][2]uint64{{1, 10}, {20, 30}},
+			start:    5,
+			end:      25,
+			expected: 12,
+		},
+		{
+			ranges:   [][2]uint64{{1, 10}, {120, 130}},
+			start:    1,
+			end:      100,
+			expected: 10,
+		},
+		{
+			ranges:   [][2]uint64{{20, 30}},
+			start:    1,
+			end:      10,
+			expected: 0,
+		},
+	} {
+		intervals := NewIntervals(1)
+		intervals.ranges = tc.ranges
+
+		got := intervals.Covered(tc.start, tc.end)
+		if got != tc.expected {
+			t.Errorf("interval #%d: expected %v, got %v", i, tc.expected, got)
+		}
+	}
+}
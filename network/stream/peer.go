@@ -27,6 +27,7 @@ import (
 	"github.com/ethersphere/swarm/network"
 	"github.com/ethersphere/swarm/network/stream/intervals"
 	"github.com/ethersphere/swarm/state"
+	"golang.org/x/time/rate"
 )
 
 // Peer is the Peer extension for the streaming protocol
@@ -44,6 +45,8 @@ type Peer struct {
 	openOffers         map[uint]offer    // maintain open offers on the server side
 	clientOpenGetRange map[string]uint   // maintain open GetRange requests to eliminate overlapping requests on the client side
 	serverOpenGetRange map[string]uint   // maintain open GetRange requests to eliminate overlapping requests on the server side
+	budget             *rate.Limiter     // syncing budget of the peer, nil if unlimited
+	syncedBytes        uint64            // size of chunks requested by syncing, accessed atomically
 
 	quit chan struct{} // closed when peer is going offline
 }
@@ -0,0 +1,214 @@
+// Copyright 2019 The Swarm Authors
+// This file is part of the Swarm library.
+//
+// The Swarm library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Swarm library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Swarm library. If not, see <http://www.gnu.org/licenses/>.
+
+package stream
+
+import (
+	"encoding/hex"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethersphere/swarm/network/stream/intervals"
+	"github.com/ethersphere/swarm/state"
+)
+
+const (
+	// syncRateBucket is the time span of a single sample of the sync rate
+	syncRateBucket = 10 * time.Second
+	// syncRateWindow is the time span of samples used to estimate the sync rate
+	syncRateWindow = 5 * time.Minute
+)
+
+// SyncProgress holds the progress of historical syncing from all
+// connected peers and the estimated time to complete it.
+type SyncProgress struct {
+	Peers     []PeerSyncProgress `json:"peers"`
+	Synced    uint64             `json:"synced"`    // number of chunk indexes synced from all peers
+	Remaining uint64             `json:"remaining"` // number of chunk indexes not yet synced from all peers
+	Rate      float64            `json:"rate"`      // number of chunk indexes synced per second recently
+	ETA       time.Duration      `json:"eta"`       // estimated time to sync the remaining chunk indexes, zero if not known
+	Done      bool               `json:"done"`      // true if all chunk indexes up to the peer cursors are synced
+}
+
+// PeerSyncProgress holds the progress of historical syncing from a single peer.
+type PeerSyncProgress struct {
+	Peer      string            `json:"peer"` // the peer address
+	Bins      []BinSyncProgress `json:"bins"`
+	Synced    uint64            `json:"synced"`
+	Remaining uint64            `json:"remaining"`
+}
+
+// BinSyncProgress holds the progress of historical syncing of a single
+// bin from a peer. Cursor is the bin cursor of the peer when the stream
+// was established, up to which the chunk indexes are synced historically.
+type BinSyncProgress struct {
+	Bin       uint8  `json:"bin"`
+	Cursor    uint64 `json:"cursor"`
+	Synced    uint64 `json:"synced"`
+	Remaining uint64 `json:"remaining"`
+	Intervals string `json:"intervals"`
+}
+
+// SyncProgress returns the progress of historical syncing by peer and bin,
+// based on the peer cursors and synced intervals. The number of remaining
+// chunks is an upper bound, as the same chunks are offered by many peers,
+// and so is the estimated time to sync them.
+func (r *Registry) SyncProgress() (*SyncProgress, error) {
+	r.mtx.RLock()
+	peers := make([]*Peer, 0, len(r.peers))
+	for _, p := range r.peers {
+		peers = append(peers, p)
+	}
+	r.mtx.RUnlock()
+
+	progress := &SyncProgress{
+		Peers: make([]PeerSyncProgress, 0, len(peers)),
+	}
+	for _, p := range peers {
+		pp := PeerSyncProgress{
+			Peer: hex.EncodeToString(p.OAddr)[:16],
+			Bins: make([]BinSyncProgress, 0),
+		}
+		for s, cursor := range p.getCursorsCopy() {
+			v := strings.SplitN(s, "|", 2)
+			if len(v) != 2 || v[0] != syncStreamName {
+				continue
+			}
+			bin, err := parseSyncKey(v[1])
+			if err != nil {
+				return nil, err
+			}
+			i := &intervals.Intervals{}
+			err = r.intervalsStore.Get(p.peerStreamIntervalKey(NewID(v[0], v[1])), i)
+			switch err {
+			case nil:
+			case state.ErrNotFound:
+				i = intervals.NewIntervals(1)
+			default:
+				return nil, err
+			}
+			synced := i.Covered(1, cursor)
+			pp.Bins = append(pp.Bins, BinSyncProgress{
+				Bin:       bin,
+				Cursor:    cursor,
+				Synced:    synced,
+				Remaining: cursor - synced,
+				Intervals: i.String(),
+			})
+			pp.Synced += synced
+			pp.Remaining += cursor - synced
+		}
+		sort.Slice(pp.Bins, func(i, j int) bool {
+			return pp.Bins[i].Bin < pp.Bins[j].Bin
+		})
+		progress.Peers = append(progress.Peers, pp)
+		progress.Synced += pp.Synced
+		progress.Remaining += pp.Remaining
+	}
+	sort.Slice(progress.Peers, func(i, j int) bool {
+		return progress.Peers[i].Peer < progress.Peers[j].Peer
+	})
+
+	progress.Rate = r.syncRate.rate()
+	if progress.Rate > 0 {
+		progress.ETA = time.Duration(float64(progress.Remaining) / progress.Rate * float64(time.Second))
+	}
+	progress.Done = progress.Remaining == 0
+	return progress, nil
+}
+
+// sealWant seals the interval of the want and records the number
+// of historically synced chunk indexes for the sync rate estimation.
+func (r *Registry) sealWant(p *Peer, w *want) error {
+	if err := p.sealWant(w); err != nil {
+		return err
+	}
+	if !w.head && w.stream.Name == syncStreamName && *w.to >= w.from {
+		r.syncRate.add(*w.to - w.from + 1)
+	}
+	return nil
+}
+
+// syncRate estimates the number of chunk indexes synced per
+// second from the counts in the recent syncRateWindow.
+type syncRate struct {
+	mu      sync.Mutex
+	buckets map[int64]uint64 // counts by the bucket index, which is the time divided by syncRateBucket
+	start   time.Time        // the time when the first count was added
+	now     func() time.Time // returns the current time, replaced in tests
+}
+
+func newSyncRate() *syncRate {
+	return &syncRate{
+		buckets: make(map[int64]uint64),
+		now:     time.Now,
+	}
+}
+
+// add records n synced chunk indexes at the current time.
+func (s *syncRate) add(n uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if s.start.IsZero() {
+		s.start = now
+	}
+	s.buckets[s.bucket(now)] += n
+	s.prune(now)
+}
+
+// rate returns the average number of chunk indexes synced per
+// second within the syncRateWindow, or since the first count
+// if it was added more recently.
+func (s *syncRate) rate() float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.prune(now)
+	var total uint64
+	for _, n := range s.buckets {
+		total += n
+	}
+	if total == 0 {
+		return 0
+	}
+	d := now.Sub(s.start)
+	if d > syncRateWindow {
+		d = syncRateWindow
+	}
+	if d < syncRateBucket {
+		d = syncRateBucket
+	}
+	return float64(total) / d.Seconds()
+}
+
+// prune removes the buckets that are older than the syncRateWindow.
+func (s *syncRate) prune(now time.Time) {
+	oldest := s.bucket(now.Add(-syncRateWindow))
+	for b := range s.buckets {
+		if b <= oldest {
+			delete(s.buckets, b)
+		}
+	}
+}
+
+func (s *syncRate) bucket(t time.Time) int64 {
+	return t.UnixNano() / int64(syncRateBucket)
+}
@@ -0,0 +1,135 @@
+// Copyright 2019 The Swarm Authors
+// This file is part of the Swarm library.
+//
+// The Swarm library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Swarm library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Swarm library. If not, see <http://www.gnu.org/licenses/>.
+
+package stream
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ethersphere/swarm/chunk"
+	"github.com/ethersphere/swarm/network/simulation"
+	"github.com/ethersphere/swarm/testutil"
+)
+
+// TestSyncProgress validates that the progress of syncing from a
+// peer is reported as done once all of its chunks are synced.
+func TestSyncProgress(t *testing.T) {
+	sim := simulation.NewBzzInProc(map[string]simulation.ServiceFunc{
+		"bzz-sync": newSyncSimServiceFunc(&SyncSimServiceOptions{Autostart: true}),
+	}, false)
+	defer sim.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	uploadNode, err := sim.AddNode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := testutil.RandomBytes(1, 3*BatchSize*chunk.DefaultSize)
+	_, wait, err := nodeFileStore(sim, uploadNode).Store(ctx, bytes.NewReader(data), int64(len(data)), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := wait(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	syncNode, err := sim.AddNode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sim.Net.Connect(uploadNode, syncNode); err != nil {
+		t.Fatal(err)
+	}
+	registry := sim.Service("bzz-sync", syncNode).(*Registry)
+
+	var progress *SyncProgress
+	for {
+		progress, err = registry.SyncProgress()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if progress.Done && progress.Synced > 0 {
+			break
+		}
+		select {
+		case <-time.After(50 * time.Millisecond):
+		case <-ctx.Done():
+			t.Fatalf("syncing not done: %+v", progress)
+		}
+	}
+
+	if len(progress.Peers) != 1 {
+		t.Fatalf("got progress of %v peers, want 1", len(progress.Peers))
+	}
+	var cursors uint64
+	for _, b := range progress.Peers[0].Bins {
+		if b.Synced != b.Cursor || b.Remaining != 0 {
+			t.Errorf("bin %v: got %v synced and %v remaining, want %v synced", b.Bin, b.Synced, b.Remaining, b.Cursor)
+		}
+		cursors += b.Cursor
+	}
+	if progress.Synced != cursors {
+		t.Errorf("got %v synced, want %v", progress.Synced, cursors)
+	}
+	if progress.Rate <= 0 {
+		t.Errorf("got sync rate %v, want positive", progress.Rate)
+	}
+	if progress.ETA != 0 {
+		t.Errorf("got eta %v, want 0", progress.ETA)
+	}
+}
+
+// TestSyncRate validates the estimation of the sync rate
+// within the sliding window of recent counts.
+func TestSyncRate(t *testing.T) {
+	now := time.Unix(1000000, 0)
+	s := newSyncRate()
+	s.now = func() time.Time { return now }
+
+	if r := s.rate(); r != 0 {
+		t.Errorf("got rate %v without counts, want 0", r)
+	}
+
+	// the rate of counts within a single bucket
+	// is averaged over the bucket time span
+	s.add(100)
+	if r, want := s.rate(), 100/syncRateBucket.Seconds(); r != want {
+		t.Errorf("got rate %v, want %v", r, want)
+	}
+
+	now = now.Add(time.Minute)
+	s.add(500)
+	if r, want := s.rate(), 600/time.Minute.Seconds(); r != want {
+		t.Errorf("got rate %v, want %v", r, want)
+	}
+
+	// the first count is out of the window
+	now = now.Add(syncRateWindow - 30*time.Second)
+	if r, want := s.rate(), 500/syncRateWindow.Seconds(); r != want {
+		t.Errorf("got rate %v, want %v", r, want)
+	}
+
+	// all counts are out of the window
+	now = now.Add(syncRateWindow)
+	if r := s.rate(); r != 0 {
+		t.Errorf("got rate %v, want 0", r)
+	}
+}
@@ -111,6 +111,8 @@ type Registry struct {
 	lastReceivedChunkTimeMu sync.RWMutex              // synchronize access to lastReceivedChunkTime
 	lastReceivedChunkTime   time.Time                 // last received chunk time
 	logger                  log.Logger                // the logger for the registry. appends base address to all logs
+	budget                  *syncBudget               // bandwidth budget of syncing
+	syncRate                *syncRate                 // rate of historical syncing
 }
 
 // New creates a new stream protocol handler
@@ -123,6 +125,8 @@ func New(intervalsStore state.Store, address *network.BzzAddr, providers ...Stre
 		address:        address,
 		logger:         log.New("base", address.ShortString()),
 		spec:           Spec,
+		budget:         newSyncBudget(&BudgetParams{}, nil),
+		syncRate:       newSyncRate(),
 	}
 	for _, p := range providers {
 		r.providers[p.StreamName()] = p
@@ -131,9 +135,17 @@ func New(intervalsStore state.Store, address *network.BzzAddr, providers ...Stre
 	return r
 }
 
+// SetBudgetParams sets the bandwidth budget of syncing. Syncing is paused
+// based on retrieval latencies returned by the latency function, which can
+// be nil. It must be called before the Registry is started.
+func (r *Registry) SetBudgetParams(p *BudgetParams, latency RetrievalLatencyFunc) {
+	r.budget = newSyncBudget(p, latency)
+}
+
 // Run is being dispatched when 2 nodes connect
 func (r *Registry) Run(bp *network.BzzPeer) error {
 	sp := newPeer(bp, r.address, r.intervalsStore, r.providers)
+	sp.budget = r.budget.newPeerLimiter()
 	// enable msg pauser for stream protocol, this is used only in tests
 	sp.Peer.SetMsgPauser(handleMsgPauser)
 	r.addPeer(sp)
@@ -487,7 +499,7 @@ func (r *Registry) clientHandleOfferedHashes(ctx context.Context, p *Peer, msg *
 	// lenhashes == 0 means there's no hashes in the requested range with the upper bound of
 	// the LastIndex on the incoming message. we should seal the interval and request the subsequent
 	if lenHashes == 0 {
-		if err := p.sealWant(w); err != nil {
+		if err := r.sealWant(p, w); err != nil {
 			return protocols.Break(fmt.Errorf("persisting interval from %d, to %d: %w", w.from, w.to, err))
 		}
 		return r.requestSubsequentRange(ctx, p, provider, w, msg.LastIndex)
@@ -545,7 +557,7 @@ func (r *Registry) clientHandleOfferedHashes(ctx context.Context, p *Peer, msg *
 	if ctr == 0 {
 		streamEmptyWantedHashes.Inc(1)
 		wantedHashesMsg.BitVector = []byte{} // set the bitvector value to an empty slice, this is to signal the server we dont want any hashes
-		if err := p.sealWant(w); err != nil {
+		if err := r.sealWant(p, w); err != nil {
 			return protocols.Break(fmt.Errorf("persisting interval from %d, to %d: %w", w.from, w.to, err))
 		}
 		if err := p.Send(ctx, wantedHashesMsg); err != nil {
@@ -555,6 +567,13 @@ func (r *Registry) clientHandleOfferedHashes(ctx context.Context, p *Peer, msg *
 		// request the next range in case no chunks wanted
 		return r.requestSubsequentRange(ctx, p, provider, w, msg.LastIndex)
 	} else {
+		// wait for the bandwidth budget before
+		// requesting the chunks from the peer
+		if err := r.waitBudget(ctx, p, provider, w, int(ctr)*chunk.DefaultSize); err != nil {
+			p.logger.Debug("waiting for syncing budget", "ruid", w.ruid, "err", err)
+			return nil
+		}
+
 		// we want some hashes
 		streamWantedHashes.Inc(1)
 		wantedHashesMsg.BitVector = want.Bytes() // set to bitvector
@@ -574,7 +593,7 @@ func (r *Registry) clientHandleOfferedHashes(ctx context.Context, p *Peer, msg *
 		}
 
 		// seal the interval
-		if err := p.sealWant(w); err != nil {
+		if err := r.sealWant(p, w); err != nil {
 			return protocols.Break(fmt.Errorf("persisting interval from %d, to %d: %w", w.from, w.to, err))
 		}
 	case <-time.After(timeouts.SyncBatchTimeout):
@@ -797,6 +816,32 @@ func (r *Registry) clientHandleChunkDelivery(ctx context.Context, p *Peer, msg *
 	return nil
 }
 
+// waitBudget blocks until the size of chunks of the want can be
+// requested from the peer within the syncing budget, or until
+// the node is shutting down or the peer is removed.
+func (r *Registry) waitBudget(ctx context.Context, p *Peer, provider StreamProvider, w *want, size int) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-r.quit:
+		case <-p.quit:
+		case <-ctx.Done():
+		}
+		cancel()
+	}()
+
+	withinDepth := true
+	if sp, ok := provider.(*syncProvider); ok {
+		withinDepth = sp.withinDepth(w.stream)
+	}
+	if err := r.budget.wait(ctx, p.budget, size, withinDepth, w.head); err != nil {
+		return err
+	}
+	atomic.AddUint64(&p.syncedBytes, uint64(size))
+	return nil
+}
+
 // clientSealBatch seals a given batch (want). it launches a separate goroutine that check every chunk being delivered on the given ruid
 // if an unsolicited chunk is received it drops the peer
 func (r *Registry) clientSealBatch(ctx context.Context, p *Peer, provider StreamProvider, w *want) <-chan error {
@@ -985,12 +1030,14 @@ type PeerInfo struct {
 	Peers     []PeerState                  `json:"peers"`
 	Cursors   map[string]map[string]uint64 `json:"cursors"`
 	Intervals map[string]string            `json:"intervals"`
+	Budget    *BudgetInfo                  `json:"budget"`
 }
 
 // PeerState holds information about a connected peer.
 type PeerState struct {
-	Peer    string            `json:"peer"` // the peer address
-	Cursors map[string]uint64 `json:"cursors"`
+	Peer        string            `json:"peer"` // the peer address
+	Cursors     map[string]uint64 `json:"cursors"`
+	SyncedBytes uint64            `json:"syncedBytes"` // size of chunks requested by syncing
 }
 
 // PeerInfo returns a response in which the queried node's
@@ -999,6 +1046,7 @@ func (r *Registry) PeerInfo() (*PeerInfo, error) {
 	info := &PeerInfo{
 		Base:    r.address.ShortUnder(),
 		Cursors: make(map[string]map[string]uint64),
+		Budget:  r.budget.info(),
 	}
 	for name, p := range r.providers {
 		info.Cursors[name] = make(map[string]uint64)
@@ -1034,8 +1082,9 @@ func (r *Registry) PeerInfo() (*PeerInfo, error) {
 	}
 	for _, p := range r.peers {
 		info.Peers = append(info.Peers, PeerState{
-			Peer:    hex.EncodeToString(p.OAddr)[:16],
-			Cursors: p.getCursorsCopy(),
+			Peer:        hex.EncodeToString(p.OAddr)[:16],
+			Cursors:     p.getCursorsCopy(),
+			SyncedBytes: atomic.LoadUint64(&p.syncedBytes),
 		})
 	}
 	return info, nil
@@ -1074,7 +1123,14 @@ func (r *Registry) runProtocol(p *p2p.Peer, rw p2p.MsgReadWriter) error {
 }
 
 func (r *Registry) APIs() []rpc.API {
-	return nil
+	return []rpc.API{
+		{
+			Namespace: "stream",
+			Version:   "1.0",
+			Service:   NewAPI(r),
+			Public:    false,
+		},
+	}
 }
 
 func (r *Registry) Start(server *p2p.Server) error {
@@ -294,6 +294,16 @@ func (s *syncProvider) WantStream(p *Peer, streamID ID) bool {
 	return checkKeyInSlice(int(v), subBins)
 }
 
+// withinDepth returns true if the stream is of
+// a bin within the neighbourhood depth.
+func (s *syncProvider) withinDepth(streamID ID) bool {
+	bin, err := parseSyncKey(streamID.Key)
+	if err != nil {
+		return false
+	}
+	return int(bin) >= s.kad.NeighbourhoodDepth()
+}
+
 var (
 	SyncInitBackoff = 500 * time.Millisecond
 )
@@ -0,0 +1,484 @@
+// Copyright 2019 The Swarm Authors
+// This file is part of the Swarm library.
+//
+// The Swarm library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Swarm library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Swarm library. If not, see <http://www.gnu.org/licenses/>.
+
+package stream
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/hashicorp/golang-lru/simplelru"
+
+	"github.com/ethersphere/swarm/chunk"
+	"github.com/ethersphere/swarm/log"
+	"github.com/ethersphere/swarm/network"
+	"github.com/ethersphere/swarm/state"
+	"github.com/ethersphere/swarm/storage"
+)
+
+const (
+	treeStreamName    = "TREE"
+	treeCacheCapacity = 16 // number of trees for which sizes and paused walks are kept
+)
+
+var (
+	// ErrTreeNotFound is returned by RequestTree if the
+	// peer does not have all chunks of the content.
+	ErrTreeNotFound = errors.New("content tree not found on peer")
+
+	// ErrTreeEncrypted is returned by RequestTree for the reference
+	// of encrypted content, as it includes the encryption key.
+	ErrTreeEncrypted = errors.New("content tree of encrypted content can not be requested")
+
+	// treeCheckInterval is the time between checks
+	// whether all chunks of a requested tree are synced
+	treeCheckInterval = 100 * time.Millisecond
+
+	// treeCursorTTL is the time for which the size of a tree is
+	// reported as the stream cursor without walking the tree again
+	treeCursorTTL = time.Minute
+)
+
+// TreeWalkFunc calls walkFn with the addresses of all chunks of the content
+// with the root address, in the same order on every call. It is used by the
+// tree provider to list the chunks that it offers to peers.
+type TreeWalkFunc func(ctx context.Context, root chunk.Address, walkFn func(chunk.Address) error) error
+
+// treeProvider is the stream provider of all chunks of a content tree. Stream
+// keys are hex encoded root addresses and stream cursors are the number of
+// chunks walked in the tree. As opposed to syncing, the streams are bounded
+// and they are requested explicitly with Registry RequestTree. Trees of
+// encrypted content are not streamed, as their root reference includes the
+// encryption key, which must not be revealed to peers.
+type treeProvider struct {
+	netStore  *storage.NetStore // netstore
+	walk      TreeWalkFunc      // lists chunks of the trees that are offered
+	name      string            // name of the stream we are responsible for
+	cursors   *lru.Cache        // treeCursor of recently offered trees by stream key
+	mu        sync.Mutex        // protects requested and walkers
+	requested map[string]int    // number of RequestTree calls in progress by peer and stream key
+	walkers   *simplelru.LRU    // paused walks of recently offered trees by stream key and next index
+	quit      chan struct{}     // shutdown
+	logger    log.Logger        // logger that appends the base address to loglines
+}
+
+// treeCursor is the number of chunks of a tree and the time when it was walked.
+type treeCursor struct {
+	count    uint64
+	walkedAt time.Time
+}
+
+// NewTreeProvider creates a new stream provider that streams all chunks of
+// content trees. Argument walk lists the chunks of a tree, and if it is nil,
+// only the chunks of a single file tree are streamed.
+func NewTreeProvider(ns *storage.NetStore, baseAddr *network.BzzAddr, walk TreeWalkFunc) StreamProvider {
+	cursors, err := lru.New(treeCacheCapacity)
+	if err != nil {
+		panic(err)
+	}
+	walkers, err := simplelru.NewLRU(treeCacheCapacity, nil)
+	if err != nil {
+		panic(err)
+	}
+	if walk == nil {
+		walk = func(ctx context.Context, root chunk.Address, walkFn func(chunk.Address) error) error {
+			return storage.WalkChunkTree(ctx, ns.Store, root, walkFn)
+		}
+	}
+	return &treeProvider{
+		netStore:  ns,
+		walk:      walk,
+		name:      treeStreamName,
+		cursors:   cursors,
+		requested: make(map[string]int),
+		walkers:   walkers,
+		quit:      make(chan struct{}),
+		logger:    log.NewBaseAddressLogger(baseAddr.ShortString()),
+	}
+}
+
+// treeWalker walks a tree in a goroutine that is paused until the next
+// address is received, so that the tree is streamed in subsequent batches
+// without keeping all of its addresses in memory.
+type treeWalker struct {
+	next   uint64             // index of the address that is received next
+	addrs  chan chunk.Address // addresses of the walk, closed when the walk is done
+	err    error              // error of the walk, set before addrs is closed
+	cancel func()             // stops the walk
+}
+
+// newTreeWalker starts the walk of the tree with the root address.
+func (s *treeProvider) newTreeWalker(root chunk.Address) *treeWalker {
+	ctx, cancel := context.WithCancel(context.Background())
+	w := &treeWalker{
+		next:   1,
+		addrs:  make(chan chunk.Address),
+		cancel: cancel,
+	}
+	go func() {
+		defer close(w.addrs)
+		w.err = s.walk(ctx, root, func(addr chunk.Address) error {
+			select {
+			case w.addrs <- addr:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-s.quit:
+				return errors.New("shutting down")
+			}
+		})
+	}()
+	return w
+}
+
+// takeWalker returns the paused walk of the tree that continues at
+// the index, or a new walk if there is none.
+func (s *treeProvider) takeWalker(root chunk.Address, index uint64) *treeWalker {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := treeWalkerKey(root, index)
+	if v, ok := s.walkers.Peek(key); ok {
+		s.walkers.Remove(key)
+		return v.(*treeWalker)
+	}
+	return s.newTreeWalker(root)
+}
+
+// putWalker pauses the walk of the tree, so that it can be
+// taken by the subscription that continues at its next index.
+func (s *treeProvider) putWalker(root chunk.Address, w *treeWalker) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := treeWalkerKey(root, w.next)
+	if v, ok := s.walkers.Peek(key); ok {
+		v.(*treeWalker).cancel()
+	} else if s.walkers.Len() >= treeCacheCapacity {
+		if _, v, ok := s.walkers.RemoveOldest(); ok {
+			v.(*treeWalker).cancel()
+		}
+	}
+	s.walkers.Add(key, w)
+}
+
+func treeWalkerKey(root chunk.Address, index uint64) string {
+	return fmt.Sprintf("%s|%d", root.Hex(), index)
+}
+
+// NeedData checks which of the chunks are not in the local store
+func (s *treeProvider) NeedData(ctx context.Context, addrs ...chunk.Address) ([]bool, error) {
+	has, err := s.netStore.Store.HasMulti(ctx, addrs...)
+	if err != nil {
+		return nil, err
+	}
+	wants := make([]bool, len(has))
+	for i, have := range has {
+		wants[i] = !have
+	}
+	return wants, nil
+}
+
+// Get the supplied addresses for delivery
+func (s *treeProvider) Get(ctx context.Context, addrs ...chunk.Address) ([]chunk.Chunk, error) {
+	return s.netStore.Store.GetMulti(ctx, chunk.ModeGetRequest, addrs...)
+}
+
+// Put the given chunks to the local storage, the same as the retrieved ones
+func (s *treeProvider) Put(ctx context.Context, ch ...chunk.Chunk) (exists []bool, err error) {
+	return s.netStore.Put(ctx, chunk.ModePutRequest, ch...)
+}
+
+// Set is a noop, as the offered chunks are not synced
+func (s *treeProvider) Set(ctx context.Context, addrs ...chunk.Address) error {
+	return nil
+}
+
+// Subscribe returns the chunks of the tree with indexes within the interval.
+// Indexes of chunks start from 1, as intervals do not start from 0. Chunks
+// that are walked more than once in the tree are offered more than once.
+// Only the chunks that are walked are checked to be in the local store,
+// and the subscription ends at the first chunk that is not.
+func (s *treeProvider) Subscribe(ctx context.Context, key interface{}, from, to uint64) (<-chan chunk.Descriptor, func()) {
+	root := key.(chunk.Address)
+	s.logger.Debug("treeProvider.Subscribe", "root", root, "from", from, "to", to)
+
+	ctx, cancel := context.WithCancel(ctx)
+	c := make(chan chunk.Descriptor)
+	go func() {
+		defer close(c)
+
+		if from == 0 {
+			from = 1
+		}
+		w := s.takeWalker(root, from)
+		for to == 0 || w.next <= to {
+			var addr chunk.Address
+			var ok bool
+			select {
+			case addr, ok = <-w.addrs:
+			case <-ctx.Done():
+				s.putWalker(root, w)
+				return
+			case <-s.quit:
+				w.cancel()
+				return
+			}
+			if !ok {
+				if w.err != nil {
+					s.logger.Debug("treeProvider.Subscribe", "root", root, "err", w.err)
+					// the tree is walked again for the next cursor
+					s.cursors.Remove(root.Hex())
+				}
+				return
+			}
+			i := w.next
+			w.next++
+			if i < from {
+				continue
+			}
+			select {
+			case c <- chunk.Descriptor{Address: addr, BinID: i}:
+			case <-ctx.Done():
+				s.putWalker(root, w)
+				return
+			case <-s.quit:
+				w.cancel()
+				return
+			}
+		}
+		s.putWalker(root, w)
+	}()
+	return c, cancel
+}
+
+// Cursor returns the number of chunks in the tree, or 0 if not all chunks
+// of the tree are in the local store. The tree is walked again only if it
+// was not walked within treeCursorTTL, so that repeated stream info requests
+// do not walk large trees.
+func (s *treeProvider) Cursor(k string) (cursor uint64, err error) {
+	key, err := s.ParseKey(k)
+	if err != nil {
+		return 0, err
+	}
+	if v, ok := s.cursors.Get(k); ok {
+		c := v.(treeCursor)
+		if time.Since(c.walkedAt) < treeCursorTTL {
+			return c.count, nil
+		}
+	}
+	var count uint64
+	err = s.walk(context.Background(), key.(chunk.Address), func(chunk.Address) error {
+		count++
+		return nil
+	})
+	if err != nil {
+		s.logger.Debug("treeProvider.Cursor", "root", k, "err", err)
+		s.cursors.Remove(k)
+		return 0, nil
+	}
+	s.cursors.Add(k, treeCursor{count: count, walkedAt: time.Now()})
+	return count, nil
+}
+
+// InitPeer does not establish any streams, as they are requested with RequestTree
+func (s *treeProvider) InitPeer(p *Peer) {}
+
+// WantStream checks if the tree is requested from the peer
+func (s *treeProvider) WantStream(p *Peer, streamID ID) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.requested[treeRequestKey(p.ID(), streamID)] > 0
+}
+
+// request marks the stream as wanted from the peer and returns
+// true if it is not already requested by another call.
+func (s *treeProvider) request(id enode.ID, streamID ID) (first bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := treeRequestKey(id, streamID)
+	s.requested[key]++
+	return s.requested[key] == 1
+}
+
+// release reverts the request and returns true
+// if the stream is no longer wanted from the peer.
+func (s *treeProvider) release(id enode.ID, streamID ID) (last bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := treeRequestKey(id, streamID)
+	s.requested[key]--
+	if s.requested[key] > 0 {
+		return false
+	}
+	delete(s.requested, key)
+	return true
+}
+
+func treeRequestKey(id enode.ID, streamID ID) string {
+	return id.String() + "|" + streamID.Key
+}
+
+func (s *treeProvider) ParseKey(streamKey string) (interface{}, error) {
+	b, err := hex.DecodeString(streamKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) != chunk.AddressLength {
+		return nil, fmt.Errorf("invalid tree root length %v", len(b))
+	}
+	return chunk.Address(b), nil
+}
+
+func (s *treeProvider) EncodeKey(i interface{}) (string, error) {
+	v, ok := i.(chunk.Address)
+	if !ok {
+		return "", errors.New("error encoding key")
+	}
+	return v.Hex(), nil
+}
+
+func (s *treeProvider) StreamName() string { return s.name }
+
+func (s *treeProvider) Boundedness() bool { return true }
+
+func (s *treeProvider) Autostart() bool { return true }
+
+func (s *treeProvider) Close() {
+	close(s.quit)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, key := range s.walkers.Keys() {
+		if v, ok := s.walkers.Peek(key); ok {
+			v.(*treeWalker).cancel()
+		}
+	}
+	s.walkers.Purge()
+}
+
+// RequestTree syncs all chunks of the content tree with the root address
+// from the peer, that are not in the local store. It blocks until all chunks
+// are received, returning ErrTreeNotFound if the peer does not have them.
+// The tree of encrypted content is not requested and ErrTreeEncrypted is
+// returned.
+func (r *Registry) RequestTree(ctx context.Context, id enode.ID, root chunk.Address) error {
+	if len(root) > chunk.AddressLength {
+		return ErrTreeEncrypted
+	}
+	stream := NewID(treeStreamName, root.Hex())
+	provider, ok := r.getProvider(stream).(*treeProvider)
+	if !ok {
+		return errors.New("tree stream provider not registered")
+	}
+	p := r.getPeer(id)
+	if p == nil {
+		return fmt.Errorf("peer %s not connected", id)
+	}
+	if _, err := provider.ParseKey(stream.Key); err != nil {
+		return err
+	}
+
+	key := p.peerStreamIntervalKey(stream)
+	if provider.request(id, stream) {
+		// chunks of a tree synced before could be garbage collected,
+		// so the intervals are started anew for every request
+		if err := r.resetTreeStream(p, stream); err != nil {
+			provider.release(id, stream)
+			return err
+		}
+		if _, err := p.getOrCreateInterval(key); err != nil {
+			provider.release(id, stream)
+			return err
+		}
+		if err := p.Send(ctx, &StreamInfoReq{Streams: []ID{stream}}); err != nil {
+			provider.release(id, stream)
+			return err
+		}
+	}
+	defer func() {
+		if provider.release(id, stream) {
+			if err := r.resetTreeStream(p, stream); err != nil {
+				p.logger.Error("reset tree stream", "stream", stream, "err", err)
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(treeCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-p.quit:
+			return fmt.Errorf("peer %s disconnected", id)
+		case <-r.quit:
+			return errors.New("shutting down")
+		}
+		cursor, ok := p.getCursor(stream)
+		if !ok {
+			// stream info response not yet received
+			continue
+		}
+		if cursor == 0 {
+			return ErrTreeNotFound
+		}
+		from, _, _, err := p.nextInterval(stream, 0)
+		if err != nil {
+			return err
+		}
+		if from > cursor {
+			return nil
+		}
+	}
+}
+
+// RequestTreeFromPeers requests the content tree with RequestTree from
+// the peers in order, until one of them has all of its chunks.
+func (r *Registry) RequestTreeFromPeers(ctx context.Context, peers []enode.ID, root chunk.Address) (err error) {
+	err = ErrTreeNotFound
+	for _, id := range peers {
+		err = r.RequestTree(ctx, id, root)
+		if err == nil || err == ErrTreeEncrypted || ctx.Err() != nil {
+			return err
+		}
+		r.logger.Debug("request tree from peer", "peer", id, "root", root, "err", err)
+	}
+	return err
+}
+
+// resetTreeStream removes the cursor and intervals of the stream for the peer.
+func (r *Registry) resetTreeStream(p *Peer, stream ID) error {
+	p.deleteCursor(stream)
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	err := r.intervalsStore.Delete(p.peerStreamIntervalKey(stream))
+	if err != nil && err != state.ErrNotFound {
+		return err
+	}
+	return nil
+}
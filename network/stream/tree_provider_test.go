@@ -0,0 +1,182 @@
+// Copyright 2019 The Swarm Authors
+// This file is part of the Swarm library.
+//
+// The Swarm library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Swarm library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Swarm library. If not, see <http://www.gnu.org/licenses/>.
+
+package stream
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ethersphere/swarm/chunk"
+	"github.com/ethersphere/swarm/network"
+	"github.com/ethersphere/swarm/network/simulation"
+	"github.com/ethersphere/swarm/storage"
+	"github.com/ethersphere/swarm/storage/localstore"
+	"github.com/ethersphere/swarm/testutil"
+)
+
+// TestRequestTree validates that all chunks of a file are synced from
+// a peer with the tree stream, that a tree which the peer does not have
+// is reported as not found, and that the tree of an encrypted file is
+// not requested.
+func TestRequestTree(t *testing.T) {
+	sim := simulation.NewBzzInProc(map[string]simulation.ServiceFunc{
+		"bzz-sync": newSyncSimServiceFunc(&SyncSimServiceOptions{TreeProvider: true}),
+	}, false)
+	defer sim.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	uploadNode, err := sim.AddNode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a file with more chunks than a single batch
+	data := testutil.RandomBytes(1, 3*BatchSize*chunk.DefaultSize+100)
+	root, wait, err := nodeFileStore(sim, uploadNode).Store(ctx, bytes.NewReader(data), int64(len(data)), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := wait(ctx); err != nil {
+		t.Fatal(err)
+	}
+	encryptedRoot, wait, err := nodeFileStore(sim, uploadNode).Store(ctx, bytes.NewReader(data), int64(len(data)), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := wait(ctx); err != nil {
+		t.Fatal(err)
+	}
+	uploadStore := sim.MustNodeItem(uploadNode, bucketKeyLocalStore).(*localstore.DB)
+	want := treeChunkCount(ctx, t, uploadStore, root)
+
+	syncNode, err := sim.AddNode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sim.Net.Connect(uploadNode, syncNode); err != nil {
+		t.Fatal(err)
+	}
+	registry := sim.Service("bzz-sync", syncNode).(*Registry)
+	for registry.getPeer(uploadNode) == nil {
+		select {
+		case <-time.After(10 * time.Millisecond):
+		case <-ctx.Done():
+			t.Fatal(ctx.Err())
+		}
+	}
+
+	if err := registry.RequestTree(ctx, uploadNode, root); err != nil {
+		t.Fatal(err)
+	}
+	syncStore := sim.MustNodeItem(syncNode, bucketKeyLocalStore).(*localstore.DB)
+	if got := treeChunkCount(ctx, t, syncStore, root); got != want {
+		t.Errorf("got %v synced chunks, want %v", got, want)
+	}
+
+	if err := registry.RequestTree(ctx, uploadNode, encryptedRoot); err != ErrTreeEncrypted {
+		t.Errorf("got error %v for the encrypted tree, want %v", err, ErrTreeEncrypted)
+	}
+
+	// the tree is requested again, without any chunks to sync
+	if err := registry.RequestTree(ctx, uploadNode, root); err != nil {
+		t.Fatal(err)
+	}
+
+	missing := storage.GenerateRandomChunk(chunk.DefaultSize).Address()
+	if err := registry.RequestTree(ctx, uploadNode, missing); err != ErrTreeNotFound {
+		t.Errorf("got error %v, want %v", err, ErrTreeNotFound)
+	}
+
+	p := registry.getPeer(uploadNode)
+	for _, addr := range []chunk.Address{root, missing} {
+		if _, ok := p.getCursor(NewID(treeStreamName, addr.Hex())); ok {
+			t.Errorf("stream cursor of tree %s not removed", addr)
+		}
+	}
+}
+
+// treeChunkCount returns the number of chunks of the
+// tree with the root address in the store.
+func treeChunkCount(ctx context.Context, t *testing.T, store chunk.Store, root chunk.Address) (count int) {
+	t.Helper()
+
+	err := storage.WalkChunkTree(ctx, store, root, func(chunk.Address) error {
+		count++
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return count
+}
+
+// TestTreeProviderSubscribe validates that subsequent subscriptions of a tree
+// continue its walk and that the cursor is not walked again within the TTL.
+func TestTreeProviderSubscribe(t *testing.T) {
+	tree := make([]chunk.Address, 10)
+	for i := range tree {
+		tree[i] = storage.GenerateRandomChunk(chunk.DefaultSize).Address()
+	}
+	var walks int
+	s := NewTreeProvider(nil, network.RandomBzzAddr(), func(ctx context.Context, root chunk.Address, walkFn func(chunk.Address) error) error {
+		walks++
+		for _, addr := range tree {
+			if err := walkFn(addr); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	defer s.Close()
+
+	root := tree[0]
+	for i := 0; i < 2; i++ {
+		cursor, err := s.Cursor(root.Hex())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cursor != uint64(len(tree)) {
+			t.Fatalf("got cursor %v, want %v", cursor, len(tree))
+		}
+	}
+	if walks != 1 {
+		t.Fatalf("got %v walks for cursors, want 1", walks)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	for _, r := range [][2]uint64{{1, 4}, {5, 8}, {9, 10}} {
+		c, stop := s.Subscribe(ctx, root, r[0], r[1])
+		want := r[0]
+		for d := range c {
+			if d.BinID != want || !bytes.Equal(d.Address, tree[want-1]) {
+				t.Fatalf("got chunk %v at %v, want %v at %v", d.Address, d.BinID, tree[want-1], want)
+			}
+			want++
+		}
+		stop()
+		if want != r[1]+1 {
+			t.Fatalf("got chunks to %v, want to %v", want-1, r[1])
+		}
+	}
+	if walks != 2 {
+		t.Errorf("got %v walks, want 2", walks)
+	}
+}
@@ -236,6 +236,40 @@ func TestForwardBasic(t *testing.T) {
 	}
 }
 
+// TestForwardLightNodes checks that light node peers only
+// receive the messages that are addressed to them.
+func TestForwardLightNodes(t *testing.T) {
+	baseAddrBytes := make([]byte, 32)
+	for i := 0; i < len(baseAddrBytes); i++ {
+		baseAddrBytes[i] = 0xFF
+	}
+	base := pot.NewAddressFromBytes(baseAddrBytes)
+	full := pot.RandomAddressAt(base, 0)
+	light := pot.RandomAddressAt(base, 5)
+
+	kad := network.NewKademlia(base[:], network.NewKadParams())
+	ps := createPss(t, kad)
+	defer ps.Stop()
+	addPeers(kad, []pot.Address{full})
+	lightPeer := newTestDiscoveryPeer(light, kad)
+	lightPeer.BzzAddr.WithCapabilities(network.NewLightCapabilities())
+	kad.On(lightPeer)
+
+	peers := []pot.Address{full, light}
+	testForwardMsg(t, ps, &testCase{
+		name:      "light node is closer to the recipient",
+		recipient: pot.RandomAddressAt(light, 64).Bytes(),
+		peers:     peers,
+		expected:  []int{0},
+	})
+	testForwardMsg(t, ps, &testCase{
+		name:      "light node is the recipient",
+		recipient: light.Bytes(),
+		peers:     peers,
+		expected:  []int{1},
+	})
+}
+
 // this function tests the forwarding of a single message. the recipient address is passed as param,
 // along with addresses of all peers, and indices of those peers which are expected to receive the message.
 func testForwardMsg(t *testing.T, ps *Pss, c *testCase) {
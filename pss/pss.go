@@ -769,6 +769,10 @@ func (p *Pss) forward(msg *message.Message) error {
 			return false
 		}
 		for _, lbPeer := range bin.LBPeers {
+			// light nodes only receive messages addressed to them
+			if lbPeer.Peer.IsLightNode() && !bytes.HasPrefix(lbPeer.Peer.Address(), msg.To) {
+				continue
+			}
 			if sendFunc(p, lbPeer.Peer, msg) {
 				lbPeer.AddUseCount()
 				sent++
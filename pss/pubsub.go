@@ -45,13 +45,17 @@ func (p *PubSub) BaseAddr() []byte {
 }
 
 func isPssPeer(bp *network.BzzPeer) bool {
-	return bp.HasCap(protocolName)
+	return bp.HasCap(protocolName) && !bp.IsLightNode()
 }
 
 // IsClosestTo returns true is self is the closest known node to addr
 // as uniquely defined by the MSB XOR distance
-// among pss capable peers
+// among pss capable full node peers.
+// A light node is never the closest, as it does not store chunks for the network.
 func (p *PubSub) IsClosestTo(addr []byte) bool {
+	if network.IsLightNode(p.pss.Capabilities) {
+		return false
+	}
 	return p.pss.IsClosestTo(addr, isPssPeer)
 }
 
@@ -33,6 +33,7 @@ import (
 	"github.com/syndtr/goleveldb/leveldb"
 	"github.com/syndtr/goleveldb/leveldb/iterator"
 	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb/util"
 )
 
 const (
@@ -151,6 +152,27 @@ func (db *DB) WriteBatch(batch *leveldb.Batch) (err error) {
 	return nil
 }
 
+// SizeOf returns the approximate size of the database
+// on disk in the key range from start to limit.
+func (db *DB) SizeOf(start, limit []byte) (size int64, err error) {
+	sizes, err := db.ldb.SizeOf([]util.Range{{Start: start, Limit: limit}})
+	if err != nil {
+		return 0, err
+	}
+	return sizes.Sum(), nil
+}
+
+// CompactRange wraps LevelDB CompactRange method to increment metrics counter.
+func (db *DB) CompactRange(start, limit []byte) (err error) {
+	err = db.ldb.CompactRange(util.Range{Start: start, Limit: limit})
+	if err != nil {
+		metrics.GetOrRegisterCounter("DB/compactrangeFail", nil).Inc(1)
+		return err
+	}
+	metrics.GetOrRegisterCounter("DB/compactrange", nil).Inc(1)
+	return nil
+}
+
 // Close closes LevelDB database.
 func (db *DB) Close() (err error) {
 	close(db.quit)
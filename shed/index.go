@@ -21,6 +21,7 @@ import (
 
 	"github.com/syndtr/goleveldb/leveldb"
 	"github.com/syndtr/goleveldb/leveldb/iterator"
+	"github.com/syndtr/goleveldb/leveldb/util"
 )
 
 // Item holds fields relevant to Swarm Chunk data and metadata.
@@ -430,6 +431,38 @@ func (f Index) Count() (count int, err error) {
 	return count, it.Error()
 }
 
+// IndexStats holds the number of keys in an Index,
+// the total size of its keys and values and the
+// approximate size they take on disk.
+type IndexStats struct {
+	Count      int   `json:"count"`
+	KeyBytes   int64 `json:"keyBytes"`
+	ValueBytes int64 `json:"valueBytes"`
+	DiskBytes  int64 `json:"diskBytes"`
+}
+
+// Stats returns the statistics of index keys and values.
+func (f Index) Stats() (s IndexStats, err error) {
+	it := f.db.NewIterator()
+	defer it.Release()
+
+	for ok := it.Seek(f.prefix); ok; ok = it.Next() {
+		key := it.Key()
+		if key[0] != f.prefix[0] {
+			break
+		}
+		s.Count++
+		s.KeyBytes += int64(len(key))
+		s.ValueBytes += int64(len(it.Value()))
+	}
+	if err := it.Error(); err != nil {
+		return s, err
+	}
+	r := util.BytesPrefix(f.prefix)
+	s.DiskBytes, err = f.db.SizeOf(r.Start, r.Limit)
+	return s, err
+}
+
 // CountFrom returns the number of items in index keys
 // starting from the key encoded from the provided Item.
 func (f Index) CountFrom(start Item) (count int, err error) {
@@ -1104,3 +1104,51 @@ func TestIndex_HasMulti(t *testing.T) {
 		t.Errorf("got %v, want %v", got, want)
 	}
 }
+
+// TestIndex_Stats validates that Index.Stats returns the number
+// and the sizes of keys and values of only the index items.
+func TestIndex_Stats(t *testing.T) {
+	db, cleanupFunc := newTestDB(t)
+	defer cleanupFunc()
+
+	index, err := db.NewIndex("retrieval", retrievalIndexFuncs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// items of another index are not counted
+	secondIndex, err := db.NewIndex("second-index", retrievalIndexFuncs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var want IndexStats
+	for i := 0; i < 100; i++ {
+		item := Item{
+			Address: []byte(fmt.Sprintf("stats-hash-%03d", i)),
+			Data:    bytes.Repeat([]byte{byte(i)}, 1000),
+		}
+		if err := index.Put(item); err != nil {
+			t.Fatal(err)
+		}
+		if err := secondIndex.Put(item); err != nil {
+			t.Fatal(err)
+		}
+		want.Count++
+		want.KeyBytes += int64(1 + len(item.Address)) // index prefix and address
+		want.ValueBytes += int64(8 + len(item.Data))  // store timestamp and data
+	}
+	if err := db.CompactRange(nil, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := index.Stats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Count != want.Count || got.KeyBytes != want.KeyBytes || got.ValueBytes != want.ValueBytes {
+		t.Fatalf("got stats %+v, want %+v", got, want)
+	}
+	if got.DiskBytes <= 0 {
+		t.Fatalf("got disk bytes %v, want more than 0", got.DiskBytes)
+	}
+}
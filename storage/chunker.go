@@ -371,6 +371,8 @@ type LazyChunkReader struct {
 	hashSize  int64 // inherit from chunker
 	depth     int
 	getter    Getter
+	// prefetcher, if not nil, fetches chunks ahead of sequential reads
+	prefetcher *prefetcher
 }
 
 func (tc *TreeChunker) Join(ctx context.Context) *LazyChunkReader {
@@ -458,6 +460,17 @@ func (r *LazyChunkReader) ReadAt(b []byte, off int64) (read int, err error) {
 	for ; treeSize < size; treeSize *= r.branches {
 		depth++
 	}
+	if r.prefetcher != nil {
+		if start, end, ok := r.prefetcher.read(off, off+int64(len(b)), size); ok {
+			metrics.GetOrRegisterCounter("lazychunkreader/prefetch", nil).Inc(1)
+			log.Trace("lazychunkreader.prefetch", "key", r.addr, "start", start, "end", end)
+			// prefetched chunks are not latency critical, so they are
+			// retrieved sequentially to avoid extra requests to peers,
+			// and they are not fetched after the reader context is done
+			ctx := WithRetrievalStrategy(r.ctx, RetrievalSequential)
+			go r.prefetch(ctx, start, end, depth, treeSize/r.branches, r.chunkData)
+		}
+	}
 	wg := sync.WaitGroup{}
 	length := int64(len(b))
 	for d := 0; d < r.depth; d++ {
@@ -48,15 +48,21 @@ type FileStore struct {
 	putterStore ChunkStore
 	hashFunc    SwarmHasher
 	tags        *chunk.Tags
+	prefetch    *PrefetchParams
+	fetches     chan struct{} // limits parallel prefetched chunks of all readers
 }
 
 type FileStoreParams struct {
 	Hash string
+	// Prefetch configures read-ahead prefetching of chunks
+	// for sequential reads of retrieved files, if enabled.
+	Prefetch *PrefetchParams
 }
 
 func NewFileStoreParams() *FileStoreParams {
 	return &FileStoreParams{
-		Hash: DefaultHash,
+		Hash:     DefaultHash,
+		Prefetch: NewPrefetchParams(),
 	}
 }
 
@@ -74,12 +80,17 @@ func NewLocalFileStore(datadir string, basekey []byte, tags *chunk.Tags) (*FileS
 
 func NewFileStore(store ChunkStore, putterStore ChunkStore, params *FileStoreParams, tags *chunk.Tags) *FileStore {
 	hashFunc := MakeHashFunc(params.Hash)
-	return &FileStore{
+	f := &FileStore{
 		ChunkStore:  store,
 		putterStore: putterStore,
 		hashFunc:    hashFunc,
 		tags:        tags,
 	}
+	if params.Prefetch != nil && params.Prefetch.Enabled {
+		f.prefetch = params.Prefetch
+		f.fetches = make(chan struct{}, f.prefetch.Fetches)
+	}
+	return f
 }
 
 // Retrieve is a public API. Main entry point for document retrieval directly. Used by the
@@ -96,6 +107,9 @@ func (f *FileStore) Retrieve(ctx context.Context, addr Address) (reader *LazyChu
 
 	getter := NewHasherStore(f.ChunkStore, f.hashFunc, isEncrypted, tag)
 	reader = TreeJoin(ctx, addr, getter, 0)
+	if f.prefetch != nil {
+		reader.prefetcher = newPrefetcher(f.prefetch, f.fetches)
+	}
 	return
 }
 
@@ -0,0 +1,32 @@
+// Copyright 2019 The Swarm Authors
+// This file is part of the Swarm library.
+//
+// The Swarm library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Swarm library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Swarm library. If not, see <http://www.gnu.org/licenses/>.
+
+package localstore
+
+import (
+	"github.com/ethersphere/swarm/chunk"
+	"github.com/ethersphere/swarm/shed"
+)
+
+// IterateCached calls fn with the addresses of all chunks stored with
+// chunk.ModePutCache and the time they were cached at. Cached chunks are not
+// in the gc index, their number is bounded by the retrieval cache. The
+// iteration stops when fn returns true or an error.
+func (db *DB) IterateCached(fn func(addr chunk.Address, cachedAt int64) (stop bool, err error)) error {
+	return db.cacheIndex.Iterate(func(item shed.Item) (stop bool, err error) {
+		return fn(copyAddress(item.Address), item.AccessTimestamp)
+	}, nil)
+}
@@ -0,0 +1,62 @@
+// Copyright 2019 The Swarm Authors
+// This file is part of the Swarm library.
+//
+// The Swarm library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Swarm library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Swarm library. If not, see <http://www.gnu.org/licenses/>.
+
+package localstore
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ethersphere/swarm/chunk"
+)
+
+// TestIterateCached validates that only cached chunks are iterated
+// with the time they were cached at.
+func TestIterateCached(t *testing.T) {
+	db, cleanupFunc := newTestDB(t, nil)
+	defer cleanupFunc()
+
+	var cachedAt int64 = 1000
+	defer setNow(func() int64 {
+		return cachedAt
+	})()
+
+	cached := generateTestRandomChunk()
+	requested := generateTestRandomChunk()
+
+	ctx := context.Background()
+	if _, err := db.Put(ctx, chunk.ModePutCache, cached); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Put(ctx, chunk.ModePutRequest, requested); err != nil {
+		t.Fatal(err)
+	}
+
+	got := make(map[string]int64)
+	err := db.IterateCached(func(addr chunk.Address, ts int64) (stop bool, err error) {
+		got[addr.Hex()] = ts
+		return false, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("got %v iterated chunks, want 1", len(got))
+	}
+	if ts, ok := got[cached.Address().Hex()]; !ok || ts != cachedAt {
+		t.Errorf("got cached chunk timestamp %v (iterated %v), want %v", ts, ok, cachedAt)
+	}
+}
@@ -0,0 +1,386 @@
+// Copyright 2019 The Swarm Authors
+// This file is part of the Swarm library.
+//
+// The Swarm library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Swarm library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Swarm library. If not, see <http://www.gnu.org/licenses/>.
+
+package localstore
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ethersphere/swarm/chunk"
+	"github.com/ethersphere/swarm/log"
+	"github.com/ethersphere/swarm/shed"
+	"github.com/syndtr/goleveldb/leveldb"
+)
+
+// checkBatchSize is the number of repair operations
+// after which the batch is written to the database
+const checkBatchSize = 10000
+
+// CheckOptions configure the consistency check of the database.
+type CheckOptions struct {
+	// Repair fixes the found inconsistencies if true.
+	Repair bool
+	// Validators, if not empty, are used to validate the data of
+	// all chunks. A chunk is valid if any of the validators accepts
+	// it, and invalid chunks are removed on repair.
+	Validators []chunk.Validator
+}
+
+// CheckReport holds the number of inconsistencies
+// found by the consistency check of the database.
+type CheckReport struct {
+	// Chunks is the number of chunks in the retrieval data index.
+	Chunks int64
+	// InvalidChunks is the number of chunks with data
+	// not accepted by any of the validators.
+	InvalidChunks int64
+	// OrphanAccess is the number of retrieval access index entries
+	// without a valid chunk.
+	OrphanAccess int64
+	// OrphanPull is the number of pull index entries without
+	// a valid chunk with the same bin id.
+	OrphanPull int64
+	// OrphanPush is the number of push index entries without
+	// a valid chunk with the same store timestamp.
+	OrphanPush int64
+	// OrphanGC is the number of gc index entries without a valid
+	// chunk with the same bin id and access timestamp.
+	OrphanGC int64
+	// MissingGC is the number of accessed chunks that are
+	// not pinned, not cached and are not in the gc index.
+	MissingGC int64
+	// OrphanCache is the number of retrieval cache index entries
+	// without a valid chunk.
+	OrphanCache int64
+	// OrphanGCExclude is the number of gc exclude index entries
+	// without a valid accessed and pinned chunk. They break
+	// the garbage collection.
+	OrphanGCExclude int64
+	// MissingPinned is the number of pinned chunks without data
+	// or with invalid data.
+	// They are not repaired, as the data can not be recovered.
+	MissingPinned int64
+	// WrongBinIDs is the number of bins with the last bin id lower
+	// than the bin id of a chunk in the bin.
+	WrongBinIDs int64
+	// GCSize is the stored gc size and WantGCSize the number of
+	// entries in the gc index it should be.
+	GCSize     uint64
+	WantGCSize uint64
+	// Repaired is true if the inconsistencies are fixed.
+	Repaired bool
+}
+
+// OK returns true if no inconsistencies are found,
+// apart from pinned chunks without data.
+func (r *CheckReport) OK() bool {
+	return r.InvalidChunks == 0 &&
+		r.OrphanAccess == 0 &&
+		r.OrphanPull == 0 &&
+		r.OrphanPush == 0 &&
+		r.OrphanGC == 0 &&
+		r.MissingGC == 0 &&
+		r.OrphanCache == 0 &&
+		r.OrphanGCExclude == 0 &&
+		r.WrongBinIDs == 0 &&
+		r.GCSize == r.WantGCSize
+}
+
+// String returns a summary of the report.
+func (r *CheckReport) String() string {
+	var s strings.Builder
+	fmt.Fprintf(&s, "chunks: %d\n", r.Chunks)
+	for _, f := range []struct {
+		name  string
+		count int64
+	}{
+		{"invalid chunks", r.InvalidChunks},
+		{"orphan retrieval access entries", r.OrphanAccess},
+		{"orphan pull entries", r.OrphanPull},
+		{"orphan push entries", r.OrphanPush},
+		{"orphan gc entries", r.OrphanGC},
+		{"missing gc entries", r.MissingGC},
+		{"orphan cache entries", r.OrphanCache},
+		{"orphan gc exclude entries", r.OrphanGCExclude},
+		{"pinned chunks without data", r.MissingPinned},
+		{"wrong bin ids", r.WrongBinIDs},
+	} {
+		fmt.Fprintf(&s, "%s: %d\n", f.name, f.count)
+	}
+	fmt.Fprintf(&s, "gc size: %d (want %d)\n", r.GCSize, r.WantGCSize)
+	return s.String()
+}
+
+// Check verifies that all database indexes and the gc size agree with
+// each other and with the retrieval data index, and that the chunk data
+// is valid. With the Repair option, orphan index entries and invalid
+// chunks are removed, missing gc index entries added, and gc size and
+// bin ids corrected. The database should not be used by other goroutines
+// while it is checked.
+func (db *DB) Check(o *CheckOptions) (report *CheckReport, err error) {
+	if o == nil {
+		o = new(CheckOptions)
+	}
+	// protect database from changing indexes and gcSize
+	db.batchMu.Lock()
+	defer db.batchMu.Unlock()
+
+	report = new(CheckReport)
+	batch := new(leveldb.Batch)
+	// write writes the batch with the repair operations
+	// if the batch is large enough or force is true
+	write := func(force bool) error {
+		if !o.Repair || batch.Len() == 0 || (!force && batch.Len() < checkBatchSize) {
+			return nil
+		}
+		if err := db.shed.WriteBatch(batch); err != nil {
+			return err
+		}
+		batch.Reset()
+		return nil
+	}
+
+	// the chunk data is checked first, so that index
+	// entries of invalid chunks are detected as orphans
+	invalid := make(map[string]bool)
+	maxBinIDs := make(map[uint8]uint64)
+	err = db.retrievalDataIndex.Iterate(func(item shed.Item) (stop bool, err error) {
+		report.Chunks++
+		po := db.po(item.Address)
+		if item.BinID > maxBinIDs[po] {
+			maxBinIDs[po] = item.BinID
+		}
+		if len(o.Validators) > 0 && !validChunk(chunk.NewChunk(item.Address, item.Data), o.Validators) {
+			log.Debug("localstore check: invalid chunk", "address", chunk.Address(item.Address))
+			report.InvalidChunks++
+			invalid[string(item.Address)] = true
+		}
+		return false, nil
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+	for po, maxBinID := range maxBinIDs {
+		binID, err := db.binIDs.Get(uint64(po))
+		if err != nil {
+			return nil, err
+		}
+		if binID < maxBinID {
+			report.WrongBinIDs++
+			db.binIDs.PutInBatch(batch, uint64(po), maxBinID)
+		}
+	}
+
+	// data returns the retrieval data index item of a valid chunk
+	data := func(item shed.Item) (i shed.Item, ok bool, err error) {
+		if invalid[string(item.Address)] {
+			return i, false, nil
+		}
+		i, err = db.retrievalDataIndex.Get(item)
+		if err == leveldb.ErrNotFound {
+			return i, false, nil
+		}
+		return i, err == nil, err
+	}
+
+	err = db.retrievalAccessIndex.Iterate(func(item shed.Item) (stop bool, err error) {
+		i, ok, err := data(item)
+		if err != nil {
+			return true, err
+		}
+		if !ok {
+			report.OrphanAccess++
+			db.retrievalAccessIndex.DeleteInBatch(batch, item)
+			return false, write(false)
+		}
+		pinned, err := db.pinIndex.Has(item)
+		if err != nil || pinned {
+			return err != nil, err
+		}
+		cached, err := db.cacheIndex.Has(item)
+		if err != nil || cached {
+			return err != nil, err
+		}
+		item.BinID = i.BinID
+		has, err := db.gcIndex.Has(item)
+		if err != nil {
+			return true, err
+		}
+		if !has {
+			report.MissingGC++
+			if err := db.gcIndex.PutInBatch(batch, item); err != nil {
+				return true, err
+			}
+		}
+		return false, write(false)
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := write(true); err != nil {
+		return nil, err
+	}
+
+	err = db.pullIndex.Iterate(func(item shed.Item) (stop bool, err error) {
+		i, ok, err := data(item)
+		if err != nil {
+			return true, err
+		}
+		if !ok || i.BinID != item.BinID {
+			report.OrphanPull++
+			db.pullIndex.DeleteInBatch(batch, item)
+		}
+		return false, write(false)
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.pushIndex.Iterate(func(item shed.Item) (stop bool, err error) {
+		i, ok, err := data(item)
+		if err != nil {
+			return true, err
+		}
+		if !ok || i.StoreTimestamp != item.StoreTimestamp {
+			report.OrphanPush++
+			db.pushIndex.DeleteInBatch(batch, item)
+		}
+		return false, write(false)
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.gcIndex.Iterate(func(item shed.Item) (stop bool, err error) {
+		i, ok, err := data(item)
+		if err != nil {
+			return true, err
+		}
+		if ok && i.BinID == item.BinID {
+			a, err := db.retrievalAccessIndex.Get(item)
+			switch err {
+			case nil:
+				if a.AccessTimestamp == item.AccessTimestamp {
+					report.WantGCSize++
+					return false, nil
+				}
+			case leveldb.ErrNotFound:
+			default:
+				return true, err
+			}
+		}
+		report.OrphanGC++
+		db.gcIndex.DeleteInBatch(batch, item)
+		return false, write(false)
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+	if !o.Repair {
+		// missing entries are already in the gc index on repair
+		report.WantGCSize += uint64(report.MissingGC)
+	}
+
+	err = db.cacheIndex.Iterate(func(item shed.Item) (stop bool, err error) {
+		_, ok, err := data(item)
+		if err != nil {
+			return true, err
+		}
+		if !ok {
+			report.OrphanCache++
+			db.cacheIndex.DeleteInBatch(batch, item)
+		}
+		return false, write(false)
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.gcExcludeIndex.Iterate(func(item shed.Item) (stop bool, err error) {
+		_, ok, err := data(item)
+		if err != nil {
+			return true, err
+		}
+		if ok {
+			ok, err = db.pinIndex.Has(item)
+			if err != nil {
+				return true, err
+			}
+		}
+		if ok {
+			ok, err = db.retrievalAccessIndex.Has(item)
+			if err != nil {
+				return true, err
+			}
+		}
+		if !ok {
+			report.OrphanGCExclude++
+			db.gcExcludeIndex.DeleteInBatch(batch, item)
+		}
+		return false, write(false)
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.pinIndex.Iterate(func(item shed.Item) (stop bool, err error) {
+		// the data of invalid chunks can not be used either
+		if invalid[string(item.Address)] {
+			report.MissingPinned++
+			return false, nil
+		}
+		has, err := db.retrievalDataIndex.Has(item)
+		if err != nil {
+			return true, err
+		}
+		if !has {
+			report.MissingPinned++
+		}
+		return false, nil
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	for addr := range invalid {
+		db.retrievalDataIndex.DeleteInBatch(batch, addressToItem(chunk.Address(addr)))
+	}
+
+	report.GCSize, err = db.gcSize.Get()
+	if err != nil {
+		return nil, err
+	}
+	if report.GCSize != report.WantGCSize {
+		db.gcSize.PutInBatch(batch, report.WantGCSize)
+	}
+
+	if err := write(true); err != nil {
+		return nil, err
+	}
+	report.Repaired = o.Repair
+	return report, nil
+}
+
+// validChunk returns true if any of the validators accepts the chunk.
+func validChunk(ch chunk.Chunk, validators []chunk.Validator) bool {
+	for _, v := range validators {
+		if v.Validate(ch) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,170 @@
+// Copyright 2019 The Swarm Authors
+// This file is part of the Swarm library.
+//
+// The Swarm library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Swarm library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Swarm library. If not, see <http://www.gnu.org/licenses/>.
+
+package localstore
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/ethersphere/swarm/chunk"
+)
+
+// validatorFunc is a chunk.Validator that calls itself.
+type validatorFunc func(ch chunk.Chunk) bool
+
+func (f validatorFunc) Validate(ch chunk.Chunk) bool { return f(ch) }
+
+// TestCheck breaks the consistency of database indexes and validates
+// that Check reports the inconsistencies and repairs them.
+func TestCheck(t *testing.T) {
+	db, cleanup := newTestDB(t, &Options{Capacity: 100})
+	defer cleanup()
+
+	ctx := context.Background()
+	chunks := make([]chunk.Chunk, 10)
+	for i := range chunks {
+		chunks[i] = generateTestRandomChunk()
+		if _, err := db.Put(ctx, chunk.ModePutRequest, chunks[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// an uploaded chunk that is not synced
+	// is not in the gc and access indexes
+	uploaded := generateTestRandomChunk()
+	if _, err := db.Put(ctx, chunk.ModePutUpload, uploaded); err != nil {
+		t.Fatal(err)
+	}
+	// a cached chunk is accessed and not in the gc index
+	if _, err := db.Put(ctx, chunk.ModePutCache, generateTestRandomChunk()); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := db.Check(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.OK() {
+		t.Fatalf("got inconsistencies in a consistent database:\n%s", report)
+	}
+
+	// pinning the unsynced chunk leaves a gc exclude entry without
+	// an access entry, which breaks the garbage collection
+	if err := db.Set(ctx, chunk.ModeSetPin, uploaded.Address()); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := db.collectGarbage(); err == nil {
+		t.Fatal("expected garbage collection error")
+	}
+
+	// access of a chunk not in the database creates orphan
+	// access, pull and gc entries
+	if err := db.Set(ctx, chunk.ModeSetAccess, generateTestRandomChunk().Address()); err != nil {
+		t.Fatal(err)
+	}
+	// a chunk with the gc entry removed
+	item, err := db.retrievalDataIndex.Get(addressToItem(chunks[0].Address()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	access, err := db.retrievalAccessIndex.Get(item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	item.AccessTimestamp = access.AccessTimestamp
+	if err := db.gcIndex.Delete(item); err != nil {
+		t.Fatal(err)
+	}
+	// an orphan push entry of a removed chunk
+	if err := db.pushIndex.Put(addressToItem(generateTestRandomChunk().Address())); err != nil {
+		t.Fatal(err)
+	}
+	// an orphan cache entry of a removed chunk
+	if err := db.cacheIndex.Put(addressToItem(generateTestRandomChunk().Address())); err != nil {
+		t.Fatal(err)
+	}
+	// the gc size drifted
+	if err := db.gcSize.Put(1000); err != nil {
+		t.Fatal(err)
+	}
+	// a bin id lower than the bin ids of its chunks
+	po := db.po(chunks[1].Address())
+	if err := db.binIDs.Put(uint64(po), 0); err != nil {
+		t.Fatal(err)
+	}
+	// a pinned chunk with invalid data
+	invalid := chunks[2]
+	if err := db.Set(ctx, chunk.ModeSetPin, invalid.Address()); err != nil {
+		t.Fatal(err)
+	}
+	validator := validatorFunc(func(ch chunk.Chunk) bool {
+		return !bytes.Equal(ch.Address(), invalid.Address())
+	})
+
+	report, err = db.Check(&CheckOptions{Validators: []chunk.Validator{validator}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := CheckReport{
+		Chunks:          12,
+		InvalidChunks:   1,
+		OrphanAccess:    2, // the not stored and the invalid chunk
+		OrphanPull:      1,
+		OrphanPush:      1,
+		OrphanGC:        2, // the not stored and the invalid chunk
+		MissingGC:       1,
+		OrphanCache:     1,
+		OrphanGCExclude: 2, // the unsynced and the invalid chunk
+		MissingPinned:   1, // the invalid chunk
+		WrongBinIDs:     1,
+		GCSize:          1000,
+		WantGCSize:      9, // accessed chunks without the invalid one
+	}
+	if *report != want {
+		t.Fatalf("got report %+v, want %+v", *report, want)
+	}
+
+	report, err = db.Check(&CheckOptions{Repair: true, Validators: []chunk.Validator{validator}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want.Repaired = true
+	if *report != want {
+		t.Fatalf("got repair report %+v, want %+v", *report, want)
+	}
+
+	report, err = db.Check(&CheckOptions{Validators: []chunk.Validator{validator}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.OK() || report.Chunks != 11 {
+		t.Fatalf("got inconsistencies after repair:\n%s", report)
+	}
+	if _, err := db.Get(ctx, chunk.ModeGetRequest, invalid.Address()); err != chunk.ErrChunkNotFound {
+		t.Fatalf("got error %v for the removed invalid chunk, want %v", err, chunk.ErrChunkNotFound)
+	}
+	if _, _, err := db.collectGarbage(); err != nil {
+		t.Fatalf("garbage collection after repair: %v", err)
+	}
+	binID, err := db.binIDs.Get(uint64(po))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if binID == 0 {
+		t.Fatal("bin id not repaired")
+	}
+}
@@ -23,11 +23,11 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
-	"sync"
 
 	"github.com/ethersphere/swarm/chunk"
 	"github.com/ethersphere/swarm/log"
 	"github.com/ethersphere/swarm/shed"
+	"github.com/syndtr/goleveldb/leveldb"
 )
 
 const (
@@ -40,10 +40,76 @@ const (
 	currentExportVersion = "2"
 )
 
+// importBatchSize is the number of chunks stored with a single Put call
+// in Import. Put holds the database batch lock for the whole call, so
+// storing chunks concurrently would not be faster than storing them in
+// batches, which also keeps the chunks stored in archive order for
+// resuming an interrupted import.
+const importBatchSize = 128
+
+// BinRange is a range of proximity order bins,
+// both Start and End are inclusive.
+type BinRange struct {
+	Start, End uint8
+}
+
+// ExportOptions select the chunks written by ExportWithOptions and
+// report the progress of the export. All set filters must match
+// for a chunk to be exported, and a nil value exports all chunks.
+type ExportOptions struct {
+	// BinRange, if not nil, selects chunks with proximity
+	// order to the base key within the range.
+	BinRange *BinRange
+	// PinnedOnly selects only pinned chunks.
+	PinnedOnly bool
+	// StoredSince and StoredUntil select chunks stored within the
+	// time range in unix nanoseconds, both inclusive. Zero values
+	// leave the range open.
+	StoredSince int64
+	StoredUntil int64
+	// Addresses, if not nil, selects only chunks with these addresses,
+	// for example chunks reachable from a root hash. Addresses of
+	// chunks that are not in the database are skipped.
+	Addresses []chunk.Address
+	// Progress, if not nil, is called after every exported chunk
+	// with the number of chunks exported so far.
+	Progress func(count int64)
+}
+
+// match returns true if the chunk item is selected by the options.
+func (o *ExportOptions) match(db *DB, item shed.Item) (bool, error) {
+	if o.BinRange != nil {
+		po := db.po(item.Address)
+		if po < o.BinRange.Start || po > o.BinRange.End {
+			return false, nil
+		}
+	}
+	if o.StoredSince != 0 && item.StoreTimestamp < o.StoredSince {
+		return false, nil
+	}
+	if o.StoredUntil != 0 && item.StoreTimestamp > o.StoredUntil {
+		return false, nil
+	}
+	if o.PinnedOnly {
+		return db.pinIndex.Has(item)
+	}
+	return true, nil
+}
+
 // Export writes a tar structured data to the writer of
 // all chunks in the retrieval data index. It returns the
 // number of chunks exported.
 func (db *DB) Export(w io.Writer) (count int64, err error) {
+	return db.ExportWithOptions(w, nil)
+}
+
+// ExportWithOptions writes a tar structured data to the writer
+// of chunks in the retrieval data index selected by the options.
+// It returns the number of chunks exported.
+func (db *DB) ExportWithOptions(w io.Writer, o *ExportOptions) (count int64, err error) {
+	if o == nil {
+		o = new(ExportOptions)
+	}
 	tw := tar.NewWriter(w)
 	defer tw.Close()
 
@@ -58,7 +124,11 @@ func (db *DB) Export(w io.Writer) (count int64, err error) {
 		return 0, err
 	}
 
-	err = db.retrievalDataIndex.Iterate(func(item shed.Item) (stop bool, err error) {
+	export := func(item shed.Item) (stop bool, err error) {
+		ok, err := o.match(db, item)
+		if err != nil || !ok {
+			return false, err
+		}
 
 		hdr := &tar.Header{
 			Name: hex.EncodeToString(item.Address),
@@ -73,134 +143,168 @@ func (db *DB) Export(w io.Writer) (count int64, err error) {
 			return false, err
 		}
 		count++
+		if o.Progress != nil {
+			o.Progress(count)
+		}
 		return false, nil
-	}, nil)
+	}
+
+	switch {
+	case o.Addresses != nil:
+		for _, addr := range o.Addresses {
+			item, err := db.retrievalDataIndex.Get(addressToItem(addr))
+			if err == leveldb.ErrNotFound {
+				log.Debug("export: chunk not found", "address", addr)
+				continue
+			}
+			if err != nil {
+				return count, err
+			}
+			if _, err := export(item); err != nil {
+				return count, err
+			}
+		}
+	case o.PinnedOnly:
+		// pinned chunks are usually a small part of the database
+		err = db.pinIndex.Iterate(func(pinItem shed.Item) (stop bool, err error) {
+			item, err := db.retrievalDataIndex.Get(pinItem)
+			if err == leveldb.ErrNotFound {
+				return false, nil
+			}
+			if err != nil {
+				return true, err
+			}
+			return export(item)
+		}, nil)
+	default:
+		err = db.retrievalDataIndex.Iterate(export, nil)
+	}
 
 	return count, err
 }
 
+// ImportOptions configure the import of chunks with ImportWithOptions.
+type ImportOptions struct {
+	// Legacy requires the archive to be an export of a legacy LDBStore
+	// database, which has no export version file. Archives without
+	// a version file are always read as legacy exports.
+	Legacy bool
+	// Skip is the number of chunks at the start of the archive that
+	// are not stored, as they were stored by an interrupted import.
+	Skip int64
+	// Progress, if not nil, is called after every stored batch of chunks
+	// with the number of chunks in the archive read so far, including the
+	// skipped ones. All of them are stored, so it can be used as the Skip
+	// value to resume an interrupted import.
+	Progress func(count int64)
+}
+
 // Import reads a tar structured data from the reader and
 // stores chunks in the database. It returns the number of
 // chunks imported.
 func (db *DB) Import(r io.Reader, legacy bool) (count int64, err error) {
+	return db.ImportWithOptions(r, &ImportOptions{Legacy: legacy})
+}
+
+// ImportWithOptions reads a tar structured data from the reader and
+// stores chunks in the database, resuming from the chunk and reporting
+// the progress given by the options. It returns the number of chunks
+// imported, not including the skipped ones.
+func (db *DB) ImportWithOptions(r io.Reader, o *ImportOptions) (count int64, err error) {
+	if o == nil {
+		o = new(ImportOptions)
+	}
 	tr := tar.NewReader(r)
 
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-
-	errC := make(chan error)
-	doneC := make(chan struct{})
-	tokenPool := make(chan struct{}, 100)
-	var wg sync.WaitGroup
-	go func() {
-		var (
-			firstFile = true
-			// if exportVersionFilename file is not present
-			// assume legacy version
-			version = legacyExportVersion
-		)
-		for {
-			hdr, err := tr.Next()
-			if err != nil {
-				if err == io.EOF {
-					break
+	var (
+		firstFile = true
+		// if exportVersionFilename file is not present
+		// assume legacy version
+		version = legacyExportVersion
+		// number of chunks read from the archive
+		read  int64
+		batch []chunk.Chunk
+	)
+	store := func() error {
+		if len(batch) > 0 {
+			if _, err := db.Put(context.Background(), chunk.ModePutUpload, batch...); err != nil {
+				return err
+			}
+			count += int64(len(batch))
+			batch = batch[:0]
+		}
+		if o.Progress != nil {
+			o.Progress(read)
+		}
+		return nil
+	}
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return count, err
+		}
+		if firstFile {
+			firstFile = false
+			if hdr.Name == exportVersionFilename {
+				data, err := ioutil.ReadAll(tr)
+				if err != nil {
+					return count, err
 				}
-				select {
-				case errC <- err:
-				case <-ctx.Done():
+				version = string(data)
+				if version != legacyExportVersion && version != currentExportVersion {
+					return count, fmt.Errorf("unsupported export data version %q", version)
 				}
-			}
-			if firstFile {
-				firstFile = false
-				if hdr.Name == exportVersionFilename {
-					data, err := ioutil.ReadAll(tr)
-					if err != nil {
-						select {
-						case errC <- err:
-						case <-ctx.Done():
-						}
-					}
-					version = string(data)
-					continue
+				if o.Legacy && version != legacyExportVersion {
+					return count, fmt.Errorf("export data version %q is not a legacy export", version)
 				}
-			}
-
-			if len(hdr.Name) != 64 {
-				log.Warn("ignoring non-chunk file", "name", hdr.Name)
 				continue
 			}
+		}
 
-			keybytes, err := hex.DecodeString(hdr.Name)
-			if err != nil {
-				log.Warn("ignoring invalid chunk file", "name", hdr.Name, "err", err)
-				continue
-			}
+		if len(hdr.Name) != 64 {
+			log.Warn("ignoring non-chunk file", "name", hdr.Name)
+			continue
+		}
 
-			data, err := ioutil.ReadAll(tr)
-			if err != nil {
-				select {
-				case errC <- err:
-				case <-ctx.Done():
-				}
-			}
-			key := chunk.Address(keybytes)
-
-			var ch chunk.Chunk
-			switch version {
-			case legacyExportVersion:
-				// LDBStore Export exported chunk data prefixed with the chunk key.
-				// That is not necessary, as the key is in the chunk filename,
-				// but backward compatibility needs to be preserved.
-				ch = chunk.NewChunk(key, data[32:])
-			case currentExportVersion:
-				ch = chunk.NewChunk(key, data)
-			default:
-				select {
-				case errC <- fmt.Errorf("unsupported export data version %q", version):
-				case <-ctx.Done():
-				}
-			}
-			tokenPool <- struct{}{}
-			wg.Add(1)
-
-			go func() {
-				_, err := db.Put(ctx, chunk.ModePutUpload, ch)
-				select {
-				case errC <- err:
-				case <-ctx.Done():
-					wg.Done()
-					<-tokenPool
-				default:
-					_, err := db.Put(ctx, chunk.ModePutUpload, ch)
-					if err != nil {
-						errC <- err
-					}
-					wg.Done()
-					<-tokenPool
-				}
-			}()
+		keybytes, err := hex.DecodeString(hdr.Name)
+		if err != nil {
+			log.Warn("ignoring invalid chunk file", "name", hdr.Name, "err", err)
+			continue
+		}
 
-			count++
+		read++
+		if read <= o.Skip {
+			continue
 		}
-		wg.Wait()
-		close(doneC)
-	}()
 
-	// wait for all chunks to be stored
-	for {
-		select {
-		case err := <-errC:
-			if err != nil {
-				return count, err
+		data, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return count, err
+		}
+		key := chunk.Address(keybytes)
+
+		var ch chunk.Chunk
+		switch version {
+		case legacyExportVersion:
+			// LDBStore Export exported chunk data prefixed with the chunk key.
+			// That is not necessary, as the key is in the chunk filename,
+			// but backward compatibility needs to be preserved.
+			if len(data) < 32 {
+				return count, fmt.Errorf("invalid legacy chunk data of %s", hdr.Name)
 			}
-		case <-ctx.Done():
-			return count, ctx.Err()
-		default:
-			select {
-			case <-doneC:
-				return count, nil
-			default:
+			ch = chunk.NewChunk(key, data[32:])
+		case currentExportVersion:
+			ch = chunk.NewChunk(key, data)
+		}
+		batch = append(batch, ch)
+		if len(batch) == importBatchSize {
+			if err := store(); err != nil {
+				return count, err
 			}
 		}
 	}
+	return count, store()
 }
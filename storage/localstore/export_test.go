@@ -19,9 +19,11 @@ package localstore
 import (
 	"bytes"
 	"context"
+	"errors"
 	"testing"
 
 	"github.com/ethersphere/swarm/chunk"
+	"github.com/ethersphere/swarm/shed"
 )
 
 // TestExportImport constructs two databases, one to put and export
@@ -78,3 +80,216 @@ func TestExportImport(t *testing.T) {
 		}
 	}
 }
+
+// TestExportFilters tests that ExportWithOptions exports only
+// the chunks selected by the options and reports the progress.
+func TestExportFilters(t *testing.T) {
+	db, cleanup := newTestDB(t, nil)
+	defer cleanup()
+
+	var storeTimestamp int64
+	defer setNow(func() int64 {
+		storeTimestamp++
+		return storeTimestamp
+	})()
+
+	chunks := make([]chunk.Chunk, 50)
+	for i := range chunks {
+		chunks[i] = generateTestRandomChunk()
+		if _, err := db.Put(context.Background(), chunk.ModePutUpload, chunks[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	pinned := chunks[:5]
+	for _, ch := range pinned {
+		if err := db.Set(context.Background(), chunk.ModeSetPin, ch.Address()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, tc := range []struct {
+		name    string
+		options *ExportOptions
+		want    func(i int, ch chunk.Chunk) bool
+	}{
+		{
+			name:    "bin range",
+			options: &ExportOptions{BinRange: &BinRange{Start: 1, End: 3}},
+			want: func(i int, ch chunk.Chunk) bool {
+				po := db.po(ch.Address())
+				return po >= 1 && po <= 3
+			},
+		},
+		{
+			name:    "pinned",
+			options: &ExportOptions{PinnedOnly: true},
+			want:    func(i int, ch chunk.Chunk) bool { return i < len(pinned) },
+		},
+		{
+			name:    "store timestamp",
+			options: &ExportOptions{StoredSince: 11, StoredUntil: 20},
+			want:    func(i int, ch chunk.Chunk) bool { return i >= 10 && i < 20 },
+		},
+		{
+			name:    "addresses",
+			options: &ExportOptions{Addresses: []chunk.Address{chunks[7].Address(), chunks[42].Address(), generateTestRandomChunk().Address()}},
+			want:    func(i int, ch chunk.Chunk) bool { return i == 7 || i == 42 },
+		},
+		{
+			name:    "pinned addresses",
+			options: &ExportOptions{PinnedOnly: true, Addresses: []chunk.Address{chunks[1].Address(), chunks[42].Address()}},
+			want:    func(i int, ch chunk.Chunk) bool { return i == 1 },
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var progress int64
+			tc.options.Progress = func(count int64) {
+				progress = count
+			}
+			var buf bytes.Buffer
+			count, err := db.ExportWithOptions(&buf, tc.options)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if progress != count {
+				t.Errorf("got progress %v, want %v", progress, count)
+			}
+
+			db2, cleanup2 := newTestDB(t, nil)
+			defer cleanup2()
+			if _, err := db2.Import(&buf, false); err != nil {
+				t.Fatal(err)
+			}
+			var wantCount int64
+			for i, ch := range chunks {
+				want := tc.want(i, ch)
+				if want {
+					wantCount++
+				}
+				has, err := db2.Has(context.Background(), ch.Address())
+				if err != nil {
+					t.Fatal(err)
+				}
+				if has != want {
+					t.Errorf("chunk %v: got exported %v, want %v", i, has, want)
+				}
+			}
+			if count != wantCount {
+				t.Errorf("got export count %v, want %v", count, wantCount)
+			}
+		})
+	}
+}
+
+// TestImportLegacy tests that an export with the current
+// version is not imported as a legacy export.
+func TestImportLegacy(t *testing.T) {
+	db1, cleanup1 := newTestDB(t, nil)
+	defer cleanup1()
+
+	if _, err := db1.Put(context.Background(), chunk.ModePutUpload, generateTestRandomChunk()); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if _, err := db1.Export(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	db2, cleanup2 := newTestDB(t, nil)
+	defer cleanup2()
+
+	c, err := db2.Import(&buf, true)
+	if err == nil {
+		t.Fatal("expected error importing current version export as legacy")
+	}
+	if c != 0 {
+		t.Errorf("got import count %v, want 0", c)
+	}
+}
+
+// TestImportResume tests that an interrupted import
+// can be resumed from the reported progress.
+func TestImportResume(t *testing.T) {
+	db1, cleanup1 := newTestDB(t, nil)
+	defer cleanup1()
+
+	chunkCount := 3*importBatchSize + 10
+	for i := 0; i < chunkCount; i++ {
+		if _, err := db1.Put(context.Background(), chunk.ModePutUpload, generateTestRandomChunk()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var buf bytes.Buffer
+	if _, err := db1.Export(&buf); err != nil {
+		t.Fatal(err)
+	}
+	archive := buf.Bytes()
+
+	db2, cleanup2 := newTestDB(t, nil)
+	defer cleanup2()
+
+	// interrupt the import by failing to read after the first two batches
+	var checkpoint int64
+	_, err := db2.ImportWithOptions(&failingReader{r: bytes.NewReader(archive), n: len(archive) / 3 * 2}, &ImportOptions{
+		Progress: func(count int64) {
+			checkpoint = count
+		},
+	})
+	if err != errFailingReader {
+		t.Fatalf("got error %v, want %v", err, errFailingReader)
+	}
+	if checkpoint != 2*importBatchSize {
+		t.Fatalf("got checkpoint %v, want %v", checkpoint, 2*importBatchSize)
+	}
+
+	var progress int64
+	count, err := db2.ImportWithOptions(bytes.NewReader(archive), &ImportOptions{
+		Skip: checkpoint,
+		Progress: func(count int64) {
+			progress = count
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := int64(chunkCount) - checkpoint; count != want {
+		t.Errorf("got import count %v, want %v", count, want)
+	}
+	if progress != int64(chunkCount) {
+		t.Errorf("got progress %v, want %v", progress, chunkCount)
+	}
+
+	err = db1.retrievalDataIndex.Iterate(func(item shed.Item) (stop bool, err error) {
+		has, err := db2.Has(context.Background(), item.Address)
+		if err != nil {
+			return true, err
+		}
+		if !has {
+			t.Errorf("chunk %x not imported", item.Address)
+		}
+		return false, nil
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+var errFailingReader = errors.New("read failed")
+
+// failingReader returns errFailingReader after n bytes are read.
+type failingReader struct {
+	r *bytes.Reader
+	n int
+}
+
+func (f *failingReader) Read(p []byte) (int, error) {
+	if f.n <= 0 {
+		return 0, errFailingReader
+	}
+	if len(p) > f.n {
+		p = p[:f.n]
+	}
+	n, err := f.r.Read(p)
+	f.n -= n
+	return n, err
+}
@@ -89,6 +89,10 @@ type DB struct {
 	// pin files Index
 	pinIndex shed.Index
 
+	// retrieval cache index of chunks retrieved for requests that are
+	// admitted to the cache, which are not in the gc index
+	cacheIndex shed.Index
+
 	// field that stores number of intems in gc index
 	gcSize shed.Uint64Field
 
@@ -126,6 +130,12 @@ type DB struct {
 	// underlaying LevelDB to prevent possible panics from
 	// iterators
 	subscritionsWG sync.WaitGroup
+
+	// status of the last started compaction
+	compaction   CompactionStatus
+	compactionMu sync.Mutex
+	// wait for the compaction to stop before closing
+	compactionWG sync.WaitGroup
 }
 
 // Options struct holds optional parameters for configuring DB.
@@ -430,6 +440,30 @@ func New(path string, baseKey []byte, o *Options) (db *DB, err error) {
 		return nil, err
 	}
 
+	// Create a index structure for chunks in the retrieval cache
+	// by the time they were cached
+	db.cacheIndex, err = db.shed.NewIndex("Hash->CacheTimestamp", shed.IndexFuncs{
+		EncodeKey: func(fields shed.Item) (key []byte, err error) {
+			return fields.Address, nil
+		},
+		DecodeKey: func(key []byte) (e shed.Item, err error) {
+			e.Address = key
+			return e, nil
+		},
+		EncodeValue: func(fields shed.Item) (value []byte, err error) {
+			b := make([]byte, 8)
+			binary.BigEndian.PutUint64(b, uint64(fields.AccessTimestamp))
+			return b, nil
+		},
+		DecodeValue: func(keyItem shed.Item, value []byte) (e shed.Item, err error) {
+			e.AccessTimestamp = int64(binary.BigEndian.Uint64(value))
+			return e, nil
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
 	// start garbage collection worker
 	go db.collectGarbageWorker()
 	return db, nil
@@ -444,6 +478,7 @@ func (db *DB) Close() (err error) {
 	go func() {
 		db.updateGCWG.Wait()
 		db.subscritionsWG.Wait()
+		db.compactionWG.Wait()
 		// wait for gc worker to
 		// return before closing the shed
 		<-db.collectGarbageWorkerDone
@@ -470,15 +505,7 @@ func (db *DB) po(addr chunk.Address) (bin uint8) {
 // the returned map keys are the index name, values are the number of elements in the index
 func (db *DB) DebugIndices() (indexInfo map[string]int, err error) {
 	indexInfo = make(map[string]int)
-	for k, v := range map[string]shed.Index{
-		"retrievalDataIndex":   db.retrievalDataIndex,
-		"retrievalAccessIndex": db.retrievalAccessIndex,
-		"pushIndex":            db.pushIndex,
-		"pullIndex":            db.pullIndex,
-		"gcIndex":              db.gcIndex,
-		"gcExcludeIndex":       db.gcExcludeIndex,
-		"pinIndex":             db.pinIndex,
-	} {
+	for k, v := range db.indexes() {
 		indexSize, err := v.Count()
 		if err != nil {
 			return indexInfo, err
@@ -494,6 +521,20 @@ func (db *DB) DebugIndices() (indexInfo map[string]int, err error) {
 	return indexInfo, err
 }
 
+// indexes returns all indexes in localstore by their names.
+func (db *DB) indexes() map[string]shed.Index {
+	return map[string]shed.Index{
+		"retrievalDataIndex":   db.retrievalDataIndex,
+		"retrievalAccessIndex": db.retrievalAccessIndex,
+		"pushIndex":            db.pushIndex,
+		"pullIndex":            db.pullIndex,
+		"gcIndex":              db.gcIndex,
+		"gcExcludeIndex":       db.gcExcludeIndex,
+		"pinIndex":             db.pinIndex,
+		"cacheIndex":           db.cacheIndex,
+	}
+}
+
 // chunkToItem creates new Item with data provided by the Chunk.
 func chunkToItem(ch chunk.Chunk) shed.Item {
 	return shed.Item{
@@ -154,11 +154,16 @@ func (db *DB) updateGC(item shed.Item) (err error) {
 	// update retrieve access index
 	db.retrievalAccessIndex.PutInBatch(batch, item)
 	// add new entry to gc index
-	ok, err := db.pinIndex.Has(item)
+	// unless the chunk is pinned or accounted by the retrieval cache
+	pinned, err := db.pinIndex.Has(item)
 	if err != nil {
 		return err
 	}
-	if !ok {
+	cached, err := db.cacheIndex.Has(item)
+	if err != nil {
+		return err
+	}
+	if !pinned && !cached {
 		err = db.gcIndex.PutInBatch(batch, item)
 		if err != nil {
 			return err
@@ -89,6 +89,20 @@ func (db *DB) put(mode chunk.ModePut, chs ...chunk.Chunk) (exist []bool, err err
 			gcSizeChange += c
 		}
 
+	case chunk.ModePutCache:
+		for i, ch := range chs {
+			if containsChunk(ch.Address(), chs[:i]...) {
+				exist[i] = true
+				continue
+			}
+			exists, c, err := db.putCache(batch, binIDs, chunkToItem(ch))
+			if err != nil {
+				return nil, err
+			}
+			exist[i] = exists
+			gcSizeChange += c
+		}
+
 	case chunk.ModePutUpload:
 		for i, ch := range chs {
 			if containsChunk(ch.Address(), chs[:i]...) {
@@ -193,6 +207,42 @@ func (db *DB) putRequest(batch *leveldb.Batch, binIDs map[uint8]uint64, item she
 	return exists, gcSizeChange, nil
 }
 
+// putCache adds an Item to the batch by updating required indexes:
+//  - put to indexes: retrieve, cache
+//  - it does not enter the syncpool nor the gc index
+// Chunks that are already stored are not cached and are
+// put as with putRequest, unless they are already cached.
+// The batch can be written to the database.
+// Provided batch and binID map are updated.
+func (db *DB) putCache(batch *leveldb.Batch, binIDs map[uint8]uint64, item shed.Item) (exists bool, gcSizeChange int64, err error) {
+	cached, err := db.cacheIndex.Has(item)
+	if err != nil {
+		return false, 0, err
+	}
+	if cached {
+		return true, 0, nil
+	}
+	exists, err = db.retrievalDataIndex.Has(item)
+	if err != nil {
+		return false, 0, err
+	}
+	if exists {
+		return db.putRequest(batch, binIDs, item)
+	}
+
+	item.StoreTimestamp = now()
+	item.BinID, err = db.incBinID(binIDs, db.po(item.Address))
+	if err != nil {
+		return false, 0, err
+	}
+	item.AccessTimestamp = now()
+	db.retrievalDataIndex.PutInBatch(batch, item)
+	db.retrievalAccessIndex.PutInBatch(batch, item)
+	db.cacheIndex.PutInBatch(batch, item)
+
+	return false, 0, nil
+}
+
 // putUpload adds an Item to the batch by updating required indexes:
 //  - put to indexes: retrieve, push, pull
 // The batch can be written to the database.
@@ -294,7 +344,7 @@ func (db *DB) putSync(batch *leveldb.Batch, binIDs map[uint8]uint64, item shed.I
 // warrants a gc set. this is to mitigate index leakage in edge cases where
 // a chunk is added to a node's localstore and given that the chunk is
 // already within that node's NN (thus, it can be added to the gc index
-// safely)
+// safely). A cached chunk is removed from the retrieval cache.
 func (db *DB) setGC(batch *leveldb.Batch, item shed.Item) (gcSizeChange int64, err error) {
 	if item.BinID == 0 {
 		i, err := db.retrievalDataIndex.Get(item)
@@ -307,8 +357,14 @@ func (db *DB) setGC(batch *leveldb.Batch, item shed.Item) (gcSizeChange int64, e
 	switch err {
 	case nil:
 		item.AccessTimestamp = i.AccessTimestamp
-		db.gcIndex.DeleteInBatch(batch, item)
-		gcSizeChange--
+		cached, err := db.uncache(batch, item)
+		if err != nil {
+			return 0, err
+		}
+		if !cached {
+			db.gcIndex.DeleteInBatch(batch, item)
+			gcSizeChange--
+		}
 	case leveldb.ErrNotFound:
 		// the chunk is not accessed before
 	default:
@@ -332,6 +388,18 @@ func (db *DB) setGC(batch *leveldb.Batch, item shed.Item) (gcSizeChange int64, e
 	return gcSizeChange, nil
 }
 
+// uncache removes the item from the retrieval cache index and
+// returns true if it was cached, in which case it is not in the
+// gc index. Provided batch is updated.
+func (db *DB) uncache(batch *leveldb.Batch, item shed.Item) (cached bool, err error) {
+	cached, err = db.cacheIndex.Has(item)
+	if err != nil || !cached {
+		return false, err
+	}
+	db.cacheIndex.DeleteInBatch(batch, item)
+	return true, nil
+}
+
 // incBinID is a helper function for db.put* methods that increments bin id
 // based on the current value in the database. This function must be called under
 // a db.batchMu lock. Provided binID map is updated.
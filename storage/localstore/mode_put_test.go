@@ -148,6 +148,55 @@ func TestModePutUpload(t *testing.T) {
 	}
 }
 
+// TestModePutCache validates that ModePutCache stores chunks in the
+// cache index and keeps them out of the gc index, also when accessed.
+func TestModePutCache(t *testing.T) {
+	for _, tc := range multiChunkTestCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, cleanupFunc := newTestDB(t, nil)
+			defer cleanupFunc()
+
+			wantTimestamp := time.Now().UTC().UnixNano()
+			defer setNow(func() (t int64) {
+				return wantTimestamp
+			})()
+
+			chunks := generateTestRandomChunks(tc.count)
+
+			_, err := db.Put(context.Background(), chunk.ModePutCache, chunks...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, ch := range chunks {
+				newRetrieveIndexesTestWithAccess(db, ch, wantTimestamp, wantTimestamp)(t)
+			}
+
+			newItemsCountTest(db.cacheIndex, tc.count)(t)
+			newItemsCountTest(db.pullIndex, 0)(t)
+			newItemsCountTest(db.gcIndex, 0)(t)
+			newIndexGCSizeTest(db)(t)
+
+			testHookUpdateGCChan := make(chan struct{})
+			defer setTestHookUpdateGC(func() {
+				testHookUpdateGCChan <- struct{}{}
+			})()
+
+			for _, ch := range chunks {
+				_, err := db.Get(context.Background(), chunk.ModeGetRequest, ch.Address())
+				if err != nil {
+					t.Fatal(err)
+				}
+				// wait for update gc goroutine to be done
+				<-testHookUpdateGCChan
+			}
+
+			newItemsCountTest(db.gcIndex, 0)(t)
+			newIndexGCSizeTest(db)(t)
+		})
+	}
+}
+
 // TestModePutUpload_parallel uploads chunks in parallel
 // and validates if all chunks can be retrieved with correct data.
 func TestModePutUpload_parallel(t *testing.T) {
@@ -59,6 +59,7 @@ func (db *DB) set(mode chunk.ModeSet, addrs ...chunk.Address) (err error) {
 	// to be done after write batch function successfully executes
 	var gcSizeChange int64                      // number to add or subtract from gcSize
 	triggerPullFeed := make(map[uint8]struct{}) // signal pull feed subscriptions to iterate
+	var triggerPushFeed bool                    // signal push feed subscriptions to iterate
 
 	switch mode {
 	case chunk.ModeSetAccess:
@@ -97,6 +98,33 @@ func (db *DB) set(mode chunk.ModeSet, addrs ...chunk.Address) (err error) {
 			gcSizeChange += c
 		}
 
+	case chunk.ModeSetEvict:
+		for _, addr := range addrs {
+			c, err := db.setEvict(batch, addr)
+			if err != nil {
+				return err
+			}
+			gcSizeChange += c
+		}
+
+	case chunk.ModeSetUncache:
+		for _, addr := range addrs {
+			c, err := db.setUncache(batch, addr)
+			if err != nil {
+				return err
+			}
+			gcSizeChange += c
+		}
+
+	case chunk.ModeSetReupload:
+		for _, addr := range addrs {
+			err := db.setReupload(batch, addr)
+			if err != nil {
+				return err
+			}
+		}
+		triggerPushFeed = true
+
 	case chunk.ModeSetPin:
 		for _, addr := range addrs {
 			err := db.setPin(batch, addr)
@@ -128,6 +156,9 @@ func (db *DB) set(mode chunk.ModeSet, addrs ...chunk.Address) (err error) {
 	for po := range triggerPullFeed {
 		db.triggerPullSubscriptions(po)
 	}
+	if triggerPushFeed {
+		db.triggerPushSubscriptions()
+	}
 	return nil
 }
 
@@ -161,8 +192,14 @@ func (db *DB) setAccess(batch *leveldb.Batch, binIDs map[uint8]uint64, addr chun
 	switch err {
 	case nil:
 		item.AccessTimestamp = i.AccessTimestamp
-		db.gcIndex.DeleteInBatch(batch, item)
-		gcSizeChange--
+		cached, err := db.uncache(batch, item)
+		if err != nil {
+			return 0, err
+		}
+		if !cached {
+			db.gcIndex.DeleteInBatch(batch, item)
+			gcSizeChange--
+		}
 	case leveldb.ErrNotFound:
 		// the chunk is not accessed before
 	default:
@@ -290,8 +327,14 @@ func (db *DB) setSync(batch *leveldb.Batch, addr chunk.Address, mode chunk.ModeS
 	switch err {
 	case nil:
 		item.AccessTimestamp = i.AccessTimestamp
-		db.gcIndex.DeleteInBatch(batch, item)
-		gcSizeChange--
+		cached, err := db.uncache(batch, item)
+		if err != nil {
+			return 0, err
+		}
+		if !cached {
+			db.gcIndex.DeleteInBatch(batch, item)
+			gcSizeChange--
+		}
 	case leveldb.ErrNotFound:
 		// the chunk is not accessed before
 	default:
@@ -317,7 +360,7 @@ func (db *DB) setSync(batch *leveldb.Batch, addr chunk.Address, mode chunk.ModeS
 }
 
 // setRemove removes the chunk by updating indexes:
-//  - delete from retrieve, pull, gc
+//  - delete from retrieve, pull, gc, cache
 // Provided batch is updated.
 func (db *DB) setRemove(batch *leveldb.Batch, addr chunk.Address) (gcSizeChange int64, err error) {
 	item := addressToItem(addr)
@@ -344,6 +387,7 @@ func (db *DB) setRemove(batch *leveldb.Batch, addr chunk.Address) (gcSizeChange
 	db.retrievalAccessIndex.DeleteInBatch(batch, item)
 	db.pullIndex.DeleteInBatch(batch, item)
 	db.gcIndex.DeleteInBatch(batch, item)
+	db.cacheIndex.DeleteInBatch(batch, item)
 	// a check is needed for decrementing gcSize
 	// as delete is not reporting if the key/value pair
 	// is deleted or not
@@ -354,6 +398,108 @@ func (db *DB) setRemove(batch *leveldb.Batch, addr chunk.Address) (gcSizeChange
 	return gcSizeChange, nil
 }
 
+// setEvict removes the cached chunk by updating the same indexes as
+// setRemove, unless the chunk is pinned or is in the push index as it is
+// not yet synced, in which case it is only removed from the cache as with
+// setUncache. Chunks that are not cached are ignored.
+// Provided batch is updated.
+func (db *DB) setEvict(batch *leveldb.Batch, addr chunk.Address) (gcSizeChange int64, err error) {
+	item := addressToItem(addr)
+
+	cached, err := db.cacheIndex.Has(item)
+	if err != nil || !cached {
+		return 0, err
+	}
+	pinned, err := db.pinIndex.Has(item)
+	if err != nil {
+		return 0, err
+	}
+	i, err := db.retrievalDataIndex.Get(item)
+	switch err {
+	case nil:
+		item.StoreTimestamp = i.StoreTimestamp
+	case leveldb.ErrNotFound:
+		db.cacheIndex.DeleteInBatch(batch, item)
+		return 0, nil
+	default:
+		return 0, err
+	}
+	unsynced, err := db.pushIndex.Has(item)
+	if err != nil {
+		return 0, err
+	}
+	if pinned || unsynced {
+		return db.setUncache(batch, addr)
+	}
+	return db.setRemove(batch, addr)
+}
+
+// setUncache removes the chunk from the retrieval cache by updating indexes:
+//  - delete from cache, insert to gc
+// unless it is pinned. Chunks that are not cached are ignored.
+// Provided batch is updated.
+func (db *DB) setUncache(batch *leveldb.Batch, addr chunk.Address) (gcSizeChange int64, err error) {
+	item := addressToItem(addr)
+
+	cached, err := db.cacheIndex.Has(item)
+	if err != nil || !cached {
+		return 0, err
+	}
+	db.cacheIndex.DeleteInBatch(batch, item)
+
+	i, err := db.retrievalDataIndex.Get(item)
+	switch err {
+	case nil:
+		item.BinID = i.BinID
+	case leveldb.ErrNotFound:
+		return 0, nil
+	default:
+		return 0, err
+	}
+	i, err = db.retrievalAccessIndex.Get(item)
+	switch err {
+	case nil:
+		item.AccessTimestamp = i.AccessTimestamp
+	case leveldb.ErrNotFound:
+		item.AccessTimestamp = now()
+		db.retrievalAccessIndex.PutInBatch(batch, item)
+	default:
+		return 0, err
+	}
+	pinned, err := db.pinIndex.Has(item)
+	if err != nil || pinned {
+		return 0, err
+	}
+	if err := db.gcIndex.PutInBatch(batch, item); err != nil {
+		return 0, err
+	}
+	return 1, nil
+}
+
+// setReupload adds the chunk to the push index without a tag,
+// so that it is push synced again. Chunks that are not in the
+// database or that are not yet push synced are ignored.
+// Provided batch is updated.
+func (db *DB) setReupload(batch *leveldb.Batch, addr chunk.Address) (err error) {
+	item := addressToItem(addr)
+
+	i, err := db.retrievalDataIndex.Get(item)
+	switch err {
+	case nil:
+		item.StoreTimestamp = i.StoreTimestamp
+	case leveldb.ErrNotFound:
+		return nil
+	default:
+		return err
+	}
+	unsynced, err := db.pushIndex.Has(item)
+	if err != nil || unsynced {
+		return err
+	}
+	db.pushIndex.PutInBatch(batch, item)
+	return nil
+}
+
 // setPin increments pin counter for the chunk by updating
 // pin index and sets the chunk to be excluded from garbage collection.
 // Provided batch is updated.
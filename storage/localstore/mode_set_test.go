@@ -17,6 +17,7 @@
 package localstore
 
 import (
+	"bytes"
 	"context"
 	"testing"
 	"time"
@@ -363,3 +364,133 @@ func TestModeSetRemove(t *testing.T) {
 		})
 	}
 }
+
+// TestModeSetEvict validates that ModeSetEvict removes cached synced
+// chunks, removes from the cache the ones that are pinned or not yet
+// push synced, and ignores the ones that are not cached.
+func TestModeSetEvict(t *testing.T) {
+	db, cleanupFunc := newTestDB(t, nil)
+	defer cleanupFunc()
+
+	synced := generateTestRandomChunk()
+	pinned := generateTestRandomChunk()
+	unsynced := generateTestRandomChunk()
+	notCached := generateTestRandomChunk()
+	missing := generateTestRandomChunk()
+
+	ctx := context.Background()
+	if _, err := db.Put(ctx, chunk.ModePutUpload, unsynced); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Put(ctx, chunk.ModePutCache, synced, pinned, unsynced); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Put(ctx, chunk.ModePutRequest, notCached); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Set(ctx, chunk.ModeSetPin, pinned.Address()); err != nil {
+		t.Fatal(err)
+	}
+
+	err := db.Set(ctx, chunk.ModeSetEvict, synced.Address(), pinned.Address(), unsynced.Address(), notCached.Address(), missing.Address())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name    string
+		ch      chunk.Chunk
+		wantErr error
+	}{
+		{name: "synced", ch: synced, wantErr: leveldb.ErrNotFound},
+		{name: "pinned", ch: pinned},
+		{name: "unsynced", ch: unsynced},
+		{name: "not cached", ch: notCached},
+	} {
+		_, err := db.retrievalDataIndex.Get(addressToItem(tc.ch.Address()))
+		if err != tc.wantErr {
+			t.Errorf("%s: got error %v, want %v", tc.name, err, tc.wantErr)
+		}
+	}
+
+	t.Run("retrieve data index count", newItemsCountTest(db.retrievalDataIndex, 3))
+
+	t.Run("cache index count", newItemsCountTest(db.cacheIndex, 0))
+
+	t.Run("gc size", newIndexGCSizeTest(db))
+}
+
+// TestModeSetUncache validates that ModeSetUncache removes cached chunks
+// from the cache index and adds them to the gc index, unless pinned.
+func TestModeSetUncache(t *testing.T) {
+	db, cleanupFunc := newTestDB(t, nil)
+	defer cleanupFunc()
+
+	cached := generateTestRandomChunk()
+	pinned := generateTestRandomChunk()
+	notCached := generateTestRandomChunk()
+
+	ctx := context.Background()
+	if _, err := db.Put(ctx, chunk.ModePutCache, cached, pinned); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Put(ctx, chunk.ModePutRequest, notCached); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Set(ctx, chunk.ModeSetPin, pinned.Address()); err != nil {
+		t.Fatal(err)
+	}
+	t.Run("gc index count cached", newItemsCountTest(db.gcIndex, 1))
+
+	err := db.Set(ctx, chunk.ModeSetUncache, cached.Address(), pinned.Address(), notCached.Address())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("retrieve data index count", newItemsCountTest(db.retrievalDataIndex, 3))
+
+	t.Run("cache index count", newItemsCountTest(db.cacheIndex, 0))
+
+	t.Run("gc index count", newItemsCountTest(db.gcIndex, 2))
+
+	t.Run("gc size", newIndexGCSizeTest(db))
+}
+
+// TestModeSetReupload validates that ModeSetReupload adds push synced
+// chunks to the push index and triggers push subscriptions.
+func TestModeSetReupload(t *testing.T) {
+	db, cleanupFunc := newTestDB(t, nil)
+	defer cleanupFunc()
+
+	ch := generateTestRandomChunk()
+	missing := generateTestRandomChunk()
+
+	ctx := context.Background()
+	if _, err := db.Put(ctx, chunk.ModePutUpload, ch); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Set(ctx, chunk.ModeSetSyncPush, ch.Address()); err != nil {
+		t.Fatal(err)
+	}
+	t.Run("push index count synced", newItemsCountTest(db.pushIndex, 0))
+
+	chunks, stop := db.SubscribePush(ctx)
+	defer stop()
+
+	err := db.Set(ctx, chunk.ModeSetReupload, ch.Address(), missing.Address())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Run("push index count", newItemsCountTest(db.pushIndex, 1))
+
+	select {
+	case got := <-chunks:
+		if !bytes.Equal(got.Address(), ch.Address()) {
+			t.Errorf("got chunk %s, want %s", got.Address(), ch.Address())
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("reuploaded chunk not received by push subscription")
+	}
+
+	t.Run("gc size", newIndexGCSizeTest(db))
+}
@@ -0,0 +1,294 @@
+// Copyright 2019 The Swarm Authors
+// This file is part of the Swarm library.
+//
+// The Swarm library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Swarm library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Swarm library. If not, see <http://www.gnu.org/licenses/>.
+
+package localstore
+
+import (
+	"errors"
+	"time"
+
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethersphere/swarm/chunk"
+	"github.com/ethersphere/swarm/log"
+	"github.com/ethersphere/swarm/shed"
+)
+
+// ErrCompactionRunning is returned by StartCompaction
+// if the database is already being compacted.
+var ErrCompactionRunning = errors.New("compaction is running")
+
+// compactionRangeSize is the approximate size of key ranges the
+// database is compacted in, so that a single LevelDB compaction
+// does not block writes for a long time.
+const compactionRangeSize = 32 * 1024 * 1024
+
+// maxPrefixRanges is the maximum number of key ranges a single index
+// prefix is split in, by the two bytes that follow it.
+const maxPrefixRanges = 1 << 16
+
+// Stats holds the statistics of database indexes
+// and chunks in proximity order bins.
+type Stats struct {
+	// Indexes are the key and value statistics by index name.
+	Indexes map[string]shed.IndexStats `json:"indexes"`
+	// GCSize is the number of chunks in the gc index.
+	GCSize uint64 `json:"gcSize"`
+	// DiskBytes is the approximate size of the database on disk.
+	DiskBytes int64 `json:"diskBytes"`
+	// Bins are the statistics of chunks by their proximity order
+	// to the base key, indexed by the proximity order.
+	Bins []BinStats `json:"bins"`
+	// Compaction is the status of the last started compaction.
+	Compaction CompactionStatus `json:"compaction"`
+}
+
+// BinStats holds the statistics of chunks in a proximity order bin.
+type BinStats struct {
+	Chunks    int64 `json:"chunks"`
+	DataBytes int64 `json:"dataBytes"`
+	// StoreAge is the distribution of the time since chunks
+	// were stored and AccessAge since they were accessed.
+	StoreAge  AgeDistribution `json:"storeAge"`
+	AccessAge AgeDistribution `json:"accessAge"`
+}
+
+// AgeDistribution holds the number of chunks by age ranges.
+type AgeDistribution struct {
+	Hour  int64 `json:"hour"`  // less than an hour
+	Day   int64 `json:"day"`   // less than a day
+	Week  int64 `json:"week"`  // less than a week
+	Month int64 `json:"month"` // less than 30 days
+	Older int64 `json:"older"`
+}
+
+// add counts a chunk with the timestamp in unix nanoseconds.
+func (d *AgeDistribution) add(now, timestamp int64) {
+	switch age := time.Duration(now - timestamp); {
+	case age < time.Hour:
+		d.Hour++
+	case age < 24*time.Hour:
+		d.Day++
+	case age < 7*24*time.Hour:
+		d.Week++
+	case age < 30*24*time.Hour:
+		d.Month++
+	default:
+		d.Older++
+	}
+}
+
+// CompactionStatus holds the progress of a database compaction.
+type CompactionStatus struct {
+	Running bool `json:"running"`
+	// Ranges is the number of compacted key ranges out of Total.
+	Ranges   int       `json:"ranges"`
+	Total    int       `json:"total"`
+	Started  time.Time `json:"started,omitempty"`
+	Finished time.Time `json:"finished,omitempty"`
+	Error    string    `json:"error,omitempty"`
+}
+
+// Stats returns the statistics of database indexes and chunks.
+// It iterates over all chunks, which may take a long time
+// for large databases.
+func (db *DB) Stats() (s *Stats, err error) {
+	metricName := "localstore/Stats"
+	metrics.GetOrRegisterCounter(metricName, nil).Inc(1)
+	defer totalTimeMetric(metricName, time.Now())
+
+	s = &Stats{
+		Indexes: make(map[string]shed.IndexStats),
+		Bins:    make([]BinStats, chunk.MaxPO+1),
+	}
+	for name, index := range db.indexes() {
+		s.Indexes[name], err = index.Stats()
+		if err != nil {
+			return nil, err
+		}
+	}
+	s.GCSize, err = db.gcSize.Get()
+	if err != nil {
+		return nil, err
+	}
+	s.DiskBytes, err = db.shed.SizeOf([]byte{0}, []byte{0xff, 0xff})
+	if err != nil {
+		return nil, err
+	}
+
+	now := now()
+	err = db.retrievalDataIndex.Iterate(func(item shed.Item) (stop bool, err error) {
+		bin := &s.Bins[db.po(item.Address)]
+		bin.Chunks++
+		bin.DataBytes += int64(len(item.Data))
+		bin.StoreAge.add(now, item.StoreTimestamp)
+		return false, nil
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+	err = db.retrievalAccessIndex.Iterate(func(item shed.Item) (stop bool, err error) {
+		s.Bins[db.po(item.Address)].AccessAge.add(now, item.AccessTimestamp)
+		return false, nil
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	s.Compaction = db.CompactionStatus()
+	return s, nil
+}
+
+// StartCompaction starts compacting the database in the background,
+// in key ranges, so that the database can be used while compacting.
+// Disk space of deleted chunks is reclaimed by the compaction. The
+// progress is returned by CompactionStatus.
+func (db *DB) StartCompaction() error {
+	db.compactionMu.Lock()
+	defer db.compactionMu.Unlock()
+
+	if db.compaction.Running {
+		return ErrCompactionRunning
+	}
+	db.compaction = CompactionStatus{
+		Running: true,
+		Started: time.Now(),
+	}
+	db.compactionWG.Add(1)
+	go func() {
+		defer db.compactionWG.Done()
+
+		err := db.compact()
+
+		db.compactionMu.Lock()
+		defer db.compactionMu.Unlock()
+		db.compaction.Running = false
+		db.compaction.Finished = time.Now()
+		if err != nil {
+			log.Error("localstore compaction", "err", err)
+			db.compaction.Error = err.Error()
+		}
+	}()
+	return nil
+}
+
+// CompactionStatus returns the status of the last started compaction.
+func (db *DB) CompactionStatus() CompactionStatus {
+	db.compactionMu.Lock()
+	defer db.compactionMu.Unlock()
+
+	return db.compaction
+}
+
+// compact compacts all key ranges of the database
+// or until the database is closed.
+func (db *DB) compact() (err error) {
+	metricName := "localstore/compact"
+	metrics.GetOrRegisterCounter(metricName, nil).Inc(1)
+	defer totalTimeMetric(metricName, time.Now())
+	defer func() {
+		if err != nil {
+			metrics.GetOrRegisterCounter(metricName+"/error", nil).Inc(1)
+		}
+	}()
+
+	ranges, err := db.compactionRanges()
+	if err != nil {
+		return err
+	}
+	db.compactionMu.Lock()
+	db.compaction.Total = len(ranges)
+	db.compactionMu.Unlock()
+
+	for i, r := range ranges {
+		select {
+		case <-db.close:
+			return errors.New("database closed")
+		default:
+		}
+		if err := db.shed.CompactRange(r.start, r.limit); err != nil {
+			return err
+		}
+		db.compactionMu.Lock()
+		db.compaction.Ranges = i + 1
+		db.compactionMu.Unlock()
+	}
+	return nil
+}
+
+// keyRange is a range of database keys from start, inclusive,
+// to limit, exclusive, or to the last key if limit is nil.
+type keyRange struct {
+	start, limit []byte
+}
+
+// compactionRanges returns the key ranges the database is compacted in.
+// Keys of every index prefix, the first byte of keys, are split into
+// ranges of about compactionRangeSize by the two bytes that follow the
+// prefix, which are address bytes in most indexes. Index prefixes
+// without keys are skipped.
+func (db *DB) compactionRanges() (ranges []keyRange, err error) {
+	it := db.shed.NewIterator()
+	defer it.Release()
+
+	for ok := it.First(); ok; {
+		key := it.Key()
+		if len(key) == 0 {
+			ok = it.Next()
+			continue
+		}
+		prefix := key[0]
+		start := []byte{prefix}
+		var limit []byte
+		if prefix < 255 {
+			limit = []byte{prefix + 1}
+		}
+		size, err := db.shed.SizeOf(start, limit)
+		if err != nil {
+			return nil, err
+		}
+		ranges = append(ranges, prefixRanges(prefix, size/compactionRangeSize+1)...)
+		if limit == nil {
+			break
+		}
+		ok = it.Seek(limit)
+	}
+	return ranges, it.Error()
+}
+
+// prefixRanges splits keys with the prefix byte into n ranges,
+// at most maxPrefixRanges, by the two bytes that follow the prefix.
+func prefixRanges(prefix byte, n int64) (ranges []keyRange) {
+	if n > maxPrefixRanges {
+		n = maxPrefixRanges
+	}
+	bound := func(i int64) []byte {
+		switch {
+		case i == 0:
+			// include keys with only the prefix byte
+			return []byte{prefix}
+		case i < n:
+			v := i * maxPrefixRanges / n
+			return []byte{prefix, byte(v >> 8), byte(v)}
+		case prefix < 255:
+			return []byte{prefix + 1}
+		}
+		return nil
+	}
+	for i := int64(0); i < n; i++ {
+		ranges = append(ranges, keyRange{start: bound(i), limit: bound(i + 1)})
+	}
+	return ranges
+}
@@ -0,0 +1,201 @@
+// Copyright 2019 The Swarm Authors
+// This file is part of the Swarm library.
+//
+// The Swarm library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Swarm library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Swarm library. If not, see <http://www.gnu.org/licenses/>.
+
+package localstore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ethersphere/swarm/chunk"
+)
+
+// TestStats validates that Stats returns the index statistics and
+// the number and age distribution of chunks in proximity order bins.
+func TestStats(t *testing.T) {
+	db, cleanup := newTestDB(t, nil)
+	defer cleanup()
+
+	current := time.Now().UnixNano()
+	defer setNow(func() int64 {
+		return current
+	})()
+
+	ctx := context.Background()
+	// chunks stored two days ago and accessed now
+	old := generateTestRandomChunks(5)
+	current -= int64(48 * time.Hour)
+	if _, err := db.Put(ctx, chunk.ModePutUpload, old...); err != nil {
+		t.Fatal(err)
+	}
+	current += int64(48 * time.Hour)
+	for _, ch := range old {
+		if err := db.Set(ctx, chunk.ModeSetSyncPull, ch.Address()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// chunks stored and accessed now
+	recent := generateTestRandomChunks(10)
+	if _, err := db.Put(ctx, chunk.ModePutRequest, recent...); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := db.Stats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := s.Indexes["retrievalDataIndex"].Count; got != 15 {
+		t.Errorf("got retrieval data index count %v, want %v", got, 15)
+	}
+	if got := s.Indexes["pullIndex"].Count; got != 5 {
+		t.Errorf("got pull index count %v, want %v", got, 5)
+	}
+	if s.GCSize != 15 {
+		t.Errorf("got gc size %v, want %v", s.GCSize, 15)
+	}
+	if len(s.Bins) != chunk.MaxPO+1 {
+		t.Fatalf("got %v bins, want %v", len(s.Bins), chunk.MaxPO+1)
+	}
+
+	want := make([]BinStats, chunk.MaxPO+1)
+	for _, ch := range old {
+		bin := &want[db.po(ch.Address())]
+		bin.Chunks++
+		bin.DataBytes += int64(len(ch.Data()))
+		bin.StoreAge.Week++
+		bin.AccessAge.Hour++
+	}
+	for _, ch := range recent {
+		bin := &want[db.po(ch.Address())]
+		bin.Chunks++
+		bin.DataBytes += int64(len(ch.Data()))
+		bin.StoreAge.Hour++
+		bin.AccessAge.Hour++
+	}
+	for po := range want {
+		if s.Bins[po] != want[po] {
+			t.Errorf("bin %v: got %+v, want %+v", po, s.Bins[po], want[po])
+		}
+	}
+}
+
+// TestCompaction validates that the compaction compacts all key ranges.
+func TestCompaction(t *testing.T) {
+	db, cleanup := newTestDB(t, nil)
+	defer cleanup()
+
+	ctx := context.Background()
+	chunks := generateTestRandomChunks(100)
+	if _, err := db.Put(ctx, chunk.ModePutUpload, chunks...); err != nil {
+		t.Fatal(err)
+	}
+	for _, ch := range chunks[:50] {
+		if err := db.Set(ctx, chunk.ModeSetRemove, ch.Address()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := db.StartCompaction(); err != nil {
+		t.Fatal(err)
+	}
+	var status CompactionStatus
+	for start := time.Now(); time.Since(start) < 10*time.Second; time.Sleep(10 * time.Millisecond) {
+		if status = db.CompactionStatus(); !status.Running {
+			break
+		}
+	}
+	if status.Running {
+		t.Fatal("compaction did not finish")
+	}
+	if status.Error != "" {
+		t.Fatalf("compaction error: %s", status.Error)
+	}
+	if status.Total == 0 || status.Ranges != status.Total {
+		t.Errorf("got %v of %v compacted ranges, want all", status.Ranges, status.Total)
+	}
+	if status.Finished.Before(status.Started) {
+		t.Errorf("got finish time %v before start time %v", status.Finished, status.Started)
+	}
+
+	for _, ch := range chunks[50:] {
+		if _, err := db.Get(ctx, chunk.ModeGetRequest, ch.Address()); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// TestCompactionRanges validates that compaction key ranges
+// are only of index prefixes with keys.
+func TestCompactionRanges(t *testing.T) {
+	db, cleanup := newTestDB(t, nil)
+	defer cleanup()
+
+	if _, err := db.Put(context.Background(), chunk.ModePutUpload, generateTestRandomChunks(10)...); err != nil {
+		t.Fatal(err)
+	}
+
+	prefixes := make(map[byte]bool)
+	it := db.shed.NewIterator()
+	for ok := it.First(); ok; ok = it.Next() {
+		prefixes[it.Key()[0]] = true
+	}
+	it.Release()
+
+	ranges, err := db.compactionRanges()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[byte]bool)
+	for _, r := range ranges {
+		if !prefixes[r.start[0]] {
+			t.Errorf("got range %x-%x of a prefix without keys", r.start, r.limit)
+		}
+		got[r.start[0]] = true
+	}
+	if len(got) != len(prefixes) {
+		t.Errorf("got ranges of %v prefixes, want %v", len(got), len(prefixes))
+	}
+}
+
+// TestPrefixRanges validates that key ranges of a prefix
+// cover all of its keys without overlapping.
+func TestPrefixRanges(t *testing.T) {
+	for _, prefix := range []byte{0, 7, 255} {
+		for _, n := range []int64{1, 3, 1000, maxPrefixRanges, maxPrefixRanges + 1} {
+			ranges := prefixRanges(prefix, n)
+			want := n
+			if want > maxPrefixRanges {
+				want = maxPrefixRanges
+			}
+			if int64(len(ranges)) != want {
+				t.Fatalf("prefix %x, n %v: got %v ranges, want %v", prefix, n, len(ranges), want)
+			}
+			if string(ranges[0].start) != string([]byte{prefix}) {
+				t.Fatalf("prefix %x, n %v: got first range start %x", prefix, n, ranges[0].start)
+			}
+			for i := 1; i < len(ranges); i++ {
+				if string(ranges[i].start) != string(ranges[i-1].limit) || string(ranges[i].start) <= string(ranges[i-1].start) {
+					t.Fatalf("prefix %x, n %v, range %v: got start %x after range %x-%x", prefix, n, i, ranges[i].start, ranges[i-1].start, ranges[i-1].limit)
+				}
+			}
+			limit := ranges[len(ranges)-1].limit
+			if prefix == 255 && limit != nil || prefix < 255 && string(limit) != string([]byte{prefix + 1}) {
+				t.Fatalf("prefix %x, n %v: got last range limit %x", prefix, n, limit)
+			}
+		}
+	}
+}
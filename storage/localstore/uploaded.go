@@ -0,0 +1,87 @@
+// Copyright 2019 The Swarm Authors
+// This file is part of the Swarm library.
+//
+// The Swarm library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Swarm library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Swarm library. If not, see <http://www.gnu.org/licenses/>.
+
+package localstore
+
+import (
+	"github.com/ethersphere/swarm/chunk"
+	"github.com/ethersphere/swarm/shed"
+	"github.com/syndtr/goleveldb/leveldb"
+)
+
+// IterateUploaded calls fn with the addresses of all pinned chunks, followed
+// by the chunks that are uploaded to this node and not pinned. Uploaded chunks
+// are the ones with a tag in the pull index. The iteration stops when fn
+// returns true or an error. If start is not nil, the iteration continues
+// after the chunk with the start address, as passed to fn by the previous
+// iteration, so that the chunks can be iterated in batches without keeping
+// the iterators open. If that chunk is not stored anymore, the uploaded
+// chunks are iterated from the beginning of its bin.
+func (db *DB) IterateUploaded(start chunk.Address, fn func(addr chunk.Address) (stop bool, err error)) error {
+	var pinOptions, pullOptions *shed.IterateOptions
+	if start != nil {
+		item := addressToItem(start)
+		pinned, err := db.pinIndex.Has(item)
+		if err != nil {
+			return err
+		}
+		if pinned {
+			pinOptions = &shed.IterateOptions{StartFrom: &item, SkipStartFromItem: true}
+		} else {
+			i, err := db.retrievalDataIndex.Get(item)
+			switch err {
+			case nil:
+				item.BinID = i.BinID
+				pullOptions = &shed.IterateOptions{StartFrom: &item, SkipStartFromItem: true}
+			case leveldb.ErrNotFound:
+				pullOptions = &shed.IterateOptions{StartFrom: &item}
+			default:
+				return err
+			}
+		}
+	}
+	if pullOptions == nil {
+		var stopped bool
+		err := db.pinIndex.Iterate(func(item shed.Item) (stop bool, err error) {
+			stopped, err = fn(copyAddress(item.Address))
+			return stopped, err
+		}, pinOptions)
+		if err != nil || stopped {
+			return err
+		}
+	}
+	return db.pullIndex.Iterate(func(item shed.Item) (stop bool, err error) {
+		if item.Tag == 0 {
+			return false, nil
+		}
+		pinned, err := db.pinIndex.Has(item)
+		if err != nil {
+			return true, err
+		}
+		if pinned {
+			return false, nil
+		}
+		return fn(copyAddress(item.Address))
+	}, pullOptions)
+}
+
+// copyAddress returns a copy of the address, as the
+// index iterators reuse the memory of keys and values.
+func copyAddress(addr []byte) chunk.Address {
+	a := make(chunk.Address, len(addr))
+	copy(a, addr)
+	return a
+}
@@ -0,0 +1,107 @@
+// Copyright 2019 The Swarm Authors
+// This file is part of the Swarm library.
+//
+// The Swarm library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Swarm library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Swarm library. If not, see <http://www.gnu.org/licenses/>.
+
+package localstore
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ethersphere/swarm/chunk"
+)
+
+// TestIterateUploaded validates that pinned and uploaded chunks are
+// iterated only once, also when the iteration is continued after every
+// chunk, and that synced chunks are not iterated.
+func TestIterateUploaded(t *testing.T) {
+	db, cleanupFunc := newTestDB(t, &Options{Tags: chunk.NewTags()})
+	defer cleanupFunc()
+
+	tag, err := db.tags.Create("test", 3, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	uploaded := generateTestRandomChunk().WithTagID(tag.Uid)
+	pinnedUploaded := generateTestRandomChunk().WithTagID(tag.Uid)
+	pinned := generateTestRandomChunk()
+	synced := generateTestRandomChunk()
+
+	ctx := context.Background()
+	if _, err := db.Put(ctx, chunk.ModePutUpload, uploaded, pinnedUploaded); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Put(ctx, chunk.ModePutSync, pinned, synced); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Set(ctx, chunk.ModeSetPin, pinnedUploaded.Address(), pinned.Address()); err != nil {
+		t.Fatal(err)
+	}
+
+	got := make(map[string]int)
+	err = db.IterateUploaded(nil, func(addr chunk.Address) (stop bool, err error) {
+		got[addr.Hex()]++
+		return false, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 3 {
+		t.Errorf("got %v iterated chunks, want 3", len(got))
+	}
+	for _, ch := range []chunk.Chunk{uploaded, pinnedUploaded, pinned} {
+		if n := got[ch.Address().Hex()]; n != 1 {
+			t.Errorf("chunk %s iterated %v times, want once", ch.Address(), n)
+		}
+	}
+
+	continued := make(map[string]int)
+	var start chunk.Address
+	for i := 0; i <= 3; i++ {
+		var next chunk.Address
+		err = db.IterateUploaded(start, func(addr chunk.Address) (stop bool, err error) {
+			next = addr
+			return true, nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if next == nil {
+			break
+		}
+		continued[next.Hex()]++
+		start = next
+	}
+	if len(continued) != 3 {
+		t.Errorf("got %v continued iterated chunks, want 3", len(continued))
+	}
+	for addr, n := range continued {
+		if got[addr] != 1 || n != 1 {
+			t.Errorf("chunk %s continued iterated %v times, want once", addr, n)
+		}
+	}
+
+	var count int
+	err = db.IterateUploaded(nil, func(addr chunk.Address) (stop bool, err error) {
+		count++
+		return true, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Errorf("got %v iterated chunks after stop, want 1", count)
+	}
+}
@@ -99,18 +99,32 @@ type NetStore struct {
 	requestGroup singleflight.Group
 	RemoteGet    RemoteGetFunc
 	logger       log.Logger
+
+	retrieval     *RetrievalParams
+	latencies     *latencyWindow // recent chunk delivery latencies for the hedge delay
+	extraRequests chan struct{}  // semaphore of hedged and fan-out requests in flight
 }
 
 // NewNetStore creates a new NetStore using the provided chunk.Store and localID of the node.
 func NewNetStore(store chunk.Store, baseAddr *network.BzzAddr) *NetStore {
 	fetchers, _ := lru.New(fetchersCapacity)
 
-	return &NetStore{
-		fetchers: fetchers,
-		Store:    store,
-		LocalID:  baseAddr.ID(),
-		logger:   log.NewBaseAddressLogger(baseAddr.ShortString()),
+	n := &NetStore{
+		fetchers:  fetchers,
+		Store:     store,
+		LocalID:   baseAddr.ID(),
+		logger:    log.NewBaseAddressLogger(baseAddr.ShortString()),
+		latencies: new(latencyWindow),
 	}
+	n.SetRetrievalParams(NewRetrievalParams())
+	return n
+}
+
+// SetRetrievalParams sets the parameters of chunk retrieval from peers.
+// It must be called before the NetStore is used.
+func (n *NetStore) SetRetrievalParams(p *RetrievalParams) {
+	n.retrieval = p
+	n.extraRequests = make(chan struct{}, p.MaxExtraRequests)
 }
 
 // Put stores a chunk in localstore, and delivers to all requestor peers using the fetcher stored in
@@ -160,6 +174,36 @@ func (n *NetStore) Put(ctx context.Context, mode chunk.ModePut, chs ...Chunk) ([
 	return exist, nil
 }
 
+// Deliver delivers chunks to all goroutines waiting on their fetchers
+// without storing them in the LocalStore. It is used for chunks that
+// are retrieved, but are not worth keeping. Chunks are validated as
+// with Put, if the LocalStore is a validator.
+func (n *NetStore) Deliver(chs ...Chunk) error {
+	if v, ok := n.Store.(chunk.Validator); ok {
+		for _, ch := range chs {
+			if !v.Validate(ch) {
+				return chunk.ErrChunkInvalid
+			}
+		}
+	}
+
+	n.putMu.Lock()
+	defer n.putMu.Unlock()
+
+	for _, ch := range chs {
+		fi, ok := n.fetchers.Get(ch.Address().String())
+		if ok {
+			fii := fi.(*Fetcher)
+			n.logger.Trace("netstore.deliver chunk delivered", "ref", ch.Address().String())
+			fii.SafeClose(ch)
+
+			metrics.GetOrRegisterResettingTimer(fmt.Sprintf("netstore/fetcher/lifetime/%s", fii.CreatedBy), nil).UpdateSince(fii.CreatedAt)
+			n.fetchers.Remove(ch.Address().String())
+		}
+	}
+	return nil
+}
+
 // Close chunk store
 func (n *NetStore) Close() error {
 	return n.Store.Close()
@@ -227,13 +271,27 @@ func (n *NetStore) Get(ctx context.Context, mode chunk.ModeGet, req *Request) (c
 // RemoteFetch is handling the retry mechanism when making a chunk request to our peers.
 // For a given chunk Request, we call RemoteGet, which selects the next eligible peer and
 // issues a RetrieveRequest and we wait for a delivery. If a delivery doesn't arrive within the SearchTimeout
-// we retry.
+// we retry. Depending on the retrieval strategy, the chunk is also requested from more peers
+// at once (fan-out) or from the next peer after the hedge delay (hedged), within the limits of
+// RetrievalParams. Requests to peers that did not deliver the chunk are cancelled on return.
 func (n *NetStore) RemoteFetch(ctx context.Context, req *Request, fi *Fetcher) (chunk.Chunk, error) {
 	// while we haven't timed-out, and while we don't have a chunk,
 	// iterate over peers and try to find a chunk
 	metrics.GetOrRegisterCounter("remote/fetch", nil).Inc(1)
 
 	ref := req.Addr
+	strategy := n.retrievalStrategy(ctx)
+
+	var (
+		cleanups []func() // cancel requests to peers
+		extra    int      // number of acquired extra requests
+	)
+	defer func() {
+		for _, cleanup := range cleanups {
+			cleanup()
+		}
+		n.releaseExtraRequests(extra)
+	}()
 
 	for {
 		metrics.GetOrRegisterCounter("remote/fetch/inner", nil).Inc(1)
@@ -245,42 +303,148 @@ func (n *NetStore) RemoteFetch(ctx context.Context, req *Request, fi *Fetcher) (
 
 		ctx = context.WithValue(ctx, "remote.fetch", osp)
 
-		log.Trace("remote.fetch", "ref", ref)
+		log.Trace("remote.fetch", "ref", ref, "strategy", strategy)
 
-		currentPeer, cleanup, err := n.RemoteGet(ctx, req, n.LocalID)
-		if err != nil {
+		// request sends a retrieve request to the next eligible peer
+		request := func(ctx context.Context) error {
+			currentPeer, cleanup, err := n.RemoteGet(ctx, req, n.LocalID)
+			if err != nil {
+				return err
+			}
+			cleanups = append(cleanups, cleanup)
+
+			// add peer to the set of peers to skip from now
+			n.logger.Trace("remote.fetch, adding peer to skip", "ref", ref, "peer", currentPeer.String())
+			req.PeersToSkip.Store(currentPeer.String(), time.Now())
+			return nil
+		}
+		// extraRequest sends a request in addition to the first one
+		// of the search, if the limit of extra requests allows it
+		extraRequest := func() bool {
+			if !n.acquireExtraRequest() {
+				return false
+			}
+			if err := request(withExtraRequest(ctx)); err != nil {
+				n.logger.Trace(err.Error(), "ref", ref)
+				n.releaseExtraRequests(1)
+				return false
+			}
+			extra++
+			return true
+		}
+
+		if err := request(ctx); err != nil {
 			n.logger.Trace(err.Error(), "ref", ref)
 			osp.LogFields(olog.String("err", err.Error()))
 			osp.Finish()
 			return nil, ErrNoSuitablePeer
 		}
-		defer cleanup()
+		start := time.Now()
+		requests := 1
 
-		// add peer to the set of peers to skip from now
-		n.logger.Trace("remote.fetch, adding peer to skip", "ref", ref, "peer", currentPeer.String())
-		req.PeersToSkip.Store(currentPeer.String(), time.Now())
+		if strategy == RetrievalFanOut {
+			for requests < n.retrieval.FanOut && requests < n.retrieval.MaxRequests && extraRequest() {
+				metrics.GetOrRegisterCounter("remote/fetch/fanout", nil).Inc(1)
+				requests++
+			}
+		}
+		var hedge <-chan time.Time
+		if strategy == RetrievalHedged && requests < n.retrieval.MaxRequests {
+			hedge = time.After(n.hedgeDelay())
+		}
+		search := time.After(timeouts.SearchTimeout)
+
+	WAIT:
+		for {
+			select {
+			case <-fi.Delivered:
+				n.logger.Trace("remote.fetch, chunk delivered", "ref", ref, "base", hex.EncodeToString(n.LocalID[:16]))
+				n.latencies.add(time.Since(start))
+
+				osp.LogFields(olog.Bool("delivered", true))
+				osp.Finish()
+				return fi.Chunk, nil
+			case <-hedge:
+				hedge = nil
+				if !extraRequest() {
+					break
+				}
+				metrics.GetOrRegisterCounter("remote/fetch/hedged", nil).Inc(1)
+				requests++
+				if requests < n.retrieval.MaxRequests {
+					hedge = time.After(n.hedgeDelay())
+				}
+			case <-search:
+				metrics.GetOrRegisterCounter("remote/fetch/timeout/search", nil).Inc(1)
+
+				osp.LogFields(olog.Bool("timeout", true))
+				osp.Finish()
+				break WAIT
+			case <-ctx.Done(): // global fetcher timeout
+				n.logger.Trace("remote.fetch, global timeout fail", "ref", ref, "err", ctx.Err())
+				metrics.GetOrRegisterCounter("remote/fetch/timeout/global", nil).Inc(1)
+
+				osp.LogFields(olog.Bool("fail", true))
+				osp.Finish()
+				return nil, ctx.Err()
+			}
+		}
+	}
+}
 
-		select {
-		case <-fi.Delivered:
-			n.logger.Trace("remote.fetch, chunk delivered", "ref", ref, "base", hex.EncodeToString(n.LocalID[:16]))
+// retrievalStrategy returns the strategy set in the context,
+// or the one of the retrieval params.
+func (n *NetStore) retrievalStrategy(ctx context.Context) RetrievalStrategy {
+	s := GetRetrievalStrategy(ctx)
+	if s == RetrievalDefault {
+		s = n.retrieval.Strategy
+	}
+	if s == RetrievalDefault {
+		s = RetrievalSequential
+	}
+	return s
+}
 
-			osp.LogFields(olog.Bool("delivered", true))
-			osp.Finish()
-			return fi.Chunk, nil
-		case <-time.After(timeouts.SearchTimeout):
-			metrics.GetOrRegisterCounter("remote/fetch/timeout/search", nil).Inc(1)
+// hedgeDelay returns the time after which a chunk is
+// requested from the next peer with the hedged strategy.
+func (n *NetStore) hedgeDelay() time.Duration {
+	d, ok := n.latencies.percentile(n.retrieval.HedgePercentile)
+	if !ok || d > n.retrieval.HedgeMaxDelay {
+		return n.retrieval.HedgeMaxDelay
+	}
+	if d < n.retrieval.HedgeMinDelay {
+		return n.retrieval.HedgeMinDelay
+	}
+	return d
+}
 
-			osp.LogFields(olog.Bool("timeout", true))
-			osp.Finish()
-			break
-		case <-ctx.Done(): // global fetcher timeout
-			n.logger.Trace("remote.fetch, global timeout fail", "ref", ref, "err", ctx.Err())
-			metrics.GetOrRegisterCounter("remote/fetch/timeout/global", nil).Inc(1)
+// RetrievalLatency returns the p-th percentile of recent chunk delivery
+// latencies from peers, with p between 0 and 1. It returns false if there
+// are not enough measured latencies or if no chunk was retrieved within
+// maxAge, as the latencies may not reflect the current network conditions.
+func (n *NetStore) RetrievalLatency(p float64, maxAge time.Duration) (d time.Duration, ok bool) {
+	if time.Since(n.latencies.lastAdded()) > maxAge {
+		return 0, false
+	}
+	return n.latencies.percentile(p)
+}
 
-			osp.LogFields(olog.Bool("fail", true))
-			osp.Finish()
-			return nil, ctx.Err()
-		}
+// acquireExtraRequest returns true if a hedged or fan-out
+// request can be sent within the MaxExtraRequests limit.
+func (n *NetStore) acquireExtraRequest() bool {
+	select {
+	case n.extraRequests <- struct{}{}:
+		return true
+	default:
+		metrics.GetOrRegisterCounter("remote/fetch/extra/limit", nil).Inc(1)
+		return false
+	}
+}
+
+// releaseExtraRequests releases count acquired extra requests.
+func (n *NetStore) releaseExtraRequests(count int) {
+	for i := 0; i < count; i++ {
+		<-n.extraRequests
 	}
 }
 
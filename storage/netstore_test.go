@@ -0,0 +1,222 @@
+// Copyright 2019 The Swarm Authors
+// This file is part of the Swarm library.
+//
+// The Swarm library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Swarm library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Swarm library. If not, see <http://www.gnu.org/licenses/>.
+
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethersphere/swarm/chunk"
+	"github.com/ethersphere/swarm/network"
+	"github.com/ethersphere/swarm/network/timeouts"
+)
+
+// TestRemoteFetchStrategies validates the number of peers a chunk
+// is requested from with different retrieval strategies, and that
+// requests of peers that did not deliver the chunk are cancelled.
+func TestRemoteFetchStrategies(t *testing.T) {
+	defer func(d time.Duration) { timeouts.SearchTimeout = d }(timeouts.SearchTimeout)
+	timeouts.SearchTimeout = time.Second
+
+	never := time.Duration(-1)
+	for _, tc := range []struct {
+		name     string
+		strategy RetrievalStrategy // set in the context
+		params   func(p *RetrievalParams)
+		delays   []time.Duration // of deliveries by peers in the order of requests
+		requests int
+		// delivered is true if the chunk is delivered
+		// before the timeout of the context
+		delivered bool
+	}{
+		{
+			name:     "default",
+			delays:   []time.Duration{never, 10 * time.Millisecond},
+			requests: 1,
+		},
+		{
+			name:     "sequential",
+			strategy: RetrievalSequential,
+			delays:   []time.Duration{never, 10 * time.Millisecond},
+			requests: 1,
+		},
+		{
+			name:      "hedged",
+			strategy:  RetrievalHedged,
+			delays:    []time.Duration{never, 10 * time.Millisecond},
+			requests:  2,
+			delivered: true,
+		},
+		{
+			name: "hedged by params",
+			params: func(p *RetrievalParams) {
+				p.Strategy = RetrievalHedged
+			},
+			delays:    []time.Duration{never, 10 * time.Millisecond},
+			requests:  2,
+			delivered: true,
+		},
+		{
+			name:      "hedged max requests",
+			strategy:  RetrievalHedged,
+			delays:    []time.Duration{never, never, never, 10 * time.Millisecond},
+			requests:  3,
+			delivered: false,
+		},
+		{
+			name:     "hedged without extra requests",
+			strategy: RetrievalHedged,
+			params: func(p *RetrievalParams) {
+				p.MaxExtraRequests = 0
+			},
+			delays:   []time.Duration{never, 10 * time.Millisecond},
+			requests: 1,
+		},
+		{
+			name:      "fan-out",
+			strategy:  RetrievalFanOut,
+			delays:    []time.Duration{never, never, 10 * time.Millisecond},
+			requests:  3,
+			delivered: true,
+		},
+		{
+			name:     "fan-out limited by extra requests",
+			strategy: RetrievalFanOut,
+			params: func(p *RetrievalParams) {
+				p.MaxExtraRequests = 1
+			},
+			delays:   []time.Duration{never, never, 10 * time.Millisecond},
+			requests: 2,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			n := NewNetStore(NewMapChunkStore(), network.RandomBzzAddr())
+			params := NewRetrievalParams()
+			params.HedgeMaxDelay = 20 * time.Millisecond
+			if tc.params != nil {
+				tc.params(params)
+			}
+			n.SetRetrievalParams(params)
+
+			ch := GenerateRandomChunk(chunk.DefaultSize)
+
+			var (
+				mu        sync.Mutex
+				requests  int
+				cancelled int
+			)
+			searchDone := make(chan struct{})
+			n.RemoteGet = func(ctx context.Context, req *Request, localID enode.ID) (*enode.ID, func(), error) {
+				mu.Lock()
+				defer mu.Unlock()
+
+				if requests == len(tc.delays) {
+					return nil, func() {}, errors.New("no peer")
+				}
+				delay := tc.delays[requests]
+				requests++
+				if delay != never {
+					go func() {
+						select {
+						case <-time.After(delay):
+						case <-searchDone:
+							return
+						}
+						if _, err := n.Put(context.Background(), chunk.ModePutRequest, ch); err != nil {
+							t.Error(err)
+						}
+					}()
+				}
+				id := enode.ID{byte(requests)}
+				return &id, func() {
+					mu.Lock()
+					cancelled++
+					mu.Unlock()
+				}, nil
+			}
+
+			// the chunk is not requested again after the SearchTimeout
+			ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+			defer cancel()
+			if tc.strategy != RetrievalDefault {
+				ctx = WithRetrievalStrategy(ctx, tc.strategy)
+			}
+			got, err := n.Get(ctx, chunk.ModeGetRequest, NewRequest(ch.Address()))
+			close(searchDone)
+			if tc.delivered {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(got.Address(), ch.Address()) {
+					t.Fatalf("got chunk %v, want %v", got.Address(), ch.Address())
+				}
+			} else if err != context.DeadlineExceeded {
+				t.Fatalf("got error %v, want %v", err, context.DeadlineExceeded)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if requests != tc.requests {
+				t.Errorf("got %v requests, want %v", requests, tc.requests)
+			}
+			if cancelled != requests {
+				t.Errorf("got %v cancelled requests, want %v", cancelled, requests)
+			}
+			if l := len(n.extraRequests); l != 0 {
+				t.Errorf("got %v extra requests in flight, want none", l)
+			}
+		})
+	}
+}
+
+// TestLatencyWindow validates percentiles of delivery latencies.
+func TestLatencyWindow(t *testing.T) {
+	w := new(latencyWindow)
+	for i := 1; i < minLatencySamples; i++ {
+		w.add(time.Duration(i) * time.Millisecond)
+	}
+	if _, ok := w.percentile(0.5); ok {
+		t.Fatal("got percentile without enough samples")
+	}
+	// the window is filled with more than its
+	// size, overwriting the oldest latencies
+	for i := 0; i < 2*latencyWindowSize; i++ {
+		w.add(time.Duration(i%latencyWindowSize+1) * time.Millisecond)
+	}
+	for _, tc := range []struct {
+		p    float64
+		want time.Duration
+	}{
+		{p: 0, want: time.Millisecond},
+		{p: 0.5, want: 129 * time.Millisecond},
+		{p: 0.95, want: 244 * time.Millisecond},
+		{p: 1, want: 256 * time.Millisecond},
+	} {
+		got, ok := w.percentile(tc.p)
+		if !ok {
+			t.Fatalf("percentile %v: not enough samples", tc.p)
+		}
+		if got != tc.want {
+			t.Errorf("percentile %v: got %v, want %v", tc.p, got, tc.want)
+		}
+	}
+}
@@ -22,6 +22,7 @@ import (
 	"encoding/hex"
 	"errors"
 	"sync"
+	"time"
 
 	"github.com/ethersphere/swarm/api"
 	"github.com/ethersphere/swarm/chunk"
@@ -34,6 +35,10 @@ import (
 const (
 	Version        = "1.0"
 	WorkerChanSize = 8 // Max no of goroutines when walking the file tree
+
+	// requestTreeTimeout is the maximal time of syncing
+	// content from peers before it is pinned
+	requestTreeTimeout = 10 * time.Minute
 )
 
 var (
@@ -78,14 +83,20 @@ func (f *PinInfo) UnmarshalBinary(data []byte) error {
 	return nil
 }
 
+// RequestTreeFunc syncs the chunks of the content with the root address
+// that are not in the local store from the peers. It is implemented with
+// the stream Registry RequestTreeFromPeers method.
+type RequestTreeFunc func(ctx context.Context, root chunk.Address) error
+
 // API is the main object which implements all things pinning.
 type API struct {
-	db         *localstore.DB
-	api        *api.API
-	fileParams *storage.FileStoreParams
-	tag        *chunk.Tags
-	hashSize   int
-	state      state.Store // the state store used to store info about pinned files
+	db          *localstore.DB
+	api         *api.API
+	fileParams  *storage.FileStoreParams
+	tag         *chunk.Tags
+	hashSize    int
+	state       state.Store     // the state store used to store info about pinned files
+	requestTree RequestTreeFunc // syncs content that is not in the local store, nil if disabled
 }
 
 // NewAPI creates a API object that is required for pinning and unpinning
@@ -101,15 +112,29 @@ func NewAPI(lstore *localstore.DB, stateStore state.Store, params *storage.FileS
 	}
 }
 
+// SetRequestTree sets the function with which the content that is not in
+// the local database is synced from peers before it is pinned. With nil,
+// only the content that is in the local database can be pinned.
+func (p *API) SetRequestTree(f RequestTreeFunc) {
+	p.requestTree = f
+}
+
 // PinFiles is used to pin a RAW file or a collection (which hash manifest's)
 // to the local Swarm node. It takes the root hash as the argument and walks
 // down the merkle tree and pin all the chunks that are encountered on the
 // way. It pins both data chunk and tree chunks. The pre-requisite is that
-// the file should be present in the local database. This function is called
+// the file should be present in the local database, or that it is not
+// encrypted and can be synced from peers with the function set by
+// SetRequestTree. This function is called
 // from two places 1) Just after the file is uploaded 2) anytime after
 // uploading the file using the pin command. This function can pin both
 // encrypted and non-encrypted files.
 func (p *API) PinFiles(addr []byte, isRaw bool, credentials string) error {
+	if err := p.fetch(addr); err != nil {
+		log.Error("Could not sync content from peers", "rootHash", hex.EncodeToString(addr), "err", err)
+		return err
+	}
+
 	hasChunk, err := p.db.Has(context.Background(), chunk.Address(p.removeDecryptionKeyFromChunkHash(addr)))
 	if !hasChunk {
 		log.Error("Could not pin hash. File not uploaded", "rootHash", hex.EncodeToString(addr))
@@ -182,6 +207,26 @@ func (p *API) PinFiles(addr []byte, isRaw bool, credentials string) error {
 	return nil
 }
 
+// fetch syncs the content from peers, unless all of its chunks are in the
+// local database, so that its chunks are not retrieved one by one. Encrypted
+// content is not synced, as its reference includes the encryption key, which
+// must not be revealed to peers, and only the local content can be pinned.
+func (p *API) fetch(addr []byte) error {
+	if p.requestTree == nil || len(addr) > chunk.AddressLength {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), requestTreeTimeout)
+	defer cancel()
+
+	err := api.WalkChunks(ctx, p.db, storage.Address(addr), func(storage.Address) error {
+		return nil
+	})
+	if err == nil {
+		return nil
+	}
+	return p.requestTree(ctx, chunk.Address(addr))
+}
+
 // UnPinFiles is used to unpin an already pinned file. It takes the root
 // hash of the file and walks down the merkle tree unpinning all the chunks
 // that are encountered on the way. The pre-requisite is that the file should
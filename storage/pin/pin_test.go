@@ -83,6 +83,47 @@ func TestPinCollectionUploadEncrypted(t *testing.T) {
 	pinUnpinAndFailIfError(t, p, hash, 3, false)
 }
 
+// TestPinRequestTree validates that content is synced from peers before
+// it is pinned, only if it is not in the local database and it is not
+// encrypted, as the reference of encrypted content includes its key.
+func TestPinRequestTree(t *testing.T) {
+	p, f, closeFunc := getPinApiAndFileStore(t)
+	defer closeFunc()
+
+	var requested []chunk.Address
+	errNotFound := errors.New("not found")
+	p.SetRequestTree(func(ctx context.Context, root chunk.Address) error {
+		requested = append(requested, root)
+		return errNotFound
+	})
+
+	hash := uploadFile(t, f, testutil.RandomBytes(3, 10000), true)
+	if err := p.PinFiles(hash, true, ""); err != nil {
+		t.Fatal(err)
+	}
+	if len(requested) != 0 {
+		t.Fatalf("got requested trees %v of local content", requested)
+	}
+
+	// the reference of missing encrypted content is not sent to peers
+	encrypted := uploadFile(t, f, testutil.RandomBytes(4, 10000), true)
+	if err := p.db.Set(context.Background(), chunk.ModeSetRemove, chunk.Address(encrypted[:chunk.AddressLength])); err != nil {
+		t.Fatal(err)
+	}
+	p.PinFiles(encrypted, true, "")
+	if len(requested) != 0 {
+		t.Fatalf("got requested trees %v of encrypted content", requested)
+	}
+
+	missing := storage.GenerateRandomChunk(chunk.DefaultSize).Address()
+	if err := p.PinFiles(missing, true, ""); err != errNotFound {
+		t.Fatalf("got error %v, want %v", err, errNotFound)
+	}
+	if len(requested) != 1 || !bytes.Equal(requested[0], missing) {
+		t.Fatalf("got requested trees %v, want %v", requested, missing)
+	}
+}
+
 // TestWalker tests the walkChunksFromRootHash function which is the crux of
 // commands like pin, unpin & list.
 func TestWalker(t *testing.T) {
@@ -0,0 +1,176 @@
+// Copyright 2019 The Swarm Authors
+// This file is part of the Swarm library.
+//
+// The Swarm library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Swarm library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Swarm library. If not, see <http://www.gnu.org/licenses/>.
+
+package storage
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethersphere/swarm/chunk"
+	"github.com/ethersphere/swarm/log"
+)
+
+// PrefetchParams configure read-ahead prefetching of chunks
+// for sequential reads of files retrieved from a FileStore.
+type PrefetchParams struct {
+	// Enabled enables prefetching. It is disabled by default, as
+	// prefetched chunks are retrieved and paid for even if they
+	// are never read.
+	Enabled bool
+	// Window is the number of bytes after the end of the last
+	// read for which chunks are fetched in advance.
+	Window int64
+	// SequentialReads is the number of consecutive sequential reads
+	// after which the reader starts prefetching.
+	SequentialReads int
+	// Fetches is the maximum number of chunks fetched
+	// in parallel by all readers of a FileStore.
+	Fetches int
+}
+
+// NewPrefetchParams returns the default prefetch parameters,
+// with prefetching disabled.
+func NewPrefetchParams() *PrefetchParams {
+	return &PrefetchParams{
+		Window:          128 * chunk.DefaultSize,
+		SequentialReads: 2,
+		Fetches:         64,
+	}
+}
+
+// prefetcher detects sequential reads of a LazyChunkReader and fetches
+// leaf chunks and intermediate tree nodes ahead of them, so that they
+// are in the local store when the reader reaches them.
+type prefetcher struct {
+	params  *PrefetchParams
+	fetches chan struct{} // semaphore shared by all readers of a FileStore
+
+	mu         sync.Mutex
+	off        int64 // offset of the last read
+	next       int64 // end offset of the last read
+	sequential int   // number of consecutive sequential reads
+	fetched    int64 // end offset up to which chunks are prefetched
+}
+
+// newPrefetcher returns a prefetcher that fetches
+// chunks within the limit of the fetches semaphore.
+func newPrefetcher(params *PrefetchParams, fetches chan struct{}) *prefetcher {
+	return &prefetcher{
+		params:  params,
+		fetches: fetches,
+	}
+}
+
+// read records a read of the data between off and eoff
+// and returns the range of data to prefetch, if any.
+func (p *prefetcher) read(off, eoff, size int64) (start, end int64, ok bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// reads that continue within the window after the last read are
+	// sequential, which allows for small gaps and reordered
+	// concurrent reads, like the ones of a lookahead reader
+	if off >= p.off && off <= p.next+p.params.Window {
+		p.sequential++
+	} else {
+		p.sequential = 0
+		p.fetched = 0
+	}
+	p.off = off
+	if eoff > p.next || p.sequential == 0 {
+		p.next = eoff
+	}
+	if p.sequential < p.params.SequentialReads {
+		return 0, 0, false
+	}
+
+	start = p.fetched
+	if start < eoff {
+		start = eoff
+	}
+	end = eoff + p.params.Window
+	if end > size {
+		end = size
+	}
+	// avoid prefetching small ranges on every read
+	if end-start < p.params.Window/2 && end < size {
+		return 0, 0, false
+	}
+	if start >= end {
+		return 0, 0, false
+	}
+	p.fetched = end
+	return start, end, true
+}
+
+// prefetch fetches the chunks of the data between off and eoff of the subtree
+// with the root chunkData, the same way as the LazyChunkReader join does.
+// It returns when all chunks are fetched or the context is done.
+func (r *LazyChunkReader) prefetch(ctx context.Context, off int64, eoff int64, depth int, treeSize int64, chunkData ChunkData) {
+	// find appropriate block level
+	for chunkData.Size() < uint64(treeSize) && depth > r.depth {
+		treeSize /= r.branches
+		depth--
+	}
+	// leaf chunk is already fetched
+	if depth == r.depth {
+		return
+	}
+
+	start := off / treeSize
+	end := (eoff + treeSize - 1) / treeSize
+	currentBranches := int64(len(chunkData)-8) / r.hashSize
+	if end > currentBranches {
+		end = currentBranches
+	}
+
+	wg := &sync.WaitGroup{}
+	defer wg.Wait()
+	for i := start; i < end; i++ {
+		soff := i * treeSize
+		seoff := soff + treeSize
+		if soff < off {
+			soff = off
+		}
+		if seoff > eoff {
+			seoff = eoff
+		}
+		select {
+		case r.prefetcher.fetches <- struct{}{}:
+		case <-ctx.Done():
+			return
+		}
+		wg.Add(1)
+		go func(i, soff, seoff int64) {
+			defer wg.Done()
+			childAddress := chunkData[8+i*r.hashSize : 8+(i+1)*r.hashSize]
+			startTime := time.Now()
+			childData, err := r.getter.Get(ctx, Reference(childAddress))
+			<-r.prefetcher.fetches
+			if err != nil || len(childData) < 9 {
+				metrics.GetOrRegisterResettingTimer("lcr/prefetch/get/err", nil).UpdateSince(startTime)
+				log.Trace("lazychunkreader.prefetch", "key", r.addr, "chunk", Address(childAddress), "err", err)
+				return
+			}
+			metrics.GetOrRegisterResettingTimer("lcr/prefetch/get", nil).UpdateSince(startTime)
+			roff := i * treeSize
+			r.prefetch(ctx, soff-roff, seoff-roff, depth-1, treeSize/r.branches, childData)
+		}(i, soff, seoff)
+	}
+}
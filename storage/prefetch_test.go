@@ -0,0 +1,162 @@
+// Copyright 2019 The Swarm Authors
+// This file is part of the Swarm library.
+//
+// The Swarm library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Swarm library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Swarm library. If not, see <http://www.gnu.org/licenses/>.
+
+package storage
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethersphere/swarm/chunk"
+	"github.com/ethersphere/swarm/testutil"
+)
+
+// getRecordingStore records addresses of chunks
+// retrieved from the embedded chunk store.
+type getRecordingStore struct {
+	ChunkStore
+	mu  sync.Mutex
+	got map[string]bool
+}
+
+func (s *getRecordingStore) Get(ctx context.Context, mode chunk.ModeGet, ref Address) (Chunk, error) {
+	s.mu.Lock()
+	s.got[string(ref)] = true
+	s.mu.Unlock()
+	return s.ChunkStore.Get(ctx, mode, ref)
+}
+
+// count returns the number of distinct retrieved chunks.
+func (s *getRecordingStore) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.got)
+}
+
+// TestPrefetch validates that chunks ahead of sequential reads are
+// prefetched within the window, and that random reads and reads
+// with prefetching disabled are not.
+func TestPrefetch(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		offsets  []int64 // of chunk size reads
+		disabled bool
+		want     int // number of distinct retrieved chunks
+	}{
+		{
+			name:    "sequential",
+			offsets: []int64{0, chunk.DefaultSize},
+			// root, first intermediate chunk, 2 read and 10 prefetched leaf chunks
+			want: 14,
+		},
+		{
+			name:     "disabled",
+			offsets:  []int64{0, chunk.DefaultSize},
+			disabled: true,
+			// root, first intermediate chunk and 2 read leaf chunks
+			want: 4,
+		},
+		{
+			name:    "random",
+			offsets: []int64{200 * chunk.DefaultSize, 0, 100 * chunk.DefaultSize},
+			// root, two intermediate chunks and three read leaf chunks
+			want: 6,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			store := &getRecordingStore{
+				ChunkStore: NewMapChunkStore(),
+				got:        make(map[string]bool),
+			}
+			params := NewFileStoreParams()
+			params.Prefetch.Enabled = !tc.disabled
+			params.Prefetch.Window = 10 * chunk.DefaultSize
+			fileStore := NewFileStore(store, store, params, chunk.NewTags())
+
+			// a file with 300 leaf chunks, under 3 intermediate chunks
+			size := int64(300 * chunk.DefaultSize)
+			data := testutil.RandomBytes(1, int(size))
+			ctx := context.Background()
+			addr, wait, err := fileStore.Store(ctx, bytes.NewReader(data), size, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := wait(ctx); err != nil {
+				t.Fatal(err)
+			}
+
+			reader, _ := fileStore.Retrieve(ctx, addr)
+			buf := make([]byte, chunk.DefaultSize)
+			for _, off := range tc.offsets {
+				n, err := reader.ReadAt(buf, off)
+				if err != nil && err != io.EOF {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(buf[:n], data[off:off+int64(n)]) {
+					t.Fatalf("got wrong data at offset %v", off)
+				}
+			}
+
+			var got int
+			for start := time.Now(); time.Since(start) < 5*time.Second; time.Sleep(10 * time.Millisecond) {
+				if got = store.count(); got >= tc.want {
+					break
+				}
+			}
+			// wait for any chunks prefetched beyond the window
+			time.Sleep(100 * time.Millisecond)
+			if got = store.count(); got != tc.want {
+				t.Fatalf("got %v retrieved chunks, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+// TestPrefetcherRead validates the detection of sequential
+// reads and the ranges of data to prefetch.
+func TestPrefetcherRead(t *testing.T) {
+	p := newPrefetcher(&PrefetchParams{
+		Window:          100,
+		SequentialReads: 2,
+		Fetches:         1,
+	}, nil)
+	for i, tc := range []struct {
+		off, eoff  int64
+		start, end int64
+		ok         bool
+	}{
+		{off: 0, eoff: 10},
+		{off: 10, eoff: 20, start: 20, end: 120, ok: true},
+		// within the prefetched range
+		{off: 20, eoff: 30},
+		// a gap in sequential reads
+		{off: 80, eoff: 90, start: 120, end: 190, ok: true},
+		// the range is limited by the size
+		{off: 190, eoff: 200, start: 200, end: 250, ok: true},
+		// random reads are not prefetched
+		{off: 0, eoff: 10},
+		{off: 500, eoff: 510},
+	} {
+		start, end, ok := p.read(tc.off, tc.eoff, 250)
+		if start != tc.start || end != tc.end || ok != tc.ok {
+			t.Errorf("read %v: got range %v-%v %v, want %v-%v %v", i, start, end, ok, tc.start, tc.end, tc.ok)
+		}
+	}
+}
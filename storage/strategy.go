@@ -0,0 +1,217 @@
+// Copyright 2019 The Swarm Authors
+// This file is part of the Swarm library.
+//
+// The Swarm library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Swarm library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Swarm library. If not, see <http://www.gnu.org/licenses/>.
+
+package storage
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// RetrievalStrategy defines from how many peers and when
+// NetStore requests a chunk that is not in the local store.
+type RetrievalStrategy uint8
+
+const (
+	// RetrievalDefault uses the strategy of the NetStore RetrievalParams.
+	RetrievalDefault RetrievalStrategy = iota
+	// RetrievalSequential requests a chunk from one peer at a time
+	// and from the next peer only after the SearchTimeout.
+	RetrievalSequential
+	// RetrievalHedged requests a chunk from the next peer if it is not
+	// delivered within the hedge delay, a percentile of recent delivery
+	// latencies, while still waiting for the previous peers.
+	RetrievalHedged
+	// RetrievalFanOut requests a chunk from multiple peers at once,
+	// for latency critical reads.
+	RetrievalFanOut
+)
+
+var retrievalStrategyNames = map[RetrievalStrategy]string{
+	RetrievalDefault:    "default",
+	RetrievalSequential: "sequential",
+	RetrievalHedged:     "hedged",
+	RetrievalFanOut:     "fanout",
+}
+
+// String returns the name of the strategy.
+func (s RetrievalStrategy) String() string {
+	if name, ok := retrievalStrategyNames[s]; ok {
+		return name
+	}
+	return fmt.Sprintf("RetrievalStrategy(%d)", s)
+}
+
+// MarshalText encodes the strategy as its name.
+func (s RetrievalStrategy) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText decodes the strategy from its name.
+func (s *RetrievalStrategy) UnmarshalText(text []byte) (err error) {
+	*s, err = ParseRetrievalStrategy(string(text))
+	return err
+}
+
+// ParseRetrievalStrategy returns the strategy with the given name.
+func ParseRetrievalStrategy(name string) (RetrievalStrategy, error) {
+	for s, n := range retrievalStrategyNames {
+		if n == name {
+			return s, nil
+		}
+	}
+	return RetrievalDefault, fmt.Errorf("unknown retrieval strategy %q", name)
+}
+
+type retrievalStrategyKey struct{}
+
+// WithRetrievalStrategy returns a context that makes NetStore
+// use the strategy for chunks retrieved with it, instead of the
+// strategy of its RetrievalParams.
+func WithRetrievalStrategy(ctx context.Context, s RetrievalStrategy) context.Context {
+	return context.WithValue(ctx, retrievalStrategyKey{}, s)
+}
+
+// GetRetrievalStrategy returns the strategy set in the context
+// with WithRetrievalStrategy, or RetrievalDefault if not set.
+func GetRetrievalStrategy(ctx context.Context) RetrievalStrategy {
+	s, _ := ctx.Value(retrievalStrategyKey{}).(RetrievalStrategy)
+	return s
+}
+
+type extraRequestKey struct{}
+
+// withExtraRequest returns a context that marks a request to a peer as
+// an extra request of hedged or fan-out retrieval.
+func withExtraRequest(ctx context.Context) context.Context {
+	return context.WithValue(ctx, extraRequestKey{}, true)
+}
+
+// IsExtraRequest returns true if the request to a peer is sent by hedged
+// or fan-out retrieval in addition to the first request for a chunk.
+// RemoteGet functions send such requests only if they do not make the
+// node pay for them, returning an error otherwise.
+func IsExtraRequest(ctx context.Context) bool {
+	v, _ := ctx.Value(extraRequestKey{}).(bool)
+	return v
+}
+
+// RetrievalParams configure how NetStore retrieves chunks from peers.
+type RetrievalParams struct {
+	// Strategy is used for chunks retrieved with a context
+	// without a strategy.
+	Strategy RetrievalStrategy
+	// HedgePercentile is the percentile of recent delivery latencies
+	// after which a hedged request is sent to the next peer.
+	HedgePercentile float64
+	// HedgeMinDelay and HedgeMaxDelay bound the hedge delay.
+	// HedgeMaxDelay is also used until enough latencies are measured.
+	HedgeMinDelay time.Duration
+	HedgeMaxDelay time.Duration
+	// FanOut is the number of peers a chunk is requested from
+	// at once with the fan-out strategy.
+	FanOut int
+	// MaxRequests is the maximum number of peers a chunk is
+	// requested from within a single SearchTimeout.
+	MaxRequests int
+	// MaxExtraRequests is the maximum number of hedged and fan-out
+	// requests in flight across all chunks, which bounds the additional
+	// load on peers. When it is reached, chunks are retrieved sequentially.
+	// Extra requests are also not sent to peers that would have to be paid
+	// for them, as reported by IsExtraRequest to RemoteGet.
+	MaxExtraRequests int
+}
+
+// NewRetrievalParams returns the default retrieval parameters.
+// Chunks are retrieved sequentially, unless the hedged or fan-out
+// strategy is set for the node or in the context of a retrieval.
+func NewRetrievalParams() *RetrievalParams {
+	return &RetrievalParams{
+		Strategy:         RetrievalSequential,
+		HedgePercentile:  0.95,
+		HedgeMinDelay:    50 * time.Millisecond,
+		HedgeMaxDelay:    500 * time.Millisecond,
+		FanOut:           3,
+		MaxRequests:      3,
+		MaxExtraRequests: 256,
+	}
+}
+
+const (
+	// latencyWindowSize is the number of the most recent
+	// delivery latencies used to calculate the hedge delay
+	latencyWindowSize = 256
+	// minLatencySamples is the number of latencies needed
+	// before the hedge delay is calculated from them
+	minLatencySamples = 16
+)
+
+// latencyWindow keeps the most recent chunk delivery latencies.
+type latencyWindow struct {
+	mu      sync.Mutex
+	samples []time.Duration
+	next    int       // index of the sample to be replaced when the window is full
+	last    time.Time // time when the last sample was added
+}
+
+// add records a delivery latency.
+func (w *latencyWindow) add(d time.Duration) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.last = time.Now()
+	if len(w.samples) < latencyWindowSize {
+		w.samples = append(w.samples, d)
+		return
+	}
+	w.samples[w.next] = d
+	w.next = (w.next + 1) % latencyWindowSize
+}
+
+// percentile returns the p-th percentile of recorded latencies,
+// with p between 0 and 1, or false if there are not enough of them.
+func (w *latencyWindow) percentile(p float64) (d time.Duration, ok bool) {
+	w.mu.Lock()
+	samples := make([]time.Duration, len(w.samples))
+	copy(samples, w.samples)
+	w.mu.Unlock()
+
+	if len(samples) < minLatencySamples {
+		return 0, false
+	}
+	sort.Slice(samples, func(i, j int) bool {
+		return samples[i] < samples[j]
+	})
+	i := int(p * float64(len(samples)))
+	if i >= len(samples) {
+		i = len(samples) - 1
+	}
+	if i < 0 {
+		i = 0
+	}
+	return samples[i], true
+}
+
+// lastAdded returns the time when the last latency was recorded.
+func (w *latencyWindow) lastAdded() time.Time {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.last
+}
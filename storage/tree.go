@@ -0,0 +1,100 @@
+// Copyright 2019 The Swarm Authors
+// This file is part of the Swarm library.
+//
+// The Swarm library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The Swarm library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the Swarm library. If not, see <http://www.gnu.org/licenses/>.
+
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ethersphere/swarm/chunk"
+	"github.com/ethersphere/swarm/storage/encryption"
+)
+
+// WalkChunkTree calls walkFn with the addresses of all chunks of the
+// content tree with the root reference, depth-first and with every
+// intermediate chunk before its children, so that the order is the same
+// on every call. The reference of encrypted content includes the
+// encryption key, and intermediate chunks are decrypted with the keys of
+// their references to walk the children. Chunks are only retrieved from
+// the store, and an error is returned if any of them is not found.
+func WalkChunkTree(ctx context.Context, store ChunkStore, root Address, walkFn func(Address) error) error {
+	return WalkChunkTreeMissing(ctx, store, root, walkFn, nil)
+}
+
+// WalkChunkTreeMissing walks the tree like WalkChunkTree, but if missingFn
+// is not nil, it is called with the address of every chunk that is not
+// found in the store instead of returning an error, and the walk continues
+// without the chunks below the missing one.
+func WalkChunkTreeMissing(ctx context.Context, store ChunkStore, root Address, walkFn, missingFn func(Address) error) error {
+	var decrypter *hasherStore
+	switch len(root) {
+	case AddressLength:
+	case AddressLength + encryption.KeyLength:
+		decrypter = NewHasherStore(store, MakeHashFunc(DefaultHash), true, nil)
+	default:
+		return fmt.Errorf("walk chunk tree: invalid root reference length %v", len(root))
+	}
+	return walkChunkTree(ctx, store, decrypter, Reference(root), walkFn, missingFn)
+}
+
+// walkChunkTree walks the tree with the reference, which is of the same
+// length as the root reference. Argument decrypter is nil if the content
+// is not encrypted, and missingFn is nil if missing chunks are errors.
+func walkChunkTree(ctx context.Context, store ChunkStore, decrypter *hasherStore, ref Reference, walkFn, missingFn func(Address) error) error {
+	addr, key, err := parseReference(ref, AddressLength)
+	if err != nil {
+		return fmt.Errorf("walk chunk tree: %w", err)
+	}
+	ch, err := store.Get(ctx, chunk.ModeGetLookup, addr)
+	if err != nil {
+		if missingFn != nil {
+			return missingFn(addr)
+		}
+		return fmt.Errorf("walk chunk tree: get chunk %s: %w", addr, err)
+	}
+	if err := walkFn(addr); err != nil {
+		return err
+	}
+	data := ChunkData(ch.Data())
+	if len(data) < 8 {
+		return fmt.Errorf("walk chunk tree: chunk %s: %w", addr, chunk.ErrChunkInvalid)
+	}
+	if key != nil {
+		data, err = decrypter.decryptChunkData(data, key)
+		if err != nil {
+			return fmt.Errorf("walk chunk tree: decrypt chunk %s: %w", addr, err)
+		}
+	}
+	if data.Size() <= chunk.DefaultSize {
+		// data chunk
+		return nil
+	}
+	refSize := len(ref)
+	refs := data[8:]
+	if len(refs)%refSize != 0 {
+		return fmt.Errorf("walk chunk tree: chunk %s: %w", addr, chunk.ErrChunkInvalid)
+	}
+	for i := 0; i < len(refs); i += refSize {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := walkChunkTree(ctx, store, decrypter, Reference(refs[i:i+refSize]), walkFn, missingFn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -295,6 +295,21 @@ func (s *Swap) Check(amount int64, peer *protocols.Peer) (err error) {
 	return s.modifyBalanceOk(amount, swapPeer)
 }
 
+// CanPay returns true if the local node can be debited the amount by the
+// peer without its balance reaching the payment threshold, at which a cheque
+// is sent to the peer. It is used to avoid optional requests to the peer
+// that the local node would have to pay for.
+func (s *Swap) CanPay(amount int64, peer *protocols.Peer) bool {
+	swapPeer := s.getPeer(peer.ID())
+	if swapPeer == nil {
+		return false
+	}
+
+	swapPeer.lock.Lock()
+	defer swapPeer.lock.Unlock()
+	return swapPeer.getBalance()-amount > -s.params.PaymentThreshold
+}
+
 // Add is the (sole) accounting function
 // Swap implements the protocols.Balance interface
 func (s *Swap) Add(amount int64, peer *protocols.Peer) (err error) {
@@ -590,6 +590,33 @@ func TestPaymentThreshold(t *testing.T) {
 	}
 }
 
+// TestCanPay tests that a cost can be paid to a peer only until the
+// balance with it would reach the payment threshold
+func TestCanPay(t *testing.T) {
+	swap, clean := newTestSwap(t, ownerKey, nil)
+	defer clean()
+	testDeploy(context.Background(), swap, int256.Uint256From(0))
+
+	dummyPeer := newDummyPeer()
+	if swap.CanPay(1, dummyPeer.Peer) {
+		t.Fatal("expected a cost to a peer that is not a swap peer not to be payable")
+	}
+	testPeer, err := swap.addPeer(dummyPeer.Peer, swap.owner.address, swap.GetParams().ContractAddress)
+	if err != nil {
+		t.Fatal(err)
+	}
+	threshold := int64(DefaultPaymentThreshold)
+	if err := testPeer.setBalance(-threshold + 10); err != nil {
+		t.Fatal(err)
+	}
+	if !swap.CanPay(9, dummyPeer.Peer) {
+		t.Fatal("expected a cost below the payment threshold to be payable")
+	}
+	if swap.CanPay(10, dummyPeer.Peer) {
+		t.Fatal("expected a cost reaching the payment threshold not to be payable")
+	}
+}
+
 // TestResetBalance tests that balances are correctly reset
 // The test deploys creates swap instances for each node,
 // deploys simulated contracts, sets the balance of each
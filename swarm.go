@@ -18,6 +18,7 @@ package swarm
 
 import (
 	"bytes"
+	"context"
 	"crypto/ecdsa"
 	"errors"
 	"fmt"
@@ -35,8 +36,10 @@ import (
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/ethereum/go-ethereum/metrics"
 	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/enode"
 	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/ethersphere/swarm/api"
+	"github.com/ethersphere/swarm/api/auth"
 	httpapi "github.com/ethersphere/swarm/api/http"
 	"github.com/ethersphere/swarm/bzzeth"
 	"github.com/ethersphere/swarm/chunk"
@@ -44,6 +47,7 @@ import (
 	"github.com/ethersphere/swarm/fuse"
 	"github.com/ethersphere/swarm/log"
 	"github.com/ethersphere/swarm/network"
+	"github.com/ethersphere/swarm/network/discovery"
 	"github.com/ethersphere/swarm/network/retrieval"
 	"github.com/ethersphere/swarm/network/stream"
 	"github.com/ethersphere/swarm/p2p/protocols"
@@ -67,6 +71,10 @@ var (
 	startCounter       = metrics.NewRegisteredCounter("stack/start", nil)
 	stopCounter        = metrics.NewRegisteredCounter("stack/stop", nil)
 	uptimeGauge        = metrics.NewRegisteredGauge("stack/uptime", nil)
+
+	// requestTreePeers is the number of closest peers from
+	// which content that is pinned is requested
+	requestTreePeers = 3
 )
 
 // Swarm abstracts the complete Swarm stack
@@ -91,8 +99,12 @@ type Swarm struct {
 	tags              *chunk.Tags
 	accountingMetrics *protocols.AccountingMetrics
 	cleanupFuncs      []func() error
-	pinAPI            *pin.API // API object implements all pinning related commands
+	pinAPI            *pin.API         // API object implements all pinning related commands
+	authKeys          *auth.Keys       // API keys for the HTTP server, nil if authorization is disabled
+	gateway           *httpapi.Gateway // host based routing for the HTTP server, nil if gateway mode is disabled
 	inspector         *api.Inspector
+	dnsDiscovery      *discovery.DNS // nil if no node record trees are configured
+	lanDiscovery      *discovery.LAN // nil if local network discovery is disabled
 
 	tracerClose io.Closer
 }
@@ -218,16 +230,43 @@ func NewSwarm(config *api.Config, mockStore *mock.NodeStore) (self *Swarm, err e
 		log.Info("loaded saved tags successfully from state store")
 	}
 
+	kadParams := network.NewKadParams()
+	if config.LightNodeEnabled {
+		// light nodes keep only a few connections in every bin
+		kadParams.MinBinSize = 1
+		kadParams.MaxBinSize = 2
+	}
 	to := network.NewKademlia(
 		common.FromHex(config.BzzKey),
-		network.NewKadParams(),
+		kadParams,
 	)
 
+	// discover peers without bootnodes from node record trees
+	// published in DNS and from the nodes on the local network
+	if config.DNSDiscovery != nil && len(config.DNSDiscovery.Trees) > 0 {
+		self.dnsDiscovery, err = discovery.NewDNS(to, net.DefaultResolver, config.DNSDiscovery)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if config.LANDiscovery != nil && config.LANDiscovery.Enabled {
+		self.lanDiscovery = discovery.NewLAN(to, config.LANDiscovery)
+	}
+
+	dbCapacity := config.DbCapacity
+	putToGCCheck := to.IsWithinDepth
+	if config.LightNodeEnabled {
+		// light nodes have no area of responsibility and only keep a small cache
+		dbCapacity = config.LightNodeDbCapacity
+		putToGCCheck = nil
+	}
+	log.Info("Local store capacity", "chunks", dbCapacity, "light", config.LightNodeEnabled)
+
 	localStore, err := localstore.New(config.ChunkDbPath, config.BaseKey, &localstore.Options{
 		MockStore:    mockStore,
-		Capacity:     config.DbCapacity,
+		Capacity:     dbCapacity,
 		Tags:         self.tags,
-		PutToGCCheck: to.IsWithinDepth,
+		PutToGCCheck: putToGCCheck,
 	})
 	if err != nil {
 		return nil, err
@@ -239,8 +278,13 @@ func NewSwarm(config *api.Config, mockStore *mock.NodeStore) (self *Swarm, err e
 	)
 
 	self.netStore = storage.NewNetStore(lstore, bzzconfig.Address)
+	if config.Retrieval != nil {
+		self.netStore.SetRetrievalParams(config.Retrieval)
+	}
 	self.retrieval = retrieval.New(to, self.netStore, bzzconfig.Address, self.swap)
 	self.netStore.RemoteGet = self.retrieval.RequestFromPeers
+	self.retrieval.SetCacheParams(config.RetrievalCache, localStore.IterateCached)
+	self.retrieval.SetReplicationParams(config.Replication, localStore.IterateUploaded)
 
 	feedsHandler.SetStore(self.netStore)
 
@@ -250,7 +294,11 @@ func NewSwarm(config *api.Config, mockStore *mock.NodeStore) (self *Swarm, err e
 	}
 
 	syncProvider := stream.NewSyncProvider(self.netStore, to, bzzconfig.Address, syncing, false)
-	self.streamer = stream.New(self.stateStore, bzzconfig.Address, syncProvider)
+	treeProvider := stream.NewTreeProvider(self.netStore, bzzconfig.Address, func(ctx context.Context, root chunk.Address, walkFn func(chunk.Address) error) error {
+		return api.WalkChunks(ctx, self.netStore.Store, root, walkFn)
+	})
+	self.streamer = stream.New(self.stateStore, bzzconfig.Address, syncProvider, treeProvider)
+	self.streamer.SetBudgetParams(config.SyncBudget, self.netStore.RetrievalLatency)
 
 	// Swarm Hash Merklised Chunking for Arbitrary-length Document/File storage
 	lnetStore := storage.NewLNetStore(self.netStore)
@@ -269,11 +317,15 @@ func NewSwarm(config *api.Config, mockStore *mock.NodeStore) (self *Swarm, err e
 		pss.SetHandshakeController(self.ps, pss.NewHandshakeParams())
 	}
 
-	if config.PushSyncEnabled {
+	// light nodes always push sync uploaded chunks to full nodes,
+	// but do not store chunks pushed by other nodes
+	if config.PushSyncEnabled || config.LightNodeEnabled {
 		// expire time for push-sync messages should be lower than regular chat-like messages to avoid network flooding
 		pubsub := pss.NewPubSub(self.ps, 20*time.Second)
 		self.pushSync = pushsync.NewPusher(localStore, pubsub, self.tags)
-		self.storer = pushsync.NewStorer(self.netStore, pubsub)
+		if !config.LightNodeEnabled {
+			self.storer = pushsync.NewStorer(self.netStore, pubsub)
+		}
 	}
 
 	self.api = api.NewAPI(self.fileStore, self.dns, self.rns, feedsHandler, self.privateKey, self.tags)
@@ -281,10 +333,30 @@ func NewSwarm(config *api.Config, mockStore *mock.NodeStore) (self *Swarm, err e
 	if config.EnablePinning {
 		// Instantiate the pinAPI object with the already opened localstore
 		self.pinAPI = pin.NewAPI(localStore, self.stateStore, self.config.FileStoreParams, self.tags, self.api)
+		self.pinAPI.SetRequestTree(func(ctx context.Context, root chunk.Address) error {
+			var peers []enode.ID
+			to.EachConn(root[:chunk.AddressLength], 255, func(p *network.Peer, _ int) bool {
+				peers = append(peers, p.ID())
+				return len(peers) < requestTreePeers
+			})
+			return self.streamer.RequestTreeFromPeers(ctx, peers, root)
+		})
+	}
+	if config.EnableHTTPAuth {
+		self.authKeys, err = auth.New(self.stateStore)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(config.GatewayHosts) > 0 || config.GatewayDomain != "" {
+		self.gateway, err = httpapi.NewGateway(config.GatewayHosts, config.GatewayDomain, config.GatewayPublic)
+		if err != nil {
+			return nil, err
+		}
 	}
 	self.sfs = fuse.NewSwarmFS(self.api)
 	log.Debug("Initialized FUSE filesystem")
-	self.inspector = api.NewInspector(self.api, self.bzz.Hive, self.netStore, self.streamer, localStore)
+	self.inspector = api.NewInspector(self.api, self.bzz.Hive, self.netStore, self.streamer, localStore, self.retrieval)
 
 	return self, nil
 }
@@ -379,6 +451,27 @@ func (s *Swarm) Start(srv *p2p.Server) error {
 	}
 	log.Info("Swarm network started", "bzzaddr", fmt.Sprintf("%x", s.bzz.Hive.BaseAddr()))
 
+	// add the bzz address to the node record, so that
+	// the node can be discovered from its record
+	srv.LocalNode().Set(network.NewENRAddrEntry(s.bzz.Hive.BaseAddr()))
+	if s.dnsDiscovery != nil {
+		log.Info("Starting DNS discovery", "trees", s.config.DNSDiscovery.Trees)
+		s.dnsDiscovery.Start()
+	}
+	if s.lanDiscovery != nil {
+		// light nodes are not connected to by other nodes,
+		// so they only listen to the announcements
+		var self func() *enode.Node
+		if !s.config.LightNodeEnabled {
+			self = srv.Self
+		}
+		log.Info("Starting local network discovery", "group", s.config.LANDiscovery.Group)
+		if err := s.lanDiscovery.Start(self); err != nil {
+			log.Error("local network discovery failed", "err", err)
+			s.lanDiscovery = nil
+		}
+	}
+
 	err = s.bzzEth.Start(srv)
 	if err != nil {
 		return err
@@ -390,7 +483,20 @@ func (s *Swarm) Start(srv *p2p.Server) error {
 	// start swarm http proxy server
 	if s.config.Port != "" {
 		addr := net.JoinHostPort(s.config.ListenAddr, s.config.Port)
-		server := httpapi.NewServer(s.api, s.pinAPI, s.config.Cors)
+		var opts []httpapi.ServerOption
+		if s.authKeys != nil {
+			log.Info("Swarm HTTP proxy authorization enabled")
+			opts = append(opts, httpapi.ServerOptionWithAuth(s.authKeys))
+		}
+		if s.gateway != nil {
+			log.Info("Swarm HTTP proxy gateway mode enabled", "hosts", s.config.GatewayHosts, "domain", s.config.GatewayDomain, "public", s.config.GatewayPublic)
+			opts = append(opts, httpapi.ServerOptionWithGateway(s.gateway))
+		}
+		if len(s.config.PublicRetrievalStrategies) > 0 {
+			log.Info("Swarm HTTP proxy public retrieval strategies", "strategies", s.config.PublicRetrievalStrategies)
+			opts = append(opts, httpapi.ServerOptionWithRetrievalStrategies(s.config.PublicRetrievalStrategies))
+		}
+		server := httpapi.NewServer(s.api, s.pinAPI, s.config.Cors, opts...)
 
 		if s.config.Cors != "" {
 			log.Info("Swarm HTTP proxy CORS headers", "allowedOrigins", s.config.Cors)
@@ -450,6 +556,13 @@ func (s *Swarm) Stop() error {
 		}
 	}
 
+	if s.dnsDiscovery != nil {
+		s.dnsDiscovery.Stop()
+	}
+	if s.lanDiscovery != nil {
+		s.lanDiscovery.Stop()
+	}
+
 	if s.pushSync != nil {
 		s.pushSync.Close()
 	}
@@ -558,13 +671,19 @@ func (s *Swarm) APIs() []rpc.API {
 		},
 	}
 
+	if s.authKeys != nil {
+		apis = append(apis, rpc.API{
+			Namespace: "auth",
+			Version:   auth.Version,
+			Service:   auth.NewAPI(s.authKeys),
+			Public:    false,
+		})
+	}
+
 	apis = append(apis, s.bzz.APIs()...)
 
-	// this is a workaround disabling syncing altogether from a node but
-	// must be changed when multiple stream implementations are at hand
-	if s.config.SyncEnabled {
-		apis = append(apis, s.streamer.APIs()...)
-	}
+	apis = append(apis, s.streamer.APIs()...)
+	apis = append(apis, s.retrieval.APIs()...)
 	apis = append(apis, s.bzzEth.APIs()...)
 
 	if s.ps != nil {